@@ -1,6 +1,7 @@
 package api
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
@@ -11,6 +12,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/coroot/coroot/api/forms"
@@ -23,6 +25,7 @@ import (
 	"github.com/coroot/coroot/constructor"
 	"github.com/coroot/coroot/db"
 	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/notifications"
 	"github.com/coroot/coroot/prom"
 	"github.com/coroot/coroot/rbac"
 	"github.com/coroot/coroot/timeseries"
@@ -34,6 +37,11 @@ import (
 
 type LoadWorldF func(ctx context.Context, project *db.Project, from, to timeseries.Time) (*model.World, error)
 
+const (
+	notificationHistoryWindow = 7 * timeseries.Day
+	sloHistoryMaxMonths       = 24
+)
+
 type Api struct {
 	cache            *cache.Cache
 	db               *db.DB
@@ -43,6 +51,7 @@ type Api struct {
 	globalClickHouse *db.IntegrationClickhouse
 	globalPrometheus *db.IntegrationPrometheus
 	licenseMgr       LicenseManager
+	notifier         *notifications.IncidentNotifier
 
 	authSecret        string
 	authAnonymousRole rbac.RoleName
@@ -51,10 +60,13 @@ type Api struct {
 	instanceUuid   string
 
 	loadWorld LoadWorldF
+
+	logTailers     map[db.ProjectId]int
+	logTailersLock sync.Mutex
 }
 
 func NewApi(cache *cache.Cache, db *db.DB, collector *collector.Collector, pricing *pricing.Manager, roles rbac.RoleManager, licenseMgr LicenseManager,
-	globalClickHouse *db.IntegrationClickhouse, globalPrometheus *db.IntegrationPrometheus,
+	notifier *notifications.IncidentNotifier, globalClickHouse *db.IntegrationClickhouse, globalPrometheus *db.IntegrationPrometheus,
 	deploymentUuid, instanceUuid string, loadWorld LoadWorldF) *Api {
 
 	return &Api{
@@ -66,6 +78,7 @@ func NewApi(cache *cache.Cache, db *db.DB, collector *collector.Collector, prici
 		globalClickHouse: globalClickHouse,
 		globalPrometheus: globalPrometheus,
 		licenseMgr:       licenseMgr,
+		notifier:         notifier,
 		deploymentUuid:   deploymentUuid,
 		instanceUuid:     instanceUuid,
 		loadWorld:        loadWorld,
@@ -339,7 +352,7 @@ func (api *Api) Status(w http.ResponseWriter, r *http.Request, u *db.User) {
 		http.Error(w, "", http.StatusInternalServerError)
 		return
 	}
-	utils.WriteJson(w, renderStatus(project, cacheStatus, world, api.globalPrometheus))
+	utils.WriteJson(w, api.renderStatus(project, cacheStatus, world))
 }
 
 func (api *Api) Overview(w http.ResponseWriter, r *http.Request, u *db.User) {
@@ -510,6 +523,403 @@ func (api *Api) PanelData(w http.ResponseWriter, r *http.Request, u *db.User) {
 	utils.WriteJson(w, data)
 }
 
+// deleteAlertRule resolves the active alert of the rule, so that the notified destinations receive a resolution,
+// and then deletes the rule along with its alerts.
+func (api *Api) deleteAlertRule(projectId db.ProjectId, id string) error {
+	rule, err := api.db.GetAlertRule(projectId, id)
+	if err != nil {
+		return err
+	}
+	active, err := api.db.GetActiveAlerts(projectId)
+	if err != nil {
+		return err
+	}
+	if alert := active[id]; alert != nil {
+		project, err := api.db.GetProject(projectId)
+		if err != nil {
+			return err
+		}
+		now := timeseries.Now()
+		alert.ResolvedAt = now
+		if err = api.db.UpdateAlert(projectId, alert); err != nil {
+			return err
+		}
+		if api.notifier != nil {
+			api.notifier.EnqueueAlert(project, rule, alert, now)
+		}
+	}
+	return api.db.DeleteAlertRule(projectId, id)
+}
+
+func (api *Api) AlertRules(w http.ResponseWriter, r *http.Request, u *db.User) {
+	vars := mux.Vars(r)
+	projectId := db.ProjectId(vars["project"])
+	id := vars["rule"]
+
+	if r.Method == http.MethodPost {
+		if !api.IsAllowed(u, rbac.Actions.Project(string(projectId)).AlertRules().Edit()) {
+			http.Error(w, "You are not allowed to configure alert rules.", http.StatusForbidden)
+			return
+		}
+		var form forms.AlertRuleForm
+		if err := forms.ReadAndValidate(r, &form); err != nil {
+			klog.Warningln("bad request:", err)
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+		var err error
+		switch form.Action {
+		case "create":
+			id, err = api.db.CreateAlertRule(projectId, &form.AlertRule)
+			if err == nil {
+				http.Error(w, id, http.StatusCreated)
+				return
+			}
+		case "update":
+			err = api.db.UpdateAlertRule(projectId, id, &form.AlertRule)
+		case "delete":
+			err = api.deleteAlertRule(projectId, id)
+		default:
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				http.Error(w, "Alert rule not found", http.StatusNotFound)
+				return
+			}
+			klog.Errorf("failed to %s alert rule: %s", form.Action, err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	if id != "" {
+		rule, err := api.db.GetAlertRule(projectId, id)
+		if err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				klog.Warningln("alert rule not found:", id)
+				http.Error(w, "Alert rule not found", http.StatusNotFound)
+				return
+			}
+			klog.Errorln(err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		alerts, err := api.db.GetAlerts(projectId, id, 100)
+		if err != nil {
+			klog.Errorln(err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		utils.WriteJson(w, views.AlertRule(rule, alerts))
+		return
+	}
+
+	rules, err := api.db.GetAlertRules(projectId)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	active, err := api.db.GetActiveAlerts(projectId)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJson(w, views.AlertRules(rules, active))
+}
+
+func (api *Api) SLOs(w http.ResponseWriter, r *http.Request, u *db.User) {
+	vars := mux.Vars(r)
+	projectId := db.ProjectId(vars["project"])
+	id := vars["slo"]
+
+	if r.Method == http.MethodPost {
+		if !api.IsAllowed(u, rbac.Actions.Project(string(projectId)).SLOs().Edit()) {
+			http.Error(w, "You are not allowed to configure SLOs.", http.StatusForbidden)
+			return
+		}
+		var form forms.SLOForm
+		if err := forms.ReadAndValidate(r, &form); err != nil {
+			klog.Warningln("bad request:", err)
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+		var err error
+		switch form.Action {
+		case "create":
+			id, err = api.db.CreateSLO(projectId, &form.SLO)
+			if err == nil {
+				http.Error(w, id, http.StatusCreated)
+				return
+			}
+		case "update":
+			err = api.db.UpdateSLO(projectId, id, &form.SLO)
+		case "delete":
+			err = api.db.DeleteSLO(projectId, id)
+		default:
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				http.Error(w, "SLO not found", http.StatusNotFound)
+				return
+			}
+			klog.Errorf("failed to %s SLO: %s", form.Action, err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	if id != "" {
+		slo, err := api.db.GetSLO(projectId, id)
+		if err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				klog.Warningln("SLO not found:", id)
+				http.Error(w, "SLO not found", http.StatusNotFound)
+				return
+			}
+			klog.Errorln(err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		utils.WriteJson(w, slo)
+		return
+	}
+
+	slos, err := api.db.GetSLOs(projectId)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if slos == nil {
+		slos = []*model.SLO{}
+	}
+	utils.WriteJson(w, slos)
+}
+
+func (api *Api) SLOHistory(w http.ResponseWriter, r *http.Request, u *db.User) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+	q := r.URL.Query()
+
+	months := 3
+	if m := q.Get("months"); m != "" {
+		v, err := strconv.Atoi(m)
+		if err != nil || v < 1 || v > sloHistoryMaxMonths {
+			klog.Warningln("invalid months:", m)
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+		months = v
+	}
+	var appId model.ApplicationId
+	if a := q.Get("application"); a != "" {
+		var err error
+		if appId, err = model.NewApplicationIdFromString(a); err != nil {
+			klog.Warningln("invalid application id:", a)
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+	}
+	now := timeseries.Now()
+	from := timeseries.Time(model.SLOCompliancePeriodCalendarMonth.From(now).ToStandard().UTC().AddDate(0, 1-months, 0).Unix())
+
+	project, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			http.Error(w, "project not found", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	isAllowed := api.applicationViewFilter(u, project)
+
+	daily, err := api.db.GetSLODaily(projectId, from, now)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	daily = slices.DeleteFunc(daily, func(d db.SLODaily) bool {
+		return !appId.IsZero() && d.ApplicationId != appId || !isAllowed(d.ApplicationId)
+	})
+	slos, err := api.db.GetSLOs(projectId)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	slos = slices.DeleteFunc(slos, func(s *model.SLO) bool { return !isAllowed(s.ApplicationId) })
+	incidents, err := api.db.GetApplicationIncidents(projectId, from, now)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	for id := range incidents {
+		if !isAllowed(id) {
+			delete(incidents, id)
+		}
+	}
+	history := views.SLOHistory(daily, slos, incidents)
+
+	if q.Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="slo-compliance.csv"`)
+		if err = history.WriteCSV(w); err != nil {
+			klog.Errorln(err)
+		}
+		return
+	}
+	utils.WriteJson(w, history)
+}
+
+func (api *Api) Silences(w http.ResponseWriter, r *http.Request, u *db.User) {
+	vars := mux.Vars(r)
+	projectId := db.ProjectId(vars["project"])
+	id := vars["silence"]
+
+	if r.Method == http.MethodGet {
+		silences, err := api.db.GetSilences(projectId)
+		if err != nil {
+			klog.Errorln(err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		utils.WriteJson(w, views.Silences(silences))
+		return
+	}
+
+	if !api.IsAllowed(u, rbac.Actions.Project(string(projectId)).Silences().Edit()) {
+		http.Error(w, "You are not allowed to configure silences.", http.StatusForbidden)
+		return
+	}
+	var form forms.SilenceForm
+	if err := forms.ReadAndValidate(r, &form); err != nil {
+		klog.Warningln("bad request:", err)
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	var err error
+	switch form.Action {
+	case "create":
+		form.CreatedBy = cmp.Or(u.Name, u.Email)
+		form.CreatedAt = timeseries.Now()
+		id, err = api.db.CreateSilence(projectId, &form.Silence)
+		if err == nil {
+			http.Error(w, id, http.StatusCreated)
+			return
+		}
+	case "update":
+		var s *db.Silence
+		if s, err = api.db.GetSilence(projectId, id); err == nil {
+			form.CreatedBy = s.CreatedBy
+			form.CreatedAt = s.CreatedAt
+			err = api.db.UpdateSilence(projectId, id, &form.Silence)
+		}
+	case "delete":
+		err = api.db.DeleteSilence(projectId, id)
+	default:
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			http.Error(w, "Silence not found", http.StatusNotFound)
+			return
+		}
+		klog.Errorf("failed to %s silence: %s", form.Action, err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (api *Api) Notifications(w http.ResponseWriter, r *http.Request, u *db.User) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+	from := timeseries.Now().Add(-notificationHistoryWindow)
+
+	project, err := api.db.GetProject(projectId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			http.Error(w, "project not found", http.StatusNotFound)
+			return
+		}
+		klog.Errorln(err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	isAllowed := api.applicationViewFilter(u, project)
+
+	if r.Method == http.MethodGet {
+		items, err := api.db.GetIncidentNotifications(projectId, from, r.URL.Query().Get("incident"))
+		if err != nil {
+			klog.Errorln(err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		deliveries, err := api.db.GetProjectNotificationDeliveries(projectId, from)
+		if err != nil {
+			klog.Errorln(err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		incidents := map[string]bool{}
+		var allowed []db.IncidentNotification
+		for _, n := range items {
+			if isAllowed(n.ApplicationId) {
+				allowed = append(allowed, n)
+				incidents[n.IncidentKey] = true
+			}
+		}
+		var allowedDeliveries []*db.NotificationDelivery
+		for _, d := range deliveries {
+			if incidents[d.IncidentKey] {
+				allowedDeliveries = append(allowedDeliveries, d)
+			}
+		}
+		utils.WriteJson(w, views.Notifications(allowed, allowedDeliveries, notifications.RetryWindow))
+		return
+	}
+
+	if !api.IsAllowed(u, rbac.Actions.Project(string(projectId)).Incidents().Edit()) {
+		http.Error(w, "You are not allowed to resend notifications.", http.StatusForbidden)
+		return
+	}
+	var form forms.NotificationForm
+	if err := forms.ReadAndValidate(r, &form); err != nil {
+		klog.Warningln("bad request:", err)
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	items, err := api.db.GetIncidentNotifications(projectId, form.Timestamp, form.IncidentKey)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	for _, n := range items {
+		if n.Timestamp == form.Timestamp && n.Destination.String() == form.Destination {
+			if !isAllowed(n.ApplicationId) {
+				http.Error(w, "You are not allowed to view this application.", http.StatusForbidden)
+				return
+			}
+			if api.notifier != nil {
+				api.notifier.Resend(n)
+			}
+			return
+		}
+	}
+	http.Error(w, "Notification not found", http.StatusNotFound)
+}
+
 func (api *Api) ApiKeys(w http.ResponseWriter, r *http.Request, u *db.User) {
 	vars := mux.Vars(r)
 	projectId := vars["project"]
@@ -562,6 +972,7 @@ func (api *Api) ApiKeys(w http.ResponseWriter, r *http.Request, u *db.User) {
 		for i, k := range project.Settings.ApiKeys {
 			if k.Key == form.Key {
 				project.Settings.ApiKeys[i].Description = form.Description
+				project.Settings.ApiKeys[i].Quotas = form.Quotas
 			}
 		}
 	default:
@@ -574,6 +985,138 @@ func (api *Api) ApiKeys(w http.ResponseWriter, r *http.Request, u *db.User) {
 	}
 }
 
+func (api *Api) IngestionQuotas(w http.ResponseWriter, r *http.Request, u *db.User) {
+	projectId := mux.Vars(r)["project"]
+
+	project, err := api.db.GetProject(db.ProjectId(projectId))
+	if err != nil {
+		klog.Errorln("failed to get project:", err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	isAllowed := api.IsAllowed(u, rbac.Actions.Project(projectId).Settings().Edit())
+
+	if r.Method == http.MethodGet {
+		res := struct {
+			Editable bool                `json:"editable"`
+			Quotas   *db.IngestionQuotas `json:"quotas"`
+		}{
+			Editable: isAllowed && !project.Settings.Readonly,
+			Quotas:   project.Settings.IngestionQuotas,
+		}
+		utils.WriteJson(w, res)
+		return
+	}
+
+	if !isAllowed || project.Settings.Readonly {
+		http.Error(w, "You are not allowed to configure ingestion quotas.", http.StatusForbidden)
+		return
+	}
+	var form forms.IngestionQuotasForm
+	if err = forms.ReadAndValidate(r, &form); err != nil {
+		klog.Warningln("bad request:", err)
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	project.Settings.IngestionQuotas = nil
+	if !form.IngestionQuotas.IsEmpty() {
+		project.Settings.IngestionQuotas = &form.IngestionQuotas
+	}
+	if err = api.db.SaveProjectSettings(project); err != nil {
+		klog.Errorln("failed to save project ingestion quotas:", err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (api *Api) IngestPipeline(w http.ResponseWriter, r *http.Request, u *db.User) {
+	projectId := mux.Vars(r)["project"]
+
+	project, err := api.db.GetProject(db.ProjectId(projectId))
+	if err != nil {
+		klog.Errorln("failed to get project:", err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	isAllowed := api.IsAllowed(u, rbac.Actions.Project(projectId).Settings().Edit())
+
+	if r.Method == http.MethodGet {
+		res := struct {
+			Editable bool               `json:"editable"`
+			Pipeline *db.IngestPipeline `json:"pipeline"`
+		}{
+			Editable: isAllowed && !project.Settings.Readonly,
+			Pipeline: project.Settings.IngestPipeline,
+		}
+		utils.WriteJson(w, res)
+		return
+	}
+
+	if !isAllowed || project.Settings.Readonly {
+		http.Error(w, "You are not allowed to configure the ingest pipeline.", http.StatusForbidden)
+		return
+	}
+	var form forms.IngestPipelineForm
+	if err = forms.ReadAndValidate(r, &form); err != nil {
+		klog.Warningln("bad request:", err)
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	project.Settings.IngestPipeline = nil
+	if !form.IngestPipeline.IsEmpty() {
+		project.Settings.IngestPipeline = &form.IngestPipeline
+	}
+	if err = api.db.SaveProjectSettings(project); err != nil {
+		klog.Errorln("failed to save project ingest pipeline:", err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (api *Api) LogMetrics(w http.ResponseWriter, r *http.Request, u *db.User) {
+	projectId := mux.Vars(r)["project"]
+
+	project, err := api.db.GetProject(db.ProjectId(projectId))
+	if err != nil {
+		klog.Errorln("failed to get project:", err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	isAllowed := api.IsAllowed(u, rbac.Actions.Project(projectId).Settings().Edit())
+
+	if r.Method == http.MethodGet {
+		res := struct {
+			Editable bool           `json:"editable"`
+			Metrics  []db.LogMetric `json:"metrics"`
+		}{
+			Editable: isAllowed && !project.Settings.Readonly,
+			Metrics:  project.Settings.LogMetrics,
+		}
+		utils.WriteJson(w, res)
+		return
+	}
+
+	if !isAllowed || project.Settings.Readonly {
+		http.Error(w, "You are not allowed to configure log-based metrics.", http.StatusForbidden)
+		return
+	}
+	var form forms.LogMetricsForm
+	if err = forms.ReadAndValidate(r, &form); err != nil {
+		klog.Warningln("bad request:", err)
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	project.Settings.LogMetrics = form.Metrics
+	if err = api.db.SaveProjectSettings(project); err != nil {
+		klog.Errorln("failed to save project log metrics:", err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
+
 func (api *Api) Inspections(w http.ResponseWriter, r *http.Request, u *db.User) {
 	vars := mux.Vars(r)
 	projectId := vars["project"]
@@ -740,6 +1283,45 @@ func (api *Api) CustomCloudPricing(w http.ResponseWriter, r *http.Request, u *db
 	}
 }
 
+func (api *Api) NotificationRouting(w http.ResponseWriter, r *http.Request, u *db.User) {
+	vars := mux.Vars(r)
+	projectId := vars["project"]
+	p, err := api.db.GetProject(db.ProjectId(projectId))
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if r.Method == http.MethodGet {
+		utils.WriteJson(w, p.Settings.NotificationRouting)
+		return
+	}
+	if !api.IsAllowed(u, rbac.Actions.Project(projectId).NotificationRouting().Edit()) {
+		http.Error(w, "You are not allowed to configure notification routing.", http.StatusForbidden)
+		return
+	}
+	switch r.Method {
+	case http.MethodDelete:
+		p.Settings.NotificationRouting = nil
+	case http.MethodPost:
+		var form forms.NotificationRoutingForm
+		if err := forms.ReadAndValidate(r, &form); err != nil {
+			klog.Warningln("bad request:", err)
+			http.Error(w, "Invalid form", http.StatusBadRequest)
+			return
+		}
+		p.Settings.NotificationRouting = &form.NotificationRouting
+	default:
+		http.Error(w, "", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := api.db.SaveProjectSettings(p); err != nil {
+		klog.Errorln("failed to save:", err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
+
 func (api *Api) Integrations(w http.ResponseWriter, r *http.Request, u *db.User) {
 	vars := mux.Vars(r)
 	projectId := vars["project"]
@@ -921,6 +1503,13 @@ func (api *Api) Prom(w http.ResponseWriter, r *http.Request, u *db.User) {
 		c.Series(r, w)
 	case "metadata":
 		c.MetricMetadata(r, w)
+	case "read":
+		ch, ok := c.(*prom.ClickHouse)
+		if !ok {
+			http.Error(w, "remote read is only available when metrics are stored in ClickHouse", http.StatusNotFound)
+			return
+		}
+		ch.RemoteRead(r, w)
 	default:
 		parts := strings.Split(rest, "/")
 		var labelName string
@@ -933,6 +1522,28 @@ func (api *Api) Prom(w http.ResponseWriter, r *http.Request, u *db.User) {
 	}
 }
 
+func (api *Api) PromFederate(w http.ResponseWriter, r *http.Request, u *db.User) {
+	project, err := api.db.GetProject(db.ProjectId(mux.Vars(r)["project"]))
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	c, err := prom.NewClient(project.PrometheusConfig(api.globalPrometheus), project.ClickHouseConfig(api.globalClickHouse))
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	defer c.Close()
+	ch, ok := c.(*prom.ClickHouse)
+	if !ok {
+		http.Error(w, "federation is only available when metrics are stored in ClickHouse", http.StatusNotFound)
+		return
+	}
+	ch.Federate(r, w)
+}
+
 func (api *Api) Application(w http.ResponseWriter, r *http.Request, u *db.User) {
 	projectId := mux.Vars(r)["project"]
 	appId, err := GetApplicationId(r)
@@ -963,6 +1574,12 @@ func (api *Api) Application(w http.ResponseWriter, r *http.Request, u *db.User)
 		return
 	}
 
+	if err = api.loadCustomSLIs(r.Context(), project, world, app); err != nil {
+		klog.Errorln(err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
 	auditor.Audit(world, project, app, project.ClickHouseConfig(api.globalClickHouse) != nil, nil)
 
 	if project.ClickHouseConfig(api.globalClickHouse) != nil {
@@ -1031,6 +1648,79 @@ func (api *Api) Incident(w http.ResponseWriter, r *http.Request, u *db.User) {
 		http.Error(w, "failed to get incident", http.StatusInternalServerError)
 		return
 	}
+
+	if r.Method == http.MethodPost {
+		if !api.IsAllowed(u, rbac.Actions.Project(projectId).Incidents().Edit()) {
+			http.Error(w, "You are not allowed to manage incidents.", http.StatusForbidden)
+			return
+		}
+		var form forms.IncidentForm
+		if err = forms.ReadAndValidate(r, &form); err != nil {
+			klog.Warningln("bad request:", err)
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+		project, err := api.db.GetProject(db.ProjectId(projectId))
+		if err != nil {
+			klog.Errorln(err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		if incident.Resolved() && form.Action != "comment" {
+			http.Error(w, "The incident is already resolved.", http.StatusBadRequest)
+			return
+		}
+		now := timeseries.Now()
+		event := db.IncidentEvent{Timestamp: now, Author: cmp.Or(u.Name, u.Email), Text: form.Comment}
+		switch form.Action {
+		case "acknowledge":
+			if incident.Acknowledged() {
+				http.Error(w, "The incident is already acknowledged.", http.StatusBadRequest)
+				return
+			}
+			event.Type = db.IncidentEventAcknowledged
+			incident.Lifecycle.AcknowledgedAt = now
+			incident.Lifecycle.AcknowledgedBy = event.Author
+			err = api.db.UpdateIncidentLifecycle(project.Id, incident)
+		case "assign":
+			event.Type = db.IncidentEventAssigned
+			event.Text = form.Assignee
+			incident.Lifecycle.Assignee = form.Assignee
+			err = api.db.UpdateIncidentLifecycle(project.Id, incident)
+		case "comment":
+			event.Type = db.IncidentEventCommented
+		case "close":
+			event.Type = db.IncidentEventClosed
+			incident.ResolvedAt = now
+			incident.Severity = model.OK
+			incident.Lifecycle.ClosedBy = event.Author
+			err = api.db.CloseIncident(project.Id, incident)
+		}
+		if err == nil {
+			err = api.db.AddIncidentEvent(project.Id, incident.Key, event)
+		}
+		if errors.Is(err, db.ErrConflict) {
+			http.Error(w, "The incident is already resolved.", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			klog.Errorf("failed to %s incident: %s", form.Action, err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		if api.notifier != nil {
+			switch form.Action {
+			case "acknowledge":
+				api.notifier.Acknowledge(project, incident)
+			case "close":
+				app := model.NewApplication(incident.ApplicationId)
+				app.Category = project.CalcApplicationCategory(app.Id)
+				api.notifier.Enqueue(project, app, incident, now)
+			}
+		}
+		return
+	}
+
 	values := r.URL.Query()
 	values.Add("incident", incidentKey)
 	r.URL.RawQuery = values.Encode()
@@ -1055,8 +1745,26 @@ func (api *Api) Incident(w http.ResponseWriter, r *http.Request, u *db.User) {
 		http.Error(w, "You are not allowed to view this application.", http.StatusForbidden)
 		return
 	}
+	silences, err := api.db.GetSilences(project.Id)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	events, err := api.db.GetIncidentEvents(project.Id, incident.Key)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	deliveries, err := api.db.GetNotificationDeliveries(project.Id, incident.Key)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
 	auditor.Audit(world, project, app, project.ClickHouseConfig(api.globalClickHouse) != nil, nil)
-	utils.WriteJson(w, api.WithContext(project, cacheStatus, world, views.Incident(world, app, incident)))
+	utils.WriteJson(w, api.WithContext(project, cacheStatus, world, views.Incident(world, app, incident, silences, events, deliveries)))
 }
 
 func (api *Api) Inspection(w http.ResponseWriter, r *http.Request, u *db.User) {
@@ -1129,6 +1837,25 @@ func (api *Api) Inspection(w http.ResponseWriter, r *http.Request, u *db.User) {
 					if webhook := notificationSettings.Webhook; webhook != nil && webhook.Enabled {
 						res.Integrations = append(res.Integrations, Integration{Name: "Webhook"})
 					}
+					if email := notificationSettings.Email; email != nil && email.Enabled {
+						to := email.To
+						if len(to) == 0 && project.Settings.Integrations.Email != nil {
+							to = project.Settings.Integrations.Email.To
+						}
+						res.Integrations = append(res.Integrations, Integration{Name: "Email", Details: fmt.Sprintf("recipients: %s", strings.Join(to, ", "))})
+					}
+					if mattermost := notificationSettings.Mattermost; mattermost != nil && mattermost.Enabled {
+						res.Integrations = append(res.Integrations, Integration{Name: "Mattermost"})
+					}
+					if discord := notificationSettings.Discord; discord != nil && discord.Enabled {
+						res.Integrations = append(res.Integrations, Integration{Name: "Discord"})
+					}
+					if googleChat := notificationSettings.GoogleChat; googleChat != nil && googleChat.Enabled {
+						res.Integrations = append(res.Integrations, Integration{Name: "Google Chat"})
+					}
+					if telegram := notificationSettings.Telegram; telegram != nil && telegram.Enabled {
+						res.Integrations = append(res.Integrations, Integration{Name: "Telegram"})
+					}
 				}
 			}
 		}
@@ -1597,6 +2324,24 @@ func (api *Api) LoadWorld(ctx context.Context, project *db.Project, from, to tim
 	return world, cacheStatus, err
 }
 
+func (api *Api) loadCustomSLIs(ctx context.Context, project *db.Project, world *model.World, app *model.Application) error {
+	slos, err := api.db.GetApplicationSLOs(project.Id, app.Id)
+	if err != nil || len(slos) == 0 {
+		return err
+	}
+	var ch *clickhouse.Client
+	if slices.ContainsFunc(slos, func(s *model.SLO) bool { return s.Source.Traces != nil }) {
+		if ch, err = api.GetClickhouseClient(project); err != nil {
+			klog.Warningln(err)
+		}
+		if ch != nil {
+			defer ch.Close()
+		}
+	}
+	constructor.LoadCustomSLIs(ctx, api.cache.GetCacheClient(project.Id), ch, world, slos)
+	return nil
+}
+
 func (api *Api) LoadWorldByRequest(r *http.Request) (*model.World, *db.Project, *cache.Status, error) {
 	projectId := db.ProjectId(mux.Vars(r)["project"])
 	project, err := api.db.GetProject(projectId)
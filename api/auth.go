@@ -12,9 +12,12 @@ import (
 	"time"
 
 	"github.com/coroot/coroot/api/forms"
+	"github.com/coroot/coroot/collector"
 	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
 	"github.com/coroot/coroot/rbac"
 	"github.com/coroot/coroot/utils"
+	"github.com/gorilla/mux"
 	"k8s.io/klog"
 )
 
@@ -92,6 +95,37 @@ func (api *Api) Auth(h func(http.ResponseWriter, *http.Request, *db.User)) http.
 	}
 }
 
+// AuthWithApiKey additionally accepts an API key of the requested project, so that tools like Grafana or Thanos
+// can query the project without a session. The user passed to the handler is nil in this case.
+// Requests with credentials not matching any key of the project are authenticated by the session.
+func (api *Api) AuthWithApiKey(h func(http.ResponseWriter, *http.Request, *db.User)) http.HandlerFunc {
+	auth := api.Auth(h)
+	return func(w http.ResponseWriter, r *http.Request) {
+		apiKey := collector.ApiKeyFromHTTPRequest(r)
+		// the all-zero key grants ingestion without an API key, it must not grant read access
+		if apiKey == "" || apiKey == strings.Repeat("0", 32) {
+			auth(w, r)
+			return
+		}
+		project, err := api.db.GetProject(db.ProjectId(mux.Vars(r)["project"]))
+		if err != nil && !errors.Is(err, db.ErrNotFound) {
+			klog.Errorln(err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		if project != nil {
+			for _, k := range project.Settings.ApiKeys {
+				if hmac.Equal([]byte(k.Key), []byte(apiKey)) {
+					h(w, r, nil)
+					return
+				}
+			}
+		}
+		// the credentials may be meant for a reverse proxy in front of Coroot (e.g., basic auth or OAuth)
+		auth(w, r)
+	}
+}
+
 func (api *Api) Login(w http.ResponseWriter, r *http.Request) {
 	var form forms.LoginForm
 	if err := forms.ReadAndValidate(r, &form); err != nil {
@@ -236,3 +270,18 @@ func (api *Api) IsAllowed(u *db.User, actions ...rbac.Action) bool {
 	}
 	return false
 }
+
+// applicationViewFilter returns a function reporting whether the user is allowed to view the application.
+// The results are cached, so it can be used to filter long lists.
+func (api *Api) applicationViewFilter(u *db.User, project *db.Project) func(appId model.ApplicationId) bool {
+	allowed := map[model.ApplicationId]bool{}
+	return func(appId model.ApplicationId) bool {
+		res, ok := allowed[appId]
+		if !ok {
+			category := project.CalcApplicationCategory(appId)
+			res = api.IsAllowed(u, rbac.Actions.Project(string(project.Id)).Application(category, appId.Namespace, appId.Kind, appId.Name).View())
+			allowed[appId] = res
+		}
+		return res
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/coroot/coroot/cache"
+	"github.com/coroot/coroot/collector"
 	"github.com/coroot/coroot/db"
 	"github.com/coroot/coroot/model"
 	"github.com/coroot/coroot/utils"
@@ -28,6 +29,7 @@ type Status struct {
 	Prometheus       Prometheus        `json:"prometheus"`
 	NodeAgent        NodeAgent         `json:"node_agent"`
 	KubeStateMetrics *KubeStateMetrics `json:"kube_state_metrics"`
+	Ingestion        *Ingestion        `json:"ingestion"`
 }
 
 type Prometheus struct {
@@ -47,6 +49,20 @@ type KubeStateMetrics struct {
 	Applications int          `json:"applications"`
 }
 
+type Ingestion struct {
+	Status   model.Status     `json:"status"`
+	Message  string           `json:"message"`
+	Rejected int64            `json:"rejected"`
+	Quotas   []IngestionQuota `json:"quotas"`
+}
+
+type IngestionQuota struct {
+	Scope string  `json:"scope"`
+	Name  string  `json:"name"`
+	Limit int64   `json:"limit"`
+	Usage float64 `json:"usage"`
+}
+
 type Search struct {
 	Applications []Application `json:"applications"`
 	Nodes        []Node        `json:"nodes"`
@@ -74,7 +90,7 @@ type LicenseManager interface {
 func (api *Api) WithContext(p *db.Project, cacheStatus *cache.Status, w *model.World, data any) DataWithContext {
 	res := DataWithContext{
 		Context: Context{
-			Status:    renderStatus(p, cacheStatus, w, api.globalPrometheus),
+			Status:    api.renderStatus(p, cacheStatus, w),
 			Search:    renderSearch(w),
 			Incidents: renderIncidents(w),
 			Fluxcd:    w != nil && w.Flux != nil,
@@ -174,6 +190,67 @@ func renderStatus(p *db.Project, cacheStatus *cache.Status, w *model.World, glob
 	return res
 }
 
+func (api *Api) renderStatus(p *db.Project, cacheStatus *cache.Status, w *model.World) Status {
+	res := renderStatus(p, cacheStatus, w, api.globalPrometheus)
+	if p == nil || api.collector == nil {
+		return res
+	}
+	res.Ingestion = renderIngestion(p, func(apiKey string) collector.IngestionUsage {
+		return api.collector.IngestionUsage(p.Id, apiKey)
+	})
+	if res.Ingestion != nil && res.Ingestion.Status >= model.WARNING {
+		res.Status = model.WARNING
+	}
+	return res
+}
+
+func renderIngestion(p *db.Project, usage func(apiKey string) collector.IngestionUsage) *Ingestion {
+	res := &Ingestion{Status: model.OK, Message: "ok"}
+	nearLimit := false
+	add := func(scope string, q *db.IngestionQuotas, u collector.IngestionUsage) {
+		if q.IsEmpty() {
+			return
+		}
+		for _, i := range []IngestionQuota{
+			{Name: "spans_per_second", Limit: q.SpansPerSecond, Usage: u.SpansPerSecond},
+			{Name: "logs_per_second", Limit: q.LogsPerSecond, Usage: u.LogsPerSecond},
+			{Name: "samples_per_second", Limit: q.SamplesPerSecond, Usage: u.SamplesPerSecond},
+			{Name: "bytes_per_day", Limit: q.BytesPerDay, Usage: float64(u.BytesToday)},
+		} {
+			if i.Limit <= 0 {
+				continue
+			}
+			i.Scope = scope
+			res.Quotas = append(res.Quotas, i)
+			if i.Usage >= 0.9*float64(i.Limit) {
+				nearLimit = true
+			}
+		}
+	}
+	projectUsage := usage("")
+	add("project", p.Settings.IngestionQuotas, projectUsage)
+	for i, k := range p.Settings.ApiKeys {
+		scope := k.Description
+		if scope == "" {
+			scope = fmt.Sprintf("API key #%d", i+1)
+		}
+		add(scope, k.Quotas, usage(k.Key))
+	}
+	if len(res.Quotas) == 0 {
+		return nil
+	}
+	res.Rejected = projectUsage.Rejected
+	switch {
+	case res.Rejected > 0:
+		res.Status = model.WARNING
+		res.Message = fmt.Sprintf("最近一分钟内有 %d 条数据因超出摄取配额被拒绝。", res.Rejected)
+	case nearLimit:
+		res.Status = model.WARNING
+		res.Message = "数据摄取量已接近配额上限。"
+	}
+	return res
+}
+
 func renderSearch(w *model.World) Search {
 	search := Search{}
 	if w == nil {
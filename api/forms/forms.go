@@ -60,6 +60,38 @@ type ApiKeyForm struct {
 }
 
 func (f *ApiKeyForm) Valid() bool {
+	return f.Quotas == nil || f.Quotas.Validate() == nil
+}
+
+type IngestionQuotasForm struct {
+	db.IngestionQuotas
+}
+
+func (f *IngestionQuotasForm) Valid() bool {
+	return f.IngestionQuotas.Validate() == nil
+}
+
+type IngestPipelineForm struct {
+	db.IngestPipeline
+}
+
+func (f *IngestPipelineForm) Valid() bool {
+	return f.IngestPipeline.Validate() == nil
+}
+
+type LogMetricsForm struct {
+	Metrics []db.LogMetric `json:"metrics"`
+}
+
+func (f *LogMetricsForm) Valid() bool {
+	if db.ValidateLogMetrics(f.Metrics) != nil {
+		return false
+	}
+	for _, m := range f.Metrics {
+		if _, err := clickhouse.ParseLogSearch(m.Search); err != nil {
+			return false
+		}
+	}
 	return true
 }
 
@@ -72,6 +104,72 @@ func (f *DashboardForm) Valid() bool {
 	return f.Name != ""
 }
 
+type AlertRuleForm struct {
+	Action string `json:"action"`
+	db.AlertRule
+}
+
+func (f *AlertRuleForm) Valid() bool {
+	return f.Action == "delete" || f.AlertRule.Validate() == nil
+}
+
+type SLOForm struct {
+	Action string `json:"action"`
+	model.SLO
+}
+
+func (f *SLOForm) Valid() bool {
+	return f.Action == "delete" || f.SLO.Validate() == nil
+}
+
+type SilenceForm struct {
+	Action string `json:"action"`
+	db.Silence
+}
+
+func (f *SilenceForm) Valid() bool {
+	return f.Action == "delete" || f.Silence.Validate() == nil
+}
+
+type NotificationRoutingForm struct {
+	db.NotificationRouting
+}
+
+func (f *NotificationRoutingForm) Valid() bool {
+	return f.NotificationRouting.Validate() == nil
+}
+
+type NotificationForm struct {
+	Action      string          `json:"action"`
+	IncidentKey string          `json:"incident_key"`
+	Destination string          `json:"destination"`
+	Timestamp   timeseries.Time `json:"timestamp"`
+}
+
+func (f *NotificationForm) Valid() bool {
+	return f.Action == "resend" && f.IncidentKey != "" && f.Destination != "" && !f.Timestamp.IsZero()
+}
+
+type IncidentForm struct {
+	Action   string `json:"action"`
+	Assignee string `json:"assignee"`
+	Comment  string `json:"comment"`
+}
+
+func (f *IncidentForm) Valid() bool {
+	f.Assignee = strings.TrimSpace(f.Assignee)
+	f.Comment = strings.TrimSpace(f.Comment)
+	switch f.Action {
+	case "acknowledge", "close":
+		return true
+	case "assign":
+		return f.Assignee != ""
+	case "comment":
+		return f.Comment != ""
+	}
+	return false
+}
+
 type CheckConfigForm struct {
 	Configs []*model.CheckConfigSimple `json:"configs"`
 }
@@ -160,6 +258,21 @@ func (f *ApplicationCategoryForm) SendTestNotification(ctx context.Context, proj
 		if webhook := f.Test.Incident.Webhook; webhook != nil && integrations.Webhook != nil {
 			client = notifications.NewWebhook(integrations.Webhook)
 		}
+		if email := f.Test.Incident.Email; email != nil && integrations.Email != nil {
+			client = notifications.NewEmail(integrations.Email, email.To)
+		}
+		if f.Test.Incident.Mattermost != nil && integrations.Mattermost != nil {
+			client = notifications.NewMattermost(integrations.Mattermost.WebhookUrl, integrations.Mattermost.Channel)
+		}
+		if f.Test.Incident.Discord != nil && integrations.Discord != nil {
+			client = notifications.NewDiscord(integrations.Discord.WebhookUrl)
+		}
+		if f.Test.Incident.GoogleChat != nil && integrations.GoogleChat != nil {
+			client = notifications.NewGoogleChat(integrations.GoogleChat.WebhookUrl)
+		}
+		if f.Test.Incident.Telegram != nil && integrations.Telegram != nil {
+			client = notifications.NewTelegram(integrations.Telegram.BotToken, integrations.Telegram.ChatId)
+		}
 		if client != nil {
 			return client.SendIncident(ctx, integrations.BaseUrl, testIncidentNotification(project))
 		}
@@ -173,6 +286,21 @@ func (f *ApplicationCategoryForm) SendTestNotification(ctx context.Context, proj
 		if webhook := f.Test.Deployment.Webhook; webhook != nil && integrations.Webhook != nil {
 			client = notifications.NewWebhook(integrations.Webhook)
 		}
+		if email := f.Test.Deployment.Email; email != nil && integrations.Email != nil {
+			client = notifications.NewEmail(integrations.Email, email.To)
+		}
+		if f.Test.Deployment.Mattermost != nil && integrations.Mattermost != nil {
+			client = notifications.NewMattermost(integrations.Mattermost.WebhookUrl, integrations.Mattermost.Channel)
+		}
+		if f.Test.Deployment.Discord != nil && integrations.Discord != nil {
+			client = notifications.NewDiscord(integrations.Discord.WebhookUrl)
+		}
+		if f.Test.Deployment.GoogleChat != nil && integrations.GoogleChat != nil {
+			client = notifications.NewGoogleChat(integrations.GoogleChat.WebhookUrl)
+		}
+		if f.Test.Deployment.Telegram != nil && integrations.Telegram != nil {
+			client = notifications.NewTelegram(integrations.Telegram.BotToken, integrations.Telegram.ChatId)
+		}
 		if client != nil {
 			return client.SendDeployment(ctx, project, testDeploymentNotification())
 		}
@@ -289,6 +417,16 @@ func NewIntegrationForm(t db.IntegrationType, globalClickHouse *db.IntegrationCl
 		return &IntegrationFormOpsgenie{}
 	case db.IntegrationTypeWebhook:
 		return &IntegrationFormWebhook{}
+	case db.IntegrationTypeEmail:
+		return &IntegrationFormEmail{}
+	case db.IntegrationTypeMattermost:
+		return &IntegrationFormMattermost{}
+	case db.IntegrationTypeDiscord:
+		return &IntegrationFormDiscord{}
+	case db.IntegrationTypeGoogleChat:
+		return &IntegrationFormGoogleChat{}
+	case db.IntegrationTypeTelegram:
+		return &IntegrationFormTelegram{}
 	}
 	return nil
 }
@@ -634,6 +772,9 @@ func (f *IntegrationFormWebhook) Get(project *db.Project, masked bool) {
 	f.IntegrationWebhook = *cfg
 	if masked {
 		f.Url = "<hidden>"
+		if f.Secret != "" {
+			f.Secret = "<hidden>"
+		}
 	}
 }
 
@@ -664,6 +805,253 @@ func (f *IntegrationFormWebhook) Test(ctx context.Context, project *db.Project)
 	return nil
 }
 
+type IntegrationFormEmail struct {
+	db.IntegrationEmail
+}
+
+func (f *IntegrationFormEmail) Valid() bool {
+	if err := f.Validate(); err != nil {
+		return false
+	}
+	return true
+}
+
+func (f *IntegrationFormEmail) Get(project *db.Project, masked bool) {
+	cfg := project.Settings.Integrations.Email
+	if cfg == nil {
+		f.SmtpPort = 587
+		f.TLS = db.EmailTLSModeStartTLS
+		f.Incidents = true
+		f.Deployments = true
+		return
+	}
+	f.IntegrationEmail = *cfg
+	if masked {
+		f.SmtpHost = "<hidden>"
+		f.Username = "<hidden>"
+		f.Password = "<hidden>"
+	}
+}
+
+func (f *IntegrationFormEmail) Update(ctx context.Context, project *db.Project, clear bool) error {
+	cfg := &f.IntegrationEmail
+	if clear {
+		cfg = nil
+	}
+	project.Settings.Integrations.Email = cfg
+	return nil
+}
+
+func (f *IntegrationFormEmail) Test(ctx context.Context, project *db.Project) error {
+	cfg := &f.IntegrationEmail
+	email := notifications.NewEmail(cfg, nil)
+	if cfg.Incidents {
+		err := email.SendIncident(ctx, project.Settings.Integrations.BaseUrl, testIncidentNotification(project))
+		if err != nil {
+			return err
+		}
+	}
+	if cfg.Deployments {
+		err := email.SendDeployment(ctx, project, testDeploymentNotification())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type IntegrationFormMattermost struct {
+	db.IntegrationMattermost
+}
+
+func (f *IntegrationFormMattermost) Valid() bool {
+	if err := f.Validate(); err != nil {
+		return false
+	}
+	return true
+}
+
+func (f *IntegrationFormMattermost) Get(project *db.Project, masked bool) {
+	cfg := project.Settings.Integrations.Mattermost
+	if cfg == nil {
+		f.Incidents = true
+		f.Deployments = true
+		return
+	}
+	f.IntegrationMattermost = *cfg
+	if masked {
+		f.WebhookUrl = "<hidden>"
+	}
+}
+
+func (f *IntegrationFormMattermost) Update(ctx context.Context, project *db.Project, clear bool) error {
+	cfg := &f.IntegrationMattermost
+	if clear {
+		cfg = nil
+	}
+	project.Settings.Integrations.Mattermost = cfg
+	return nil
+}
+
+func (f *IntegrationFormMattermost) Test(ctx context.Context, project *db.Project) error {
+	client := notifications.NewMattermost(f.WebhookUrl, f.Channel)
+	if f.Incidents {
+		if err := client.SendIncident(ctx, project.Settings.Integrations.BaseUrl, testIncidentNotification(project)); err != nil {
+			return err
+		}
+	}
+	if f.Deployments {
+		if err := client.SendDeployment(ctx, project, testDeploymentNotification()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type IntegrationFormDiscord struct {
+	db.IntegrationDiscord
+}
+
+func (f *IntegrationFormDiscord) Valid() bool {
+	if err := f.Validate(); err != nil {
+		return false
+	}
+	return true
+}
+
+func (f *IntegrationFormDiscord) Get(project *db.Project, masked bool) {
+	cfg := project.Settings.Integrations.Discord
+	if cfg == nil {
+		f.Incidents = true
+		f.Deployments = true
+		return
+	}
+	f.IntegrationDiscord = *cfg
+	if masked {
+		f.WebhookUrl = "<hidden>"
+	}
+}
+
+func (f *IntegrationFormDiscord) Update(ctx context.Context, project *db.Project, clear bool) error {
+	cfg := &f.IntegrationDiscord
+	if clear {
+		cfg = nil
+	}
+	project.Settings.Integrations.Discord = cfg
+	return nil
+}
+
+func (f *IntegrationFormDiscord) Test(ctx context.Context, project *db.Project) error {
+	client := notifications.NewDiscord(f.WebhookUrl)
+	if f.Incidents {
+		if err := client.SendIncident(ctx, project.Settings.Integrations.BaseUrl, testIncidentNotification(project)); err != nil {
+			return err
+		}
+	}
+	if f.Deployments {
+		if err := client.SendDeployment(ctx, project, testDeploymentNotification()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type IntegrationFormGoogleChat struct {
+	db.IntegrationGoogleChat
+}
+
+func (f *IntegrationFormGoogleChat) Valid() bool {
+	if err := f.Validate(); err != nil {
+		return false
+	}
+	return true
+}
+
+func (f *IntegrationFormGoogleChat) Get(project *db.Project, masked bool) {
+	cfg := project.Settings.Integrations.GoogleChat
+	if cfg == nil {
+		f.Incidents = true
+		f.Deployments = true
+		return
+	}
+	f.IntegrationGoogleChat = *cfg
+	if masked {
+		f.WebhookUrl = "<hidden>"
+	}
+}
+
+func (f *IntegrationFormGoogleChat) Update(ctx context.Context, project *db.Project, clear bool) error {
+	cfg := &f.IntegrationGoogleChat
+	if clear {
+		cfg = nil
+	}
+	project.Settings.Integrations.GoogleChat = cfg
+	return nil
+}
+
+func (f *IntegrationFormGoogleChat) Test(ctx context.Context, project *db.Project) error {
+	client := notifications.NewGoogleChat(f.WebhookUrl)
+	if f.Incidents {
+		if err := client.SendIncident(ctx, project.Settings.Integrations.BaseUrl, testIncidentNotification(project)); err != nil {
+			return err
+		}
+	}
+	if f.Deployments {
+		if err := client.SendDeployment(ctx, project, testDeploymentNotification()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type IntegrationFormTelegram struct {
+	db.IntegrationTelegram
+}
+
+func (f *IntegrationFormTelegram) Valid() bool {
+	if err := f.Validate(); err != nil {
+		return false
+	}
+	return true
+}
+
+func (f *IntegrationFormTelegram) Get(project *db.Project, masked bool) {
+	cfg := project.Settings.Integrations.Telegram
+	if cfg == nil {
+		f.Incidents = true
+		f.Deployments = true
+		return
+	}
+	f.IntegrationTelegram = *cfg
+	if masked {
+		f.BotToken = "<hidden>"
+	}
+}
+
+func (f *IntegrationFormTelegram) Update(ctx context.Context, project *db.Project, clear bool) error {
+	cfg := &f.IntegrationTelegram
+	if clear {
+		cfg = nil
+	}
+	project.Settings.Integrations.Telegram = cfg
+	return nil
+}
+
+func (f *IntegrationFormTelegram) Test(ctx context.Context, project *db.Project) error {
+	client := notifications.NewTelegram(f.BotToken, f.ChatId)
+	if f.Incidents {
+		if err := client.SendIncident(ctx, project.Settings.Integrations.BaseUrl, testIncidentNotification(project)); err != nil {
+			return err
+		}
+	}
+	if f.Deployments {
+		if err := client.SendDeployment(ctx, project, testDeploymentNotification()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func testIncidentNotification(project *db.Project) *db.IncidentNotification {
 	return &db.IncidentNotification{
 		ProjectId:     project.Id,
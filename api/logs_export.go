@@ -0,0 +1,121 @@
+package api
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coroot/coroot/api/views"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/rbac"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/coroot/coroot/utils"
+	"github.com/gorilla/mux"
+	"k8s.io/klog"
+)
+
+const logExportsHistoryLimit = 1000
+
+// LogsExport streams all the log entries of the application matching the query as NDJSON or CSV.
+// Every export is recorded, so it is possible to find out who exported which logs.
+func (api *Api) LogsExport(w http.ResponseWriter, r *http.Request, u *db.User) {
+	projectId := mux.Vars(r)["project"]
+	appId, err := GetApplicationId(r)
+	if err != nil {
+		klog.Warningln(err)
+		http.Error(w, "invalid application id", http.StatusBadRequest)
+		return
+	}
+	if !api.IsAllowed(u, rbac.Actions.Project(projectId).Logs().View()) {
+		http.Error(w, "You are not allowed to view logs.", http.StatusForbidden)
+		return
+	}
+
+	world, project, _, err := api.LoadWorldByRequest(r)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if project == nil || world == nil {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+	app := world.GetApplication(appId)
+	if app == nil {
+		klog.Warningln("application not found:", appId)
+		http.Error(w, "Application not found", http.StatusNotFound)
+		return
+	}
+	if !api.IsAllowed(u, rbac.Actions.Project(projectId).Application(app.Category, app.Id.Namespace, app.Id.Kind, app.Id.Name).View()) {
+		http.Error(w, "You are not allowed to view this application.", http.StatusForbidden)
+		return
+	}
+	ch, err := api.GetClickhouseClient(project)
+	if err != nil {
+		klog.Warningln(err)
+		http.Error(w, "ClickHouse is not available", http.StatusServiceUnavailable)
+		return
+	}
+	defer ch.Close()
+
+	ctx := r.Context()
+	export, err := views.LogsExport(ctx, ch, app, r.URL.Query(), world)
+	if err != nil {
+		klog.Warningln(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	record := &db.LogExport{
+		User:          cmp.Or(u.Name, u.Email),
+		ApplicationId: app.Id,
+		Query:         export.Query,
+		Format:        export.Format,
+		From:          world.Ctx.From,
+		To:            world.Ctx.To,
+		StartedAt:     timeseries.Now(),
+	}
+	if err = api.db.CreateLogExport(project.Id, record); err != nil {
+		klog.Errorln(err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	klog.Infof("%s: %s started log export %s of %s (%s)", project.Id, record.User, record.Id, app.Id, record.Format)
+
+	filename := fmt.Sprintf("%s-logs-%s.%s", app.Id.Name, time.Now().UTC().Format("20060102-150405"), export.Format)
+	w.Header().Set("Content-Type", export.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.Header().Set("Trailer", "X-Export-Rows, X-Export-Error")
+
+	record.Rows, err = export.Write(ctx, ch, w)
+	record.FinishedAt = timeseries.Now()
+	w.Header().Set("X-Export-Rows", fmt.Sprint(record.Rows))
+	if err != nil {
+		klog.Warningf("%s: log export %s failed: %s", project.Id, record.Id, err)
+		record.Error = err.Error()
+		w.Header().Set("X-Export-Error", strings.ReplaceAll(record.Error, "\n", " "))
+	}
+	if err = api.db.FinishLogExport(project.Id, record); err != nil {
+		klog.Errorln(err)
+	}
+	klog.Infof("%s: log export %s finished: %d entries", project.Id, record.Id, record.Rows)
+}
+
+func (api *Api) LogExports(w http.ResponseWriter, r *http.Request, u *db.User) {
+	projectId := mux.Vars(r)["project"]
+	if !api.IsAllowed(u, rbac.Actions.Project(projectId).Settings().Edit()) {
+		http.Error(w, "You are not allowed to view the log export history.", http.StatusForbidden)
+		return
+	}
+	exports, err := api.db.GetLogExports(db.ProjectId(projectId), logExportsHistoryLimit)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJson(w, exports)
+}
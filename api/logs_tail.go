@@ -0,0 +1,153 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coroot/coroot/api/views"
+	"github.com/coroot/coroot/api/views/logs"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/rbac"
+	"github.com/gorilla/mux"
+	"k8s.io/klog"
+)
+
+const (
+	maxLogTailersPerProject = 10
+	logTailKeepAlive        = 15 * time.Second
+)
+
+// LogsTail streams new log entries of the application as server-sent events.
+func (api *Api) LogsTail(w http.ResponseWriter, r *http.Request, u *db.User) {
+	projectId := db.ProjectId(mux.Vars(r)["project"])
+	appId, err := GetApplicationId(r)
+	if err != nil {
+		klog.Warningln(err)
+		http.Error(w, "invalid application id", http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	world, project, _, err := api.LoadWorldByRequest(r)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if project == nil || world == nil {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+	app := world.GetApplication(appId)
+	if app == nil {
+		klog.Warningln("application not found:", appId)
+		http.Error(w, "Application not found", http.StatusNotFound)
+		return
+	}
+	if !api.IsAllowed(u, rbac.Actions.Project(string(projectId)).Application(app.Category, app.Id.Namespace, app.Id.Kind, app.Id.Name).View()) {
+		http.Error(w, "You are not allowed to view this application.", http.StatusForbidden)
+		return
+	}
+	ch, err := api.GetClickhouseClient(project)
+	if err != nil {
+		klog.Warningln(err)
+		http.Error(w, "ClickHouse is not available", http.StatusServiceUnavailable)
+		return
+	}
+	defer ch.Close()
+
+	if !api.acquireLogTailer(projectId) {
+		http.Error(w, fmt.Sprintf("too many log tailers for the project (max %d)", maxLogTailersPerProject), http.StatusTooManyRequests)
+		return
+	}
+	defer api.releaseLogTailer(projectId)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var lock sync.Mutex
+	write := func(msg string) error {
+		lock.Lock()
+		defer lock.Unlock()
+		if _, err := io.WriteString(w, msg); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+	send := func(event string, data []byte) error {
+		return write(fmt.Sprintf("event: %s\ndata: %s\n\n", event, data))
+	}
+
+	// comments keep the connection open through proxies while there are no new entries
+	done := make(chan struct{})
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(logTailKeepAlive)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if write(": keep-alive\n\n") != nil {
+					return
+				}
+			}
+		}
+	}()
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+
+	ctx := r.Context()
+	err = views.LogsTail(ctx, ch, app, r.URL.Query(), world, func(batch *logs.TailBatch) error {
+		data, err := json.Marshal(batch)
+		if err != nil {
+			return err
+		}
+		return send("entries", data)
+	})
+	if err != nil && ctx.Err() == nil {
+		klog.Warningln(err)
+		data, _ := json.Marshal(err.Error())
+		_ = send("error", data)
+	}
+}
+
+func (api *Api) acquireLogTailer(projectId db.ProjectId) bool {
+	api.logTailersLock.Lock()
+	defer api.logTailersLock.Unlock()
+	if api.logTailers == nil {
+		api.logTailers = map[db.ProjectId]int{}
+	}
+	if api.logTailers[projectId] >= maxLogTailersPerProject {
+		return false
+	}
+	api.logTailers[projectId]++
+	return true
+}
+
+func (api *Api) releaseLogTailer(projectId db.ProjectId) {
+	api.logTailersLock.Lock()
+	defer api.logTailersLock.Unlock()
+	api.logTailers[projectId]--
+	if api.logTailers[projectId] <= 0 {
+		delete(api.logTailers, projectId)
+	}
+}
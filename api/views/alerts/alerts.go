@@ -0,0 +1,68 @@
+package alerts
+
+import (
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+)
+
+type AlertRule struct {
+	*db.AlertRule
+	Status model.Status `json:"status"`
+	Alert  *db.Alert    `json:"alert,omitempty"`
+}
+
+type View struct {
+	Rules []AlertRule `json:"rules"`
+}
+
+type RuleView struct {
+	Rule   AlertRule   `json:"rule"`
+	Alerts []*db.Alert `json:"alerts"`
+}
+
+func Render(rules []*db.AlertRule, active map[string]*db.Alert) *View {
+	v := &View{Rules: make([]AlertRule, 0, len(rules))}
+	for _, r := range rules {
+		v.Rules = append(v.Rules, rule(r, active[r.Id]))
+	}
+	return v
+}
+
+func RenderRule(r *db.AlertRule, alerts []*db.Alert) *RuleView {
+	var active *db.Alert
+	for _, a := range alerts {
+		if !a.Resolved() {
+			active = a
+			break
+		}
+	}
+	if alerts == nil {
+		alerts = []*db.Alert{}
+	}
+	return &RuleView{Rule: rule(r, active), Alerts: alerts}
+}
+
+func rule(r *db.AlertRule, active *db.Alert) AlertRule {
+	res := AlertRule{AlertRule: r, Status: model.OK, Alert: active}
+	switch {
+	case !r.Enabled:
+		res.Status = model.UNKNOWN
+	case active != nil:
+		res.Status = active.Severity
+	}
+	return res
+}
+
+type Silence struct {
+	*db.Silence
+	Active bool `json:"active"`
+}
+
+func RenderSilences(silences []*db.Silence, now timeseries.Time) []Silence {
+	res := make([]Silence, 0, len(silences))
+	for _, s := range silences {
+		res = append(res, Silence{Silence: s, Active: s.IsActive(now)})
+	}
+	return res
+}
@@ -0,0 +1,113 @@
+package alerts
+
+import (
+	"fmt"
+
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+)
+
+type NotificationState string
+
+const (
+	NotificationStateScheduled NotificationState = "scheduled"
+	NotificationStatePending   NotificationState = "pending"
+	NotificationStateRetrying  NotificationState = "retrying"
+	NotificationStateSent      NotificationState = "sent"
+	NotificationStateFailed    NotificationState = "failed"
+)
+
+type Notification struct {
+	IncidentKey string                     `json:"incident_key"`
+	Application model.ApplicationId        `json:"application"`
+	Status      model.Status               `json:"status"`
+	Destination string                     `json:"destination"`
+	Escalation  bool                       `json:"escalation"`
+	Timestamp   timeseries.Time            `json:"timestamp"`
+	SentAt      timeseries.Time            `json:"sent_at"`
+	State       NotificationState          `json:"state"`
+	Attempts    int                        `json:"attempts"`
+	LastError   string                     `json:"last_error"`
+	Deliveries  []*db.NotificationDelivery `json:"deliveries"`
+}
+
+type NotificationsStat struct {
+	Destination string `json:"destination"`
+	Sent        int    `json:"sent"`
+	Failed      int    `json:"failed"`
+	Retrying    int    `json:"retrying"`
+	Pending     int    `json:"pending"`
+}
+
+type NotificationsView struct {
+	Notifications []Notification       `json:"notifications"`
+	Stats         []*NotificationsStat `json:"stats"`
+}
+
+func RenderNotifications(notifications []db.IncidentNotification, deliveries []*db.NotificationDelivery, now timeseries.Time, retryWindow timeseries.Duration) *NotificationsView {
+	byNotification := map[string][]*db.NotificationDelivery{}
+	for _, d := range deliveries {
+		k := notificationKey(d.IncidentKey, d.Destination, d.NotificationTimestamp)
+		byNotification[k] = append(byNotification[k], d)
+	}
+	v := &NotificationsView{Notifications: make([]Notification, 0, len(notifications)), Stats: []*NotificationsStat{}}
+	stats := map[string]*NotificationsStat{}
+	for _, n := range notifications {
+		destination := n.Destination.String()
+		item := Notification{
+			IncidentKey: n.IncidentKey,
+			Application: n.ApplicationId,
+			Status:      n.Status,
+			Destination: destination,
+			Escalation:  n.Details != nil && n.Details.Escalation,
+			Timestamp:   n.Timestamp,
+			SentAt:      n.SentAt,
+			State:       notificationState(n, now, retryWindow),
+			Attempts:    n.Attempts,
+			LastError:   n.LastError,
+			Deliveries:  byNotification[notificationKey(n.IncidentKey, destination, n.Timestamp)],
+		}
+		if item.Deliveries == nil {
+			item.Deliveries = []*db.NotificationDelivery{}
+		}
+		v.Notifications = append(v.Notifications, item)
+
+		s := stats[destination]
+		if s == nil {
+			s = &NotificationsStat{Destination: destination}
+			stats[destination] = s
+			v.Stats = append(v.Stats, s)
+		}
+		switch item.State {
+		case NotificationStateSent:
+			s.Sent++
+		case NotificationStateFailed:
+			s.Failed++
+		case NotificationStateRetrying:
+			s.Retrying++
+		case NotificationStatePending:
+			s.Pending++
+		}
+	}
+	return v
+}
+
+func notificationState(n db.IncidentNotification, now timeseries.Time, retryWindow timeseries.Duration) NotificationState {
+	switch {
+	case !n.SentAt.IsZero():
+		return NotificationStateSent
+	case n.Timestamp.After(now):
+		return NotificationStateScheduled
+	case n.Timestamp.Before(now.Add(-retryWindow)):
+		// the notifier doesn't retry notifications older than the retry window
+		return NotificationStateFailed
+	case n.Attempts > 0:
+		return NotificationStateRetrying
+	}
+	return NotificationStatePending
+}
+
+func notificationKey(incidentKey, destination string, timestamp timeseries.Time) string {
+	return fmt.Sprintf("%s/%s/%d", incidentKey, destination, timestamp)
+}
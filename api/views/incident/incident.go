@@ -1,6 +1,7 @@
 package incident
 
 import (
+	"github.com/coroot/coroot/db"
 	"github.com/coroot/coroot/model"
 	"github.com/coroot/coroot/timeseries"
 	"github.com/coroot/coroot/utils"
@@ -56,23 +57,41 @@ type SLODetails struct {
 
 type View struct {
 	Incident
-	AvailabilitySLO *SLODetails     `json:"availability_slo,omitempty"`
-	LatencySLO      *SLODetails     `json:"latency_slo,omitempty"`
-	ActualFrom      timeseries.Time `json:"actual_from"`
-	ActualTo        timeseries.Time `json:"actual_to"`
+	AvailabilitySLO *SLODetails                `json:"availability_slo,omitempty"`
+	LatencySLO      *SLODetails                `json:"latency_slo,omitempty"`
+	ActualFrom      timeseries.Time            `json:"actual_from"`
+	ActualTo        timeseries.Time            `json:"actual_to"`
+	Silences        []*db.Silence              `json:"silences"`
+	Timeline        []*db.IncidentEvent        `json:"timeline"`
+	Deliveries      []*db.NotificationDelivery `json:"deliveries"`
 
 	Widgets []*model.Widget `json:"widgets"`
 }
 
-func Render(w *model.World, app *model.Application, incident *model.ApplicationIncident) *View {
+func Render(w *model.World, app *model.Application, incident *model.ApplicationIncident, silences []*db.Silence, events []*db.IncidentEvent, deliveries []*db.NotificationDelivery) *View {
 	to := timeseries.Now()
 	if incident.Resolved() {
 		to = incident.ResolvedAt
 	}
 	v := &View{
-		Incident: renderIncident(w, incident),
-		ActualTo: to,
-		Widgets:  incidentWidgets(w, app),
+		Incident:   renderIncident(w, incident),
+		ActualTo:   to,
+		Widgets:    incidentWidgets(w, app),
+		Silences:   []*db.Silence{},
+		Timeline:   events,
+		Deliveries: deliveries,
+	}
+	if v.Timeline == nil {
+		v.Timeline = []*db.IncidentEvent{}
+	}
+	if v.Deliveries == nil {
+		v.Deliveries = []*db.NotificationDelivery{}
+	}
+	target := db.NewIncidentSilenceTarget(app, incident)
+	for _, s := range silences {
+		if s.ActiveDuring(incident.OpenedAt, to) && s.Matches(target) {
+			v.Silences = append(v.Silences, s)
+		}
 	}
 	if len(app.AvailabilitySLIs) > 0 {
 		sli := app.AvailabilitySLIs[0]
@@ -0,0 +1,151 @@
+package logs
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/coroot/coroot/clickhouse"
+	"github.com/coroot/coroot/model"
+)
+
+const (
+	ExportFormatNDJSON = "ndjson"
+	ExportFormatCSV    = "csv"
+
+	exportBufferSize = 64 * 1024
+)
+
+var exportCSVHeader = []string{"timestamp", "service", "severity", "trace_id", "message", "log_attributes", "resource_attributes"}
+
+type exportEntry struct {
+	Timestamp          string            `json:"timestamp"`
+	Service            string            `json:"service"`
+	Severity           string            `json:"severity"`
+	TraceId            string            `json:"trace_id,omitempty"`
+	Message            string            `json:"message"`
+	LogAttributes      map[string]string `json:"log_attributes,omitempty"`
+	ResourceAttributes map[string]string `json:"resource_attributes,omitempty"`
+}
+
+type Export struct {
+	Format string
+	Query  string
+	query  clickhouse.LogQuery
+}
+
+// NewExport prepares the export of all the application log entries matching the query within the world's time range.
+// The query has the same format as the one used by Render, the limit is ignored.
+func NewExport(ctx context.Context, ch *clickhouse.Client, app *model.Application, query url.Values, w *model.World) (*Export, error) {
+	e := &Export{Format: query.Get("format"), Query: query.Get("query")}
+	switch e.Format {
+	case "":
+		e.Format = ExportFormatNDJSON
+	case ExportFormatNDJSON, ExportFormatCSV:
+	default:
+		return nil, fmt.Errorf("unknown format: %s", e.Format)
+	}
+	q, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if e.query, err = resolveLogQuery(ctx, ch, app, w, q); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *Export) ContentType() string {
+	if e.Format == ExportFormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// Write streams the entries to out and returns the number of entries written.
+// If the export fails midway, an error marker is written as the last line (an {"error": ...} object for NDJSON,
+// a "# error: ..." row for CSV), so that a truncated file can be told apart from a complete one.
+func (e *Export) Write(ctx context.Context, ch *clickhouse.Client, out io.Writer) (int64, error) {
+	buf := bufio.NewWriterSize(out, exportBufferSize)
+	w, err := newExportWriter(e.Format, buf)
+	if err != nil {
+		return 0, err
+	}
+	var rows int64
+	err = ch.ExportLogs(ctx, e.query, func(le *model.LogEntry) error {
+		if err := w.write(le); err != nil {
+			return err
+		}
+		rows++
+		return nil
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			_ = w.fail(err)
+			_ = buf.Flush()
+		}
+		return rows, err
+	}
+	return rows, buf.Flush()
+}
+
+type exportWriter struct {
+	write func(le *model.LogEntry) error
+	fail  func(err error) error
+}
+
+func newExportWriter(format string, w io.Writer) (*exportWriter, error) {
+	entry := func(le *model.LogEntry) exportEntry {
+		return exportEntry{
+			Timestamp:          le.Timestamp.UTC().Format(time.RFC3339Nano),
+			Service:            le.ServiceName,
+			Severity:           le.Severity.String(),
+			TraceId:            le.TraceId,
+			Message:            le.Body,
+			LogAttributes:      le.LogAttributes,
+			ResourceAttributes: le.ResourceAttributes,
+		}
+	}
+	if format == ExportFormatCSV {
+		cw := csv.NewWriter(w)
+		if err := cw.Write(exportCSVHeader); err != nil {
+			return nil, err
+		}
+		write := func(le *model.LogEntry) error {
+			e := entry(le)
+			logAttrs, err := json.Marshal(e.LogAttributes)
+			if err != nil {
+				return err
+			}
+			resourceAttrs, err := json.Marshal(e.ResourceAttributes)
+			if err != nil {
+				return err
+			}
+			if err = cw.Write([]string{e.Timestamp, e.Service, e.Severity, e.TraceId, e.Message, string(logAttrs), string(resourceAttrs)}); err != nil {
+				return err
+			}
+			cw.Flush()
+			return cw.Error()
+		}
+		fail := func(err error) error {
+			_, err = fmt.Fprintf(w, "# error: %s\n", strings.ReplaceAll(err.Error(), "\n", " "))
+			return err
+		}
+		return &exportWriter{write: write, fail: fail}, nil
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	write := func(le *model.LogEntry) error {
+		return enc.Encode(entry(le))
+	}
+	fail := func(err error) error {
+		return enc.Encode(map[string]string{"error": err.Error()})
+	}
+	return &exportWriter{write: write, fail: fail}, nil
+}
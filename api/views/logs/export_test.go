@@ -0,0 +1,45 @@
+package logs
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/coroot/coroot/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportWriter(t *testing.T) {
+	e := &model.LogEntry{
+		ServiceName:        "/k8s/default/app/app",
+		Timestamp:          time.Date(2025, 1, 2, 3, 4, 5, 123456789, time.UTC),
+		Severity:           model.SeverityError,
+		Body:               "failed to connect: \"db\"",
+		TraceId:            "abc",
+		LogAttributes:      map[string]string{"code": "500"},
+		ResourceAttributes: map[string]string{"host": "node-1"},
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := newExportWriter(ExportFormatNDJSON, buf)
+	require.NoError(t, err)
+	require.NoError(t, w.write(e))
+	require.NoError(t, w.fail(errors.New("read timeout")))
+	assert.Equal(t,
+		`{"timestamp":"2025-01-02T03:04:05.123456789Z","service":"/k8s/default/app/app","severity":"error","trace_id":"abc","message":"failed to connect: \"db\"","log_attributes":{"code":"500"},"resource_attributes":{"host":"node-1"}}`+"\n"+
+			`{"error":"read timeout"}`+"\n",
+		buf.String())
+
+	buf.Reset()
+	w, err = newExportWriter(ExportFormatCSV, buf)
+	require.NoError(t, err)
+	require.NoError(t, w.write(e))
+	require.NoError(t, w.fail(errors.New("read timeout")))
+	assert.Equal(t,
+		"timestamp,service,severity,trace_id,message,log_attributes,resource_attributes\n"+
+			`2025-01-02T03:04:05.123456789Z,/k8s/default/app/app,error,abc,"failed to connect: ""db""","{""code"":""500""}","{""host"":""node-1""}"`+"\n"+
+			"# error: read timeout\n",
+		buf.String())
+}
@@ -4,6 +4,7 @@ import (
 	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"slices"
@@ -64,6 +65,7 @@ type Query struct {
 	Source  model.LogSource        `json:"source"`
 	View    string                 `json:"view"`
 	Filters []clickhouse.LogFilter `json:"filters"`
+	Search  string                 `json:"search"`
 	Limit   int                    `json:"limit"`
 	Suggest *string                `json:"suggest,omitempty"`
 	Since   string                 `json:"since"`
@@ -119,12 +121,7 @@ func Render(ctx context.Context, ch *clickhouse.Client, app *model.Application,
 			otelServices = append(otelServices, s)
 		}
 	}
-	otelService := ""
-	if app.Settings != nil && app.Settings.Logs != nil {
-		otelService = app.Settings.Logs.Service
-	} else {
-		otelService = model.GuessService(otelServices, w, app)
-	}
+	otelService := getOtelService(app, w, otelServices)
 
 	if logsFromAgentFound {
 		v.Sources = append(v.Sources, model.LogSourceAgent)
@@ -176,26 +173,11 @@ func renderEntries(ctx context.Context, v *View, ch *clickhouse.Client, app *mod
 	if len(app.Instances) == 0 {
 		return
 	}
-	var err error
-	lq := clickhouse.LogQuery{
-		Ctx:     w.Ctx,
-		Filters: q.Filters,
-		Limit:   q.Limit,
-	}
-	switch v.Source {
-	case model.LogSourceOtel:
-		lq.Services = []string{otelService}
-	case model.LogSourceAgent:
-		lq.Services = getServices(app)
-		hashes := utils.NewStringSet()
-		for _, f := range q.Filters {
-			if f.Name == "pattern.hash" {
-				hashes.Add(getSimilarHashes(app, f.Value)...)
-			}
-		}
-		for _, hash := range hashes.Items() {
-			lq.Filters = append(lq.Filters, clickhouse.LogFilter{Name: "pattern.hash", Op: "=", Value: hash})
-		}
+	lq, err := buildLogQuery(app, w, q, v.Source, otelService)
+	if err != nil {
+		v.Status = model.WARNING
+		v.Message = err.Error()
+		return
 	}
 
 	var histogram []model.LogHistogramBucket
@@ -230,25 +212,7 @@ func renderEntries(ctx context.Context, v *View, ch *clickhouse.Client, app *mod
 
 	var maxTs int64
 	for _, e := range entries {
-		entry := Entry{
-			Timestamp:  e.Timestamp.UnixMilli(),
-			Severity:   e.Severity.String(),
-			Color:      e.Severity.Color(),
-			Message:    e.Body,
-			Attributes: map[string]string{},
-			TraceId:    e.TraceId,
-		}
-		for name, value := range e.LogAttributes {
-			if name != "" && value != "" {
-				entry.Attributes[name] = value
-			}
-		}
-		for name, value := range e.ResourceAttributes {
-			if name != "" && value != "" {
-				entry.Attributes[name] = value
-			}
-		}
-		v.Entries = append(v.Entries, entry)
+		v.Entries = append(v.Entries, newEntry(e))
 		maxTs = max(maxTs, e.Timestamp.UnixNano())
 	}
 	if maxTs != 0 {
@@ -259,6 +223,104 @@ func renderEntries(ctx context.Context, v *View, ch *clickhouse.Client, app *mod
 	}
 }
 
+func buildLogQuery(app *model.Application, w *model.World, q Query, source model.LogSource, otelService string) (clickhouse.LogQuery, error) {
+	search, err := clickhouse.ParseLogSearch(q.Search)
+	if err != nil {
+		return clickhouse.LogQuery{}, err
+	}
+	lq := clickhouse.LogQuery{
+		Ctx:     w.Ctx,
+		Filters: q.Filters,
+		Search:  search,
+		Limit:   q.Limit,
+	}
+	switch source {
+	case model.LogSourceOtel:
+		lq.Services = []string{otelService}
+	case model.LogSourceAgent:
+		lq.Services = getServices(app)
+		hashes := utils.NewStringSet()
+		for _, f := range q.Filters {
+			if f.Name == "pattern.hash" {
+				hashes.Add(getSimilarHashes(app, f.Value)...)
+			}
+		}
+		for _, hash := range hashes.Items() {
+			lq.Filters = append(lq.Filters, clickhouse.LogFilter{Name: "pattern.hash", Op: "=", Value: hash})
+		}
+	}
+	return lq, nil
+}
+
+func parseQuery(query url.Values) (Query, error) {
+	var q Query
+	if s := query.Get("query"); s != "" {
+		if err := json.Unmarshal([]byte(s), &q); err != nil {
+			return q, err
+		}
+	}
+	return q, nil
+}
+
+// resolveLogQuery builds the ClickHouse query the same way as Render does,
+// choosing the OpenTelemetry logs of the application if the source is not specified and they are available.
+func resolveLogQuery(ctx context.Context, ch *clickhouse.Client, app *model.Application, w *model.World, q Query) (clickhouse.LogQuery, error) {
+	if ch == nil {
+		return clickhouse.LogQuery{}, errors.New("ClickHouse integration is not configured")
+	}
+	source := q.Source
+	var otelService string
+	if source != model.LogSourceAgent {
+		services, err := ch.GetServicesFromLogs(ctx, w.Ctx.From)
+		if err != nil {
+			return clickhouse.LogQuery{}, err
+		}
+		var otelServices []string
+		for _, s := range services {
+			if !strings.HasPrefix(s, "/") {
+				otelServices = append(otelServices, s)
+			}
+		}
+		otelService = getOtelService(app, w, otelServices)
+		if source == "" {
+			source = model.LogSourceAgent
+			if otelService != "" {
+				source = model.LogSourceOtel
+			}
+		}
+	}
+	return buildLogQuery(app, w, q, source, otelService)
+}
+
+func newEntry(e *model.LogEntry) Entry {
+	entry := Entry{
+		Timestamp:  e.Timestamp.UnixMilli(),
+		Severity:   e.Severity.String(),
+		Color:      e.Severity.Color(),
+		Message:    e.Body,
+		Attributes: map[string]string{},
+		TraceId:    e.TraceId,
+	}
+	for name, value := range e.LogAttributes {
+		if name != "" && value != "" {
+			entry.Attributes[name] = value
+		}
+	}
+	for name, value := range e.ResourceAttributes {
+		if name != "" && value != "" {
+			entry.Attributes[name] = value
+		}
+	}
+	return entry
+}
+
+func getOtelService(app *model.Application, w *model.World, otelServices []string) string {
+	if app.Settings != nil && app.Settings.Logs != nil {
+		return app.Settings.Logs.Service
+	}
+	return model.GuessService(otelServices, w, app)
+}
+
 func renderPatterns(v *View, app *model.Application, ctx timeseries.Context) {
 	bySeverity := map[model.Severity]*timeseries.Aggregate{}
 	for severity, msgs := range app.LogMessages {
@@ -0,0 +1,121 @@
+package logs
+
+import (
+	"context"
+	"hash/fnv"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/coroot/coroot/clickhouse"
+	"github.com/coroot/coroot/model"
+)
+
+const (
+	tailPollInterval = 2 * time.Second
+	tailBatchLimit   = 500
+	// entries usually land in ClickHouse a few seconds after their timestamps because of the collector batching
+	tailLag = 10 * time.Second
+)
+
+type TailBatch struct {
+	Entries []Entry `json:"entries"`
+	// only the newest entries are sent if there are more than tailBatchLimit of them per poll interval
+	Truncated bool   `json:"truncated"`
+	MaxTs     string `json:"max_ts"`
+}
+
+type tailKey struct {
+	ts   int64
+	hash uint64
+}
+
+// Tail polls ClickHouse for new entries matching the query and passes them to send until the context is canceled.
+func Tail(ctx context.Context, ch *clickhouse.Client, app *model.Application, query url.Values, w *model.World, send func(*TailBatch) error) error {
+	q, err := parseQuery(query)
+	if err != nil {
+		return err
+	}
+	q.Limit = tailBatchLimit
+	lq, err := resolveLogQuery(ctx, ch, app, w, q)
+	if err != nil {
+		return err
+	}
+
+	since := time.Now()
+	if i, _ := strconv.ParseInt(q.Since, 10, 64); i > 0 {
+		since = time.Unix(0, i)
+	}
+	t := newTailState(since)
+
+	ticker := time.NewTicker(tailPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		lq.Since = t.since.Add(-tailLag)
+		entries, err := ch.TailLogs(ctx, lq)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		batch := t.batch(entries)
+		if len(batch.Entries) == 0 {
+			continue
+		}
+		if err = send(batch); err != nil {
+			return err
+		}
+	}
+}
+
+// tailState tracks the entries already sent, since consecutive polls overlap by tailLag.
+type tailState struct {
+	start time.Time
+	since time.Time
+	sent  map[tailKey]time.Time
+}
+
+func newTailState(since time.Time) *tailState {
+	return &tailState{start: since, since: since, sent: map[tailKey]time.Time{}}
+}
+
+// batch builds a batch from the entries of a poll (sorted from the newest to the oldest) that haven't been sent yet.
+func (t *tailState) batch(entries []*model.LogEntry) *TailBatch {
+	batch := &TailBatch{}
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if !e.Timestamp.After(t.start) {
+			continue
+		}
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(e.ServiceName))
+		_, _ = h.Write([]byte(e.Body))
+		k := tailKey{ts: e.Timestamp.UnixNano(), hash: h.Sum64()}
+		if _, ok := t.sent[k]; ok {
+			continue
+		}
+		if len(entries) >= tailBatchLimit && i == len(entries)-1 {
+			// the oldest entry returned hasn't been sent yet, so there may be skipped entries before it
+			batch.Truncated = true
+		}
+		t.sent[k] = e.Timestamp
+		batch.Entries = append(batch.Entries, newEntry(e))
+		if e.Timestamp.After(t.since) {
+			t.since = e.Timestamp
+		}
+	}
+	for k, ts := range t.sent {
+		if ts.Before(t.since.Add(-tailLag)) {
+			delete(t.sent, k)
+		}
+	}
+	batch.MaxTs = strconv.FormatInt(t.since.UnixNano(), 10)
+	return batch
+}
@@ -0,0 +1,83 @@
+package logs
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/coroot/coroot/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTailBatch(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	entry := func(offset time.Duration, body string) *model.LogEntry {
+		return &model.LogEntry{ServiceName: "app", Timestamp: now.Add(offset), Severity: model.SeverityInfo, Body: body}
+	}
+	// the entries are returned from the newest to the oldest
+	poll := func(entries ...*model.LogEntry) []*model.LogEntry {
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+		return entries
+	}
+	messages := func(b *TailBatch) []string {
+		var res []string
+		for _, e := range b.Entries {
+			res = append(res, e.Message)
+		}
+		return res
+	}
+
+	ts := newTailState(now)
+
+	b := ts.batch(poll(entry(-time.Second, "before start"), entry(0, "at start"), entry(time.Second, "a"), entry(2*time.Second, "b")))
+	assert.Equal(t, []string{"a", "b"}, messages(b))
+	assert.False(t, b.Truncated)
+	assert.Equal(t, strconv.FormatInt(now.Add(2*time.Second).UnixNano(), 10), b.MaxTs)
+
+	// overlapping polls: the entries already sent are skipped, the late ones are not
+	b = ts.batch(poll(entry(time.Second, "a"), entry(time.Second+time.Millisecond, "late"), entry(2*time.Second, "b"), entry(3*time.Second, "c")))
+	assert.Equal(t, []string{"late", "c"}, messages(b))
+
+	// equal timestamps: entries are told apart by their service and body
+	b = ts.batch(poll(entry(3*time.Second, "c"), entry(3*time.Second, "c2"), entry(3*time.Second, "c3")))
+	assert.Equal(t, []string{"c2", "c3"}, messages(b))
+	assert.Equal(t, strconv.FormatInt(now.Add(3*time.Second).UnixNano(), 10), b.MaxTs)
+
+	b = ts.batch(poll(entry(3*time.Second, "c"), entry(3*time.Second, "c2")))
+	assert.Empty(t, b.Entries)
+	assert.Equal(t, strconv.FormatInt(now.Add(3*time.Second).UnixNano(), 10), b.MaxTs)
+
+	// the sent entries older than tailLag are forgotten
+	b = ts.batch(poll(entry(tailLag+4*time.Second, "d")))
+	assert.Equal(t, []string{"d"}, messages(b))
+	assert.Len(t, ts.sent, 1)
+}
+
+func TestTailBatchTruncated(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	var entries []*model.LogEntry
+	for i := tailBatchLimit; i > 0; i-- {
+		entries = append(entries, &model.LogEntry{ServiceName: "app", Timestamp: now.Add(time.Duration(i) * time.Millisecond), Body: fmt.Sprint(i)})
+	}
+
+	ts := newTailState(now)
+	b := ts.batch(entries)
+	require.Len(t, b.Entries, tailBatchLimit)
+	assert.True(t, b.Truncated)
+
+	// a full batch is not truncated if its oldest entry has already been sent
+	newer := append([]*model.LogEntry{{ServiceName: "app", Timestamp: now.Add(time.Second), Body: "new"}}, entries[:tailBatchLimit-1]...)
+	b = ts.batch(newer)
+	require.Len(t, b.Entries, 1)
+	assert.False(t, b.Truncated)
+
+	// nor if it has fewer entries than the limit
+	ts = newTailState(now)
+	b = ts.batch(entries[1:])
+	assert.Len(t, b.Entries, tailBatchLimit-1)
+	assert.False(t, b.Truncated)
+}
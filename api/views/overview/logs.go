@@ -41,6 +41,7 @@ type LogsQuery struct {
 	Agent   bool                   `json:"agent"`
 	Otel    bool                   `json:"otel"`
 	Filters []clickhouse.LogFilter `json:"filters"`
+	Search  string                 `json:"search"`
 	Limit   int                    `json:"limit"`
 	Suggest *string                `json:"suggest,omitempty"`
 	Since   string                 `json:"since"`
@@ -67,9 +68,15 @@ func renderLogs(ctx context.Context, ch *clickhouse.Client, w *model.World, quer
 		q.Limit = defaultLimit
 	}
 
+	search, err := clickhouse.ParseLogSearch(q.Search)
+	if err != nil {
+		v.Error = err.Error()
+		return v
+	}
 	lq := clickhouse.LogQuery{
 		Ctx:     w.Ctx,
 		Filters: q.Filters,
+		Search:  search,
 		Limit:   q.Limit,
 	}
 
@@ -84,7 +91,6 @@ func renderLogs(ctx context.Context, ch *clickhouse.Client, w *model.World, quer
 
 	var histogram []model.LogHistogramBucket
 	var entries []*model.LogEntry
-	var err error
 	if q.Suggest != nil {
 		v.Suggest, err = ch.GetLogFilters(ctx, lq, *q.Suggest)
 	} else {
@@ -0,0 +1,161 @@
+package slo
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+)
+
+const monthLayout = "2006-01"
+
+type History struct {
+	Months []*Month `json:"months"`
+}
+
+type Month struct {
+	Month         string              `json:"month"`
+	ApplicationId model.ApplicationId `json:"application_id"`
+	SLO           string              `json:"slo"`
+	Name          string              `json:"name"`
+	Objective     float32             `json:"objective"`
+	Days          int                 `json:"days"`
+	Good          float64             `json:"good"`
+	Total         float64             `json:"total"`
+	Compliance    float32             `json:"compliance"`
+	// BudgetConsumed is the percentage of the month's error budget spent, it exceeds 100 if the objective wasn't met.
+	BudgetConsumed float32     `json:"budget_consumed"`
+	Met            bool        `json:"met"`
+	Incidents      []*Incident `json:"incidents"`
+
+	from, to timeseries.Time
+}
+
+type Incident struct {
+	Key        string          `json:"key"`
+	OpenedAt   timeseries.Time `json:"opened_at"`
+	ResolvedAt timeseries.Time `json:"resolved_at"`
+	Severity   model.Status    `json:"severity"`
+}
+
+func RenderHistory(daily []db.SLODaily, slos []*model.SLO, incidents map[model.ApplicationId][]*model.ApplicationIncident) *History {
+	names := map[string]string{db.SLOAvailability: "Availability", db.SLOLatency: "Latency"}
+	for _, s := range slos {
+		names[s.Id] = s.Name
+	}
+
+	byKey := map[string]*Month{}
+	v := &History{Months: []*Month{}}
+	for _, d := range daily {
+		name, ok := names[d.SLO]
+		if !ok {
+			continue
+		}
+		from := model.SLOCompliancePeriodCalendarMonth.From(d.Day)
+		key := fmt.Sprintf("%d/%s/%s", from, d.ApplicationId, d.SLO)
+		m := byKey[key]
+		if m == nil {
+			fromStd := from.ToStandard().UTC()
+			m = &Month{
+				Month:         fromStd.Format(monthLayout),
+				ApplicationId: d.ApplicationId,
+				SLO:           d.SLO,
+				Name:          name,
+				Incidents:     []*Incident{},
+				from:          from,
+				to:            timeseries.Time(fromStd.AddDate(0, 1, 0).Unix()),
+			}
+			byKey[key] = m
+			v.Months = append(v.Months, m)
+		}
+		// the daily rows are ordered by day, so the objective in effect at the end of the month is used
+		m.Objective = d.Objective
+		m.Days++
+		m.Good += d.Good
+		m.Total += d.Total
+	}
+
+	for _, m := range v.Months {
+		if m.Total > 0 {
+			m.Compliance = float32(m.Good / m.Total * 100)
+		}
+		if allowed := 1 - float64(m.Objective)/100; allowed > 0 && m.Total > 0 {
+			m.BudgetConsumed = float32((m.Total - m.Good) / (m.Total * allowed) * 100)
+		}
+		m.Met = m.Compliance >= m.Objective
+		for _, i := range incidents[m.ApplicationId] {
+			if !i.OpenedAt.Before(m.to) || (i.Resolved() && i.ResolvedAt.Before(m.from)) {
+				continue
+			}
+			if burned(i, m.SLO) {
+				m.Incidents = append(m.Incidents, &Incident{Key: i.Key, OpenedAt: i.OpenedAt, ResolvedAt: i.ResolvedAt, Severity: i.Severity})
+			}
+		}
+	}
+
+	sort.SliceStable(v.Months, func(i, j int) bool {
+		mi, mj := v.Months[i], v.Months[j]
+		if mi.Month != mj.Month {
+			return mi.Month > mj.Month
+		}
+		if mi.ApplicationId != mj.ApplicationId {
+			return mi.ApplicationId.String() < mj.ApplicationId.String()
+		}
+		return mi.Name < mj.Name
+	})
+	return v
+}
+
+func (v *History) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"month", "application", "slo", "objective", "compliance", "budget_consumed", "met", "days", "good", "total", "incidents"})
+	for _, m := range v.Months {
+		keys := make([]string, 0, len(m.Incidents))
+		for _, i := range m.Incidents {
+			keys = append(keys, i.Key)
+		}
+		_ = cw.Write([]string{
+			m.Month,
+			m.ApplicationId.String(),
+			m.Name,
+			fmt.Sprintf("%.3f", m.Objective),
+			fmt.Sprintf("%.3f", m.Compliance),
+			fmt.Sprintf("%.2f", m.BudgetConsumed),
+			fmt.Sprint(m.Met),
+			fmt.Sprint(m.Days),
+			fmt.Sprintf("%.0f", m.Good),
+			fmt.Sprintf("%.0f", m.Total),
+			strings.Join(keys, " "),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func burned(i *model.ApplicationIncident, slo string) bool {
+	severe := func(brs []model.BurnRate) bool {
+		for _, br := range brs {
+			if br.Severity > model.OK {
+				return true
+			}
+		}
+		return false
+	}
+	switch slo {
+	case db.SLOAvailability:
+		return i.Details.AvailabilityImpact.AffectedRequestPercentage > 0 || severe(i.Details.AvailabilityBurnRates)
+	case db.SLOLatency:
+		return i.Details.LatencyImpact.AffectedRequestPercentage > 0 || severe(i.Details.LatencyBurnRates)
+	}
+	for _, c := range i.Details.CustomSLOBurnRates {
+		if c.SLOId == slo {
+			return true
+		}
+	}
+	return false
+}
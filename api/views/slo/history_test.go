@@ -0,0 +1,73 @@
+package slo
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderHistory(t *testing.T) {
+	day := func(month time.Month, d int) timeseries.Time {
+		return timeseries.Time(time.Date(2024, month, d, 0, 0, 0, 0, time.UTC).Unix())
+	}
+	app := model.NewApplicationId("default", model.ApplicationKindDeployment, "api")
+	daily := []db.SLODaily{
+		{ApplicationId: app, SLO: db.SLOAvailability, Day: day(5, 1), Objective: 99, Good: 990, Total: 1000},
+		{ApplicationId: app, SLO: db.SLOAvailability, Day: day(5, 2), Objective: 99, Good: 995, Total: 1000},
+		{ApplicationId: app, SLO: "checkout", Day: day(5, 2), Objective: 90, Good: 100, Total: 100},
+		{ApplicationId: app, SLO: "deleted", Day: day(5, 2), Objective: 90, Good: 1, Total: 100},
+		{ApplicationId: app, SLO: db.SLOLatency, Day: day(6, 1), Objective: 95, Good: 900, Total: 1000},
+	}
+	slos := []*model.SLO{{Id: "checkout", Name: "Checkout", ApplicationId: app}}
+	incidents := map[model.ApplicationId][]*model.ApplicationIncident{app: {
+		{Key: "april", OpenedAt: day(4, 10), ResolvedAt: day(4, 11), Details: model.IncidentDetails{AvailabilityImpact: model.Impact{AffectedRequestPercentage: 5}}},
+		{Key: "may", OpenedAt: day(5, 31), ResolvedAt: day(6, 1), Details: model.IncidentDetails{AvailabilityImpact: model.Impact{AffectedRequestPercentage: 5}}},
+		{Key: "june", OpenedAt: day(6, 1), Details: model.IncidentDetails{LatencyImpact: model.Impact{AffectedRequestPercentage: 10}}},
+	}}
+
+	h := RenderHistory(daily, slos, incidents)
+	require.Len(t, h.Months, 3)
+
+	latency := h.Months[0]
+	assert.Equal(t, "2024-06", latency.Month)
+	assert.Equal(t, "Latency", latency.Name)
+	assert.InDelta(t, 90, latency.Compliance, 0.001)
+	assert.InDelta(t, 200, latency.BudgetConsumed, 0.001)
+	assert.False(t, latency.Met)
+	require.Len(t, latency.Incidents, 1)
+	assert.Equal(t, "june", latency.Incidents[0].Key)
+
+	availability := h.Months[1]
+	assert.Equal(t, "2024-05", availability.Month)
+	assert.Equal(t, "Availability", availability.Name)
+	assert.Equal(t, 2, availability.Days)
+	assert.Equal(t, float64(1985), availability.Good)
+	assert.Equal(t, float64(2000), availability.Total)
+	assert.InDelta(t, 99.25, availability.Compliance, 0.001)
+	assert.InDelta(t, 75, availability.BudgetConsumed, 0.001)
+	assert.True(t, availability.Met)
+	require.Len(t, availability.Incidents, 1)
+	assert.Equal(t, "may", availability.Incidents[0].Key)
+
+	checkout := h.Months[2]
+	assert.Equal(t, "Checkout", checkout.Name)
+	assert.InDelta(t, 100, checkout.Compliance, 0.001)
+	assert.Equal(t, float32(0), checkout.BudgetConsumed)
+	assert.True(t, checkout.Met)
+	assert.Empty(t, checkout.Incidents)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, h.WriteCSV(buf))
+	assert.Equal(t,
+		"month,application,slo,objective,compliance,budget_consumed,met,days,good,total,incidents\n"+
+			"2024-06,default:Deployment:api,Latency,95.000,90.000,200.00,false,1,900,1000,june\n"+
+			"2024-05,default:Deployment:api,Availability,99.000,99.250,75.00,true,2,1985,2000,may\n"+
+			"2024-05,default:Deployment:api,Checkout,90.000,100.000,0.00,true,1,100,100,\n",
+		buf.String())
+}
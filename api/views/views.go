@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/url"
 
+	"github.com/coroot/coroot/api/views/alerts"
 	"github.com/coroot/coroot/api/views/application"
 	"github.com/coroot/coroot/api/views/applications"
 	"github.com/coroot/coroot/api/views/aws"
@@ -14,12 +15,14 @@ import (
 	"github.com/coroot/coroot/api/views/overview"
 	"github.com/coroot/coroot/api/views/profiling"
 	"github.com/coroot/coroot/api/views/roles"
+	"github.com/coroot/coroot/api/views/slo"
 	"github.com/coroot/coroot/api/views/tracing"
 	"github.com/coroot/coroot/api/views/users"
 	"github.com/coroot/coroot/clickhouse"
 	"github.com/coroot/coroot/db"
 	"github.com/coroot/coroot/model"
 	"github.com/coroot/coroot/rbac"
+	"github.com/coroot/coroot/timeseries"
 )
 
 func Overview(ctx context.Context, ch *clickhouse.Client, p *db.Project, w *model.World, view, query string) *overview.Overview {
@@ -30,8 +33,8 @@ func Application(p *db.Project, w *model.World, app *model.Application) *applica
 	return application.Render(p, w, app)
 }
 
-func Incident(w *model.World, app *model.Application, i *model.ApplicationIncident) *incident.View {
-	return incident.Render(w, app, i)
+func Incident(w *model.World, app *model.Application, i *model.ApplicationIncident, silences []*db.Silence, events []*db.IncidentEvent, deliveries []*db.NotificationDelivery) *incident.View {
+	return incident.Render(w, app, i, silences, events, deliveries)
 }
 
 func Incidents(w *model.World, incidents []*model.ApplicationIncident) []incident.Incident {
@@ -62,6 +65,26 @@ func AWS(w *model.World) *aws.View {
 	return aws.Render(w)
 }
 
+func AlertRules(rules []*db.AlertRule, active map[string]*db.Alert) *alerts.View {
+	return alerts.Render(rules, active)
+}
+
+func AlertRule(rule *db.AlertRule, history []*db.Alert) *alerts.RuleView {
+	return alerts.RenderRule(rule, history)
+}
+
+func Notifications(notifications []db.IncidentNotification, deliveries []*db.NotificationDelivery, retryWindow timeseries.Duration) *alerts.NotificationsView {
+	return alerts.RenderNotifications(notifications, deliveries, timeseries.Now(), retryWindow)
+}
+
+func Silences(silences []*db.Silence) []alerts.Silence {
+	return alerts.RenderSilences(silences, timeseries.Now())
+}
+
+func SLOHistory(daily []db.SLODaily, slos []*model.SLO, incidents map[model.ApplicationId][]*model.ApplicationIncident) *slo.History {
+	return slo.RenderHistory(daily, slos, incidents)
+}
+
 func Roles(rs []rbac.Role) *roles.View {
 	return roles.Render(rs)
 }
@@ -77,3 +100,11 @@ func User(u *db.User, projects map[db.ProjectId]string, viewonly bool) *users.Us
 var (
 	Dashboards = &dashboards.Dashboards{}
 )
+
+func LogsTail(ctx context.Context, ch *clickhouse.Client, app *model.Application, q url.Values, w *model.World, send func(*logs.TailBatch) error) error {
+	return logs.Tail(ctx, ch, app, q, w, send)
+}
+
+func LogsExport(ctx context.Context, ch *clickhouse.Client, app *model.Application, q url.Values, w *model.World) (*logs.Export, error) {
+	return logs.NewExport(ctx, ch, app, q, w)
+}
@@ -14,6 +14,7 @@ func (a *appAuditor) slo() {
 	sloRequestsChart(a.app, report, a.clickHouseEnabled)
 	availability(a.w, a.app, report)
 	latency(a.w, a.app, report)
+	customSLOs(a.app, report)
 	clientRequests(a.app, report)
 }
 
@@ -67,6 +68,53 @@ func latency(w *model.World, app *model.Application, report *model.AuditReport)
 	}
 }
 
+func customSLOs(app *model.Application, report *model.AuditReport) {
+	if len(app.CustomSLIs) == 0 {
+		return
+	}
+	table := report.GetOrCreateTable("SLO", "Objective", "Compliance period", "Compliance", "Error budget remaining", "Status")
+	if table == nil {
+		return
+	}
+	for _, sli := range app.CustomSLIs {
+		cfg := sli.Config
+		status := model.NewTableCell()
+		compliance := model.NewTableCell()
+		budget := model.NewTableCell()
+		switch {
+		case sli.Error != "":
+			status.SetStatus(model.UNKNOWN, sli.Error)
+		case sli.Total.TailIsEmpty():
+			status.SetStatus(model.UNKNOWN, "no data")
+		default:
+			status.SetStatus(model.OK, "OK")
+			for _, br := range sli.BurnRates {
+				if br.Severity > model.OK {
+					status.SetStatus(br.Severity, br.FormatSLOStatus())
+					break
+				}
+			}
+		}
+		if !timeseries.IsNaN(sli.Compliance) && sli.Compliance > 0 {
+			compliance.SetValue(utils.FormatPercentage(sli.Compliance))
+		}
+		if last := sli.ErrorBudgetRemaining.Last(); !timeseries.IsNaN(last) {
+			budget.SetValue(utils.FormatFloat(last)).SetUnit("%")
+		}
+		table.AddRow(
+			model.NewTableCell(cfg.Name),
+			model.NewTableCell(utils.FormatPercentage(cfg.ObjectivePercentage)),
+			model.NewTableCell(string(cfg.CompliancePeriod)),
+			compliance,
+			budget,
+			status,
+		)
+		if ch := report.GetOrCreateChart(fmt.Sprintf("Error budget remaining: <var>%s</var>, %%", cfg.Name), nil); ch != nil {
+			ch.AddSeries("remaining", sli.ErrorBudgetRemaining, "green")
+		}
+	}
+}
+
 func lastIncident(app *model.Application) *model.ApplicationIncident {
 	if len(app.Incidents) == 0 {
 		return nil
@@ -108,6 +108,16 @@ func (c *Cache) updaterWorker(projects *sync.Map, projectId db.ProjectId, step t
 			klog.Errorln("could not get check configs:", err)
 			return
 		}
+		alertRules, err := c.db.GetAlertRules(projectId)
+		if err != nil {
+			klog.Errorln("could not get alert rules:", err)
+			return
+		}
+		slos, err := c.db.GetSLOs(projectId)
+		if err != nil {
+			klog.Errorln("could not get SLOs:", err)
+			return
+		}
 
 		queries := slices.Clone(constructor.QUERIES)
 		for appId := range checkConfigs {
@@ -120,6 +130,16 @@ func (c *Cache) updaterWorker(projects *sync.Map, projectId db.ProjectId, step t
 				queries = append(queries, constructor.Q("", latencyCfg.Histogram(), "le"))
 			}
 		}
+		for _, rule := range alertRules {
+			if m := rule.Source.Metrics; rule.Enabled && m != nil {
+				queries = append(queries, constructor.Q("", m.Query, m.GroupingLabels()...))
+			}
+		}
+		for _, slo := range slos {
+			if m := slo.Source.Metrics; m != nil {
+				queries = append(queries, constructor.Q("", m.GoodQuery), constructor.Q("", m.TotalQuery))
+			}
+		}
 
 		var recordingRules []constructor.Query
 		for q := range constructor.RecordingRules {
@@ -158,13 +158,17 @@ func (c *LowLevelClient) Migrate(ctx context.Context, cfg config.CollectorConfig
 		t = strings.ReplaceAll(t, "@ttl_traces", fmt.Sprintf("%d", cfg.TracesTTL))
 		t = strings.ReplaceAll(t, "@ttl_logs", fmt.Sprintf("%d", cfg.LogsTTL))
 		t = strings.ReplaceAll(t, "@ttl_profiles", fmt.Sprintf("%d", cfg.ProfilesTTL))
+		t = strings.ReplaceAll(t, "@ttl_metrics_5m", fmt.Sprintf("%d", cfg.MetricsTTL5m))
+		t = strings.ReplaceAll(t, "@ttl_metrics_1h", fmt.Sprintf("%d", cfg.MetricsTTL1h))
 		t = strings.ReplaceAll(t, "@ttl_metrics", fmt.Sprintf("%d", cfg.MetricsTTL))
 		if c.cluster != "" {
 			t = strings.ReplaceAll(t, "@merge_tree", "ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/{table}', '{replica}')")
 			t = strings.ReplaceAll(t, "@replacing_merge_tree", "ReplicatedReplacingMergeTree('/clickhouse/tables/{shard}/{database}/{table}', '{replica}')")
+			t = strings.ReplaceAll(t, "@aggregating_merge_tree", "ReplicatedAggregatingMergeTree('/clickhouse/tables/{shard}/{database}/{table}', '{replica}')")
 		} else {
 			t = strings.ReplaceAll(t, "@merge_tree", "MergeTree()")
 			t = strings.ReplaceAll(t, "@replacing_merge_tree", "ReplacingMergeTree()")
+			t = strings.ReplaceAll(t, "@aggregating_merge_tree", "AggregatingMergeTree()")
 		}
 		err := c.Exec(ctx, t)
 		if err != nil {
@@ -358,6 +362,58 @@ ORDER BY (MetricName, MetricHash, toUnixTimestamp(Timestamp))
 TTL toDateTime(Timestamp) + toIntervalSecond(@ttl_metrics)
 SETTINGS index_granularity = 8192`,
 
+		`
+CREATE TABLE IF NOT EXISTS metrics_5m @on_cluster (
+	Timestamp DateTime('UTC') CODEC(Delta, ZSTD(1)),
+	MetricHash UInt64 CODEC(ZSTD(1)),
+	MetricName LowCardinality(String) CODEC(ZSTD(1)),
+	Labels Map(LowCardinality(String), String) CODEC(ZSTD(1)),
+	Min SimpleAggregateFunction(min, Float64) CODEC(ZSTD(1)),
+	Max SimpleAggregateFunction(max, Float64) CODEC(ZSTD(1)),
+	Sum SimpleAggregateFunction(sum, Float64) CODEC(ZSTD(1)),
+	Count SimpleAggregateFunction(sum, UInt64) CODEC(ZSTD(1)),
+	INDEX idx_labels_key mapKeys(Labels) TYPE bloom_filter(0.01) GRANULARITY 1,
+	INDEX idx_labels_value mapValues(Labels) TYPE bloom_filter(0.01) GRANULARITY 1
+) ENGINE @aggregating_merge_tree
+PARTITION BY toDate(Timestamp)
+ORDER BY (MetricName, MetricHash, Timestamp)
+TTL Timestamp + toIntervalSecond(@ttl_metrics_5m)
+SETTINGS index_granularity = 8192`,
+
+		`
+CREATE MATERIALIZED VIEW IF NOT EXISTS metrics_5m_mv @on_cluster TO metrics_5m AS
+SELECT
+	toStartOfFiveMinutes(Timestamp) AS Timestamp, MetricHash, MetricName, any(Labels) AS Labels,
+	min(Value) AS Min, max(Value) AS Max, sum(Value) AS Sum, count() AS Count
+FROM metrics
+GROUP BY MetricName, MetricHash, Timestamp`,
+
+		`
+CREATE TABLE IF NOT EXISTS metrics_1h @on_cluster (
+	Timestamp DateTime('UTC') CODEC(Delta, ZSTD(1)),
+	MetricHash UInt64 CODEC(ZSTD(1)),
+	MetricName LowCardinality(String) CODEC(ZSTD(1)),
+	Labels Map(LowCardinality(String), String) CODEC(ZSTD(1)),
+	Min SimpleAggregateFunction(min, Float64) CODEC(ZSTD(1)),
+	Max SimpleAggregateFunction(max, Float64) CODEC(ZSTD(1)),
+	Sum SimpleAggregateFunction(sum, Float64) CODEC(ZSTD(1)),
+	Count SimpleAggregateFunction(sum, UInt64) CODEC(ZSTD(1)),
+	INDEX idx_labels_key mapKeys(Labels) TYPE bloom_filter(0.01) GRANULARITY 1,
+	INDEX idx_labels_value mapValues(Labels) TYPE bloom_filter(0.01) GRANULARITY 1
+) ENGINE @aggregating_merge_tree
+PARTITION BY toYYYYMM(Timestamp)
+ORDER BY (MetricName, MetricHash, Timestamp)
+TTL Timestamp + toIntervalSecond(@ttl_metrics_1h)
+SETTINGS index_granularity = 8192`,
+
+		`
+CREATE MATERIALIZED VIEW IF NOT EXISTS metrics_1h_mv @on_cluster TO metrics_1h AS
+SELECT
+	toStartOfHour(Timestamp) AS Timestamp, MetricHash, MetricName, any(Labels) AS Labels,
+	min(Value) AS Min, max(Value) AS Max, sum(Value) AS Sum, count() AS Count
+FROM metrics
+GROUP BY MetricName, MetricHash, Timestamp`,
+
 		`
 CREATE TABLE IF NOT EXISTS metrics_metadata @on_cluster (
     MetricFamilyName LowCardinality(String) CODEC(ZSTD(1)),
@@ -397,6 +453,12 @@ SETTINGS index_granularity = 8192`,
 		`CREATE TABLE IF NOT EXISTS metrics_distributed ON CLUSTER @cluster AS metrics
 		ENGINE = Distributed(@cluster, currentDatabase(), metrics, MetricHash)`,
 
+		`CREATE TABLE IF NOT EXISTS metrics_5m_distributed ON CLUSTER @cluster AS metrics_5m
+		ENGINE = Distributed(@cluster, currentDatabase(), metrics_5m, MetricHash)`,
+
+		`CREATE TABLE IF NOT EXISTS metrics_1h_distributed ON CLUSTER @cluster AS metrics_1h
+		ENGINE = Distributed(@cluster, currentDatabase(), metrics_1h, MetricHash)`,
+
 		`CREATE TABLE IF NOT EXISTS metrics_metadata_distributed ON CLUSTER @cluster AS metrics_metadata
 		ENGINE = Distributed(@cluster, currentDatabase(), metrics_metadata, sipHash64(MetricFamilyName))`,
 	}
@@ -407,7 +469,7 @@ func ReplaceTables(query string, distributed bool) string {
 		"otel_logs", "otel_logs_service_name_severity_text",
 		"otel_traces", "otel_traces_trace_id_ts", "otel_traces_service_name",
 		"profiling_stacks", "profiling_samples", "profiling_profiles",
-		"metrics", "metrics_metadata",
+		"metrics", "metrics_5m", "metrics_1h", "metrics_metadata",
 	}
 	for _, t := range tbls {
 		placeholder := "@@table_" + t + "@@"
@@ -0,0 +1,113 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+)
+
+// GetLogMetric calculates the values of the log-based metric for the [from, to) interval.
+// Each point covers step seconds of logs and is timestamped with the start of its interval.
+func (c *Client) GetLogMetric(ctx context.Context, m *db.LogMetric, from, to timeseries.Time, step timeseries.Duration) ([]*model.MetricValues, error) {
+	search, err := ParseLogSearch(m.Search)
+	if err != nil {
+		return nil, err
+	}
+	query := LogQuery{Ctx: timeseries.NewContext(from, to, step), Services: m.Services, Search: search}
+	q, args := logMetricQuery(m, query)
+	rows, err := c.Query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byLabels := map[uint64]*model.MetricValues{}
+	var res []*model.MetricValues
+	var t time.Time
+	var v float64
+	groups := make([]string, len(m.GroupBy))
+	dest := []any{&t, &v}
+	for i := range groups {
+		dest = append(dest, &groups[i])
+	}
+	for rows.Next() {
+		if err = rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		ls := model.Labels{}
+		for i, g := range m.GroupBy {
+			if groups[i] != "" {
+				ls[logMetricLabelName(g)] = groups[i]
+			}
+		}
+		h := ls.Hash()
+		mv := byLabels[h]
+		if mv == nil {
+			mv = &model.MetricValues{Labels: ls, LabelsHash: h, Values: timeseries.New(from, query.Ctx.PointsCount(), step)}
+			byLabels[h] = mv
+			res = append(res, mv)
+		}
+		mv.Values.Set(timeseries.Time(t.Unix()), float32(v))
+	}
+	return res, rows.Err()
+}
+
+func logMetricQuery(m *db.LogMetric, query LogQuery) (string, []any) {
+	where, args := query.filters(nil)
+	where = append(where, "Timestamp < @log_metric_to")
+	args = append(args, clickhouse.DateNamed("log_metric_to", query.Ctx.To.ToStandard(), clickhouse.NanoSeconds))
+
+	value := ""
+	switch {
+	case m.Attribute != "":
+		value = "toFloat64OrNull(if(mapContains(LogAttributes, @log_metric_attr), LogAttributes[@log_metric_attr], ResourceAttributes[@log_metric_attr]))"
+		args = append(args, clickhouse.Named("log_metric_attr", m.Attribute))
+	case m.Regex != "":
+		value = "toFloat64OrNull(extract(Body, @log_metric_regex))"
+		args = append(args, clickhouse.Named("log_metric_regex", m.Regex))
+	}
+	agg := "toFloat64(count(1))"
+	if value != "" {
+		where = append(where, value+" IS NOT NULL")
+		agg = fmt.Sprintf("%s(assumeNotNull(%s))", m.Type, value)
+	}
+
+	columns := []string{fmt.Sprintf("toStartOfInterval(Timestamp, INTERVAL %d second)", query.Ctx.Step), agg}
+	groupBy := []string{"1"}
+	for i, g := range m.GroupBy {
+		if g == "service.name" {
+			columns = append(columns, "ServiceName")
+		} else {
+			name := fmt.Sprintf("log_metric_group_%d", i)
+			columns = append(columns, fmt.Sprintf("if(mapContains(LogAttributes, @%[1]s), LogAttributes[@%[1]s], ResourceAttributes[@%[1]s])", name))
+			args = append(args, clickhouse.Named(name, g))
+		}
+		groupBy = append(groupBy, fmt.Sprint(i+3))
+	}
+
+	q := "SELECT " + strings.Join(columns, ", ")
+	q += " FROM @@table_otel_logs@@"
+	q += " WHERE " + strings.Join(where, " AND ")
+	q += " GROUP BY " + strings.Join(groupBy, ", ")
+	return q, args
+}
+
+// logMetricLabelName converts an attribute name to a valid Prometheus label name.
+func logMetricLabelName(attr string) string {
+	b := []byte(attr)
+	for i, c := range b {
+		if !(c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')) {
+			b[i] = '_'
+		}
+	}
+	if len(b) > 0 && b[0] >= '0' && b[0] <= '9' {
+		return "_" + string(b)
+	}
+	return string(b)
+}
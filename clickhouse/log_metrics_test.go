@@ -0,0 +1,29 @@
+package clickhouse
+
+import (
+	"testing"
+
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogMetricQuery(t *testing.T) {
+	query := LogQuery{Ctx: timeseries.NewContext(0, 600, 60), Services: []string{"api"}}
+
+	q, _ := logMetricQuery(&db.LogMetric{Name: "errors", Type: db.LogMetricTypeCount, GroupBy: []string{"service.name"}}, query)
+	assert.Equal(t,
+		"SELECT toStartOfInterval(Timestamp, INTERVAL 60 second), toFloat64(count(1)), ServiceName FROM @@table_otel_logs@@"+
+			" WHERE ServiceName = @serviceName AND Timestamp BETWEEN @from AND @to AND Timestamp < @log_metric_to GROUP BY 1, 3",
+		q)
+
+	q, _ = logMetricQuery(&db.LogMetric{Name: "latency", Type: db.LogMetricTypeAvg, Regex: `took (\d+)ms`, GroupBy: []string{"http.method"}}, query)
+	assert.Equal(t,
+		"SELECT toStartOfInterval(Timestamp, INTERVAL 60 second), avg(assumeNotNull(toFloat64OrNull(extract(Body, @log_metric_regex)))),"+
+			" if(mapContains(LogAttributes, @log_metric_group_0), LogAttributes[@log_metric_group_0], ResourceAttributes[@log_metric_group_0]) FROM @@table_otel_logs@@"+
+			" WHERE ServiceName = @serviceName AND Timestamp BETWEEN @from AND @to AND Timestamp < @log_metric_to AND toFloat64OrNull(extract(Body, @log_metric_regex)) IS NOT NULL GROUP BY 1, 3",
+		q)
+
+	assert.Equal(t, "http_method", logMetricLabelName("http.method"))
+	assert.Equal(t, "_5xx", logMetricLabelName("5xx"))
+}
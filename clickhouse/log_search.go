@@ -0,0 +1,453 @@
+package clickhouse
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/utils"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// LogSearch is a parsed log search query:
+//
+//	timeout                        full-text search for a word
+//	"connection refused"           full-text search for a phrase
+//	/time(d)?out/                  regex match on the message
+//	http.status_code:500           attribute equals the value (also field=value and field!=value)
+//	k8s.namespace:/prod-.*/        attribute matches the regex
+//	duration_ms>=1500              numeric comparison (>, >=, <, <=)
+//	user.id:*                      attribute exists
+//	severity>=warning              severity, service, trace_id and message are matched against the corresponding columns
+//	a AND (b OR NOT c) -d          boolean operators, grouping and negation; terms without an operator are ANDed
+type LogSearch struct {
+	root logSearchNode
+}
+
+// ParseLogSearch returns nil if the query is empty.
+func ParseLogSearch(query string) (*LogSearch, error) {
+	p := &logSearchParser{s: query}
+	p.skipSpace()
+	if p.eof() {
+		return nil, nil
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, p.errorf("unexpected %q", p.s[p.pos])
+	}
+	return &LogSearch{root: root}, nil
+}
+
+func (s *LogSearch) where(args *[]any) string {
+	b := &logSearchBuilder{args: args}
+	return s.root.sql(b)
+}
+
+type logSearchBuilder struct {
+	args *[]any
+	n    int
+}
+
+func (b *logSearchBuilder) param(v any) string {
+	name := fmt.Sprintf("search_%d", b.n)
+	b.n++
+	*b.args = append(*b.args, clickhouse.Named(name, v))
+	return "@" + name
+}
+
+type logSearchNode interface {
+	sql(b *logSearchBuilder) string
+}
+
+type logSearchAnd []logSearchNode
+
+func (n logSearchAnd) sql(b *logSearchBuilder) string {
+	conds := make([]string, 0, len(n))
+	for _, c := range n {
+		conds = append(conds, c.sql(b))
+	}
+	return "(" + strings.Join(conds, " AND ") + ")"
+}
+
+type logSearchOr []logSearchNode
+
+func (n logSearchOr) sql(b *logSearchBuilder) string {
+	conds := make([]string, 0, len(n))
+	for _, c := range n {
+		conds = append(conds, c.sql(b))
+	}
+	return "(" + strings.Join(conds, " OR ") + ")"
+}
+
+type logSearchNot struct {
+	node logSearchNode
+}
+
+func (n logSearchNot) sql(b *logSearchBuilder) string {
+	return "NOT " + n.node.sql(b)
+}
+
+type logSearchText struct {
+	value string
+	regex bool
+}
+
+func (n logSearchText) sql(b *logSearchBuilder) string {
+	if n.regex {
+		return fmt.Sprintf("match(Body, %s)", b.param(n.value))
+	}
+	// hasToken uses the token bloom filter index, but it's case-sensitive and can't match a phrase
+	tokens := utils.Uniq(strings.FieldsFunc(n.value, func(r rune) bool {
+		return unicode.IsSpace(r) || (r <= unicode.MaxASCII && !unicode.IsNumber(r) && !unicode.IsLetter(r))
+	}))
+	title := cases.Title(language.Und)
+	var ands []string
+	for _, t := range tokens {
+		var ors []string
+		for _, s := range utils.NewStringSet(t, strings.ToLower(t), strings.ToUpper(t), title.String(t)).Items() {
+			ors = append(ors, fmt.Sprintf("hasToken(Body, %s)", b.param(s)))
+		}
+		ands = append(ands, "("+strings.Join(ors, " OR ")+")")
+	}
+	if len(tokens) != 1 || tokens[0] != n.value {
+		ands = append(ands, fmt.Sprintf("positionCaseInsensitiveUTF8(Body, %s) > 0", b.param(n.value)))
+	}
+	return "(" + strings.Join(ands, " AND ") + ")"
+}
+
+type logSearchField struct {
+	name  string
+	op    string
+	value string
+	regex bool
+}
+
+func (n logSearchField) sql(b *logSearchBuilder) string {
+	switch strings.ToLower(n.name) {
+	case "message", "body":
+		cond := logSearchText{value: n.value, regex: n.regex}.sql(b)
+		if n.op == "!=" {
+			return "NOT " + cond
+		}
+		return cond
+	case "severity", "level":
+		lo, hi := model.SeverityFromString(strings.ToLower(n.value)).Range()
+		switch n.op {
+		case "!=":
+			return fmt.Sprintf("SeverityNumber NOT BETWEEN %s AND %s", b.param(lo), b.param(hi))
+		case ">":
+			return fmt.Sprintf("SeverityNumber > %s", b.param(hi))
+		case ">=":
+			return fmt.Sprintf("SeverityNumber >= %s", b.param(lo))
+		case "<":
+			return fmt.Sprintf("SeverityNumber < %s", b.param(lo))
+		case "<=":
+			return fmt.Sprintf("SeverityNumber <= %s", b.param(hi))
+		}
+		return fmt.Sprintf("SeverityNumber BETWEEN %s AND %s", b.param(lo), b.param(hi))
+	case "service", "service.name":
+		return n.column("ServiceName", b)
+	case "trace_id", "traceid":
+		return n.column("TraceId", b)
+	}
+
+	name := b.param(n.name)
+	switch {
+	case n.value == "*" && !n.regex:
+		cond := fmt.Sprintf("(mapContains(LogAttributes, %[1]s) OR mapContains(ResourceAttributes, %[1]s))", name)
+		if n.op == "!=" {
+			return "NOT " + cond
+		}
+		return cond
+	case n.op == ">" || n.op == ">=" || n.op == "<" || n.op == "<=":
+		f, _ := strconv.ParseFloat(n.value, 64)
+		return fmt.Sprintf("(toFloat64OrNull(LogAttributes[%[1]s]) %[2]s %[3]s OR toFloat64OrNull(ResourceAttributes[%[1]s]) %[2]s %[3]s)", name, n.op, b.param(f))
+	case n.regex:
+		cond := fmt.Sprintf("(match(LogAttributes[%[1]s], %[2]s) OR match(ResourceAttributes[%[1]s], %[2]s))", name, b.param(n.value))
+		if n.op == "!=" {
+			return "NOT " + cond
+		}
+		return cond
+	case n.op == "!=":
+		return fmt.Sprintf("(LogAttributes[%[1]s] != %[2]s AND ResourceAttributes[%[1]s] != %[2]s)", name, b.param(n.value))
+	}
+	return fmt.Sprintf("(LogAttributes[%[1]s] = %[2]s OR ResourceAttributes[%[1]s] = %[2]s)", name, b.param(n.value))
+}
+
+func (n logSearchField) column(column string, b *logSearchBuilder) string {
+	switch {
+	case n.regex && n.op == "!=":
+		return fmt.Sprintf("NOT match(%s, %s)", column, b.param(n.value))
+	case n.regex:
+		return fmt.Sprintf("match(%s, %s)", column, b.param(n.value))
+	case n.op == "!=":
+		return fmt.Sprintf("%s != %s", column, b.param(n.value))
+	}
+	return fmt.Sprintf("%s = %s", column, b.param(n.value))
+}
+
+type logSearchParser struct {
+	s   string
+	pos int
+}
+
+func (p *logSearchParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid search query at position %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *logSearchParser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *logSearchParser) skipSpace() {
+	for !p.eof() && unicode.IsSpace(rune(p.s[p.pos])) {
+		p.pos++
+	}
+}
+
+// keyword reports whether the input continues with the upper-case keyword followed by a separator.
+func (p *logSearchParser) keyword(k string) bool {
+	if !strings.HasPrefix(p.s[p.pos:], k) {
+		return false
+	}
+	end := p.pos + len(k)
+	return end == len(p.s) || unicode.IsSpace(rune(p.s[end])) || p.s[end] == '('
+}
+
+func (p *logSearchParser) parseOr() (logSearchNode, error) {
+	var res logSearchOr
+	for {
+		n, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, n)
+		p.skipSpace()
+		if !p.keyword("OR") {
+			break
+		}
+		p.pos += len("OR")
+	}
+	if len(res) == 1 {
+		return res[0], nil
+	}
+	return res, nil
+}
+
+func (p *logSearchParser) parseAnd() (logSearchNode, error) {
+	var res logSearchAnd
+	for {
+		p.skipSpace()
+		if p.eof() || p.s[p.pos] == ')' || p.keyword("OR") {
+			break
+		}
+		if p.keyword("AND") {
+			p.pos += len("AND")
+			p.skipSpace()
+		}
+		n, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, n)
+	}
+	switch len(res) {
+	case 0:
+		if p.eof() {
+			return nil, p.errorf("unexpected end of query")
+		}
+		return nil, p.errorf("unexpected %q", p.s[p.pos])
+	case 1:
+		return res[0], nil
+	}
+	return res, nil
+}
+
+func (p *logSearchParser) parseNot() (logSearchNode, error) {
+	p.skipSpace()
+	switch {
+	case p.keyword("NOT"):
+		p.pos += len("NOT")
+	case strings.HasPrefix(p.s[p.pos:], "-") && p.pos+1 < len(p.s) && !unicode.IsSpace(rune(p.s[p.pos+1])):
+		p.pos++
+	default:
+		return p.parsePrimary()
+	}
+	n, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	return logSearchNot{node: n}, nil
+}
+
+func (p *logSearchParser) parsePrimary() (logSearchNode, error) {
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf("unexpected end of query")
+	}
+	switch p.s[p.pos] {
+	case '(':
+		p.pos++
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.eof() || p.s[p.pos] != ')' {
+			return nil, p.errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return n, nil
+	case '"':
+		s, err := p.parseQuoted()
+		if err != nil {
+			return nil, err
+		}
+		return logSearchText{value: s}, nil
+	case '/':
+		s, err := p.parseRegex()
+		if err != nil {
+			return nil, err
+		}
+		return logSearchText{value: s, regex: true}, nil
+	}
+
+	start := p.pos
+	for !p.eof() && isLogSearchFieldChar(p.s[p.pos]) {
+		p.pos++
+	}
+	if name := p.s[start:p.pos]; name != "" {
+		if op := p.parseOp(); op != "" {
+			return p.parseFieldValue(name, op)
+		}
+	}
+	p.pos = start
+	word := p.parseWord()
+	if word == "" {
+		return nil, p.errorf("unexpected %q", p.s[p.pos])
+	}
+	return logSearchText{value: word}, nil
+}
+
+func (p *logSearchParser) parseOp() string {
+	for _, op := range []string{">=", "<=", "!=", ":", "=", ">", "<"} {
+		if strings.HasPrefix(p.s[p.pos:], op) {
+			p.pos += len(op)
+			if op != ":" {
+				return op
+			}
+			// Kibana-like field:>10
+			for _, cmp := range []string{">=", "<=", ">", "<"} {
+				if strings.HasPrefix(p.s[p.pos:], cmp) {
+					p.pos += len(cmp)
+					return cmp
+				}
+			}
+			return "="
+		}
+	}
+	return ""
+}
+
+func (p *logSearchParser) parseFieldValue(name, op string) (logSearchNode, error) {
+	n := logSearchField{name: name, op: op}
+	var err error
+	switch {
+	case p.eof() || unicode.IsSpace(rune(p.s[p.pos])) || p.s[p.pos] == ')':
+		return nil, p.errorf("missing value for %s", name)
+	case p.s[p.pos] == '"':
+		n.value, err = p.parseQuoted()
+	case p.s[p.pos] == '/' && (op == "=" || op == "!="):
+		n.value, err = p.parseRegex()
+		n.regex = true
+	default:
+		n.value = p.parseWord()
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case ">", ">=", "<", "<=":
+		switch strings.ToLower(name) {
+		case "severity", "level":
+		default:
+			if _, err = strconv.ParseFloat(n.value, 64); err != nil {
+				return nil, p.errorf("%s%s%s: a number is expected", name, op, n.value)
+			}
+		}
+	}
+	return n, nil
+}
+
+func (p *logSearchParser) parseWord() string {
+	start := p.pos
+	for !p.eof() {
+		c := p.s[p.pos]
+		if unicode.IsSpace(rune(c)) || c == '(' || c == ')' {
+			break
+		}
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *logSearchParser) parseQuoted() (string, error) {
+	start := p.pos
+	p.pos++
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.s[p.pos]
+		p.pos++
+		switch c {
+		case '\\':
+			if !p.eof() {
+				sb.WriteByte(p.s[p.pos])
+				p.pos++
+			}
+		case '"':
+			return sb.String(), nil
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	p.pos = start
+	return "", p.errorf("unterminated quoted string")
+}
+
+func (p *logSearchParser) parseRegex() (string, error) {
+	start := p.pos
+	p.pos++
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.s[p.pos]
+		p.pos++
+		switch {
+		case c == '\\' && !p.eof() && p.s[p.pos] == '/':
+			sb.WriteByte('/')
+			p.pos++
+		case c == '/':
+			if _, err := regexp.Compile(sb.String()); err != nil {
+				p.pos = start
+				return "", p.errorf("%s", err)
+			}
+			return sb.String(), nil
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	p.pos = start
+	return "", p.errorf("unterminated regular expression")
+}
+
+func isLogSearchFieldChar(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || c == '@' || c == '/' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
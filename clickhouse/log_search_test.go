@@ -0,0 +1,59 @@
+package clickhouse
+
+import (
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLogSearch(t *testing.T) {
+	sql := func(query string) (string, []any) {
+		s, err := ParseLogSearch(query)
+		require.NoError(t, err, query)
+		var args []any
+		return s.where(&args), args
+	}
+
+	s, err := ParseLogSearch("  ")
+	assert.NoError(t, err)
+	assert.Nil(t, s)
+
+	where, args := sql(`timeout`)
+	assert.Equal(t, "((hasToken(Body, @search_0) OR hasToken(Body, @search_1) OR hasToken(Body, @search_2)))", where)
+	assert.Len(t, args, 3)
+
+	_, args = sql(`eRRor`)
+	var variants []any
+	for _, a := range args {
+		variants = append(variants, a.(driver.NamedValue).Value)
+	}
+	assert.ElementsMatch(t, []any{"eRRor", "error", "ERROR", "Error"}, variants)
+
+	where, args = sql(`"connection refused"`)
+	assert.Equal(t, "((hasToken(Body, @search_0) OR hasToken(Body, @search_1) OR hasToken(Body, @search_2)) AND (hasToken(Body, @search_3) OR hasToken(Body, @search_4) OR hasToken(Body, @search_5)) AND positionCaseInsensitiveUTF8(Body, @search_6) > 0)", where)
+	assert.Equal(t, clickhouse.Named("search_6", "connection refused"), args[6])
+
+	where, args = sql(`severity>=warning service:/api-.*/ -k8s.namespace:kube-system`)
+	assert.Equal(t, "(SeverityNumber >= @search_0 AND match(ServiceName, @search_1) AND NOT (LogAttributes[@search_2] = @search_3 OR ResourceAttributes[@search_2] = @search_3))", where)
+	assert.Equal(t, []any{
+		clickhouse.Named("search_0", 13),
+		clickhouse.Named("search_1", "api-.*"),
+		clickhouse.Named("search_2", "k8s.namespace"),
+		clickhouse.Named("search_3", "kube-system"),
+	}, args)
+
+	where, args = sql(`(http.status_code:>=500 OR user.id:*) AND NOT /time(d)?out/`)
+	assert.Equal(t, "(((toFloat64OrNull(LogAttributes[@search_0]) >= @search_1 OR toFloat64OrNull(ResourceAttributes[@search_0]) >= @search_1) OR (mapContains(LogAttributes, @search_2) OR mapContains(ResourceAttributes, @search_2))) AND NOT match(Body, @search_3))", where)
+	assert.Equal(t, clickhouse.Named("search_1", float64(500)), args[1])
+
+	where, _ = sql(`a OR b c`)
+	assert.Equal(t, "(((hasToken(Body, @search_0) OR hasToken(Body, @search_1))) OR (((hasToken(Body, @search_2) OR hasToken(Body, @search_3))) AND ((hasToken(Body, @search_4) OR hasToken(Body, @search_5)))))", where)
+
+	for _, q := range []string{`(a OR b`, `a)`, `"unterminated`, `/[/`, `duration>abc`, `a AND`, `NOT`, `status:`} {
+		_, err = ParseLogSearch(q)
+		assert.Error(t, err, q)
+	}
+}
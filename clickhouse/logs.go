@@ -66,28 +66,141 @@ func (c *Client) GetLogsHistogram(ctx context.Context, query LogQuery) ([]model.
 }
 
 func (c *Client) GetLogs(ctx context.Context, query LogQuery) ([]*model.LogEntry, error) {
+	return c.getLogs(ctx, query, "")
+}
+
+// TailLogs returns the newest entries with timestamps after query.Since.
+func (c *Client) TailLogs(ctx context.Context, query LogQuery) ([]*model.LogEntry, error) {
+	return c.getLogs(ctx, query, "Timestamp DESC")
+}
+
+func (c *Client) getLogs(ctx context.Context, query LogQuery, orderBy string) ([]*model.LogEntry, error) {
+	var res []*model.LogEntry
+	err := c.queryLogs(ctx, query, orderBy, func(e *model.LogEntry) error {
+		res = append(res, e)
+		return nil
+	})
+	return res, err
+}
+
+// ExportLogs passes all the entries matching the query (ignoring the limit) to f in chronological order.
+// The entries are read in pages ordered by (Timestamp, RowHash), so each query is bounded
+// and the result set doesn't need to fit in memory.
+func (c *Client) ExportLogs(ctx context.Context, query LogQuery, f func(e *model.LogEntry) error) error {
+	query.Limit = 0
+	var cursor *logsExportCursor
+	for {
+		next, done, err := c.exportLogsPage(ctx, query, cursor, f)
+		if err != nil || done {
+			return err
+		}
+		cursor = next
+	}
+}
+
+const logsExportPageSize = 10000
+
+// logsExportRowHash breaks the ties between the entries with the same timestamp.
+const logsExportRowHash = "cityHash64(ServiceName, Body, TraceId, mapKeys(LogAttributes), mapValues(LogAttributes), mapKeys(ResourceAttributes), mapValues(ResourceAttributes))"
+
+// logsExportCursor is the position of the last exported entry. Entries with the same timestamp and hash
+// are indistinguishable, so the number of them already exported is tracked to skip them on the next page.
+type logsExportCursor struct {
+	ts   time.Time
+	hash uint64
+	seen int
+}
+
+func logsExportPageQuery(query LogQuery, cursor *logsExportCursor) (string, []any, int) {
+	where, args := query.filters(nil)
+	limit := logsExportPageSize
+	if cursor != nil {
+		where = append(where, "(Timestamp, "+logsExportRowHash+") >= (@cursorTs, @cursorHash)")
+		args = append(args,
+			clickhouse.DateNamed("cursorTs", cursor.ts, clickhouse.NanoSeconds),
+			clickhouse.Named("cursorHash", cursor.hash),
+		)
+		limit += cursor.seen
+	}
+	q := "SELECT ServiceName, Timestamp, multiIf(SeverityNumber=0, 0, intDiv(SeverityNumber, 4)+1), Body, TraceId, ResourceAttributes, LogAttributes, " + logsExportRowHash + " AS RowHash"
+	q += " FROM @@table_otel_logs@@"
+	q += " WHERE " + strings.Join(where, " AND ")
+	q += " ORDER BY Timestamp, RowHash"
+	q += " LIMIT " + fmt.Sprint(limit)
+	return q, args, limit
+}
+
+// exportLogsPage passes the entries following the cursor to f and returns the cursor of the next page,
+// or done if there are no more entries.
+func (c *Client) exportLogsPage(ctx context.Context, query LogQuery, cursor *logsExportCursor, f func(e *model.LogEntry) error) (*logsExportCursor, bool, error) {
+	q, args, limit := logsExportPageQuery(query, cursor)
+	next := &logsExportCursor{}
+	if cursor != nil {
+		*next = *cursor
+	}
+	rows, err := c.Query(ctx, q, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+	read, skipped := 0, 0
+	for rows.Next() {
+		var e model.LogEntry
+		var sev int64
+		var hash uint64
+		if err = rows.Scan(&e.ServiceName, &e.Timestamp, &sev, &e.Body, &e.TraceId, &e.ResourceAttributes, &e.LogAttributes, &hash); err != nil {
+			return nil, false, err
+		}
+		read++
+		if cursor != nil && skipped < cursor.seen && e.Timestamp.Equal(cursor.ts) && hash == cursor.hash {
+			skipped++
+			continue
+		}
+		e.Severity = model.Severity(sev)
+		if err = f(&e); err != nil {
+			return nil, false, err
+		}
+		if e.Timestamp.Equal(next.ts) && hash == next.hash {
+			next.seen++
+		} else {
+			*next = logsExportCursor{ts: e.Timestamp, hash: hash, seen: 1}
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, false, err
+	}
+	return next, read < limit, nil
+}
+
+func (c *Client) queryLogs(ctx context.Context, query LogQuery, orderBy string, f func(e *model.LogEntry) error) error {
 	where, args := query.filters(nil)
 	q := "SELECT ServiceName, Timestamp, multiIf(SeverityNumber=0, 0, intDiv(SeverityNumber, 4)+1), Body, TraceId, ResourceAttributes, LogAttributes"
 	q += " FROM @@table_otel_logs@@"
 	q += " WHERE " + strings.Join(where, " AND ")
-	q += " LIMIT " + fmt.Sprint(query.Limit)
+	if orderBy != "" {
+		q += " ORDER BY " + orderBy
+	}
+	if query.Limit > 0 {
+		q += " LIMIT " + fmt.Sprint(query.Limit)
+	}
 
 	rows, err := c.Query(ctx, q, args...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var res []*model.LogEntry
 	for rows.Next() {
 		var e model.LogEntry
 		var sev int64
 		if err = rows.Scan(&e.ServiceName, &e.Timestamp, &sev, &e.Body, &e.TraceId, &e.ResourceAttributes, &e.LogAttributes); err != nil {
-			return nil, err
+			return err
 		}
 		e.Severity = model.Severity(sev)
-		res = append(res, &e)
+		if err = f(&e); err != nil {
+			return err
+		}
 	}
-	return res, nil
+	return rows.Err()
 }
 
 func (c *Client) GetLogFilters(ctx context.Context, query LogQuery, name string) ([]string, error) {
@@ -149,6 +262,7 @@ type LogQuery struct {
 	Source   model.LogSource
 	Services []string
 	Filters  []LogFilter
+	Search   *LogSearch
 	Limit    int
 	Since    time.Time
 }
@@ -285,6 +399,10 @@ func (q LogQuery) filters(attr *string) ([]string, []any) {
 		i++
 	}
 
+	if q.Search != nil {
+		where = append(where, q.Search.where(&args))
+	}
+
 	if len(message) > 0 {
 		message = utils.Uniq(message)
 		var ands []string
@@ -0,0 +1,29 @@
+package clickhouse
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogsExportPageQuery(t *testing.T) {
+	query := LogQuery{Ctx: timeseries.NewContext(0, 3600, 0), Services: []string{"app"}}
+
+	q, args, limit := logsExportPageQuery(query, nil)
+	assert.Equal(t, logsExportPageSize, limit)
+	assert.Len(t, args, 3)
+	assert.NotContains(t, q, "@cursorTs")
+	assert.True(t, strings.HasSuffix(q, " ORDER BY Timestamp, RowHash LIMIT 10000"), q)
+
+	ts := time.Unix(100, 5)
+	q, args, limit = logsExportPageQuery(query, &logsExportCursor{ts: ts, hash: 42, seen: 3})
+	assert.Equal(t, logsExportPageSize+3, limit)
+	assert.Contains(t, q, "(Timestamp, "+logsExportRowHash+") >= (@cursorTs, @cursorHash)")
+	assert.True(t, strings.HasSuffix(q, " LIMIT 10003"), q)
+	assert.Contains(t, args, clickhouse.DateNamed("cursorTs", ts, clickhouse.NanoSeconds))
+	assert.Contains(t, args, clickhouse.Named("cursorHash", uint64(42)))
+}
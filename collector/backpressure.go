@@ -0,0 +1,145 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/ch-go"
+	chproto "github.com/ClickHouse/ch-go/proto"
+	"github.com/coroot/coroot/db"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	inflightLimit = 64
+
+	// a batch keeps the rows that failed to be stored and retries them,
+	// new requests are rejected once the batch reaches batchLimit*batchMaxPendingFactor rows
+	batchMaxPendingFactor = 10
+	batchRetryInterval    = 10 * time.Second
+)
+
+var droppedRows = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "coroot_collector_dropped_rows_total",
+		Help: "Number of telemetry rows dropped by the collector before being stored in ClickHouse",
+	},
+	[]string{"project_id", "signal"},
+)
+
+type SaturatedError struct {
+	RetryAfter time.Duration
+}
+
+func (e SaturatedError) Error() string {
+	return fmt.Sprintf("the collector is overloaded, retry in %s", e.RetryAfter)
+}
+
+// rejection describes the items of a request that were not accepted by a batch.
+type rejection struct {
+	invalid  int64
+	overflow int64
+}
+
+func (r rejection) count() int64 {
+	return r.invalid + r.overflow
+}
+
+func (r rejection) message(items string) string {
+	var parts []string
+	if r.invalid > 0 {
+		parts = append(parts, fmt.Sprintf("%d invalid %s dropped", r.invalid, items))
+	}
+	if r.overflow > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s rejected: the collector is overloaded", r.overflow, items))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// acquire limits the number of requests processed concurrently for a project.
+func (c *Collector) acquire(projectId db.ProjectId) (func(), error) {
+	c.inflightLock.Lock()
+	sem := c.inflight[projectId]
+	if sem == nil {
+		sem = make(chan struct{}, inflightLimit)
+		c.inflight[projectId] = sem
+	}
+	c.inflightLock.Unlock()
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	default:
+		return nil, SaturatedError{RetryAfter: time.Second}
+	}
+}
+
+func retryAfter(retryAt time.Time) time.Duration {
+	return max(time.Until(retryAt), time.Second)
+}
+
+// retryable reports whether the rows that failed to be stored should be kept for another attempt.
+// Only transient failures are retried: the rows rejected by ClickHouse for other reasons (e.g., a schema mismatch)
+// would be rejected again, blocking the batch.
+func retryable(err error) bool {
+	if err == nil || errors.Is(err, ErrClickhouseNotConfigured) {
+		return false
+	}
+	if e, ok := ch.AsException(err); ok {
+		return e.IsCode(
+			chproto.ErrTimeoutExceeded,
+			chproto.ErrTooManySimultaneousQueries,
+			chproto.ErrNoFreeConnection,
+			chproto.ErrSocketTimeout,
+			chproto.ErrNetworkError,
+			chproto.ErrMemoryLimitExceeded,
+			chproto.ErrTableIsReadOnly,
+			chproto.ErrTooManyParts,
+			chproto.ErrAllConnectionTriesFailed,
+			chproto.ErrKeeperException,
+		)
+	}
+	// network errors, timeouts and the tables not being created yet
+	return true
+}
+
+// retryDelay returns the time after which a rejected request can be retried.
+func retryDelay(err error) (time.Duration, bool) {
+	var se SaturatedError
+	if errors.As(err, &se) {
+		return se.RetryAfter, true
+	}
+	var qe QuotaExceededError
+	if errors.As(err, &qe) {
+		return qe.RetryAfter, true
+	}
+	return 0, false
+}
+
+func writeIngestError(w http.ResponseWriter, err error) {
+	if delay, ok := retryDelay(err); ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	http.Error(w, "", http.StatusServiceUnavailable)
+}
+
+func grpcIngestError(err error) error {
+	if delay, ok := retryDelay(err); ok {
+		st, detailsErr := status.New(codes.ResourceExhausted, err.Error()).WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+		if detailsErr != nil {
+			return status.Error(codes.ResourceExhausted, err.Error())
+		}
+		return st.Err()
+	}
+	return status.Error(codes.Unavailable, err.Error())
+}
@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/ch-go"
+	chproto "github.com/ClickHouse/ch-go/proto"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestTracesBatchBackpressure(t *testing.T) {
+	var attempts int
+	b := NewTracesBatch(2, time.Hour, nil, droppedRows.WithLabelValues("backpressure-test", signalTraces), func(query ch.Query) error {
+		attempts++
+		return errors.New("clickhouse is unavailable")
+	})
+	request := func(n int) *v1.ExportTraceServiceRequest {
+		ss := &tracev1.ScopeSpans{}
+		for i := 0; i < n; i++ {
+			ss.Spans = append(ss.Spans, &tracev1.Span{TraceId: make([]byte, 16), SpanId: make([]byte, 8)})
+		}
+		ss.Spans = append(ss.Spans, &tracev1.Span{})
+		return &v1.ExportTraceServiceRequest{ResourceSpans: []*tracev1.ResourceSpans{{ScopeSpans: []*tracev1.ScopeSpans{ss}}}}
+	}
+
+	rejected, err := b.Add(request(3))
+	require.NoError(t, err)
+	assert.Equal(t, rejection{invalid: 1}, rejected)
+	assert.Equal(t, 1, attempts)
+
+	// the failed rows are kept, only the remaining capacity is accepted and no insert is attempted until the retry interval passes
+	rejected, err = b.Add(request(20))
+	require.NoError(t, err)
+	assert.Equal(t, rejection{overflow: 4}, rejected)
+	assert.Equal(t, "4 spans rejected: the collector is overloaded", rejected.message("spans"))
+	assert.Equal(t, 20, b.Timestamp.Rows())
+	assert.Equal(t, 1, attempts)
+
+	_, err = b.Add(request(1))
+	var se SaturatedError
+	require.ErrorAs(t, err, &se)
+	assert.Equal(t, batchRetryInterval, se.RetryAfter.Round(time.Second))
+
+	w := httptest.NewRecorder()
+	writeIngestError(w, err)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "10", w.Header().Get("Retry-After"))
+}
+
+func TestTracesBatchNonRetryableError(t *testing.T) {
+	dropped := droppedRows.WithLabelValues("non-retryable-test", signalTraces)
+	var attempts int
+	b := NewTracesBatch(2, time.Hour, nil, dropped, func(query ch.Query) error {
+		attempts++
+		return &ch.Exception{Code: chproto.ErrTypeMismatch, Name: "DB::Exception", Message: "type mismatch"}
+	})
+	req := &v1.ExportTraceServiceRequest{ResourceSpans: []*tracev1.ResourceSpans{{ScopeSpans: []*tracev1.ScopeSpans{{Spans: []*tracev1.Span{
+		{TraceId: make([]byte, 16), SpanId: make([]byte, 8)},
+		{TraceId: make([]byte, 16), SpanId: make([]byte, 8)},
+	}}}}}}
+
+	// the rows rejected by ClickHouse are dropped instead of blocking the batch
+	for i := 0; i < 3; i++ {
+		_, err := b.Add(req)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 0, b.Timestamp.Rows())
+	assert.Equal(t, float64(6), testutil.ToFloat64(dropped))
+}
+
+func TestRetryable(t *testing.T) {
+	assert.False(t, retryable(nil))
+	assert.False(t, retryable(ErrClickhouseNotConfigured))
+	assert.True(t, retryable(errors.New("dial tcp 127.0.0.1:9000: connect: connection refused")))
+	assert.True(t, retryable(fmt.Errorf("insert: %w", &ch.Exception{Code: chproto.ErrTooManyParts})))
+	assert.True(t, retryable(&ch.Exception{Code: chproto.ErrMemoryLimitExceeded}))
+	assert.False(t, retryable(&ch.Exception{Code: chproto.ErrUnknownTable}))
+	assert.False(t, retryable(fmt.Errorf("insert: %w", &ch.Exception{Code: chproto.ErrTypeMismatch})))
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -14,6 +17,7 @@ import (
 	"github.com/coroot/coroot/config"
 	"github.com/coroot/coroot/db"
 	"github.com/coroot/coroot/grpc"
+	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/exp/maps"
 	"k8s.io/klog"
 )
@@ -23,6 +27,11 @@ const (
 
 	batchLimit   = 10000
 	batchTimeout = 5 * time.Second
+
+	signalTraces   = "traces"
+	signalLogs     = "logs"
+	signalProfiles = "profiles"
+	signalMetrics  = "metrics"
 )
 
 var (
@@ -46,6 +55,16 @@ type Collector struct {
 	clickhouseClients     map[db.ProjectId]*ch.LowLevelClient
 	clickhouseClientsLock sync.RWMutex
 
+	inflight     map[db.ProjectId]chan struct{}
+	inflightLock sync.Mutex
+
+	quotas *quotas
+
+	pipelines     map[db.ProjectId]*pipeline
+	pipelinesLock sync.Mutex
+
+	syslog *syslogReceiver
+
 	traceBatches       map[db.ProjectId]*TracesBatch
 	traceBatchesLock   sync.Mutex
 	logBatches         map[db.ProjectId]*LogsBatch
@@ -65,13 +84,22 @@ func New(cfg config.CollectorConfig, database *db.DB, cache *cache.Cache, global
 		globalPrometheus:  globalPrometheus,
 		migrationDone:     map[db.ProjectId]bool{},
 		clickhouseClients: map[db.ProjectId]*ch.LowLevelClient{},
+		inflight:          map[db.ProjectId]chan struct{}{},
+		quotas:            newQuotas(),
+		pipelines:         map[db.ProjectId]*pipeline{},
 		traceBatches:      map[db.ProjectId]*TracesBatch{},
 		profileBatches:    map[db.ProjectId]*ProfilesBatch{},
 		logBatches:        map[db.ProjectId]*LogsBatch{},
 		metricsBatches:    map[db.ProjectId]*MetricsBatch{},
 	}
 
+	prometheus.MustRegister(pipelineDropped, droppedRows)
+	if cfg.WALDir != "" {
+		prometheus.MustRegister(walQueueRows, walQueueBytes)
+	}
+
 	c.updateProjects()
+	c.replayWALs()
 	go func() {
 		ticker := time.NewTicker(10 * time.Second)
 		defer ticker.Stop()
@@ -84,6 +112,13 @@ func New(cfg config.CollectorConfig, database *db.DB, cache *cache.Cache, global
 
 	c.registerGRPCServices(grpcServer)
 
+	if cfg.SyslogListenAddress != "" {
+		var err error
+		if c.syslog, err = c.listenSyslog(cfg.SyslogListenAddress, cfg.SyslogApiKey); err != nil {
+			klog.Exitln("syslog receiver:", err)
+		}
+	}
+
 	return c
 }
 
@@ -143,7 +178,27 @@ func (c *Collector) getProject(apiKey string) (*db.Project, error) {
 	return nil, ErrProjectNotFound
 }
 
+// ApiKeyFromHTTPRequest is used by the receivers of third-party protocols whose clients can't always set custom headers.
+// The key is taken from the X-API-Key header, the basic auth password or the bearer token.
+func ApiKeyFromHTTPRequest(r *http.Request) string {
+	if apiKey := r.Header.Get(ApiKeyHeader); apiKey != "" {
+		return apiKey
+	}
+	if _, password, ok := r.BasicAuth(); ok {
+		return password
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return token
+	}
+	return ""
+}
+
 func (c *Collector) Close() {
+	if c.syslog != nil {
+		c.syslog.Close()
+	}
+	c.stopPipelines()
+
 	c.traceBatchesLock.Lock()
 	defer c.traceBatchesLock.Unlock()
 	for _, b := range c.traceBatches {
@@ -242,7 +297,7 @@ func (c *Collector) getTracesBatch(project *db.Project) *TracesBatch {
 	defer c.traceBatchesLock.Unlock()
 	b := c.traceBatches[project.Id]
 	if b == nil {
-		b = NewTracesBatch(batchLimit, batchTimeout, func(query chgo.Query) error {
+		b = NewTracesBatch(batchLimit, batchTimeout, c.openWAL(project.Id, signalTraces), droppedRows.WithLabelValues(string(project.Id), signalTraces), func(query chgo.Query) error {
 			return c.clickhouseDo(context.TODO(), project, query)
 		})
 		c.traceBatches[project.Id] = b
@@ -255,7 +310,7 @@ func (c *Collector) getLogsBatch(project *db.Project) *LogsBatch {
 	defer c.logBatchesLock.Unlock()
 	b := c.logBatches[project.Id]
 	if b == nil {
-		b = NewLogsBatch(batchLimit, batchTimeout, func(query chgo.Query) error {
+		b = NewLogsBatch(batchLimit, batchTimeout, c.openWAL(project.Id, signalLogs), droppedRows.WithLabelValues(string(project.Id), signalLogs), func(query chgo.Query) error {
 			return c.clickhouseDo(context.TODO(), project, query)
 		})
 		c.logBatches[project.Id] = b
@@ -268,7 +323,7 @@ func (c *Collector) getProfilesBatch(project *db.Project) *ProfilesBatch {
 	defer c.profileBatchesLock.Unlock()
 	b := c.profileBatches[project.Id]
 	if b == nil {
-		b = NewProfilesBatch(batchLimit, batchTimeout, func(query chgo.Query) error {
+		b = NewProfilesBatch(batchLimit, batchTimeout, c.openWAL(project.Id, signalProfiles), droppedRows.WithLabelValues(string(project.Id), signalProfiles), func(query chgo.Query) error {
 			return c.clickhouseDo(context.TODO(), project, query)
 		})
 		c.profileBatches[project.Id] = b
@@ -281,7 +336,7 @@ func (c *Collector) getMetricsBatch(project *db.Project) *MetricsBatch {
 	defer c.metricsBatchesLock.Unlock()
 	b := c.metricsBatches[project.Id]
 	if b == nil {
-		b = NewMetricsBatch(batchLimit, batchTimeout, func(query chgo.Query) error {
+		b = NewMetricsBatch(batchLimit, batchTimeout, c.openWAL(project.Id, signalMetrics), droppedRows.WithLabelValues(string(project.Id), signalMetrics), func(query chgo.Query) error {
 			return c.clickhouseDo(context.TODO(), project, query)
 		})
 		c.metricsBatches[project.Id] = b
@@ -289,6 +344,50 @@ func (c *Collector) getMetricsBatch(project *db.Project) *MetricsBatch {
 	return b
 }
 
+func (c *Collector) openWAL(projectId db.ProjectId, signal string) *WAL {
+	if c.cfg.WALDir == "" {
+		return nil
+	}
+	w, err := OpenWAL(filepath.Join(c.cfg.WALDir, string(projectId), signal), c.cfg.WALMaxSize, string(projectId), signal)
+	if err != nil {
+		klog.Errorf("failed to open WAL, %s of project %s will be kept in memory only: %s", signal, projectId, err)
+		return nil
+	}
+	return w
+}
+
+// replayWALs creates the batches of the projects having data left in the WAL,
+// so that the data is stored without waiting for new requests.
+func (c *Collector) replayWALs() {
+	if c.cfg.WALDir == "" {
+		return
+	}
+	c.projectsLock.RLock()
+	projects := maps.Values(c.projects)
+	c.projectsLock.RUnlock()
+	for _, p := range projects {
+		signals, err := os.ReadDir(filepath.Join(c.cfg.WALDir, string(p.Id)))
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				klog.Errorln(err)
+			}
+			continue
+		}
+		for _, s := range signals {
+			switch s.Name() {
+			case signalTraces:
+				c.getTracesBatch(p)
+			case signalLogs:
+				c.getLogsBatch(p)
+			case signalProfiles:
+				c.getProfilesBatch(p)
+			case signalMetrics:
+				c.getMetricsBatch(p)
+			}
+		}
+	}
+}
+
 func (c *Collector) GetClickhouseClusterInfo(project *db.Project) (ch.ClickHouseInfo, error) {
 	client, err := c.getClickhouseClient(project)
 	if err != nil {
@@ -6,6 +6,7 @@ import (
 	"github.com/coroot/coroot/db"
 	"github.com/coroot/coroot/grpc"
 	logsv1 "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	metricsv1 "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
 	tracesv1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
 	"google.golang.org/grpc/metadata"
 	"k8s.io/klog"
@@ -13,6 +14,7 @@ import (
 
 func (c *Collector) registerGRPCServices(server *grpc.Server) {
 	logsv1.RegisterLogsServiceServer(server, NewGRPCLogsService(c))
+	metricsv1.RegisterMetricsServiceServer(server, NewGRPCMetricsService(c))
 	tracesv1.RegisterTraceServiceServer(server, NewGRPCTracesService(c))
 }
 
@@ -34,9 +36,15 @@ func (s *GRPCTracesService) Export(ctx context.Context, req *tracesv1.ExportTrac
 		return nil, err
 	}
 
-	s.collector.getTracesBatch(project).Add(req)
-
-	return &tracesv1.ExportTraceServiceResponse{}, nil
+	rejected, err := s.collector.ingestTraces(project, apiKeyFromGRPCMetadata(ctx), req)
+	if err != nil {
+		return nil, grpcIngestError(err)
+	}
+	resp := &tracesv1.ExportTraceServiceResponse{}
+	if rejected.count() > 0 {
+		resp.PartialSuccess = &tracesv1.ExportTracePartialSuccess{RejectedSpans: rejected.count(), ErrorMessage: rejected.message("spans")}
+	}
+	return resp, nil
 }
 
 type GRPCLogsService struct {
@@ -57,15 +65,56 @@ func (s *GRPCLogsService) Export(ctx context.Context, req *logsv1.ExportLogsServ
 		return nil, err
 	}
 
-	s.collector.getLogsBatch(project).Add(req)
+	rejected, err := s.collector.ingestLogs(project, apiKeyFromGRPCMetadata(ctx), req)
+	if err != nil {
+		return nil, grpcIngestError(err)
+	}
+	resp := &logsv1.ExportLogsServiceResponse{}
+	if rejected.count() > 0 {
+		resp.PartialSuccess = &logsv1.ExportLogsPartialSuccess{RejectedLogRecords: rejected.count(), ErrorMessage: rejected.message("log records")}
+	}
+	return resp, nil
+}
+
+type GRPCMetricsService struct {
+	collector *Collector
+	metricsv1.UnimplementedMetricsServiceServer
+}
+
+func NewGRPCMetricsService(collector *Collector) *GRPCMetricsService {
+	return &GRPCMetricsService{
+		collector: collector,
+	}
+}
+
+func (s *GRPCMetricsService) Export(ctx context.Context, req *metricsv1.ExportMetricsServiceRequest) (*metricsv1.ExportMetricsServiceResponse, error) {
+	project, err := s.collector.getProjectFromGRPCMetadata(ctx)
+	if err != nil {
+		klog.Errorln("failed to get project:", err)
+		return nil, err
+	}
+
+	release, err := s.collector.acquire(project.Id)
+	if err != nil {
+		return nil, grpcIngestError(err)
+	}
+	defer release()
 
-	return &logsv1.ExportLogsServiceResponse{}, nil
+	resp, err := s.collector.exportOTLPMetrics(ctx, project, apiKeyFromGRPCMetadata(ctx), req)
+	if err != nil {
+		klog.Errorln(err)
+		return nil, grpcIngestError(err)
+	}
+	return resp, nil
 }
 
 func (c *Collector) getProjectFromGRPCMetadata(ctx context.Context) (*db.Project, error) {
-	var apiKey string
+	return c.getProject(apiKeyFromGRPCMetadata(ctx))
+}
+
+func apiKeyFromGRPCMetadata(ctx context.Context) string {
 	if values := metadata.ValueFromIncomingContext(ctx, ApiKeyHeader); len(values) > 0 {
-		apiKey = values[0]
+		return values[0]
 	}
-	return c.getProject(apiKey)
+	return ""
 }
@@ -0,0 +1,407 @@
+package collector
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"strings"
+
+	semconv "go.opentelemetry.io/collector/semconv/v1.18.0"
+	v1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"k8s.io/klog"
+)
+
+const (
+	ContentTypeThrift = "application/x-thrift"
+
+	thriftMaxDepth = 64
+)
+
+// JaegerTraces implements the HTTP endpoint of the Jaeger collector (/api/traces) accepting batches in the Thrift binary encoding.
+func (c *Collector) JaegerTraces(w http.ResponseWriter, r *http.Request) {
+	apiKey := ApiKeyFromHTTPRequest(r)
+	project, err := c.getProject(apiKey)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != ContentTypeThrift && mediaType != "application/vnd.apache.thrift.binary" {
+		http.Error(w, fmt.Sprintf("unsupported content type: %s", r.Header.Get("Content-Type")), http.StatusUnsupportedMediaType)
+		return
+	}
+	data, err := readRequestBody(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req, err := decodeJaegerBatch(data)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rejected, err := c.ingestTraces(project, apiKey, req)
+	if err != nil {
+		writeIngestError(w, err)
+		return
+	}
+	if rejected.count() > 0 {
+		klog.Warningln(rejected.message("spans"))
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// decodeJaegerBatch decodes jaeger.thrift Batch into a single OTLP resource:
+//
+//	Batch { 1: Process process, 2: list<Span> spans }
+//	Process { 1: string serviceName, 2: list<Tag> tags }
+//	Span { 1: i64 traceIdLow, 2: i64 traceIdHigh, 3: i64 spanId, 4: i64 parentSpanId, 5: string operationName,
+//	       6: list<SpanRef> references, 7: i32 flags, 8: i64 startTime, 9: i64 duration, 10: list<Tag> tags, 11: list<Log> logs }
+//	SpanRef { 1: i32 refType, 2: i64 traceIdLow, 3: i64 traceIdHigh, 4: i64 spanId }
+//	Log { 1: i64 timestamp, 2: list<Tag> fields }
+func decodeJaegerBatch(data []byte) (*v1.ExportTraceServiceRequest, error) {
+	rs := &tracev1.ResourceSpans{Resource: &resourcev1.Resource{}}
+	ss := &tracev1.ScopeSpans{}
+	rs.ScopeSpans = []*tracev1.ScopeSpans{ss}
+	t := &thriftReader{data: data}
+	err := t.readStruct(func(id int16, typ byte) error {
+		switch {
+		case id == 1 && typ == thriftStruct:
+			return t.readStruct(func(id int16, typ byte) error {
+				switch {
+				case id == 1 && typ == thriftString:
+					rs.Resource.Attributes = append(rs.Resource.Attributes, stringAttribute(semconv.AttributeServiceName, t.readString()))
+				case id == 2 && typ == thriftList:
+					tags, err := t.readTags()
+					rs.Resource.Attributes = append(rs.Resource.Attributes, tags...)
+					return err
+				default:
+					t.skip(typ)
+				}
+				return t.err
+			})
+		case id == 2 && typ == thriftList:
+			return t.readList(func() error {
+				span, err := t.readJaegerSpan()
+				ss.Spans = append(ss.Spans, span)
+				return err
+			})
+		default:
+			t.skip(typ)
+		}
+		return t.err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid jaeger batch: %w", err)
+	}
+	return &v1.ExportTraceServiceRequest{ResourceSpans: []*tracev1.ResourceSpans{rs}}, nil
+}
+
+func (t *thriftReader) readJaegerSpan() (*tracev1.Span, error) {
+	span := &tracev1.Span{Status: &tracev1.Status{}}
+	var traceIdLow, traceIdHigh, spanId, parentSpanId, startTime, duration int64
+	var tags []*commonv1.KeyValue
+	err := t.readStruct(func(id int16, typ byte) error {
+		switch {
+		case id == 1 && typ == thriftI64:
+			traceIdLow = t.readI64()
+		case id == 2 && typ == thriftI64:
+			traceIdHigh = t.readI64()
+		case id == 3 && typ == thriftI64:
+			spanId = t.readI64()
+		case id == 4 && typ == thriftI64:
+			parentSpanId = t.readI64()
+		case id == 5 && typ == thriftString:
+			span.Name = t.readString()
+		case id == 6 && typ == thriftList:
+			return t.readList(func() error {
+				var refType int32
+				var low, high, refSpanId int64
+				err := t.readStruct(func(id int16, typ byte) error {
+					switch {
+					case id == 1 && typ == thriftI32:
+						refType = t.readI32()
+					case id == 2 && typ == thriftI64:
+						low = t.readI64()
+					case id == 3 && typ == thriftI64:
+						high = t.readI64()
+					case id == 4 && typ == thriftI64:
+						refSpanId = t.readI64()
+					default:
+						t.skip(typ)
+					}
+					return t.err
+				})
+				// the parent is usually duplicated as a CHILD_OF reference, the other references are converted to links
+				if refType == 0 && low == traceIdLow && high == traceIdHigh && (parentSpanId == 0 || parentSpanId == refSpanId) {
+					parentSpanId = refSpanId
+				} else {
+					span.Links = append(span.Links, &tracev1.Span_Link{TraceId: jaegerTraceId(low, high), SpanId: jaegerSpanId(refSpanId)})
+				}
+				return err
+			})
+		case id == 8 && typ == thriftI64:
+			startTime = t.readI64()
+		case id == 9 && typ == thriftI64:
+			duration = t.readI64()
+		case id == 10 && typ == thriftList:
+			var err error
+			tags, err = t.readTags()
+			return err
+		case id == 11 && typ == thriftList:
+			return t.readList(func() error {
+				event := &tracev1.Span_Event{}
+				err := t.readStruct(func(id int16, typ byte) error {
+					switch {
+					case id == 1 && typ == thriftI64:
+						event.TimeUnixNano = uint64(t.readI64()) * 1000
+					case id == 2 && typ == thriftList:
+						fields, err := t.readTags()
+						for _, f := range fields {
+							if f.Key == "event" && event.Name == "" {
+								event.Name = valueToString(f.Value)
+								continue
+							}
+							event.Attributes = append(event.Attributes, f)
+						}
+						return err
+					default:
+						t.skip(typ)
+					}
+					return t.err
+				})
+				span.Events = append(span.Events, event)
+				return err
+			})
+		default:
+			t.skip(typ)
+		}
+		return t.err
+	})
+	span.TraceId = jaegerTraceId(traceIdLow, traceIdHigh)
+	span.SpanId = jaegerSpanId(spanId)
+	if parentSpanId != 0 {
+		span.ParentSpanId = jaegerSpanId(parentSpanId)
+	}
+	span.StartTimeUnixNano = uint64(startTime) * 1000
+	span.EndTimeUnixNano = uint64(startTime+duration) * 1000
+	for _, tag := range tags {
+		switch tag.Key {
+		case "span.kind":
+			span.Kind = tracev1.Span_SpanKind(tracev1.Span_SpanKind_value["SPAN_KIND_"+strings.ToUpper(valueToString(tag.Value))])
+		case "otel.status_code":
+			span.Status.Code = tracev1.Status_StatusCode(tracev1.Status_StatusCode_value["STATUS_CODE_"+valueToString(tag.Value)])
+		case "otel.status_description":
+			span.Status.Message = valueToString(tag.Value)
+		case "error":
+			if valueToString(tag.Value) == "true" {
+				span.Status.Code = tracev1.Status_STATUS_CODE_ERROR
+			}
+			span.Attributes = append(span.Attributes, tag)
+		default:
+			span.Attributes = append(span.Attributes, tag)
+		}
+	}
+	return span, err
+}
+
+// readTags reads list<Tag>: Tag { 1: string key, 2: i32 vType, 3: string vStr, 4: double vDouble, 5: bool vBool, 6: i64 vLong, 7: binary vBinary }
+func (t *thriftReader) readTags() ([]*commonv1.KeyValue, error) {
+	var res []*commonv1.KeyValue
+	err := t.readList(func() error {
+		kv := &commonv1.KeyValue{Value: &commonv1.AnyValue{}}
+		err := t.readStruct(func(id int16, typ byte) error {
+			switch {
+			case id == 1 && typ == thriftString:
+				kv.Key = t.readString()
+			case id == 3 && typ == thriftString:
+				kv.Value.Value = &commonv1.AnyValue_StringValue{StringValue: t.readString()}
+			case id == 4 && typ == thriftDouble:
+				kv.Value.Value = &commonv1.AnyValue_DoubleValue{DoubleValue: t.readDouble()}
+			case id == 5 && typ == thriftBool:
+				kv.Value.Value = &commonv1.AnyValue_BoolValue{BoolValue: t.readByte() != 0}
+			case id == 6 && typ == thriftI64:
+				kv.Value.Value = &commonv1.AnyValue_IntValue{IntValue: t.readI64()}
+			case id == 7 && typ == thriftString:
+				kv.Value.Value = &commonv1.AnyValue_BytesValue{BytesValue: []byte(t.readString())}
+			default:
+				t.skip(typ)
+			}
+			return t.err
+		})
+		if kv.Value.Value == nil {
+			kv.Value.Value = &commonv1.AnyValue_StringValue{}
+		}
+		res = append(res, kv)
+		return err
+	})
+	return res, err
+}
+
+func jaegerTraceId(low, high int64) []byte {
+	id := make([]byte, 16)
+	binary.BigEndian.PutUint64(id, uint64(high))
+	binary.BigEndian.PutUint64(id[8:], uint64(low))
+	return id
+}
+
+func jaegerSpanId(id int64) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(id))
+}
+
+const (
+	thriftStop   = 0
+	thriftBool   = 2
+	thriftByte   = 3
+	thriftDouble = 4
+	thriftI16    = 6
+	thriftI32    = 8
+	thriftI64    = 10
+	thriftString = 11
+	thriftStruct = 12
+	thriftMap    = 13
+	thriftSet    = 14
+	thriftList   = 15
+)
+
+var errThriftTruncated = errors.New("unexpected end of data")
+
+// thriftReader decodes the Thrift binary protocol, the first error is kept in err and makes the following reads no-op.
+type thriftReader struct {
+	data  []byte
+	err   error
+	depth int
+}
+
+func (t *thriftReader) next(n int) []byte {
+	if t.err != nil {
+		return nil
+	}
+	if n < 0 || len(t.data) < n {
+		t.err = errThriftTruncated
+		return nil
+	}
+	b := t.data[:n]
+	t.data = t.data[n:]
+	return b
+}
+
+func (t *thriftReader) readByte() byte {
+	if b := t.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (t *thriftReader) readI16() int16 {
+	if b := t.next(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (t *thriftReader) readI32() int32 {
+	if b := t.next(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (t *thriftReader) readI64() int64 {
+	if b := t.next(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (t *thriftReader) readDouble() float64 {
+	return math.Float64frombits(uint64(t.readI64()))
+}
+
+func (t *thriftReader) readString() string {
+	return string(t.next(int(t.readI32())))
+}
+
+// readStruct calls f for each field, f must read or skip the value of the field.
+func (t *thriftReader) readStruct(f func(id int16, typ byte) error) error {
+	for t.err == nil {
+		typ := t.readByte()
+		if typ == thriftStop {
+			break
+		}
+		if err := f(t.readI16(), typ); err != nil {
+			return err
+		}
+	}
+	return t.err
+}
+
+// readList calls f for each element of a list of structs.
+func (t *thriftReader) readList(f func() error) error {
+	typ := t.readByte()
+	size := int(t.readI32())
+	if t.err == nil && (typ != thriftStruct || size < 0 || size > len(t.data)) {
+		t.err = fmt.Errorf("unexpected list of %d elements of type %d", size, typ)
+	}
+	for i := 0; i < size && t.err == nil; i++ {
+		if err := f(); err != nil {
+			return err
+		}
+	}
+	return t.err
+}
+
+func (t *thriftReader) skip(typ byte) {
+	t.depth++
+	defer func() { t.depth-- }()
+	if t.depth > thriftMaxDepth {
+		if t.err == nil {
+			t.err = fmt.Errorf("nesting exceeds %d levels", thriftMaxDepth)
+		}
+		return
+	}
+	switch typ {
+	case thriftBool, thriftByte:
+		t.next(1)
+	case thriftI16:
+		t.next(2)
+	case thriftI32:
+		t.next(4)
+	case thriftDouble, thriftI64:
+		t.next(8)
+	case thriftString:
+		t.next(int(t.readI32()))
+	case thriftStruct:
+		_ = t.readStruct(func(_ int16, typ byte) error {
+			t.skip(typ)
+			return t.err
+		})
+	case thriftMap:
+		keyType, valueType := t.readByte(), t.readByte()
+		size := int(t.readI32())
+		for i := 0; i < size && t.err == nil; i++ {
+			t.skip(keyType)
+			t.skip(valueType)
+		}
+	case thriftSet, thriftList:
+		elemType := t.readByte()
+		size := int(t.readI32())
+		for i := 0; i < size && t.err == nil; i++ {
+			t.skip(elemType)
+		}
+	default:
+		if t.err == nil {
+			t.err = fmt.Errorf("unknown type: %d", typ)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package collector
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+type thriftWriter []byte
+
+func (w *thriftWriter) field(id int16, typ byte) {
+	*w = append(*w, typ)
+	*w = binary.BigEndian.AppendUint16(*w, uint16(id))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(id, thriftI64)
+	*w = binary.BigEndian.AppendUint64(*w, uint64(v))
+}
+
+func (w *thriftWriter) string(id int16, v string) {
+	w.field(id, thriftString)
+	*w = binary.BigEndian.AppendUint32(*w, uint32(len(v)))
+	*w = append(*w, v...)
+}
+
+func (w *thriftWriter) list(id int16, size int) {
+	w.field(id, thriftList)
+	*w = append(*w, thriftStruct)
+	*w = binary.BigEndian.AppendUint32(*w, uint32(size))
+}
+
+func (w *thriftWriter) stringTag(key, value string) {
+	w.string(1, key)
+	w.string(3, value)
+	*w = append(*w, thriftStop)
+}
+
+func TestDecodeJaegerBatch(t *testing.T) {
+	var w thriftWriter
+	w.field(1, thriftStruct) // process
+	w.string(1, "orders")
+	w.list(2, 1)
+	w.stringTag("hostname", "node-1")
+	w = append(w, thriftStop)
+
+	w.list(2, 1) // spans
+	w.i64(1, 2)
+	w.i64(2, 1)
+	w.i64(3, 3)
+	w.i64(4, 4)
+	w.string(5, "SELECT")
+	w.list(6, 1) // the parent duplicated as a reference
+	w.field(1, thriftI32)
+	w = binary.BigEndian.AppendUint32(w, 0)
+	w.i64(2, 2)
+	w.i64(3, 1)
+	w.i64(4, 4)
+	w = append(w, thriftStop)
+	w.field(7, thriftI32) // flags are skipped
+	w = binary.BigEndian.AppendUint32(w, 1)
+	w.i64(8, 1000)
+	w.i64(9, 250)
+	w.list(10, 2)
+	w.stringTag("span.kind", "client")
+	w.field(1, thriftString)
+	w = binary.BigEndian.AppendUint32(w, 5)
+	w = append(w, "error"...)
+	w.field(5, thriftBool)
+	w = append(w, 1, thriftStop)
+	w.list(11, 1)
+	w.i64(1, 1100)
+	w.list(2, 2)
+	w.stringTag("event", "retry")
+	w.stringTag("attempt", "2")
+	w = append(w, thriftStop)
+	w = append(w, thriftStop) // span
+	w = append(w, thriftStop) // batch
+
+	req, err := decodeJaegerBatch(w)
+	require.NoError(t, err)
+	rs := req.ResourceSpans[0]
+	assert.Equal(t, map[string]string{"service.name": "orders", "hostname": "node-1"}, attributesToMap(rs.Resource.Attributes))
+	s := rs.ScopeSpans[0].Spans[0]
+	assert.Equal(t, "00000000000000010000000000000002", hex.EncodeToString(s.TraceId))
+	assert.Equal(t, "0000000000000003", hex.EncodeToString(s.SpanId))
+	assert.Equal(t, "0000000000000004", hex.EncodeToString(s.ParentSpanId))
+	assert.Empty(t, s.Links)
+	assert.Equal(t, "SELECT", s.Name)
+	assert.Equal(t, tracev1.Span_SPAN_KIND_CLIENT, s.Kind)
+	assert.Equal(t, tracev1.Status_STATUS_CODE_ERROR, s.Status.Code)
+	assert.Equal(t, uint64(1000000), s.StartTimeUnixNano)
+	assert.Equal(t, uint64(1250000), s.EndTimeUnixNano)
+	assert.Equal(t, map[string]string{"error": "true"}, attributesToMap(s.Attributes))
+	require.Len(t, s.Events, 1)
+	assert.Equal(t, "retry", s.Events[0].Name)
+	assert.Equal(t, uint64(1100000), s.Events[0].TimeUnixNano)
+	assert.Equal(t, map[string]string{"attempt": "2"}, attributesToMap(s.Events[0].Attributes))
+
+	_, err = decodeJaegerBatch(w[:len(w)-10])
+	assert.Error(t, err)
+}
+
+func TestDecodeJaegerBatchNesting(t *testing.T) {
+	var w thriftWriter
+	for i := 0; i < 100000; i++ {
+		w.field(3, thriftStruct)
+	}
+	_, err := decodeJaegerBatch(w)
+	assert.ErrorContains(t, err, "nesting exceeds")
+}
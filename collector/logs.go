@@ -2,13 +2,15 @@ package collector
 
 import (
 	"encoding/hex"
-	"io"
+	"fmt"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/ClickHouse/ch-go"
 	chproto "github.com/ClickHouse/ch-go/proto"
+	"github.com/coroot/coroot/db"
+	"github.com/prometheus/client_golang/prometheus"
 	semconv "go.opentelemetry.io/collector/semconv/v1.18.0"
 	v1 "go.opentelemetry.io/proto/otlp/collector/logs/v1"
 	"google.golang.org/protobuf/proto"
@@ -23,53 +25,66 @@ func (c *Collector) Logs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	contentType := r.Header.Get("Content-Type")
-	switch contentType {
-	case "application/x-protobuf":
-	default:
-		http.Error(w, "unsupported content type: "+contentType, http.StatusBadRequest)
+	req := &v1.ExportLogsServiceRequest{}
+	mediaType, status, err := readOTLPRequest(r, req)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, err.Error(), status)
 		return
 	}
 
-	decoder, err := getDecoder(r.Header.Get("Content-Encoding"), r.Body)
+	rejected, err := c.ingestLogs(project, r.Header.Get(ApiKeyHeader), req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeIngestError(w, err)
 		return
 	}
+	resp := &v1.ExportLogsServiceResponse{}
+	if rejected.count() > 0 {
+		resp.PartialSuccess = &v1.ExportLogsPartialSuccess{RejectedLogRecords: rejected.count(), ErrorMessage: rejected.message("log records")}
+	}
+	writeOTLPResponse(w, mediaType, resp)
+}
 
-	data, err := io.ReadAll(decoder)
+// ingestLogs applies the ingestion quotas and the ingest pipeline to the request and adds the records to the batch.
+// It is shared by all the log receivers.
+func (c *Collector) ingestLogs(project *db.Project, apiKey string, req *v1.ExportLogsServiceRequest) (rejection, error) {
+	release, err := c.acquire(project.Id)
 	if err != nil {
-		klog.Errorln(err)
-		http.Error(w, "", http.StatusInternalServerError)
-		return
+		return rejection{}, err
 	}
-	req := &v1.ExportLogsServiceRequest{}
-	err = proto.Unmarshal(data, req)
+	defer release()
+
+	if err = c.checkQuotas(project, apiKey, signalLogs, func() int { return countLogRecords(req) }, proto.Size(req)); err != nil {
+		klog.Warningln(err)
+		return rejection{}, err
+	}
+	c.processLogs(project, req)
+	rejected, err := c.getLogsBatch(project).Add(req)
 	if err != nil {
 		klog.Errorln(err)
-		http.Error(w, "", http.StatusBadRequest)
-		return
 	}
+	return rejected, err
+}
 
-	c.getLogsBatch(project).Add(req)
-
-	resp := &v1.ExportLogsServiceResponse{}
-	w.Header().Set("Content-Type", contentType)
-	data, err = proto.Marshal(resp)
-	if err != nil {
-		klog.Errorln(err)
-		http.Error(w, "", http.StatusInternalServerError)
-		return
+func countLogRecords(req *v1.ExportLogsServiceRequest) int {
+	var records int
+	for _, l := range req.GetResourceLogs() {
+		for _, sl := range l.GetScopeLogs() {
+			records += len(sl.GetLogRecords())
+		}
 	}
-	_, _ = w.Write(data)
+	return records
 }
 
 type LogsBatch struct {
-	limit int
-	exec  func(query ch.Query) error
+	limit   int
+	exec    func(query ch.Query) error
+	wal     *WAL
+	dropped prometheus.Counter
 
-	lock sync.Mutex
-	done chan struct{}
+	lock    sync.Mutex
+	done    chan struct{}
+	retryAt time.Time
 
 	Timestamp          *chproto.ColDateTime64
 	TraceId            *chproto.ColStr
@@ -83,11 +98,13 @@ type LogsBatch struct {
 	Body               *chproto.ColStr
 }
 
-func NewLogsBatch(limit int, timeout time.Duration, exec func(query ch.Query) error) *LogsBatch {
+func NewLogsBatch(limit int, timeout time.Duration, wal *WAL, dropped prometheus.Counter, exec func(query ch.Query) error) *LogsBatch {
 	b := &LogsBatch{
-		limit: limit,
-		exec:  exec,
-		done:  make(chan struct{}),
+		limit:   limit,
+		exec:    exec,
+		wal:     wal,
+		dropped: dropped,
+		done:    make(chan struct{}),
 
 		Timestamp:          new(chproto.ColDateTime64).WithPrecision(chproto.PrecisionNano),
 		TraceId:            new(chproto.ColStr),
@@ -110,6 +127,7 @@ func NewLogsBatch(limit int, timeout time.Duration, exec func(query ch.Query) er
 				return
 			case <-ticker.C:
 				b.lock.Lock()
+				b.replay()
 				b.save()
 				b.lock.Unlock()
 			}
@@ -123,13 +141,69 @@ func (b *LogsBatch) Close() {
 	b.done <- struct{}{}
 	b.lock.Lock()
 	defer b.lock.Unlock()
+	b.retryAt = time.Time{}
 	b.save()
+	if b.wal != nil {
+		b.wal.Close()
+	}
 }
 
-func (b *LogsBatch) Add(req *v1.ExportLogsServiceRequest) {
+// Add appends the records of the request to the batch and returns the records rejected due to invalid timestamps
+// or exceeding the capacity of the batch. If the WAL is enabled, the request is written to it first.
+func (b *LogsBatch) Add(req *v1.ExportLogsServiceRequest) (rejection, error) {
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
+	var rejected rejection
+	capacity := b.limit*batchMaxPendingFactor - b.Timestamp.Rows()
+	if capacity <= 0 {
+		return rejected, SaturatedError{RetryAfter: retryAfter(b.retryAt)}
+	}
+	var records int
+	for _, l := range req.GetResourceLogs() {
+		for _, sl := range l.GetScopeLogs() {
+			if n := capacity - records; len(sl.LogRecords) > n {
+				rejected.overflow += int64(len(sl.LogRecords) - n)
+				sl.LogRecords = sl.LogRecords[:n]
+			}
+			records += len(sl.LogRecords)
+		}
+	}
+
+	if b.wal != nil {
+		data, err := proto.Marshal(req)
+		if err != nil {
+			return rejected, err
+		}
+		if err = b.wal.Append(data, records); err != nil {
+			return rejected, fmt.Errorf("failed to write to WAL: %w", err)
+		}
+	}
+	rejected.invalid = b.add(req)
+	if b.Timestamp.Rows() >= b.limit && time.Now().After(b.retryAt) {
+		b.save()
+	}
+	return rejected, nil
+}
+
+func (b *LogsBatch) replay() {
+	if b.wal == nil {
+		return
+	}
+	for b.Timestamp.Rows() < b.limit && b.wal.ReplayBacklog(func(data []byte) {
+		req := &v1.ExportLogsServiceRequest{}
+		if err := proto.Unmarshal(data, req); err != nil {
+			klog.Errorln(err)
+			return
+		}
+		b.add(req)
+	}) {
+	}
+}
+
+func (b *LogsBatch) add(req *v1.ExportLogsServiceRequest) int64 {
+	var rejected int64
+
 	for _, l := range req.GetResourceLogs() {
 		var serviceName string
 		resourceAttributes := attributesToMap(l.GetResource().GetAttributes())
@@ -150,6 +224,7 @@ func (b *LogsBatch) Add(req *v1.ExportLogsServiceRequest) {
 					logAttributes[semconv.AttributeOtelScopeVersion] = scopeVersion
 				}
 				if int64(lr.GetTimeUnixNano()) < 0 {
+					rejected++
 					continue
 				}
 				b.Timestamp.Append(time.Unix(0, int64(lr.GetTimeUnixNano())))
@@ -165,14 +240,11 @@ func (b *LogsBatch) Add(req *v1.ExportLogsServiceRequest) {
 			}
 		}
 	}
-	if b.Timestamp.Rows() < b.limit {
-		return
-	}
-	b.save()
+	return rejected
 }
 
 func (b *LogsBatch) save() {
-	if b.Timestamp.Rows() == 0 {
+	if b.Timestamp.Rows() == 0 || time.Now().Before(b.retryAt) {
 		return
 	}
 
@@ -188,9 +260,22 @@ func (b *LogsBatch) save() {
 		chproto.InputColumn{Name: "LogAttributes", Data: b.LogAttributes},
 		chproto.InputColumn{Name: "Body", Data: b.Body},
 	}
+	var walSegment int
+	if b.wal != nil {
+		walSegment = b.wal.Cut()
+	}
 	err := b.exec(ch.Query{Body: input.Into("@@table_otel_logs@@"), Input: input})
 	if err != nil {
 		klog.Errorln(err)
+		if retryable(err) {
+			b.retryAt = time.Now().Add(batchRetryInterval)
+			return
+		}
+		b.dropped.Add(float64(b.Timestamp.Rows()))
+	}
+	b.retryAt = time.Time{}
+	if b.wal != nil {
+		b.wal.Commit(walSegment)
 	}
 	for _, i := range input {
 		i.Data.(chproto.Resettable).Reset()
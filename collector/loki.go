@@ -0,0 +1,294 @@
+package collector
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coroot/coroot/model"
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	semconv "go.opentelemetry.io/collector/semconv/v1.18.0"
+	v1 "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+	"k8s.io/klog"
+)
+
+var (
+	// the first label found is used as the service name of a stream without the service_name label
+	lokiServiceNameLabels = []string{"service_name", "service", "app", "application", "job", "container"}
+	lokiLevelLabels       = []string{"level", "detected_level", "severity", "lvl"}
+	lokiTraceIdLabels     = []string{"trace_id", "traceID", "traceId"}
+)
+
+type lokiStream struct {
+	labels  []lokiLabel
+	entries []lokiEntry
+}
+
+type lokiLabel struct {
+	name  string
+	value string
+}
+
+type lokiEntry struct {
+	timestamp time.Time
+	line      string
+	metadata  []lokiLabel
+}
+
+// LokiPush implements the Loki push API (/loki/api/v1/push) used by Promtail, Grafana Alloy, Fluent Bit and others.
+func (c *Collector) LokiPush(w http.ResponseWriter, r *http.Request) {
+	apiKey := ApiKeyFromHTTPRequest(r)
+	project, err := c.getProject(apiKey)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	streams, err := readLokiPushRequest(w, r)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rejected, err := c.ingestLogs(project, apiKey, lokiStreamsToOTLP(streams))
+	if err != nil {
+		writeIngestError(w, err)
+		return
+	}
+	if rejected.count() > 0 {
+		klog.Warningln(rejected.message("log records"))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func readLokiPushRequest(w http.ResponseWriter, r *http.Request) ([]lokiStream, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	data, err := readRequestBody(w, r)
+	if err != nil {
+		return nil, err
+	}
+	switch mediaType {
+	case ContentTypeJSON:
+		return decodeLokiJSON(data)
+	case ContentTypeProtobuf, "":
+		// protobuf requests are compressed using the snappy block format regardless of Content-Encoding
+		n, err := snappy.DecodedLen(data)
+		if err != nil {
+			return nil, err
+		}
+		if n > requestBodyMaxSize {
+			return nil, fmt.Errorf("decompressed request body exceeds %d bytes", requestBodyMaxSize)
+		}
+		if data, err = snappy.Decode(nil, data); err != nil {
+			return nil, err
+		}
+		return decodeLokiProtobuf(data)
+	}
+	return nil, fmt.Errorf("unsupported content type: %s", r.Header.Get("Content-Type"))
+}
+
+func decodeLokiJSON(data []byte) ([]lokiStream, error) {
+	var req struct {
+		Streams []struct {
+			Stream map[string]string   `json:"stream"`
+			Values [][]json.RawMessage `json:"values"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, err
+	}
+	var res []lokiStream
+	for _, s := range req.Streams {
+		stream := lokiStream{labels: sortedLokiLabels(s.Stream)}
+		for _, v := range s.Values {
+			if len(v) < 2 {
+				return nil, fmt.Errorf("invalid entry: expected [timestamp, line]")
+			}
+			var ts string
+			var e lokiEntry
+			if err := json.Unmarshal(v[0], &ts); err != nil {
+				return nil, fmt.Errorf("invalid timestamp: %w", err)
+			}
+			ns, err := strconv.ParseInt(ts, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timestamp: %w", err)
+			}
+			e.timestamp = time.Unix(0, ns)
+			if err = json.Unmarshal(v[1], &e.line); err != nil {
+				return nil, fmt.Errorf("invalid line: %w", err)
+			}
+			if len(v) > 2 {
+				var metadata map[string]string
+				if err = json.Unmarshal(v[2], &metadata); err != nil {
+					return nil, fmt.Errorf("invalid structured metadata: %w", err)
+				}
+				e.metadata = sortedLokiLabels(metadata)
+			}
+			stream.entries = append(stream.entries, e)
+		}
+		res = append(res, stream)
+	}
+	return res, nil
+}
+
+// decodeLokiProtobuf decodes logproto.PushRequest:
+//
+//	PushRequest { repeated Stream streams = 1; }
+//	Stream { string labels = 1; repeated Entry entries = 2; }
+//	Entry { google.protobuf.Timestamp timestamp = 1; string line = 2; repeated LabelPair structuredMetadata = 3; }
+//	LabelPair { string name = 1; string value = 2; }
+func decodeLokiProtobuf(data []byte) ([]lokiStream, error) {
+	var res []lokiStream
+	err := walkProto(data, func(num protowire.Number, v []byte, _ uint64) error {
+		if num != 1 {
+			return nil
+		}
+		var s lokiStream
+		err := walkProto(v, func(num protowire.Number, v []byte, _ uint64) error {
+			switch num {
+			case 1:
+				ls, err := parser.ParseMetric(string(v))
+				if err != nil {
+					return fmt.Errorf("invalid stream labels %q: %w", v, err)
+				}
+				ls.Range(func(l labels.Label) {
+					s.labels = append(s.labels, lokiLabel{name: l.Name, value: l.Value})
+				})
+			case 2:
+				e, err := decodeLokiEntry(v)
+				if err != nil {
+					return err
+				}
+				s.entries = append(s.entries, e)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		res = append(res, s)
+		return nil
+	})
+	return res, err
+}
+
+func decodeLokiEntry(data []byte) (lokiEntry, error) {
+	var e lokiEntry
+	err := walkProto(data, func(num protowire.Number, v []byte, _ uint64) error {
+		switch num {
+		case 1:
+			var sec, nsec uint64
+			err := walkProto(v, func(num protowire.Number, _ []byte, x uint64) error {
+				switch num {
+				case 1:
+					sec = x
+				case 2:
+					nsec = x
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			e.timestamp = time.Unix(int64(sec), int64(nsec))
+		case 2:
+			e.line = string(v)
+		case 3:
+			var l lokiLabel
+			err := walkProto(v, func(num protowire.Number, v []byte, _ uint64) error {
+				switch num {
+				case 1:
+					l.name = string(v)
+				case 2:
+					l.value = string(v)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			e.metadata = append(e.metadata, l)
+		}
+		return nil
+	})
+	return e, err
+}
+
+// lokiStreamsToOTLP maps the stream labels to resource attributes and the structured metadata to log attributes.
+func lokiStreamsToOTLP(streams []lokiStream) *v1.ExportLogsServiceRequest {
+	req := &v1.ExportLogsServiceRequest{}
+	now := uint64(time.Now().UnixNano())
+	for _, s := range streams {
+		resource := &resourcev1.Resource{}
+		for _, l := range s.labels {
+			resource.Attributes = append(resource.Attributes, stringAttribute(l.name, l.value))
+		}
+		if findLokiLabel(s.labels, []string{semconv.AttributeServiceName}) == "" {
+			if serviceName := findLokiLabel(s.labels, lokiServiceNameLabels); serviceName != "" {
+				resource.Attributes = append(resource.Attributes, stringAttribute(semconv.AttributeServiceName, serviceName))
+			}
+		}
+		level := findLokiLabel(s.labels, lokiLevelLabels)
+
+		sl := &logsv1.ScopeLogs{}
+		for _, e := range s.entries {
+			lr := &logsv1.LogRecord{
+				TimeUnixNano:         uint64(e.timestamp.UnixNano()),
+				ObservedTimeUnixNano: now,
+				Body:                 &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: e.line}},
+			}
+			for _, l := range e.metadata {
+				lr.Attributes = append(lr.Attributes, stringAttribute(l.name, l.value))
+			}
+			if traceId, err := hex.DecodeString(findLokiLabel(e.metadata, lokiTraceIdLabels)); err == nil && len(traceId) == 16 {
+				lr.TraceId = traceId
+			}
+			entryLevel := findLokiLabel(e.metadata, lokiLevelLabels)
+			if entryLevel == "" {
+				entryLevel = level
+			}
+			if entryLevel != "" {
+				lr.SeverityText = entryLevel
+				lo, _ := model.SeverityFromString(strings.ToLower(entryLevel)).Range()
+				lr.SeverityNumber = logsv1.SeverityNumber(lo)
+			}
+			sl.LogRecords = append(sl.LogRecords, lr)
+		}
+		req.ResourceLogs = append(req.ResourceLogs, &logsv1.ResourceLogs{Resource: resource, ScopeLogs: []*logsv1.ScopeLogs{sl}})
+	}
+	return req
+}
+
+func findLokiLabel(ls []lokiLabel, names []string) string {
+	for _, name := range names {
+		for _, l := range ls {
+			if l.name == name && l.value != "" {
+				return l.value
+			}
+		}
+	}
+	return ""
+}
+
+func sortedLokiLabels(m map[string]string) []lokiLabel {
+	res := make([]lokiLabel, 0, len(m))
+	for k, v := range m {
+		res = append(res, lokiLabel{name: k, value: v})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].name < res[j].name })
+	return res
+}
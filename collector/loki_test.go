@@ -0,0 +1,74 @@
+package collector
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestDecodeLokiPushRequest(t *testing.T) {
+	var entry []byte
+	entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+	var ts []byte
+	ts = protowire.AppendTag(ts, 1, protowire.VarintType)
+	ts = protowire.AppendVarint(ts, 1700000000)
+	ts = protowire.AppendTag(ts, 2, protowire.VarintType)
+	ts = protowire.AppendVarint(ts, 500)
+	entry = protowire.AppendBytes(entry, ts)
+	entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+	entry = protowire.AppendString(entry, "GET /api 500")
+	var label []byte
+	label = protowire.AppendTag(label, 1, protowire.BytesType)
+	label = protowire.AppendString(label, "level")
+	label = protowire.AppendTag(label, 2, protowire.BytesType)
+	label = protowire.AppendString(label, "ERROR")
+	entry = protowire.AppendTag(entry, 3, protowire.BytesType)
+	entry = protowire.AppendBytes(entry, label)
+
+	var stream []byte
+	stream = protowire.AppendTag(stream, 1, protowire.BytesType)
+	stream = protowire.AppendString(stream, `{job="nginx", namespace="default"}`)
+	stream = protowire.AppendTag(stream, 2, protowire.BytesType)
+	stream = protowire.AppendBytes(stream, entry)
+	var req []byte
+	req = protowire.AppendTag(req, 1, protowire.BytesType)
+	req = protowire.AppendBytes(req, stream)
+
+	fromProto, err := decodeLokiProtobuf(req)
+	require.NoError(t, err)
+	fromJSON, err := decodeLokiJSON([]byte(`{"streams":[{"stream":{"namespace":"default","job":"nginx"},"values":[["1700000000000000500","GET /api 500",{"level":"ERROR"}]]}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, fromProto, fromJSON)
+
+	otlp := lokiStreamsToOTLP(fromProto)
+	require.Len(t, otlp.ResourceLogs, 1)
+	rl := otlp.ResourceLogs[0]
+	assert.Equal(t, map[string]string{"job": "nginx", "namespace": "default", "service.name": "nginx"}, attributesToMap(rl.Resource.Attributes))
+	lr := rl.ScopeLogs[0].LogRecords[0]
+	assert.Equal(t, uint64(time.Unix(1700000000, 500).UnixNano()), lr.TimeUnixNano)
+	assert.Equal(t, "GET /api 500", lr.Body.GetStringValue())
+	assert.Equal(t, "ERROR", lr.SeverityText)
+	assert.Equal(t, int32(17), int32(lr.SeverityNumber))
+}
+
+func TestReadLokiPushRequestLimits(t *testing.T) {
+	read := func(contentType string, body []byte) error {
+		r := httptest.NewRequest(http.MethodPost, "/loki/api/v1/push", bytes.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		_, err := readLokiPushRequest(httptest.NewRecorder(), r)
+		return err
+	}
+
+	// a snappy block is rejected by the decoded length in its header, before allocating the buffer
+	block := protowire.AppendVarint(nil, 1<<31)
+	block = append(block, 0, 0, 0, 0)
+	assert.EqualError(t, read(ContentTypeProtobuf, block), "decompressed request body exceeds 67108864 bytes")
+
+	assert.EqualError(t, read(ContentTypeJSON, make([]byte, requestBodyMaxSize+1)), "http: request body too large")
+}
@@ -3,10 +3,12 @@ package collector
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"sort"
@@ -15,10 +17,13 @@ import (
 
 	"github.com/ClickHouse/ch-go"
 	chproto "github.com/ClickHouse/ch-go/proto"
+	"github.com/coroot/coroot/db"
 	"github.com/gogo/protobuf/proto"
 	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
 	promModel "github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/prompb"
+	metricsv1 "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
 	"k8s.io/klog"
 )
 
@@ -32,11 +37,11 @@ var (
 	}}
 )
 
-func addLabelsIfNeeded(r *http.Request, body []byte, extraLabels map[string]string) ([]byte, error) {
+func addLabelsIfNeeded(body []byte, parse func() (*prompb.WriteRequest, error), extraLabels map[string]string) ([]byte, error) {
 	if len(extraLabels) == 0 {
 		return body, nil
 	}
-	req, err := parseMetricsRequestBody(r, body)
+	req, err := parse()
 	if err != nil {
 		return nil, err
 	}
@@ -63,62 +68,72 @@ func (c *Collector) Metrics(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "", http.StatusInternalServerError)
 		return
 	}
+	release, err := c.acquire(project.Id)
+	if err != nil {
+		writeIngestError(w, err)
+		return
+	}
+	defer release()
+
+	if !isRemoteWriteRequest(r) {
+		c.otlpMetrics(w, r, project)
+		return
+	}
 	cfg := project.PrometheusConfig(c.globalPrometheus)
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		klog.Errorln(err)
 		http.Error(w, "", http.StatusBadRequest)
+		return
 	}
-	if cfg.UseClickHouse {
-		req, err := parseMetricsRequestBody(r, body)
+	size, err := snappy.DecodedLen(body)
+	if err != nil {
+		size = len(body)
+	}
+	// the body is parsed at most once, only if it's needed to count the samples, store them or add the extra labels
+	parse := sync.OnceValues(func() (*prompb.WriteRequest, error) {
+		return parseMetricsRequestBody(r, body)
+	})
+	samples := func() int {
+		req, err := parse()
 		if err != nil {
-			klog.Errorln(err)
-			http.Error(w, "", http.StatusBadRequest)
-			return
+			return 0
 		}
-		c.getMetricsBatch(project).Add(req)
+		return countSamples(req)
+	}
+	if err = c.checkQuotas(project, r.Header.Get(ApiKeyHeader), signalMetrics, samples, size); err != nil {
+		klog.Warningln(err)
+		writeIngestError(w, err)
 		return
 	}
-
-	var u *url.URL
-	if cfg.RemoteWriteUrl == "" {
-		u, err = url.Parse(cfg.Url)
+	if cfg.UseClickHouse {
+		req, err := parse()
 		if err != nil {
 			klog.Errorln(err)
-			http.Error(w, "", http.StatusInternalServerError)
+			http.Error(w, "", http.StatusBadRequest)
 			return
 		}
-		u = u.JoinPath("/api/v1/write")
-	} else {
-		u, err = url.Parse(cfg.RemoteWriteUrl)
-		if err != nil {
+		if err = c.getMetricsBatch(project).Add(req); err != nil {
 			klog.Errorln(err)
-			http.Error(w, "", http.StatusInternalServerError)
-			return
+			writeIngestError(w, err)
 		}
+		return
 	}
 
-	if cfg.BasicAuth != nil {
-		u.User = url.UserPassword(cfg.BasicAuth.User, cfg.BasicAuth.Password)
-	}
-	body, err = addLabelsIfNeeded(r, body, cfg.ExtraLabels)
+	body, err = addLabelsIfNeeded(body, parse, cfg.ExtraLabels)
 	if err != nil {
 		klog.Errorln(err)
 		http.Error(w, "", http.StatusBadRequest)
 		return
 	}
 
-	req, err := http.NewRequestWithContext(r.Context(), r.Method, u.String(), bytes.NewReader(body))
+	req, httpClient, err := newRemoteWriteRequest(r.Context(), cfg, body)
 	if err != nil {
 		klog.Errorln(err)
 		http.Error(w, "", http.StatusInternalServerError)
 		return
 	}
-
-	for _, h := range cfg.CustomHeaders {
-		req.Header.Add(h.Key, h.Value)
-	}
 	for k, vs := range r.Header {
 		if k == ApiKeyHeader {
 			continue
@@ -127,10 +142,6 @@ func (c *Collector) Metrics(w http.ResponseWriter, r *http.Request) {
 			req.Header.Add(k, v)
 		}
 	}
-	httpClient := secureClient
-	if cfg.TlsSkipVerify {
-		httpClient = insecureClient
-	}
 	res, err := httpClient.Do(req)
 	if err != nil {
 		klog.Errorln(err)
@@ -162,6 +173,140 @@ func (c *Collector) Metrics(w http.ResponseWriter, r *http.Request) {
 	_, _ = io.Copy(w, res.Body)
 }
 
+func (c *Collector) otlpMetrics(w http.ResponseWriter, r *http.Request, project *db.Project) {
+	req := &metricsv1.ExportMetricsServiceRequest{}
+	mediaType, status, err := readOTLPRequest(r, req)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+	resp, err := c.exportOTLPMetrics(r.Context(), project, r.Header.Get(ApiKeyHeader), req)
+	if err != nil {
+		klog.Errorln(err)
+		if _, ok := retryDelay(err); ok {
+			writeIngestError(w, err)
+			return
+		}
+		http.Error(w, "", http.StatusBadGateway)
+		return
+	}
+	writeOTLPResponse(w, mediaType, resp)
+}
+
+// exportOTLPMetrics converts OTLP metrics to Prometheus series and stores them in ClickHouse
+// or sends them to the project's Prometheus via remote-write.
+func (c *Collector) exportOTLPMetrics(ctx context.Context, project *db.Project, apiKey string, req *metricsv1.ExportMetricsServiceRequest) (*metricsv1.ExportMetricsServiceResponse, error) {
+	wr, rejected := otlpMetricsToPrometheus(req)
+	if err := c.checkQuotas(project, apiKey, signalMetrics, func() int { return countSamples(wr) }, messageSize(req)); err != nil {
+		return nil, err
+	}
+	resp := &metricsv1.ExportMetricsServiceResponse{}
+	if rejected > 0 {
+		resp.PartialSuccess = &metricsv1.ExportMetricsPartialSuccess{
+			RejectedDataPoints: rejected,
+			ErrorMessage:       fmt.Sprintf("%d data points with delta temporality dropped", rejected),
+		}
+	}
+	if len(wr.Timeseries) == 0 {
+		return resp, nil
+	}
+
+	if err := c.WriteMetrics(ctx, project, wr); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// WriteMetrics stores the series in ClickHouse or sends them to the project's Prometheus via remote-write.
+func (c *Collector) WriteMetrics(ctx context.Context, project *db.Project, wr *prompb.WriteRequest) error {
+	cfg := project.PrometheusConfig(c.globalPrometheus)
+	if cfg.UseClickHouse {
+		return c.getMetricsBatch(project).Add(wr)
+	}
+
+	for i := range wr.Timeseries {
+		for k, v := range cfg.ExtraLabels {
+			wr.Timeseries[i].Labels = append(wr.Timeseries[i].Labels, prompb.Label{Name: k, Value: v})
+		}
+	}
+	data, err := proto.Marshal(wr)
+	if err != nil {
+		return err
+	}
+	rwReq, httpClient, err := newRemoteWriteRequest(ctx, cfg, snappy.Encode(nil, data))
+	if err != nil {
+		return err
+	}
+	rwReq.Header.Set("Content-Type", ContentTypeProtobuf)
+	rwReq.Header.Set("Content-Encoding", "snappy")
+	rwReq.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	res, err := httpClient.Do(rwReq)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}()
+	if res.StatusCode >= 400 {
+		scanner := bufio.NewScanner(io.LimitReader(res.Body, 1024))
+		line := ""
+		if scanner.Scan() {
+			line = scanner.Text()
+		}
+		if res.StatusCode == http.StatusBadRequest {
+			// the data is rejected by Prometheus, retrying won't help
+			klog.Errorf("failed to write: got %d (%s) from prometheus", res.StatusCode, line)
+			return nil
+		}
+		return fmt.Errorf("failed to write: got %d (%s) from prometheus", res.StatusCode, line)
+	}
+	return nil
+}
+
+// isRemoteWriteRequest distinguishes Prometheus remote-write requests from OTLP ones served by the same endpoint.
+// Remote-write bodies are always snappy-compressed protobuf, while OTLP clients never use snappy block compression.
+// isRemoteWriteRequest tells Prometheus remote-write requests from OTLP ones (protobuf or JSON) sent to /v1/metrics.
+func isRemoteWriteRequest(r *http.Request) bool {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == ContentTypeJSON {
+		return false
+	}
+	return r.Header.Get("X-Prometheus-Remote-Write-Version") != "" || r.Header.Get("Content-Encoding") == "snappy"
+}
+
+func newRemoteWriteRequest(ctx context.Context, cfg *db.IntegrationPrometheus, body []byte) (*http.Request, *http.Client, error) {
+	var u *url.URL
+	var err error
+	if cfg.RemoteWriteUrl == "" {
+		u, err = url.Parse(cfg.Url)
+		if err != nil {
+			return nil, nil, err
+		}
+		u = u.JoinPath("/api/v1/write")
+	} else {
+		u, err = url.Parse(cfg.RemoteWriteUrl)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.BasicAuth != nil {
+		u.User = url.UserPassword(cfg.BasicAuth.User, cfg.BasicAuth.Password)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, h := range cfg.CustomHeaders {
+		req.Header.Add(h.Key, h.Value)
+	}
+	httpClient := secureClient
+	if cfg.TlsSkipVerify {
+		httpClient = insecureClient
+	}
+	return req, httpClient, nil
+}
+
 func parseMetricsRequestBody(r *http.Request, body []byte) (*prompb.WriteRequest, error) {
 	if r.Header.Get("Content-Type") != "application/x-protobuf" {
 		return nil, fmt.Errorf("expected application/x-protobuf content-type")
@@ -183,12 +328,23 @@ func parseMetricsRequestBody(r *http.Request, body []byte) (*prompb.WriteRequest
 	return &req, nil
 }
 
+func countSamples(req *prompb.WriteRequest) int {
+	var samples int
+	for _, ts := range req.GetTimeseries() {
+		samples += len(ts.Samples)
+	}
+	return samples
+}
+
 type MetricsBatch struct {
-	limit int
-	exec  func(query ch.Query) error
+	limit   int
+	exec    func(query ch.Query) error
+	wal     *WAL
+	dropped prometheus.Counter
 
-	lock sync.Mutex
-	done chan struct{}
+	lock    sync.Mutex
+	done    chan struct{}
+	retryAt time.Time
 
 	Timestamp  *chproto.ColDateTime64
 	MetricHash *chproto.ColUInt64
@@ -202,11 +358,13 @@ type MetricsBatch struct {
 	Unit             *chproto.ColLowCardinality[string]
 }
 
-func NewMetricsBatch(limit int, timeout time.Duration, exec func(query ch.Query) error) *MetricsBatch {
+func NewMetricsBatch(limit int, timeout time.Duration, wal *WAL, dropped prometheus.Counter, exec func(query ch.Query) error) *MetricsBatch {
 	b := &MetricsBatch{
-		limit: limit,
-		exec:  exec,
-		done:  make(chan struct{}),
+		limit:   limit,
+		exec:    exec,
+		wal:     wal,
+		dropped: dropped,
+		done:    make(chan struct{}),
 
 		Timestamp:  new(chproto.ColDateTime64).WithPrecision(chproto.PrecisionMilli),
 		MetricHash: new(chproto.ColUInt64),
@@ -229,6 +387,7 @@ func NewMetricsBatch(limit int, timeout time.Duration, exec func(query ch.Query)
 				return
 			case <-ticker.C:
 				b.lock.Lock()
+				b.replay()
 				b.save()
 				b.lock.Unlock()
 			}
@@ -242,13 +401,53 @@ func (b *MetricsBatch) Close() {
 	b.done <- struct{}{}
 	b.lock.Lock()
 	defer b.lock.Unlock()
+	b.retryAt = time.Time{}
 	b.save()
+	if b.wal != nil {
+		b.wal.Close()
+	}
 }
 
-func (b *MetricsBatch) Add(req *prompb.WriteRequest) {
+// Add appends the samples of the request to the batch. If the WAL is enabled, the request is written to it first.
+func (b *MetricsBatch) Add(req *prompb.WriteRequest) error {
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
+	if b.Timestamp.Rows() >= b.limit*batchMaxPendingFactor {
+		return SaturatedError{RetryAfter: retryAfter(b.retryAt)}
+	}
+	if b.wal != nil {
+		data, err := proto.Marshal(req)
+		if err != nil {
+			return err
+		}
+		if err = b.wal.Append(data, countSamples(req)); err != nil {
+			return fmt.Errorf("failed to write to WAL: %w", err)
+		}
+	}
+	b.add(req)
+	if b.Timestamp.Rows() >= b.limit && time.Now().After(b.retryAt) {
+		b.save()
+	}
+	return nil
+}
+
+func (b *MetricsBatch) replay() {
+	if b.wal == nil {
+		return
+	}
+	for b.Timestamp.Rows() < b.limit && b.wal.ReplayBacklog(func(data []byte) {
+		req := &prompb.WriteRequest{}
+		if err := proto.Unmarshal(data, req); err != nil {
+			klog.Errorln(err)
+			return
+		}
+		b.add(req)
+	}) {
+	}
+}
+
+func (b *MetricsBatch) add(req *prompb.WriteRequest) {
 	for _, md := range req.GetMetadata() {
 		b.MetricFamilyName.Append(md.GetMetricFamilyName())
 		b.Type.Append(md.GetType().String())
@@ -282,15 +481,10 @@ func (b *MetricsBatch) Add(req *prompb.WriteRequest) {
 		}
 		delete(labels, promModel.MetricNameLabel)
 	}
-
-	if b.Timestamp.Rows() < b.limit {
-		return
-	}
-	b.save()
 }
 
 func (b *MetricsBatch) save() {
-	if b.Timestamp.Rows() == 0 {
+	if b.Timestamp.Rows() == 0 || time.Now().Before(b.retryAt) {
 		return
 	}
 
@@ -301,8 +495,22 @@ func (b *MetricsBatch) save() {
 		chproto.InputColumn{Name: "MetricHash", Data: b.MetricHash},
 		chproto.InputColumn{Name: "Value", Data: b.Value},
 	}
-	if err := b.exec(ch.Query{Body: labelsInput.Into("@@table_metrics@@"), Input: labelsInput}); err != nil {
+	var walSegment int
+	if b.wal != nil {
+		walSegment = b.wal.Cut()
+	}
+	err := b.exec(ch.Query{Body: labelsInput.Into("@@table_metrics@@"), Input: labelsInput})
+	if err != nil {
 		klog.Errorln("failed to insert metrics:", err)
+		if retryable(err) {
+			b.retryAt = time.Now().Add(batchRetryInterval)
+			return
+		}
+		b.dropped.Add(float64(b.Timestamp.Rows()))
+	}
+	b.retryAt = time.Time{}
+	if b.wal != nil {
+		b.wal.Commit(walSegment)
 	}
 
 	if b.MetricFamilyName.Rows() > 0 {
@@ -0,0 +1,118 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/klog"
+)
+
+const (
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeJSON     = "application/json"
+)
+
+// OTLP/JSON encodes trace and span IDs as hex strings instead of base64 used by the standard protobuf JSON mapping.
+var otlpJSONIdFields = map[string]bool{
+	"traceId": true, "trace_id": true,
+	"spanId": true, "span_id": true,
+	"parentSpanId": true, "parent_span_id": true,
+}
+
+// readOTLPRequest decodes an OTLP/HTTP request body in either binary protobuf or JSON encoding
+// and returns the media type to be used for the response.
+func readOTLPRequest(r *http.Request, req proto.Message) (string, int, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != ContentTypeProtobuf && mediaType != ContentTypeJSON) {
+		return "", http.StatusUnsupportedMediaType, fmt.Errorf("unsupported content type: %s", r.Header.Get("Content-Type"))
+	}
+	decoder, err := getDecoder(r.Header.Get("Content-Encoding"), r.Body)
+	if err != nil {
+		return "", http.StatusBadRequest, err
+	}
+	data, err := io.ReadAll(decoder)
+	if err != nil {
+		return "", http.StatusBadRequest, err
+	}
+	if mediaType == ContentTypeJSON {
+		err = unmarshalOTLPJSON(data, req)
+	} else {
+		err = proto.Unmarshal(data, req)
+	}
+	if err != nil {
+		return "", http.StatusBadRequest, err
+	}
+	return mediaType, http.StatusOK, nil
+}
+
+func messageSize(m proto.Message) int {
+	return proto.Size(m)
+}
+
+func writeOTLPResponse(w http.ResponseWriter, mediaType string, resp proto.Message) {
+	var data []byte
+	var err error
+	if mediaType == ContentTypeJSON {
+		data, err = protojson.Marshal(resp)
+	} else {
+		data, err = proto.Marshal(resp)
+	}
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", mediaType)
+	_, _ = w.Write(data)
+}
+
+func unmarshalOTLPJSON(data []byte, req proto.Message) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return err
+	}
+	if err := otlpJSONIdsToBase64(v); err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, req)
+}
+
+func otlpJSONIdsToBase64(v any) error {
+	switch vv := v.(type) {
+	case map[string]any:
+		for k, f := range vv {
+			if s, ok := f.(string); ok && otlpJSONIdFields[k] {
+				id, err := hex.DecodeString(s)
+				if err != nil {
+					return fmt.Errorf("invalid %s: %w", k, err)
+				}
+				vv[k] = base64.StdEncoding.EncodeToString(id)
+				continue
+			}
+			if err := otlpJSONIdsToBase64(f); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, i := range vv {
+			if err := otlpJSONIdsToBase64(i); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,394 @@
+package collector
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/prompb"
+	semconv "go.opentelemetry.io/collector/semconv/v1.18.0"
+	metricsv1 "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	otlpmetrics "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+const targetInfoMetricName = "target_info"
+
+// the unit translation follows the Prometheus' OTLP translator (prometheus/otlptranslator)
+var (
+	otlpUnits = map[string]string{
+		"d":    "days",
+		"h":    "hours",
+		"min":  "minutes",
+		"s":    "seconds",
+		"ms":   "milliseconds",
+		"us":   "microseconds",
+		"ns":   "nanoseconds",
+		"By":   "bytes",
+		"KiBy": "kibibytes",
+		"MiBy": "mebibytes",
+		"GiBy": "gibibytes",
+		"TiBy": "tibibytes",
+		"KBy":  "kilobytes",
+		"MBy":  "megabytes",
+		"GBy":  "gigabytes",
+		"TBy":  "terabytes",
+		"m":    "meters",
+		"V":    "volts",
+		"A":    "amperes",
+		"J":    "joules",
+		"W":    "watts",
+		"g":    "grams",
+		"Cel":  "celsius",
+		"Hz":   "hertz",
+		"1":    "",
+		"%":    "percent",
+	}
+	otlpPerUnits = map[string]string{
+		"s":  "second",
+		"m":  "minute",
+		"h":  "hour",
+		"d":  "day",
+		"w":  "week",
+		"mo": "month",
+		"y":  "year",
+	}
+	otlpIdentifyingResourceAttributes = map[string]bool{
+		semconv.AttributeServiceName:       true,
+		semconv.AttributeServiceNamespace:  true,
+		semconv.AttributeServiceInstanceID: true,
+	}
+)
+
+// otlpMetricsToPrometheus converts OTLP metrics to Prometheus series. Data points that have no Prometheus
+// equivalent (e.g., sums and histograms with delta temporality) are dropped and counted as rejected.
+func otlpMetricsToPrometheus(req *metricsv1.ExportMetricsServiceRequest) (*prompb.WriteRequest, int64) {
+	c := &otlpMetricsConverter{res: &prompb.WriteRequest{}, metadata: map[string]bool{}}
+	for _, rm := range req.GetResourceMetrics() {
+		c.resource(rm)
+	}
+	return c.res, c.rejected
+}
+
+type otlpMetricsConverter struct {
+	res      *prompb.WriteRequest
+	metadata map[string]bool
+	rejected int64
+}
+
+func (c *otlpMetricsConverter) resource(rm *otlpmetrics.ResourceMetrics) {
+	attrs := rm.GetResource().GetAttributes()
+	var serviceName, serviceNamespace, instance string
+	info := map[string]string{}
+	for _, kv := range attrs {
+		v := valueToString(kv.GetValue())
+		switch kv.GetKey() {
+		case semconv.AttributeServiceName:
+			serviceName = v
+		case semconv.AttributeServiceNamespace:
+			serviceNamespace = v
+		case semconv.AttributeServiceInstanceID:
+			instance = v
+		}
+		if !otlpIdentifyingResourceAttributes[kv.GetKey()] {
+			info[kv.GetKey()] = v
+		}
+	}
+	job := serviceName
+	if serviceNamespace != "" {
+		job = serviceNamespace + "/" + serviceName
+	}
+	base := map[string]string{}
+	if job != "" {
+		base["job"] = job
+	}
+	if instance != "" {
+		base["instance"] = instance
+	}
+
+	var lastTimestamp int64
+	for _, sm := range rm.GetScopeMetrics() {
+		scope := copyLabels(base)
+		if name := sm.GetScope().GetName(); name != "" {
+			scope["otel_scope_name"] = name
+		}
+		if version := sm.GetScope().GetVersion(); version != "" {
+			scope["otel_scope_version"] = version
+		}
+		for _, m := range sm.GetMetrics() {
+			lastTimestamp = max(lastTimestamp, c.metric(m, scope))
+		}
+	}
+
+	if len(info) > 0 && lastTimestamp > 0 {
+		labels := copyLabels(base)
+		for k, v := range info {
+			labels[otlpLabelName(k)] = v
+		}
+		c.addSeries(targetInfoMetricName, labels, 1, lastTimestamp)
+		c.addMetadata(targetInfoMetricName, prompb.MetricMetadata_GAUGE, "Target metadata", "")
+	}
+}
+
+// metric converts the data points of the metric and returns the timestamp of the latest one.
+func (c *otlpMetricsConverter) metric(m *otlpmetrics.Metric, scope map[string]string) int64 {
+	var last int64
+	switch d := m.GetData().(type) {
+	case *otlpmetrics.Metric_Gauge:
+		name := otlpMetricName(m.GetName(), m.GetUnit(), false, true)
+		c.addMetadata(name, prompb.MetricMetadata_GAUGE, m.GetDescription(), m.GetUnit())
+		for _, p := range d.Gauge.GetDataPoints() {
+			last = max(last, c.numberDataPoint(name, scope, p))
+		}
+	case *otlpmetrics.Metric_Sum:
+		sum := d.Sum
+		monotonic := sum.GetIsMonotonic()
+		if monotonic && sum.GetAggregationTemporality() != otlpmetrics.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE {
+			c.rejected += int64(len(sum.GetDataPoints()))
+			return 0
+		}
+		name := otlpMetricName(m.GetName(), m.GetUnit(), monotonic, !monotonic)
+		typ := prompb.MetricMetadata_GAUGE
+		if monotonic {
+			typ = prompb.MetricMetadata_COUNTER
+		}
+		c.addMetadata(name, typ, m.GetDescription(), m.GetUnit())
+		for _, p := range sum.GetDataPoints() {
+			last = max(last, c.numberDataPoint(name, scope, p))
+		}
+	case *otlpmetrics.Metric_Histogram:
+		if d.Histogram.GetAggregationTemporality() != otlpmetrics.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE {
+			c.rejected += int64(len(d.Histogram.GetDataPoints()))
+			return 0
+		}
+		name := otlpMetricName(m.GetName(), m.GetUnit(), false, false)
+		c.addMetadata(name, prompb.MetricMetadata_HISTOGRAM, m.GetDescription(), m.GetUnit())
+		for _, p := range d.Histogram.GetDataPoints() {
+			last = max(last, c.histogramDataPoint(name, scope, p))
+		}
+	case *otlpmetrics.Metric_ExponentialHistogram:
+		if d.ExponentialHistogram.GetAggregationTemporality() != otlpmetrics.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE {
+			c.rejected += int64(len(d.ExponentialHistogram.GetDataPoints()))
+			return 0
+		}
+		name := otlpMetricName(m.GetName(), m.GetUnit(), false, false)
+		c.addMetadata(name, prompb.MetricMetadata_HISTOGRAM, m.GetDescription(), m.GetUnit())
+		for _, p := range d.ExponentialHistogram.GetDataPoints() {
+			last = max(last, c.exponentialHistogramDataPoint(name, scope, p))
+		}
+	case *otlpmetrics.Metric_Summary:
+		name := otlpMetricName(m.GetName(), m.GetUnit(), false, false)
+		c.addMetadata(name, prompb.MetricMetadata_SUMMARY, m.GetDescription(), m.GetUnit())
+		for _, p := range d.Summary.GetDataPoints() {
+			last = max(last, c.summaryDataPoint(name, scope, p))
+		}
+	}
+	return last
+}
+
+func (c *otlpMetricsConverter) numberDataPoint(name string, scope map[string]string, p *otlpmetrics.NumberDataPoint) int64 {
+	ts := otlpTimestamp(p.GetTimeUnixNano())
+	var v float64
+	switch p.GetValue().(type) {
+	case *otlpmetrics.NumberDataPoint_AsInt:
+		v = float64(p.GetAsInt())
+	default:
+		v = p.GetAsDouble()
+	}
+	c.addSeries(name, otlpLabels(scope, p.GetAttributes()), otlpValue(p.GetFlags(), v), ts)
+	return ts
+}
+
+func (c *otlpMetricsConverter) histogramDataPoint(name string, scope map[string]string, p *otlpmetrics.HistogramDataPoint) int64 {
+	ts := otlpTimestamp(p.GetTimeUnixNano())
+	labels := otlpLabels(scope, p.GetAttributes())
+	if p.Sum != nil {
+		c.addSeries(name+"_sum", labels, otlpValue(p.GetFlags(), p.GetSum()), ts)
+	}
+	c.addSeries(name+"_count", labels, otlpValue(p.GetFlags(), float64(p.GetCount())), ts)
+	var cumulative uint64
+	counts := p.GetBucketCounts()
+	for i, bound := range p.GetExplicitBounds() {
+		if i < len(counts) {
+			cumulative += counts[i]
+		}
+		c.addSeries(name+"_bucket", withLabel(labels, "le", formatLe(bound)), otlpValue(p.GetFlags(), float64(cumulative)), ts)
+	}
+	c.addSeries(name+"_bucket", withLabel(labels, "le", "+Inf"), otlpValue(p.GetFlags(), float64(p.GetCount())), ts)
+	return ts
+}
+
+// exponentialHistogramDataPoint converts the exponential histogram to a classic one
+// with the bucket boundaries defined by the scale of the data point.
+func (c *otlpMetricsConverter) exponentialHistogramDataPoint(name string, scope map[string]string, p *otlpmetrics.ExponentialHistogramDataPoint) int64 {
+	ts := otlpTimestamp(p.GetTimeUnixNano())
+	labels := otlpLabels(scope, p.GetAttributes())
+	if p.Sum != nil {
+		c.addSeries(name+"_sum", labels, otlpValue(p.GetFlags(), p.GetSum()), ts)
+	}
+	c.addSeries(name+"_count", labels, otlpValue(p.GetFlags(), float64(p.GetCount())), ts)
+
+	base := math.Pow(2, math.Pow(2, -float64(p.GetScale())))
+	var cumulative uint64
+	// the bucket with the index i covers (base^i, base^(i+1)] for positive values and [-base^(i+1), -base^i) for negative ones
+	neg := p.GetNegative()
+	for i := len(neg.GetBucketCounts()) - 1; i >= 0; i-- {
+		cumulative += neg.GetBucketCounts()[i]
+		le := -math.Pow(base, float64(neg.GetOffset()+int32(i)))
+		c.addSeries(name+"_bucket", withLabel(labels, "le", formatLe(le)), otlpValue(p.GetFlags(), float64(cumulative)), ts)
+	}
+	cumulative += p.GetZeroCount()
+	c.addSeries(name+"_bucket", withLabel(labels, "le", formatLe(p.GetZeroThreshold())), otlpValue(p.GetFlags(), float64(cumulative)), ts)
+	pos := p.GetPositive()
+	for i, count := range pos.GetBucketCounts() {
+		cumulative += count
+		le := math.Pow(base, float64(pos.GetOffset()+int32(i)+1))
+		c.addSeries(name+"_bucket", withLabel(labels, "le", formatLe(le)), otlpValue(p.GetFlags(), float64(cumulative)), ts)
+	}
+	c.addSeries(name+"_bucket", withLabel(labels, "le", "+Inf"), otlpValue(p.GetFlags(), float64(p.GetCount())), ts)
+	return ts
+}
+
+func (c *otlpMetricsConverter) summaryDataPoint(name string, scope map[string]string, p *otlpmetrics.SummaryDataPoint) int64 {
+	ts := otlpTimestamp(p.GetTimeUnixNano())
+	labels := otlpLabels(scope, p.GetAttributes())
+	c.addSeries(name+"_sum", labels, otlpValue(p.GetFlags(), p.GetSum()), ts)
+	c.addSeries(name+"_count", labels, otlpValue(p.GetFlags(), float64(p.GetCount())), ts)
+	for _, q := range p.GetQuantileValues() {
+		c.addSeries(name, withLabel(labels, "quantile", formatLe(q.GetQuantile())), otlpValue(p.GetFlags(), q.GetValue()), ts)
+	}
+	return ts
+}
+
+func (c *otlpMetricsConverter) addSeries(name string, labels map[string]string, v float64, ts int64) {
+	series := prompb.TimeSeries{
+		Labels:  make([]prompb.Label, 0, len(labels)+1),
+		Samples: []prompb.Sample{{Value: v, Timestamp: ts}},
+	}
+	series.Labels = append(series.Labels, prompb.Label{Name: "__name__", Value: name})
+	for k, v := range labels {
+		series.Labels = append(series.Labels, prompb.Label{Name: k, Value: v})
+	}
+	slices.SortFunc(series.Labels, func(a, b prompb.Label) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	c.res.Timeseries = append(c.res.Timeseries, series)
+}
+
+func (c *otlpMetricsConverter) addMetadata(name string, typ prompb.MetricMetadata_MetricType, help, unit string) {
+	if c.metadata[name] {
+		return
+	}
+	c.metadata[name] = true
+	c.res.Metadata = append(c.res.Metadata, prompb.MetricMetadata{Type: typ, MetricFamilyName: name, Help: help, Unit: unit})
+}
+
+// otlpMetricName builds the Prometheus metric name: the name is sanitized and suffixed with the unit,
+// "_total" is appended to monotonic sums and "_ratio" to unitless gauges.
+func otlpMetricName(name, unit string, counter, gauge bool) string {
+	tokens := strings.FieldsFunc(name, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == ':')
+	})
+	main, per, _ := strings.Cut(unit, "/")
+	if u := otlpUnitName(main, otlpUnits); u != "" && !slices.Contains(tokens, u) {
+		tokens = append(tokens, u)
+	}
+	if u := otlpUnitName(per, otlpPerUnits); u != "" && !slices.Contains(tokens, u) {
+		tokens = append(tokens, "per", u)
+	}
+	if counter {
+		tokens = slices.DeleteFunc(tokens, func(t string) bool { return t == "total" })
+		tokens = append(tokens, "total")
+	}
+	if gauge && unit == "1" {
+		tokens = append(tokens, "ratio")
+	}
+	res := strings.Join(tokens, "_")
+	if res != "" && unicode.IsDigit(rune(res[0])) {
+		res = "_" + res
+	}
+	return res
+}
+
+func otlpUnitName(unit string, known map[string]string) string {
+	unit = strings.TrimSpace(unit)
+	if unit == "" || strings.ContainsAny(unit, "{}") {
+		return ""
+	}
+	if u, ok := known[unit]; ok {
+		return u
+	}
+	return strings.Trim(sanitizeName(unit), "_")
+}
+
+func otlpLabelName(name string) string {
+	res := sanitizeName(name)
+	switch {
+	case res == "":
+		return res
+	case unicode.IsDigit(rune(res[0])):
+		return "key_" + res
+	case res[0] == '_' && !strings.HasPrefix(res, "__"):
+		return "key" + res
+	}
+	return res
+}
+
+func sanitizeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == ':') {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+func otlpLabels(scope map[string]string, attrs []*commonv1.KeyValue) map[string]string {
+	res := copyLabels(scope)
+	for _, kv := range attrs {
+		name := otlpLabelName(kv.GetKey())
+		if _, ok := scope[name]; ok || name == "" {
+			continue
+		}
+		v := valueToString(kv.GetValue())
+		// attributes that collide after sanitization are joined
+		if prev, ok := res[name]; ok {
+			v = prev + ";" + v
+		}
+		res[name] = v
+	}
+	return res
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	res := make(map[string]string, len(labels))
+	for k, v := range labels {
+		res[k] = v
+	}
+	return res
+}
+
+func withLabel(labels map[string]string, name, value string) map[string]string {
+	res := copyLabels(labels)
+	res[name] = value
+	return res
+}
+
+func formatLe(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func otlpTimestamp(nano uint64) int64 {
+	return int64(nano / 1e6)
+}
+
+// otlpValue returns the Prometheus staleness marker for data points flagged as having no recorded value.
+func otlpValue(flags uint32, v float64) float64 {
+	if flags&uint32(otlpmetrics.DataPointFlags_DATA_POINT_FLAGS_NO_RECORDED_VALUE_MASK) != 0 {
+		return math.Float64frombits(value.StaleNaN)
+	}
+	return v
+}
@@ -0,0 +1,111 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	metricsv1 "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	otlpmetrics "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+func TestOtlpMetricName(t *testing.T) {
+	assert.Equal(t, "http_server_request_duration_seconds", otlpMetricName("http.server.request.duration", "s", false, false))
+	assert.Equal(t, "http_server_requests_total", otlpMetricName("http.server.requests", "{request}", true, false))
+	assert.Equal(t, "process_cpu_time_seconds_total", otlpMetricName("process.cpu.time", "s", true, false))
+	assert.Equal(t, "requests_total", otlpMetricName("requests_total", "1", true, false))
+	assert.Equal(t, "system_cpu_utilization_ratio", otlpMetricName("system.cpu.utilization", "1", false, true))
+	assert.Equal(t, "network_io_bytes_per_second", otlpMetricName("network.io", "By/s", false, true))
+	assert.Equal(t, "_2xx_responses", otlpMetricName("2xx.responses", "", false, false))
+
+	assert.Equal(t, "http_method", otlpLabelName("http.method"))
+	assert.Equal(t, "key_0x", otlpLabelName("0x"))
+	assert.Equal(t, "key_private", otlpLabelName("_private"))
+}
+
+func TestOtlpMetricsToPrometheus(t *testing.T) {
+	attr := func(k, v string) *commonv1.KeyValue {
+		return &commonv1.KeyValue{Key: k, Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: v}}}
+	}
+	const ts = uint64(1700000000000000000)
+	sum := 1.5
+	req := &metricsv1.ExportMetricsServiceRequest{ResourceMetrics: []*otlpmetrics.ResourceMetrics{{
+		Resource: &resourcev1.Resource{Attributes: []*commonv1.KeyValue{
+			attr("service.name", "checkout"), attr("service.namespace", "shop"), attr("service.instance.id", "pod-1"), attr("host.name", "node-1"),
+		}},
+		ScopeMetrics: []*otlpmetrics.ScopeMetrics{{
+			Scope: &commonv1.InstrumentationScope{Name: "otel"},
+			Metrics: []*otlpmetrics.Metric{
+				{Name: "http.server.requests", Data: &otlpmetrics.Metric_Sum{Sum: &otlpmetrics.Sum{
+					IsMonotonic:            true,
+					AggregationTemporality: otlpmetrics.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+					DataPoints: []*otlpmetrics.NumberDataPoint{
+						{TimeUnixNano: ts, Value: &otlpmetrics.NumberDataPoint_AsInt{AsInt: 10}, Attributes: []*commonv1.KeyValue{attr("http.method", "GET")}},
+					},
+				}}},
+				{Name: "dropped", Data: &otlpmetrics.Metric_Sum{Sum: &otlpmetrics.Sum{
+					IsMonotonic:            true,
+					AggregationTemporality: otlpmetrics.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+					DataPoints:             []*otlpmetrics.NumberDataPoint{{TimeUnixNano: ts}, {TimeUnixNano: ts}},
+				}}},
+				{Name: "latency", Unit: "s", Data: &otlpmetrics.Metric_Histogram{Histogram: &otlpmetrics.Histogram{
+					AggregationTemporality: otlpmetrics.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+					DataPoints: []*otlpmetrics.HistogramDataPoint{
+						{TimeUnixNano: ts, Count: 6, Sum: &sum, ExplicitBounds: []float64{0.1, 1}, BucketCounts: []uint64{3, 2, 1}},
+					},
+				}}},
+				{Name: "size", Unit: "By", Data: &otlpmetrics.Metric_ExponentialHistogram{ExponentialHistogram: &otlpmetrics.ExponentialHistogram{
+					AggregationTemporality: otlpmetrics.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+					DataPoints: []*otlpmetrics.ExponentialHistogramDataPoint{
+						{TimeUnixNano: ts, Count: 4, Scale: 0, ZeroCount: 1, Positive: &otlpmetrics.ExponentialHistogramDataPoint_Buckets{Offset: 1, BucketCounts: []uint64{1, 2}}},
+					},
+				}}},
+			},
+		}},
+	}}}
+
+	wr, rejected := otlpMetricsToPrometheus(req)
+	assert.Equal(t, int64(2), rejected)
+
+	series := map[string]float64{}
+	for _, s := range wr.Timeseries {
+		series[labelsString(s.Labels)] = s.Samples[0].Value
+		assert.Equal(t, int64(1700000000000), s.Samples[0].Timestamp)
+	}
+	common := `instance="pod-1",job="shop/checkout",otel_scope_name="otel"`
+	bucket := func(name, le string) string {
+		return name + `_bucket{instance="pod-1",job="shop/checkout",le="` + le + `",otel_scope_name="otel"}`
+	}
+	assert.Equal(t, map[string]float64{
+		`http_server_requests_total{http_method="GET",` + common + `}`: 10,
+
+		`latency_seconds_sum{` + common + `}`:                                  1.5,
+		`latency_seconds_count{` + common + `}`:                                6,
+		bucket("latency_seconds", "0.1"):                                       3,
+		bucket("latency_seconds", "1"):                                         5,
+		bucket("latency_seconds", "+Inf"):                                      6,
+		`size_bytes_count{` + common + `}`:                                     4,
+		bucket("size_bytes", "0"):                                              1,
+		bucket("size_bytes", "4"):                                              2,
+		bucket("size_bytes", "8"):                                              4,
+		bucket("size_bytes", "+Inf"):                                           4,
+		`target_info{host_name="node-1",instance="pod-1",job="shop/checkout"}`: 1,
+	}, series)
+}
+
+func labelsString(labels []prompb.Label) string {
+	var name, res string
+	for _, l := range labels {
+		if l.Name == "__name__" {
+			name = l.Value
+			continue
+		}
+		if res != "" {
+			res += ","
+		}
+		res += l.Name + `="` + l.Value + `"`
+	}
+	return name + "{" + res + "}"
+}
@@ -0,0 +1,115 @@
+package collector
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metricsv1 "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	v1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestReadOTLPRequestJSON(t *testing.T) {
+	body := `{"resourceSpans": [{
+		"resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "checkout"}}]},
+		"scopeSpans": [{"spans": [{
+			"traceId": "5b8efff798038103d269b633813fc60c",
+			"spanId": "eee19b7ec3c1b174",
+			"parentSpanId": "",
+			"name": "GET /cart",
+			"kind": 2,
+			"startTimeUnixNano": "1544712660000000000",
+			"endTimeUnixNano": "1544712661000000000",
+			"attributes": [{"key": "http.status_code", "value": {"intValue": "200"}}],
+			"status": {"code": "STATUS_CODE_OK"},
+			"unknownField": true
+		}]}]
+	}]}`
+	r := httptest.NewRequest(http.MethodPost, "/v1/traces", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req := &v1.ExportTraceServiceRequest{}
+	mediaType, _, err := readOTLPRequest(r, req)
+	require.NoError(t, err)
+	assert.Equal(t, ContentTypeJSON, mediaType)
+
+	s := req.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	assert.Equal(t, "5b8efff798038103d269b633813fc60c", hex.EncodeToString(s.TraceId))
+	assert.Equal(t, "eee19b7ec3c1b174", hex.EncodeToString(s.SpanId))
+	assert.Empty(t, s.ParentSpanId)
+	assert.Equal(t, tracev1.Span_SPAN_KIND_SERVER, s.Kind)
+	assert.Equal(t, uint64(1544712660000000000), s.StartTimeUnixNano)
+	assert.Equal(t, int64(200), s.Attributes[0].Value.GetIntValue())
+	assert.Equal(t, tracev1.Status_STATUS_CODE_OK, s.Status.Code)
+
+	r = httptest.NewRequest(http.MethodPost, "/v1/traces", strings.NewReader(`{"resourceSpans": [{"scopeSpans": [{"spans": [{"traceId": "xyz"}]}]}]}`))
+	r.Header.Set("Content-Type", "application/json")
+	_, status, err := readOTLPRequest(r, &v1.ExportTraceServiceRequest{})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	r = httptest.NewRequest(http.MethodPost, "/v1/traces", strings.NewReader(""))
+	r.Header.Set("Content-Type", "text/plain")
+	_, status, err = readOTLPRequest(r, &v1.ExportTraceServiceRequest{})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusUnsupportedMediaType, status)
+}
+
+func TestWriteOTLPResponseJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeOTLPResponse(w, ContentTypeJSON, &v1.ExportTraceServiceResponse{
+		PartialSuccess: &v1.ExportTracePartialSuccess{RejectedSpans: 2, ErrorMessage: "2 invalid spans dropped"},
+	})
+	assert.Equal(t, ContentTypeJSON, w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"partialSuccess": {"rejectedSpans": "2", "errorMessage": "2 invalid spans dropped"}}`, w.Body.String())
+}
+
+func TestReadOTLPMetricsRequestJSON(t *testing.T) {
+	body := `{"resourceMetrics": [{
+		"resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "checkout"}}]},
+		"scopeMetrics": [{"metrics": [{
+			"name": "http.server.requests",
+			"sum": {
+				"isMonotonic": true,
+				"aggregationTemporality": "AGGREGATION_TEMPORALITY_CUMULATIVE",
+				"dataPoints": [{
+					"timeUnixNano": "1700000000000000000",
+					"asInt": "10",
+					"exemplars": [{"timeUnixNano": "1700000000000000000", "asInt": "1", "traceId": "5b8efff798038103d269b633813fc60c", "spanId": "eee19b7ec3c1b174"}]
+				}]
+			}
+		}]}]
+	}]}`
+	r := httptest.NewRequest(http.MethodPost, "/v1/metrics", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	assert.False(t, isRemoteWriteRequest(r))
+	req := &metricsv1.ExportMetricsServiceRequest{}
+	mediaType, _, err := readOTLPRequest(r, req)
+	require.NoError(t, err)
+	assert.Equal(t, ContentTypeJSON, mediaType)
+
+	dp := req.ResourceMetrics[0].ScopeMetrics[0].Metrics[0].GetSum().DataPoints[0]
+	assert.Equal(t, int64(10), dp.GetAsInt())
+	assert.Equal(t, "5b8efff798038103d269b633813fc60c", hex.EncodeToString(dp.Exemplars[0].TraceId))
+	assert.Equal(t, "eee19b7ec3c1b174", hex.EncodeToString(dp.Exemplars[0].SpanId))
+
+	wr, rejected := otlpMetricsToPrometheus(req)
+	assert.Equal(t, int64(0), rejected)
+	require.Len(t, wr.Timeseries, 1)
+	assert.Equal(t, float64(10), wr.Timeseries[0].Samples[0].Value)
+
+	w := httptest.NewRecorder()
+	writeOTLPResponse(w, mediaType, &metricsv1.ExportMetricsServiceResponse{
+		PartialSuccess: &metricsv1.ExportMetricsPartialSuccess{RejectedDataPoints: 1, ErrorMessage: "1 data points with delta temporality dropped"},
+	})
+	assert.JSONEq(t, `{"partialSuccess": {"rejectedDataPoints": "1", "errorMessage": "1 data points with delta temporality dropped"}}`, w.Body.String())
+
+	r = httptest.NewRequest(http.MethodPost, "/v1/metrics", strings.NewReader(""))
+	r.Header.Set("Content-Type", ContentTypeProtobuf)
+	r.Header.Set("Content-Encoding", "snappy")
+	assert.True(t, isRemoteWriteRequest(r))
+}
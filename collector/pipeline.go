@@ -0,0 +1,600 @@
+package collector
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/logparser"
+	"github.com/coroot/coroot/model"
+	"github.com/prometheus/client_golang/prometheus"
+	semconv "go.opentelemetry.io/collector/semconv/v1.18.0"
+	logsv1 "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	tracesv1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	otlplogsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"k8s.io/klog"
+)
+
+const (
+	defaultDecisionWait     = 10 * time.Second
+	tailSamplingMaxSpans    = 100000
+	tailSamplingDecisions   = 100000
+	tailSamplingDecisionTTL = time.Minute
+	redactionPlaceholder    = "[REDACTED]"
+)
+
+var (
+	pipelineDropped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "coroot_collector_pipeline_dropped_total",
+			Help: "Number of spans and log records dropped by the ingest pipeline",
+		},
+		[]string{"project_id", "signal", "rule"},
+	)
+)
+
+func (c *Collector) processTraces(project *db.Project, req *tracesv1.ExportTraceServiceRequest) {
+	if p := c.getPipeline(project); p != nil {
+		p.processTraces(req)
+	}
+}
+
+func (c *Collector) processLogs(project *db.Project, req *logsv1.ExportLogsServiceRequest) {
+	if p := c.getPipeline(project); p != nil {
+		p.processLogs(req)
+	}
+}
+
+// getPipeline returns the compiled pipeline of the project, or nil if the project has no pipeline configured.
+// The pipeline is rebuilt when the project settings change.
+func (c *Collector) getPipeline(project *db.Project) *pipeline {
+	c.pipelinesLock.Lock()
+	defer c.pipelinesLock.Unlock()
+	p := c.pipelines[project.Id]
+	if p != nil && reflect.DeepEqual(p.cfg, project.Settings.IngestPipeline) {
+		return p
+	}
+	if p != nil {
+		p.stop()
+		delete(c.pipelines, project.Id)
+	}
+	if project.Settings.IngestPipeline.IsEmpty() {
+		return nil
+	}
+	p, err := newPipeline(project.Id, project.Settings.IngestPipeline, func(req *tracesv1.ExportTraceServiceRequest) int {
+		rejected, err := c.getTracesBatch(project).Add(req)
+		if err != nil {
+			n := countSpans(req)
+			klog.Errorf("%s: %d tail-sampled spans dropped: %s", project.Id, n, err)
+			return n
+		}
+		if rejected.count() > 0 {
+			klog.Warningln(rejected.message("tail-sampled spans"))
+		}
+		return int(rejected.count())
+	})
+	if err != nil {
+		klog.Errorf("invalid ingest pipeline of project %s: %s", project.Id, err)
+		return nil
+	}
+	c.pipelines[project.Id] = p
+	return p
+}
+
+func (c *Collector) stopPipelines() {
+	c.pipelinesLock.Lock()
+	defer c.pipelinesLock.Unlock()
+	for id, p := range c.pipelines {
+		p.stop()
+		delete(c.pipelines, id)
+	}
+}
+
+type pipeline struct {
+	projectId  db.ProjectId
+	cfg        *db.IngestPipeline
+	parsers    []logParser
+	renames    map[string]string
+	dropRules  []dropRule
+	redactions []redaction
+	tail       *tailSampler
+}
+
+type dropRule struct {
+	name        string
+	signal      string
+	serviceName *regexp.Regexp
+	attribute   string
+	value       *regexp.Regexp
+	maxSeverity int
+}
+
+type logParser struct {
+	serviceName     *regexp.Regexp
+	parser          *logparser.Parser
+	severityFields  []string
+	timestampFields []string
+	timestampLayout string
+}
+
+type redaction struct {
+	attribute   *regexp.Regexp
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// newPipeline compiles the pipeline, flush passes the tail-sampled spans to the batch and returns the number of spans it failed to store.
+func newPipeline(projectId db.ProjectId, cfg *db.IngestPipeline, flush func(req *tracesv1.ExportTraceServiceRequest) int) (*pipeline, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	p := &pipeline{projectId: projectId, cfg: cfg, renames: map[string]string{}}
+	for _, r := range cfg.ParsingRules {
+		lp := logParser{
+			severityFields:  logparser.DefaultSeverityFields,
+			timestampFields: logparser.DefaultTimestampFields,
+			timestampLayout: r.TimestampLayout,
+		}
+		lp.parser, _ = logparser.New(r.Format, r.Pattern)
+		if r.ServiceName != "" {
+			lp.serviceName = regexp.MustCompile("^(?:" + r.ServiceName + ")$")
+		}
+		if r.SeverityField != "" {
+			lp.severityFields = []string{r.SeverityField}
+		}
+		if r.TimestampField != "" {
+			lp.timestampFields = []string{r.TimestampField}
+		}
+		p.parsers = append(p.parsers, lp)
+	}
+	for _, r := range cfg.Renames {
+		p.renames[r.From] = r.To
+	}
+	for i, r := range cfg.DropRules {
+		rule := dropRule{name: r.Name, signal: r.Signal, attribute: r.Attribute}
+		if rule.name == "" {
+			rule.name = fmt.Sprintf("drop_rule_%d", i+1)
+		}
+		if r.ServiceName != "" {
+			rule.serviceName = regexp.MustCompile("^(?:" + r.ServiceName + ")$")
+		}
+		if r.Value != "" {
+			rule.value = regexp.MustCompile("^(?:" + r.Value + ")$")
+		}
+		if r.MaxSeverity != "" {
+			_, rule.maxSeverity = model.SeverityFromString(r.MaxSeverity).Range()
+		}
+		p.dropRules = append(p.dropRules, rule)
+	}
+	for _, r := range cfg.Redactions {
+		rd := redaction{pattern: regexp.MustCompile(r.Pattern), replacement: r.Replacement}
+		if r.Attribute != "" {
+			rd.attribute = regexp.MustCompile("^(?:" + r.Attribute + ")$")
+		}
+		if rd.replacement == "" {
+			rd.replacement = redactionPlaceholder
+		}
+		p.redactions = append(p.redactions, rd)
+	}
+	if cfg.TailSampling != nil {
+		p.tail = newTailSampler(*cfg.TailSampling, func(req *tracesv1.ExportTraceServiceRequest) {
+			p.dropped(signalTraces, "tail_sampling_flush", flush(req))
+		}, func(n int) {
+			p.dropped(signalTraces, "tail_sampling", n)
+		})
+	}
+	return p, nil
+}
+
+func (p *pipeline) stop() {
+	if p.tail != nil {
+		p.tail.stop()
+	}
+}
+
+func (p *pipeline) dropped(signal, rule string, n int) {
+	if n > 0 {
+		pipelineDropped.WithLabelValues(string(p.projectId), signal, rule).Add(float64(n))
+	}
+}
+
+func (p *pipeline) processTraces(req *tracesv1.ExportTraceServiceRequest) {
+	var percent float64
+	if hs := p.cfg.HeadSampling; hs != nil {
+		percent = hs.TracesPercent
+	}
+	for _, rs := range req.GetResourceSpans() {
+		resourceAttributes := rs.GetResource().GetAttributes()
+		p.rename(resourceAttributes)
+		p.redact(resourceAttributes)
+		serviceName := attributeValue(resourceAttributes, semconv.AttributeServiceName)
+		for _, ss := range rs.GetScopeSpans() {
+			spans := ss.Spans[:0]
+			for _, s := range ss.Spans {
+				p.rename(s.Attributes)
+				if rule := p.matchDropRule(signalTraces, serviceName, s.Attributes, resourceAttributes, 0); rule != "" {
+					p.dropped(signalTraces, rule, 1)
+					continue
+				}
+				if percent > 0 && !sampled(s.TraceId, percent) {
+					p.dropped(signalTraces, "head_sampling", 1)
+					continue
+				}
+				p.redact(s.Attributes)
+				for _, e := range s.Events {
+					p.redact(e.Attributes)
+				}
+				spans = append(spans, s)
+			}
+			if p.tail != nil {
+				spans = p.tail.add(rs, ss, spans, time.Now())
+			}
+			ss.Spans = spans
+		}
+	}
+}
+
+func (p *pipeline) processLogs(req *logsv1.ExportLogsServiceRequest) {
+	var tracesPercent, logsPercent float64
+	if hs := p.cfg.HeadSampling; hs != nil {
+		tracesPercent, logsPercent = hs.TracesPercent, hs.LogsPercent
+	}
+	for _, rl := range req.GetResourceLogs() {
+		resourceAttributes := rl.GetResource().GetAttributes()
+		p.rename(resourceAttributes)
+		p.redact(resourceAttributes)
+		serviceName := attributeValue(resourceAttributes, semconv.AttributeServiceName)
+		parsers := p.getLogParsers(serviceName)
+		for _, sl := range rl.GetScopeLogs() {
+			records := sl.LogRecords[:0]
+			for _, lr := range sl.LogRecords {
+				for _, lp := range parsers {
+					if lp.parse(lr) {
+						break
+					}
+				}
+				p.rename(lr.Attributes)
+				if rule := p.matchDropRule(signalLogs, serviceName, lr.Attributes, resourceAttributes, int(lr.SeverityNumber)); rule != "" {
+					p.dropped(signalLogs, rule, 1)
+					continue
+				}
+				if !p.sampleLogRecord(lr, tracesPercent, logsPercent) {
+					p.dropped(signalLogs, "head_sampling", 1)
+					continue
+				}
+				p.redact(lr.Attributes)
+				p.redactBody(lr)
+				records = append(records, lr)
+			}
+			sl.LogRecords = records
+		}
+	}
+}
+
+func (p *pipeline) getLogParsers(serviceName string) []*logParser {
+	var res []*logParser
+	for i := range p.parsers {
+		if lp := &p.parsers[i]; lp.serviceName == nil || lp.serviceName.MatchString(serviceName) {
+			res = append(res, lp)
+		}
+	}
+	return res
+}
+
+// parse adds the fields parsed from the body to the attributes of the record
+// and sets the severity and the timestamp if they are missing. It returns false if the body doesn't match the format.
+func (lp *logParser) parse(lr *otlplogsv1.LogRecord) bool {
+	body, ok := lr.GetBody().GetValue().(*commonv1.AnyValue_StringValue)
+	if !ok {
+		return false
+	}
+	fields := lp.parser.Parse(body.StringValue)
+	if len(fields) == 0 {
+		return false
+	}
+	if lr.SeverityNumber == otlplogsv1.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED {
+		for _, f := range lp.severityFields {
+			if v := fields[f]; v != "" {
+				if severity := logparser.Severity(v); severity != model.SeverityUnknown {
+					from, _ := severity.Range()
+					lr.SeverityNumber = otlplogsv1.SeverityNumber(from)
+					if lr.SeverityText == "" {
+						lr.SeverityText = v
+					}
+					break
+				}
+			}
+		}
+	}
+	if lr.TimeUnixNano == 0 {
+		for _, f := range lp.timestampFields {
+			if v := fields[f]; v != "" {
+				if t, ok := logparser.Timestamp(v, lp.timestampLayout); ok {
+					lr.TimeUnixNano = uint64(t.UnixNano())
+					break
+				}
+			}
+		}
+		if lr.TimeUnixNano == 0 {
+			lr.TimeUnixNano = lr.ObservedTimeUnixNano
+		}
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		if findAttribute(lr.Attributes, k) == nil {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lr.Attributes = append(lr.Attributes, &commonv1.KeyValue{
+			Key:   k,
+			Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: fields[k]}},
+		})
+	}
+	return true
+}
+
+// sampleLogRecord samples records having a trace ID at the traces rate, so they follow the decision made for the trace.
+func (p *pipeline) sampleLogRecord(lr *otlplogsv1.LogRecord, tracesPercent, logsPercent float64) bool {
+	if len(lr.TraceId) == 16 && tracesPercent > 0 {
+		return sampled(lr.TraceId, tracesPercent)
+	}
+	if logsPercent > 0 {
+		return rand.Float64()*100 < logsPercent
+	}
+	return true
+}
+
+func (p *pipeline) rename(attrs []*commonv1.KeyValue) {
+	if len(p.renames) == 0 {
+		return
+	}
+	for _, kv := range attrs {
+		if to, ok := p.renames[kv.Key]; ok {
+			kv.Key = to
+		}
+	}
+}
+
+// matchDropRule returns the name of the first rule matching the span or the log record.
+func (p *pipeline) matchDropRule(signal, serviceName string, attrs, resourceAttrs []*commonv1.KeyValue, severity int) string {
+	for _, r := range p.dropRules {
+		if r.signal != "" && r.signal != signal {
+			continue
+		}
+		if r.serviceName != nil && !r.serviceName.MatchString(serviceName) {
+			continue
+		}
+		if r.attribute != "" {
+			kv := findAttribute(attrs, r.attribute)
+			if kv == nil {
+				kv = findAttribute(resourceAttrs, r.attribute)
+			}
+			if kv == nil || (r.value != nil && !r.value.MatchString(valueToString(kv.Value))) {
+				continue
+			}
+		}
+		if r.maxSeverity > 0 && (signal != signalLogs || severity <= 0 || severity > r.maxSeverity) {
+			continue
+		}
+		return r.name
+	}
+	return ""
+}
+
+func (p *pipeline) redact(attrs []*commonv1.KeyValue) {
+	for _, r := range p.redactions {
+		for _, kv := range attrs {
+			if r.attribute != nil && !r.attribute.MatchString(kv.Key) {
+				continue
+			}
+			v := valueToString(kv.Value)
+			if redacted := r.pattern.ReplaceAllString(v, r.replacement); redacted != v {
+				kv.Value = &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: redacted}}
+			}
+		}
+	}
+}
+
+func (p *pipeline) redactBody(lr *otlplogsv1.LogRecord) {
+	body, ok := lr.GetBody().GetValue().(*commonv1.AnyValue_StringValue)
+	if !ok {
+		return
+	}
+	for _, r := range p.redactions {
+		if r.attribute == nil {
+			body.StringValue = r.pattern.ReplaceAllString(body.StringValue, r.replacement)
+		}
+	}
+}
+
+func findAttribute(attrs []*commonv1.KeyValue, key string) *commonv1.KeyValue {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv
+		}
+	}
+	return nil
+}
+
+func attributeValue(attrs []*commonv1.KeyValue, key string) string {
+	if kv := findAttribute(attrs, key); kv != nil {
+		return valueToString(kv.Value)
+	}
+	return ""
+}
+
+// sampled makes a deterministic decision based on the random part of the trace ID,
+// so all the spans of a trace are either kept or dropped.
+func sampled(traceId []byte, percent float64) bool {
+	if len(traceId) != 16 {
+		return rand.Float64()*100 < percent
+	}
+	return float64(binary.BigEndian.Uint64(traceId[8:]))/(1<<64)*100 < percent
+}
+
+// tailSampler buffers the spans of each trace for the decision wait and then passes the trace to the batch
+// if it contains an error or a slow span, or is sampled. Spans arriving after the decision follow it.
+// When the buffer is full, the spans of new traces are passed through without sampling.
+// The buffered spans are acknowledged to the sender but not written to the WAL until the decision is made,
+// so they are lost if the collector stops abnormally. The spans the batch fails to accept after the decision
+// (e.g., when it is saturated) are counted as dropped by the "tail_sampling_flush" rule.
+type tailSampler struct {
+	cfg     db.TailSampling
+	wait    time.Duration
+	flush   func(req *tracesv1.ExportTraceServiceRequest)
+	dropped func(n int)
+
+	lock      sync.Mutex
+	done      chan struct{}
+	traces    map[string]*pendingTrace
+	spans     int
+	decisions map[string]tailDecision
+}
+
+type pendingTrace struct {
+	firstSeen     time.Time
+	resourceSpans []*tracev1.ResourceSpans
+	spans         int
+	keep          bool
+}
+
+type tailDecision struct {
+	keep bool
+	at   time.Time
+}
+
+func newTailSampler(cfg db.TailSampling, flush func(req *tracesv1.ExportTraceServiceRequest), dropped func(n int)) *tailSampler {
+	s := &tailSampler{
+		cfg:       cfg,
+		wait:      cfg.DecisionWait.ToStandard(),
+		flush:     flush,
+		dropped:   dropped,
+		done:      make(chan struct{}),
+		traces:    map[string]*pendingTrace{},
+		decisions: map[string]tailDecision{},
+	}
+	if s.wait <= 0 {
+		s.wait = defaultDecisionWait
+	}
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+				s.decide(time.Now(), false)
+			}
+		}
+	}()
+	return s
+}
+
+func (s *tailSampler) stop() {
+	s.done <- struct{}{}
+	s.decide(time.Now(), true)
+}
+
+// add buffers the spans and returns the ones that must be passed to the batch immediately.
+func (s *tailSampler) add(rs *tracev1.ResourceSpans, ss *tracev1.ScopeSpans, spans []*tracev1.Span, now time.Time) []*tracev1.Span {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	res := spans[:0]
+	var dropped int
+	buffered := map[string]*tracev1.ScopeSpans{}
+	for _, span := range spans {
+		key := string(span.TraceId)
+		if d, ok := s.decisions[key]; ok {
+			if d.keep {
+				res = append(res, span)
+			} else {
+				dropped++
+			}
+			continue
+		}
+		t := s.traces[key]
+		if t == nil {
+			if s.spans >= tailSamplingMaxSpans {
+				res = append(res, span)
+				continue
+			}
+			t = &pendingTrace{firstSeen: now}
+			s.traces[key] = t
+		}
+		dst := buffered[key]
+		if dst == nil {
+			dst = &tracev1.ScopeSpans{Scope: ss.Scope, SchemaUrl: ss.SchemaUrl}
+			buffered[key] = dst
+			t.resourceSpans = append(t.resourceSpans, &tracev1.ResourceSpans{Resource: rs.Resource, SchemaUrl: rs.SchemaUrl, ScopeSpans: []*tracev1.ScopeSpans{dst}})
+		}
+		dst.Spans = append(dst.Spans, span)
+		t.spans++
+		s.spans++
+		if s.interesting(span) {
+			t.keep = true
+		}
+	}
+	if dropped > 0 {
+		s.dropped(dropped)
+	}
+	return res
+}
+
+func (s *tailSampler) interesting(span *tracev1.Span) bool {
+	if s.cfg.KeepErrors && span.GetStatus().GetCode() == tracev1.Status_STATUS_CODE_ERROR {
+		return true
+	}
+	if s.cfg.SlowThresholdMs > 0 {
+		d := time.Duration(span.EndTimeUnixNano - span.StartTimeUnixNano)
+		return d >= time.Duration(s.cfg.SlowThresholdMs)*time.Millisecond
+	}
+	return false
+}
+
+// decide makes the decisions for the traces buffered longer than the decision wait, or for all of them if all is true.
+func (s *tailSampler) decide(now time.Time, all bool) {
+	s.lock.Lock()
+	req := &tracesv1.ExportTraceServiceRequest{}
+	var dropped int
+	for key, t := range s.traces {
+		if !all && now.Sub(t.firstSeen) < s.wait {
+			continue
+		}
+		keep := t.keep || sampled([]byte(key), s.cfg.Percent)
+		if keep {
+			req.ResourceSpans = append(req.ResourceSpans, t.resourceSpans...)
+		} else {
+			dropped += t.spans
+		}
+		if len(s.decisions) < tailSamplingDecisions {
+			s.decisions[key] = tailDecision{keep: keep, at: now}
+		}
+		s.spans -= t.spans
+		delete(s.traces, key)
+	}
+	for key, d := range s.decisions {
+		if now.Sub(d.at) > tailSamplingDecisionTTL {
+			delete(s.decisions, key)
+		}
+	}
+	s.lock.Unlock()
+
+	if dropped > 0 {
+		s.dropped(dropped)
+	}
+	if len(req.ResourceSpans) > 0 {
+		s.flush(req)
+	}
+}
@@ -0,0 +1,138 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coroot/coroot/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	logsv1 "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	tracesv1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	otlplogsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestPipelineLogs(t *testing.T) {
+	p, err := newPipeline("p1", &db.IngestPipeline{
+		Renames: []db.IngestRename{{From: "user", To: "user.email"}},
+		DropRules: []db.IngestDropRule{
+			{Name: "debug", ServiceName: "noisy-.*", MaxSeverity: "debug"},
+			{Name: "healthchecks", Signal: db.IngestSignalLogs, Attribute: "http.target", Value: "/health.*"},
+		},
+		Redactions: []db.IngestRedaction{
+			{Pattern: `[\w.]+@[\w.]+`},
+			{Attribute: "card", Pattern: `\d{12}(\d{4})`, Replacement: "************$1"},
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	record := func(severity otlplogsv1.SeverityNumber, body string, attrs ...*commonv1.KeyValue) *otlplogsv1.LogRecord {
+		return &otlplogsv1.LogRecord{SeverityNumber: severity, Body: &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: body}}, Attributes: attrs}
+	}
+	req := &logsv1.ExportLogsServiceRequest{ResourceLogs: []*otlplogsv1.ResourceLogs{{
+		Resource: &resourcev1.Resource{Attributes: []*commonv1.KeyValue{stringAttribute("service.name", "noisy-app")}},
+		ScopeLogs: []*otlplogsv1.ScopeLogs{{LogRecords: []*otlplogsv1.LogRecord{
+			record(otlplogsv1.SeverityNumber_SEVERITY_NUMBER_DEBUG, "debug"),
+			record(otlplogsv1.SeverityNumber_SEVERITY_NUMBER_INFO, "GET", stringAttribute("http.target", "/healthz")),
+			record(otlplogsv1.SeverityNumber_SEVERITY_NUMBER_INFO, "order created by john@example.com", stringAttribute("user", "john@example.com"), stringAttribute("card", "4111111111111111")),
+		}}},
+	}}}
+	p.processLogs(req)
+
+	records := req.ResourceLogs[0].ScopeLogs[0].LogRecords
+	require.Len(t, records, 1)
+	assert.Equal(t, "order created by [REDACTED]", records[0].Body.GetStringValue())
+	assert.Equal(t, map[string]string{"user.email": "[REDACTED]", "card": "************1111"}, attributesToMap(records[0].Attributes))
+}
+
+func TestPipelineLogParsing(t *testing.T) {
+	p, err := newPipeline("p1", &db.IngestPipeline{
+		ParsingRules: []db.LogParsingRule{
+			{ServiceName: "api", Format: "json"},
+			{ServiceName: "api", Format: "grok", Pattern: `%{TIMESTAMP_ISO8601:ts} %{LOGLEVEL:level} %{GREEDYDATA:msg}`},
+		},
+		Renames: []db.IngestRename{{From: "user", To: "user.id"}},
+	}, nil)
+	require.NoError(t, err)
+
+	record := func(body string, attrs ...*commonv1.KeyValue) *otlplogsv1.LogRecord {
+		return &otlplogsv1.LogRecord{Body: &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: body}}, Attributes: attrs}
+	}
+	req := &logsv1.ExportLogsServiceRequest{ResourceLogs: []*otlplogsv1.ResourceLogs{{
+		Resource: &resourcev1.Resource{Attributes: []*commonv1.KeyValue{stringAttribute("service.name", "api")}},
+		ScopeLogs: []*otlplogsv1.ScopeLogs{{LogRecords: []*otlplogsv1.LogRecord{
+			record(`{"level":"warn","time":"2025-01-02T03:04:05Z","user":42,"http":{"status":503}}`, stringAttribute("level", "info")),
+			record(`2025-01-02 03:04:05.5 ERROR connection refused`),
+			record(`plain text`),
+		}}},
+	}}}
+	p.processLogs(req)
+
+	records := req.ResourceLogs[0].ScopeLogs[0].LogRecords
+	require.Len(t, records, 3)
+	assert.Equal(t, otlplogsv1.SeverityNumber_SEVERITY_NUMBER_WARN, records[0].SeverityNumber)
+	assert.Equal(t, "warn", records[0].SeverityText)
+	assert.Equal(t, uint64(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC).UnixNano()), records[0].TimeUnixNano)
+	assert.Equal(t, map[string]string{"level": "info", "time": "2025-01-02T03:04:05Z", "user.id": "42", "http.status": "503"}, attributesToMap(records[0].Attributes))
+
+	assert.Equal(t, otlplogsv1.SeverityNumber_SEVERITY_NUMBER_ERROR, records[1].SeverityNumber)
+	assert.Equal(t, uint64(time.Date(2025, 1, 2, 3, 4, 5, 5e8, time.UTC).UnixNano()), records[1].TimeUnixNano)
+	assert.Equal(t, map[string]string{"ts": "2025-01-02 03:04:05.5", "level": "ERROR", "msg": "connection refused"}, attributesToMap(records[1].Attributes))
+
+	assert.Equal(t, otlplogsv1.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED, records[2].SeverityNumber)
+	assert.Empty(t, records[2].Attributes)
+}
+
+func TestTailSampler(t *testing.T) {
+	var flushed []*tracev1.Span
+	var dropped int
+	s := &tailSampler{
+		cfg:       db.TailSampling{KeepErrors: true, SlowThresholdMs: 1000},
+		wait:      10 * time.Second,
+		flush:     func(req *tracesv1.ExportTraceServiceRequest) { flushed = append(flushed, spansOf(req)...) },
+		dropped:   func(n int) { dropped += n },
+		traces:    map[string]*pendingTrace{},
+		decisions: map[string]tailDecision{},
+	}
+	traceId := func(b byte) []byte {
+		id := make([]byte, 16)
+		id[0] = b
+		return id
+	}
+	now := time.Now()
+	rs := &tracev1.ResourceSpans{}
+	ss := &tracev1.ScopeSpans{}
+	spans := []*tracev1.Span{
+		{TraceId: traceId(1), StartTimeUnixNano: 0, EndTimeUnixNano: uint64(time.Millisecond)},
+		{TraceId: traceId(1), Status: &tracev1.Status{Code: tracev1.Status_STATUS_CODE_ERROR}},
+		{TraceId: traceId(2), StartTimeUnixNano: 0, EndTimeUnixNano: uint64(2 * time.Second)},
+		{TraceId: traceId(3)},
+	}
+	assert.Empty(t, s.add(rs, ss, spans, now))
+
+	s.decide(now.Add(5*time.Second), false)
+	assert.Empty(t, flushed)
+
+	s.decide(now.Add(10*time.Second), false)
+	assert.Len(t, flushed, 3)
+	assert.Equal(t, 1, dropped)
+	assert.Equal(t, 0, s.spans)
+
+	// late spans follow the decision made for the trace
+	late := s.add(rs, ss, []*tracev1.Span{{TraceId: traceId(1)}, {TraceId: traceId(3)}}, now.Add(11*time.Second))
+	assert.Len(t, late, 1)
+	assert.Equal(t, 2, dropped)
+}
+
+func spansOf(req *tracesv1.ExportTraceServiceRequest) []*tracev1.Span {
+	var res []*tracev1.Span
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			res = append(res, ss.Spans...)
+		}
+	}
+	return res
+}
@@ -1,8 +1,11 @@
 package collector
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"hash/fnv"
+	"io"
 	"net/http"
 	"sync"
 	"time"
@@ -11,6 +14,7 @@ import (
 	chproto "github.com/ClickHouse/ch-go/proto"
 	"github.com/coroot/coroot/model"
 	"github.com/google/pprof/profile"
+	"github.com/prometheus/client_golang/prometheus"
 	"k8s.io/klog"
 )
 
@@ -41,22 +45,54 @@ func (c *Collector) Profiles(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "service.name is empty", http.StatusBadRequest)
 		return
 	}
-	p, err := profile.Parse(r.Body)
+	data, err := io.ReadAll(r.Body)
 	if err != nil {
 		klog.Errorln(err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	p, err := profile.ParseData(data)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	release, err := c.acquire(project.Id)
+	if err != nil {
+		writeIngestError(w, err)
+		return
+	}
+	defer release()
+
+	if err = c.checkQuotas(project, r.Header.Get(ApiKeyHeader), signalProfiles, func() int { return 0 }, len(data)); err != nil {
+		klog.Warningln(err)
+		writeIngestError(w, err)
+		return
+	}
 
-	c.getProfilesBatch(project).Add(serviceName, labels, p)
+	if err = c.getProfilesBatch(project).Add(serviceName, labels, p); err != nil {
+		klog.Errorln(err)
+		writeIngestError(w, err)
+		return
+	}
+}
+
+type walProfile struct {
+	ServiceName string       `json:"service_name"`
+	Labels      model.Labels `json:"labels"`
+	Profile     []byte       `json:"profile"`
 }
 
 type ProfilesBatch struct {
-	limit int
-	exec  func(query ch.Query) error
+	limit   int
+	exec    func(query ch.Query) error
+	wal     *WAL
+	dropped prometheus.Counter
 
-	lock sync.Mutex
-	done chan struct{}
+	lock    sync.Mutex
+	done    chan struct{}
+	retryAt time.Time
 
 	ServiceName *chproto.ColLowCardinality[string]
 	Type        *chproto.ColLowCardinality[string]
@@ -68,11 +104,13 @@ type ProfilesBatch struct {
 	Stack       *chproto.ColArr[string]
 }
 
-func NewProfilesBatch(limit int, timeout time.Duration, exec func(query ch.Query) error) *ProfilesBatch {
+func NewProfilesBatch(limit int, timeout time.Duration, wal *WAL, dropped prometheus.Counter, exec func(query ch.Query) error) *ProfilesBatch {
 	b := &ProfilesBatch{
-		limit: limit,
-		exec:  exec,
-		done:  make(chan struct{}),
+		limit:   limit,
+		exec:    exec,
+		wal:     wal,
+		dropped: dropped,
+		done:    make(chan struct{}),
 
 		ServiceName: new(chproto.ColStr).LowCardinality(),
 		Type:        new(chproto.ColStr).LowCardinality(),
@@ -93,6 +131,7 @@ func NewProfilesBatch(limit int, timeout time.Duration, exec func(query ch.Query
 				return
 			case <-ticker.C:
 				b.lock.Lock()
+				b.replay()
 				b.save()
 				b.lock.Unlock()
 			}
@@ -106,13 +145,61 @@ func (b *ProfilesBatch) Close() {
 	b.done <- struct{}{}
 	b.lock.Lock()
 	defer b.lock.Unlock()
+	b.retryAt = time.Time{}
 	b.save()
+	if b.wal != nil {
+		b.wal.Close()
+	}
 }
 
-func (b *ProfilesBatch) Add(serviceName string, labels model.Labels, p *profile.Profile) {
+func (b *ProfilesBatch) Add(serviceName string, labels model.Labels, p *profile.Profile) error {
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
+	if b.ServiceName.Rows() >= b.limit*batchMaxPendingFactor {
+		return SaturatedError{RetryAfter: retryAfter(b.retryAt)}
+	}
+	if b.wal != nil {
+		buf := &bytes.Buffer{}
+		if err := p.Write(buf); err != nil {
+			return err
+		}
+		data, err := json.Marshal(walProfile{ServiceName: serviceName, Labels: labels, Profile: buf.Bytes()})
+		if err != nil {
+			return err
+		}
+		if err = b.wal.Append(data, len(p.SampleType)*len(p.Sample)); err != nil {
+			return fmt.Errorf("failed to write to WAL: %w", err)
+		}
+	}
+	b.add(serviceName, labels, p)
+	if b.ServiceName.Rows() >= b.limit && time.Now().After(b.retryAt) {
+		b.save()
+	}
+	return nil
+}
+
+func (b *ProfilesBatch) replay() {
+	if b.wal == nil {
+		return
+	}
+	for b.ServiceName.Rows() < b.limit && b.wal.ReplayBacklog(func(data []byte) {
+		var wp walProfile
+		if err := json.Unmarshal(data, &wp); err != nil {
+			klog.Errorln(err)
+			return
+		}
+		p, err := profile.ParseData(wp.Profile)
+		if err != nil {
+			klog.Errorln(err)
+			return
+		}
+		b.add(wp.ServiceName, wp.Labels, p)
+	}) {
+	}
+}
+
+func (b *ProfilesBatch) add(serviceName string, labels model.Labels, p *profile.Profile) {
 	end := time.Unix(0, p.TimeNanos)
 	start := end.Add(-time.Duration(p.DurationNanos))
 
@@ -143,15 +230,10 @@ func (b *ProfilesBatch) Add(serviceName string, labels model.Labels, p *profile.
 			b.Stack.Append(stack)
 		}
 	}
-
-	if b.ServiceName.Rows() < b.limit {
-		return
-	}
-	b.save()
 }
 
 func (b *ProfilesBatch) save() {
-	if b.ServiceName.Rows() == 0 {
+	if b.ServiceName.Rows() == 0 || time.Now().Before(b.retryAt) {
 		return
 	}
 
@@ -161,11 +243,6 @@ func (b *ProfilesBatch) save() {
 		chproto.InputColumn{Name: "LastSeen", Data: b.End},
 		chproto.InputColumn{Name: "Stack", Data: b.Stack},
 	}
-	err := b.exec(ch.Query{Body: stacksInput.Into("@@table_profiling_stacks@@"), Input: stacksInput})
-	if err != nil {
-		klog.Errorln(err)
-	}
-
 	samplesInput := chproto.Input{
 		chproto.InputColumn{Name: "ServiceName", Data: b.ServiceName},
 		chproto.InputColumn{Name: "Type", Data: b.Type},
@@ -175,9 +252,25 @@ func (b *ProfilesBatch) save() {
 		chproto.InputColumn{Name: "StackHash", Data: b.StackHash},
 		chproto.InputColumn{Name: "Value", Data: b.Value},
 	}
-	err = b.exec(ch.Query{Body: samplesInput.Into("@@table_profiling_samples@@"), Input: samplesInput})
+	var walSegment int
+	if b.wal != nil {
+		walSegment = b.wal.Cut()
+	}
+	err := b.exec(ch.Query{Body: stacksInput.Into("@@table_profiling_stacks@@"), Input: stacksInput})
+	if err == nil {
+		err = b.exec(ch.Query{Body: samplesInput.Into("@@table_profiling_samples@@"), Input: samplesInput})
+	}
 	if err != nil {
 		klog.Errorln(err)
+		if retryable(err) {
+			b.retryAt = time.Now().Add(batchRetryInterval)
+			return
+		}
+		b.dropped.Add(float64(b.ServiceName.Rows()))
+	}
+	b.retryAt = time.Time{}
+	if b.wal != nil {
+		b.wal.Commit(walSegment)
 	}
 
 	for _, i := range stacksInput {
@@ -0,0 +1,230 @@
+package collector
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/coroot/coroot/db"
+)
+
+type QuotaExceededError struct {
+	Quota      string
+	RetryAfter time.Duration
+}
+
+func (e QuotaExceededError) Error() string {
+	return fmt.Sprintf("ingestion quota exceeded (%s), retry in %s", e.Quota, e.RetryAfter)
+}
+
+type IngestionUsage struct {
+	SpansPerSecond   float64
+	LogsPerSecond    float64
+	SamplesPerSecond float64
+	BytesToday       int64
+	// the number of spans, log records and samples rejected during the last minute
+	Rejected int64
+}
+
+// checkQuotas enforces the ingestion quotas of the project and the API key.
+// The items function is called only if the project or the key has quotas.
+func (c *Collector) checkQuotas(project *db.Project, apiKey, signal string, items func() int, bytes int) error {
+	projectQuotas := project.Settings.IngestionQuotas
+	var keyQuotas *db.IngestionQuotas
+	for _, k := range project.Settings.ApiKeys {
+		if k.Key == apiKey {
+			keyQuotas = k.Quotas
+			break
+		}
+	}
+	if projectQuotas.IsEmpty() && keyQuotas.IsEmpty() {
+		return nil
+	}
+	scopes := []quotaScope{{projectId: project.Id}}
+	limits := []*db.IngestionQuotas{projectQuotas}
+	if !keyQuotas.IsEmpty() {
+		scopes = append(scopes, quotaScope{projectId: project.Id, apiKey: apiKey})
+		limits = append(limits, keyQuotas)
+	}
+	return c.quotas.take(scopes, limits, signal, items(), bytes, time.Now())
+}
+
+func (c *Collector) IngestionUsage(projectId db.ProjectId, apiKey string) IngestionUsage {
+	return c.quotas.usage(quotaScope{projectId: projectId, apiKey: apiKey}, time.Now())
+}
+
+type quotaScope struct {
+	projectId db.ProjectId
+	apiKey    string // empty for the project-wide quotas
+}
+
+type quotas struct {
+	lock   sync.Mutex
+	states map[quotaScope]*quotaState
+}
+
+type quotaState struct {
+	buckets  map[string]*tokenBucket
+	day      time.Time
+	bytes    int64
+	accepted map[string]*rateMeter
+	rejected rateMeter
+}
+
+func newQuotas() *quotas {
+	return &quotas{states: map[quotaScope]*quotaState{}}
+}
+
+func (q *quotas) take(scopes []quotaScope, limits []*db.IngestionQuotas, signal string, items, bytes int, now time.Time) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	states := make([]*quotaState, len(scopes))
+	var exceeded QuotaExceededError
+	for i, scope := range scopes {
+		states[i] = q.states[scope]
+		if states[i] == nil {
+			states[i] = &quotaState{buckets: map[string]*tokenBucket{}, accepted: map[string]*rateMeter{}}
+			q.states[scope] = states[i]
+		}
+		if limits[i] == nil {
+			continue
+		}
+		if quota, wait := states[i].check(limits[i], signal, now); wait > exceeded.RetryAfter {
+			exceeded = QuotaExceededError{Quota: quota, RetryAfter: wait}
+		}
+	}
+	if exceeded.RetryAfter > 0 {
+		for _, s := range states {
+			s.rejected.add(int64(items), now)
+		}
+		return exceeded
+	}
+	for _, s := range states {
+		if b := s.buckets[signal]; b != nil {
+			b.tokens -= float64(items)
+		}
+		s.bytes += int64(bytes)
+		m := s.accepted[signal]
+		if m == nil {
+			m = &rateMeter{}
+			s.accepted[signal] = m
+		}
+		m.add(int64(items), now)
+	}
+	return nil
+}
+
+func (q *quotas) usage(scope quotaScope, now time.Time) IngestionUsage {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	var res IngestionUsage
+	s := q.states[scope]
+	if s == nil {
+		return res
+	}
+	rate := func(signal string) float64 {
+		if m := s.accepted[signal]; m != nil {
+			return float64(m.lastMinute(now)) / 60
+		}
+		return 0
+	}
+	res.SpansPerSecond = rate(signalTraces)
+	res.LogsPerSecond = rate(signalLogs)
+	res.SamplesPerSecond = rate(signalMetrics)
+	if s.day.Equal(startOfDay(now)) {
+		res.BytesToday = s.bytes
+	}
+	res.Rejected = s.rejected.lastMinute(now)
+	return res
+}
+
+// check returns the name of the exceeded quota and the time until the request can be accepted.
+func (s *quotaState) check(limits *db.IngestionQuotas, signal string, now time.Time) (string, time.Duration) {
+	if day := startOfDay(now); !s.day.Equal(day) {
+		s.day = day
+		s.bytes = 0
+	}
+	if limits.BytesPerDay > 0 && s.bytes >= limits.BytesPerDay {
+		return "bytes_per_day", s.day.Add(24 * time.Hour).Sub(now)
+	}
+
+	var quota string
+	var limit int64
+	switch signal {
+	case signalTraces:
+		quota, limit = "spans_per_second", limits.SpansPerSecond
+	case signalLogs:
+		quota, limit = "logs_per_second", limits.LogsPerSecond
+	case signalMetrics:
+		quota, limit = "samples_per_second", limits.SamplesPerSecond
+	}
+	if limit <= 0 {
+		delete(s.buckets, signal)
+		return "", 0
+	}
+	b := s.buckets[signal]
+	if b == nil {
+		b = &tokenBucket{}
+		s.buckets[signal] = b
+	}
+	if wait := b.refill(float64(limit), now); wait > 0 {
+		return quota, wait
+	}
+	return "", 0
+}
+
+// tokenBucket holds up to one second of the rate.
+// A request is accepted if the bucket is not empty and may take more tokens than available,
+// so large requests are not rejected forever, while the following ones wait until the debt is repaid.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) refill(rate float64, now time.Time) time.Duration {
+	if b.last.IsZero() {
+		b.tokens = rate
+	} else {
+		b.tokens = min(rate, b.tokens+now.Sub(b.last).Seconds()*rate)
+	}
+	b.last = now
+	if b.tokens > 0 {
+		return 0
+	}
+	return max(time.Duration((1-b.tokens)/rate*float64(time.Second)), time.Millisecond)
+}
+
+// rateMeter counts events per minute.
+type rateMeter struct {
+	minute int64
+	count  int64
+	prev   int64
+}
+
+func (m *rateMeter) add(n int64, now time.Time) {
+	m.rotate(now)
+	m.count += n
+}
+
+// lastMinute returns the number of events during the last complete minute.
+func (m *rateMeter) lastMinute(now time.Time) int64 {
+	m.rotate(now)
+	return m.prev
+}
+
+func (m *rateMeter) rotate(now time.Time) {
+	minute := now.Unix() / 60
+	switch {
+	case minute == m.minute:
+	case minute == m.minute+1:
+		m.prev, m.count = m.count, 0
+	default:
+		m.prev, m.count = 0, 0
+	}
+	m.minute = minute
+}
+
+func startOfDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
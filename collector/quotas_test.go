@@ -0,0 +1,50 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coroot/coroot/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotas(t *testing.T) {
+	q := newQuotas()
+	project := quotaScope{projectId: "p1"}
+	key := quotaScope{projectId: "p1", apiKey: "k1"}
+	projectLimits := &db.IngestionQuotas{SpansPerSecond: 100, BytesPerDay: 1000}
+	keyLimits := &db.IngestionQuotas{LogsPerSecond: 10}
+	now := time.Date(2024, 5, 1, 23, 59, 0, 0, time.UTC)
+
+	// a request larger than the bucket is accepted, the following ones wait until the debt is repaid
+	require.NoError(t, q.take([]quotaScope{project}, []*db.IngestionQuotas{projectLimits}, signalTraces, 150, 100, now))
+	err := q.take([]quotaScope{project}, []*db.IngestionQuotas{projectLimits}, signalTraces, 10, 100, now.Add(100*time.Millisecond))
+	var qe QuotaExceededError
+	require.ErrorAs(t, err, &qe)
+	assert.Equal(t, "spans_per_second", qe.Quota)
+	assert.Equal(t, 410*time.Millisecond, qe.RetryAfter.Round(time.Millisecond))
+	require.NoError(t, q.take([]quotaScope{project}, []*db.IngestionQuotas{projectLimits}, signalTraces, 10, 100, now.Add(time.Second)))
+
+	// both the project and the key quotas are enforced
+	scopes := []quotaScope{project, key}
+	limits := []*db.IngestionQuotas{projectLimits, keyLimits}
+	require.NoError(t, q.take(scopes, limits, signalLogs, 20, 800, now.Add(time.Second)))
+	err = q.take(scopes, limits, signalLogs, 1, 100, now.Add(time.Second))
+	require.ErrorAs(t, err, &qe)
+	assert.Equal(t, "bytes_per_day", qe.Quota)
+	assert.Equal(t, 59*time.Second, qe.RetryAfter)
+
+	// the daily quota is reset at midnight
+	now = now.Add(time.Minute + time.Second)
+	require.NoError(t, q.take(scopes, limits, signalLogs, 20, 100, now))
+	err = q.take(scopes, limits, signalLogs, 1, 100, now)
+	require.ErrorAs(t, err, &qe)
+	assert.Equal(t, "logs_per_second", qe.Quota)
+	assert.Equal(t, 1100*time.Millisecond, qe.RetryAfter.Round(time.Millisecond))
+
+	u := q.usage(project, now.Add(time.Minute))
+	assert.Equal(t, int64(100), u.BytesToday)
+	assert.Equal(t, int64(1), u.Rejected)
+	assert.InDelta(t, 20.0/60, u.LogsPerSecond, 0.001)
+}
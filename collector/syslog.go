@@ -0,0 +1,447 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	semconv "go.opentelemetry.io/collector/semconv/v1.18.0"
+	v1 "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	"k8s.io/klog"
+)
+
+const (
+	syslogMaxMessageSize = 64 * 1024
+	// longer length prefixes are rejected, so that a client can't make the receiver buffer an endless run of digits
+	syslogMaxFrameLengthDigits = 6
+	syslogFlushInterval        = time.Second
+	syslogApiKeyParam          = "api_key"
+)
+
+var (
+	syslogFacilities = []string{
+		"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news", "uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+		"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+	}
+	syslogSeverities = []struct {
+		text   string
+		number logsv1.SeverityNumber
+	}{
+		{"emerg", logsv1.SeverityNumber_SEVERITY_NUMBER_FATAL4},
+		{"alert", logsv1.SeverityNumber_SEVERITY_NUMBER_FATAL3},
+		{"crit", logsv1.SeverityNumber_SEVERITY_NUMBER_FATAL},
+		{"err", logsv1.SeverityNumber_SEVERITY_NUMBER_ERROR},
+		{"warning", logsv1.SeverityNumber_SEVERITY_NUMBER_WARN},
+		{"notice", logsv1.SeverityNumber_SEVERITY_NUMBER_INFO2},
+		{"info", logsv1.SeverityNumber_SEVERITY_NUMBER_INFO},
+		{"debug", logsv1.SeverityNumber_SEVERITY_NUMBER_DEBUG},
+	}
+)
+
+type syslogMessage struct {
+	facility       int
+	severity       int
+	timestamp      time.Time
+	hostname       string
+	appName        string
+	procId         string
+	msgId          string
+	structuredData []syslogParam
+	message        string
+}
+
+// syslogParam is a structured data parameter, its name includes the SD-ID, e.g. origin.ip.
+type syslogParam struct {
+	name  string
+	value string
+}
+
+// syslogReceiver accepts RFC5424 and RFC3164 messages over TCP and UDP.
+// Messages are buffered per API key and flushed to the logs batches every second.
+type syslogReceiver struct {
+	collector *Collector
+	apiKey    string
+
+	tcp net.Listener
+	udp net.PacketConn
+
+	lock    sync.Mutex
+	pending map[string]*v1.ExportLogsServiceRequest
+	records int
+	done    chan struct{}
+}
+
+func (c *Collector) listenSyslog(address, apiKey string) (*syslogReceiver, error) {
+	r := &syslogReceiver{
+		collector: c,
+		apiKey:    apiKey,
+		pending:   map[string]*v1.ExportLogsServiceRequest{},
+		done:      make(chan struct{}),
+	}
+	var err error
+	if r.tcp, err = net.Listen("tcp", address); err != nil {
+		return nil, err
+	}
+	if r.udp, err = net.ListenPacket("udp", address); err != nil {
+		_ = r.tcp.Close()
+		return nil, err
+	}
+	klog.Infoln("syslog receiver is listening on", address)
+	go r.serveTCP()
+	go r.serveUDP()
+	go func() {
+		ticker := time.NewTicker(syslogFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.done:
+				return
+			case <-ticker.C:
+				r.flush()
+			}
+		}
+	}()
+	return r, nil
+}
+
+func (r *syslogReceiver) Close() {
+	r.done <- struct{}{}
+	_ = r.tcp.Close()
+	_ = r.udp.Close()
+	r.flush()
+}
+
+func (r *syslogReceiver) serveTCP() {
+	for {
+		conn, err := r.tcp.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				klog.Errorln("syslog:", err)
+			}
+			return
+		}
+		go func() {
+			defer conn.Close()
+			reader := bufio.NewReader(conn)
+			for {
+				frame, err := readSyslogFrame(reader)
+				if err != nil {
+					if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+						klog.Warningln("syslog:", conn.RemoteAddr(), err)
+					}
+					return
+				}
+				r.receive(frame)
+			}
+		}()
+	}
+}
+
+func (r *syslogReceiver) serveUDP() {
+	buf := make([]byte, syslogMaxMessageSize)
+	for {
+		n, _, err := r.udp.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				klog.Errorln("syslog:", err)
+			}
+			return
+		}
+		for _, line := range bytes.Split(buf[:n], []byte("\n")) {
+			r.receive(line)
+		}
+	}
+}
+
+// readSyslogFrame supports both octet-counting and newline-delimited framing (RFC6587).
+// Frames larger than syslogMaxMessageSize are truncated, like datagrams received over UDP.
+func readSyslogFrame(reader *bufio.Reader) ([]byte, error) {
+	b, err := reader.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	if b[0] >= '1' && b[0] <= '9' {
+		size := 0
+		for digits := 0; ; digits++ {
+			c, err := reader.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			if c == ' ' {
+				break
+			}
+			if c < '0' || c > '9' || digits >= syslogMaxFrameLengthDigits {
+				return nil, fmt.Errorf("invalid frame length")
+			}
+			size = size*10 + int(c-'0')
+		}
+		frame := make([]byte, min(size, syslogMaxMessageSize))
+		if _, err = io.ReadFull(reader, frame); err != nil {
+			return nil, err
+		}
+		if size > len(frame) {
+			if _, err = reader.Discard(size - len(frame)); err != nil {
+				return nil, err
+			}
+		}
+		return frame, nil
+	}
+	var line []byte
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if n := syslogMaxMessageSize - len(line); n > 0 {
+			line = append(line, chunk[:min(len(chunk), n)]...)
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err != nil && (!errors.Is(err, io.EOF) || len(line) == 0) {
+			return nil, err
+		}
+		return line, nil
+	}
+}
+
+func (r *syslogReceiver) receive(data []byte) {
+	data = bytes.TrimRight(data, "\r\n\x00")
+	if len(data) == 0 {
+		return
+	}
+	m, err := parseSyslogMessage(string(data), time.Now())
+	if err != nil {
+		klog.Warningln("syslog:", err)
+		return
+	}
+	apiKey := r.apiKey
+	for _, p := range m.structuredData {
+		if strings.HasSuffix(p.name, "."+syslogApiKeyParam) {
+			apiKey = p.value
+		}
+	}
+
+	r.lock.Lock()
+	req := r.pending[apiKey]
+	if req == nil {
+		req = &v1.ExportLogsServiceRequest{}
+		r.pending[apiKey] = req
+	}
+	req.ResourceLogs = append(req.ResourceLogs, m.toOTLP())
+	r.records++
+	full := r.records >= batchLimit
+	r.lock.Unlock()
+
+	if full {
+		r.flush()
+	}
+}
+
+func (r *syslogReceiver) flush() {
+	r.lock.Lock()
+	pending := r.pending
+	r.pending = map[string]*v1.ExportLogsServiceRequest{}
+	r.records = 0
+	r.lock.Unlock()
+
+	for apiKey, req := range pending {
+		project, err := r.collector.getProject(apiKey)
+		if err != nil {
+			klog.Errorln("syslog:", err)
+			continue
+		}
+		rejected, err := r.collector.ingestLogs(project, apiKey, req)
+		if err != nil {
+			klog.Warningf("syslog: %d messages dropped: %s", countLogRecords(req), err)
+			continue
+		}
+		if rejected.count() > 0 {
+			klog.Warningln("syslog:", rejected.message("messages"))
+		}
+	}
+}
+
+func (m *syslogMessage) toOTLP() *logsv1.ResourceLogs {
+	resource := &resourcev1.Resource{}
+	if m.hostname != "" {
+		resource.Attributes = append(resource.Attributes, stringAttribute(semconv.AttributeHostName, m.hostname))
+	}
+	if m.appName != "" {
+		resource.Attributes = append(resource.Attributes, stringAttribute(semconv.AttributeServiceName, m.appName))
+	}
+	lr := &logsv1.LogRecord{
+		TimeUnixNano:         uint64(m.timestamp.UnixNano()),
+		ObservedTimeUnixNano: uint64(time.Now().UnixNano()),
+		SeverityText:         syslogSeverities[m.severity].text,
+		SeverityNumber:       syslogSeverities[m.severity].number,
+		Body:                 &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: m.message}},
+	}
+	if m.facility < len(syslogFacilities) {
+		lr.Attributes = append(lr.Attributes, stringAttribute("syslog.facility", syslogFacilities[m.facility]))
+	}
+	if m.procId != "" {
+		lr.Attributes = append(lr.Attributes, stringAttribute("syslog.procid", m.procId))
+	}
+	if m.msgId != "" {
+		lr.Attributes = append(lr.Attributes, stringAttribute("syslog.msgid", m.msgId))
+	}
+	for _, p := range m.structuredData {
+		if !strings.HasSuffix(p.name, "."+syslogApiKeyParam) {
+			lr.Attributes = append(lr.Attributes, stringAttribute(p.name, p.value))
+		}
+	}
+	return &logsv1.ResourceLogs{Resource: resource, ScopeLogs: []*logsv1.ScopeLogs{{LogRecords: []*logsv1.LogRecord{lr}}}}
+}
+
+func parseSyslogMessage(s string, now time.Time) (*syslogMessage, error) {
+	if !strings.HasPrefix(s, "<") {
+		return nil, fmt.Errorf("invalid message: no priority")
+	}
+	end := strings.IndexByte(s, '>')
+	if end < 2 || end > 4 {
+		return nil, fmt.Errorf("invalid message: no priority")
+	}
+	pri, err := strconv.Atoi(s[1:end])
+	if err != nil || pri > 191 {
+		return nil, fmt.Errorf("invalid priority: %q", s[1:end])
+	}
+	m := &syslogMessage{facility: pri / 8, severity: pri % 8}
+	s = s[end+1:]
+	if strings.HasPrefix(s, "1 ") {
+		err = m.parseRFC5424(s[2:], now)
+	} else {
+		m.parseRFC3164(s, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// parseRFC5424 parses TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG].
+func (m *syslogMessage) parseRFC5424(s string, now time.Time) error {
+	fields := make([]string, 5)
+	for i := range fields {
+		var ok bool
+		fields[i], s, ok = strings.Cut(s, " ")
+		if !ok {
+			return fmt.Errorf("invalid RFC5424 message: missing fields")
+		}
+		if fields[i] == "-" {
+			fields[i] = ""
+		}
+	}
+	m.timestamp = now
+	if fields[0] != "" {
+		ts, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return fmt.Errorf("invalid timestamp: %w", err)
+		}
+		m.timestamp = ts
+	}
+	m.hostname, m.appName, m.procId, m.msgId = fields[1], fields[2], fields[3], fields[4]
+
+	if strings.HasPrefix(s, "-") {
+		s = s[1:]
+	} else {
+		var err error
+		if m.structuredData, s, err = parseSyslogStructuredData(s); err != nil {
+			return err
+		}
+	}
+	s = strings.TrimPrefix(s, " ")
+	m.message = strings.TrimPrefix(s, "\ufeff") // BOM
+	return nil
+}
+
+// parseSyslogStructuredData parses [SD-ID PARAM="VALUE" ...]... and returns the rest of the message.
+func parseSyslogStructuredData(s string) ([]syslogParam, string, error) {
+	var res []syslogParam
+	for strings.HasPrefix(s, "[") {
+		s = s[1:]
+		i := strings.IndexAny(s, " ]")
+		if i < 0 {
+			return nil, "", fmt.Errorf("invalid structured data")
+		}
+		id := s[:i]
+		s = s[i:]
+		for strings.HasPrefix(s, " ") {
+			s = s[1:]
+			name, rest, ok := strings.Cut(s, `="`)
+			if !ok {
+				return nil, "", fmt.Errorf("invalid structured data param")
+			}
+			s = rest
+			var value strings.Builder
+			for {
+				if len(s) == 0 {
+					return nil, "", fmt.Errorf("invalid structured data: unterminated value")
+				}
+				c := s[0]
+				s = s[1:]
+				if c == '"' {
+					break
+				}
+				if c == '\\' && len(s) > 0 && (s[0] == '"' || s[0] == '\\' || s[0] == ']') {
+					c = s[0]
+					s = s[1:]
+				}
+				value.WriteByte(c)
+			}
+			res = append(res, syslogParam{name: id + "." + name, value: value.String()})
+		}
+		if !strings.HasPrefix(s, "]") {
+			return nil, "", fmt.Errorf("invalid structured data")
+		}
+		s = s[1:]
+	}
+	return res, s, nil
+}
+
+// parseRFC3164 parses the BSD syslog format: TIMESTAMP HOSTNAME TAG[PID]: MSG.
+// It is loosely defined, so anything that can't be parsed is kept in the message.
+func (m *syslogMessage) parseRFC3164(s string, now time.Time) {
+	m.timestamp = now
+	if len(s) >= len(time.Stamp) {
+		if ts, err := time.Parse(time.Stamp, s[:len(time.Stamp)]); err == nil {
+			m.timestamp = time.Date(now.Year(), ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), ts.Second(), 0, time.UTC)
+			if m.timestamp.After(now.Add(24 * time.Hour)) {
+				m.timestamp = m.timestamp.AddDate(-1, 0, 0)
+			}
+			s = strings.TrimPrefix(s[len(time.Stamp):], " ")
+		} else if ts, rest, ok := strings.Cut(s, " "); ok {
+			if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+				m.timestamp = t
+				s = rest
+			}
+		}
+	}
+	if host, rest, ok := strings.Cut(s, " "); ok && !strings.HasSuffix(host, ":") && !strings.Contains(host, "[") {
+		m.hostname = host
+		s = rest
+	}
+	tagEnd := strings.IndexAny(s, "[: ")
+	if tagEnd > 0 {
+		tag := s[:tagEnd]
+		rest := s[tagEnd:]
+		if strings.HasPrefix(rest, "[") {
+			if pid, r, ok := strings.Cut(rest[1:], "]"); ok {
+				m.procId = pid
+				rest = r
+			}
+		}
+		if strings.HasPrefix(rest, ":") {
+			m.appName = tag
+			s = strings.TrimPrefix(rest[1:], " ")
+		}
+	}
+	m.message = s
+}
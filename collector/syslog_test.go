@@ -0,0 +1,92 @@
+package collector
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSyslogMessage(t *testing.T) {
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	m, err := parseSyslogMessage(`<165>1 2024-01-10T11:59:58.003Z router1 sshd 1234 ID47 [auth@32473 api_key="key1"][origin ip="10.0.0.1" note="a \"quoted\" value"] Failed password`, now)
+	require.NoError(t, err)
+	assert.Equal(t, 20, m.facility)
+	assert.Equal(t, 5, m.severity)
+	assert.Equal(t, time.Date(2024, 1, 10, 11, 59, 58, 3000000, time.UTC), m.timestamp)
+	assert.Equal(t, "router1", m.hostname)
+	assert.Equal(t, "sshd", m.appName)
+	assert.Equal(t, "1234", m.procId)
+	assert.Equal(t, "ID47", m.msgId)
+	assert.Equal(t, []syslogParam{{"auth@32473.api_key", "key1"}, {"origin.ip", "10.0.0.1"}, {"origin.note", `a "quoted" value`}}, m.structuredData)
+	assert.Equal(t, "Failed password", m.message)
+
+	rl := m.toOTLP()
+	assert.Equal(t, map[string]string{"host.name": "router1", "service.name": "sshd"}, attributesToMap(rl.Resource.Attributes))
+	lr := rl.ScopeLogs[0].LogRecords[0]
+	assert.Equal(t, "notice", lr.SeverityText)
+	assert.Equal(t, map[string]string{"syslog.facility": "local4", "syslog.procid": "1234", "syslog.msgid": "ID47", "origin.ip": "10.0.0.1", "origin.note": `a "quoted" value`}, attributesToMap(lr.Attributes))
+
+	m, err = parseSyslogMessage(`<34>1 - - - - - -`, now)
+	require.NoError(t, err)
+	assert.Equal(t, now, m.timestamp)
+	assert.Equal(t, "", m.hostname)
+	assert.Equal(t, "", m.message)
+
+	m, err = parseSyslogMessage(`<13>Feb  5 17:32:18 10.0.0.99 kernel[0]: link down`, now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 2, 5, 17, 32, 18, 0, time.UTC), m.timestamp)
+	assert.Equal(t, "10.0.0.99", m.hostname)
+	assert.Equal(t, "kernel", m.appName)
+	assert.Equal(t, "0", m.procId)
+	assert.Equal(t, "link down", m.message)
+
+	m, err = parseSyslogMessage(`<13>su: 'su root' failed for user`, now)
+	require.NoError(t, err)
+	assert.Equal(t, "", m.hostname)
+	assert.Equal(t, "su", m.appName)
+	assert.Equal(t, "'su root' failed for user", m.message)
+
+	_, err = parseSyslogMessage(`hello`, now)
+	assert.Error(t, err)
+}
+
+func TestReadSyslogFrame(t *testing.T) {
+	read := func(data string) ([]string, error) {
+		reader := bufio.NewReaderSize(strings.NewReader(data), 16)
+		var frames []string
+		for {
+			frame, err := readSyslogFrame(reader)
+			if errors.Is(err, io.EOF) {
+				return frames, nil
+			}
+			if err != nil {
+				return frames, err
+			}
+			frames = append(frames, string(frame))
+		}
+	}
+
+	frames, err := read("11 <13>1 - - x5 <13>1<13>1 - - y\n<13>1 - - z")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"<13>1 - - x", "<13>1", "<13>1 - - y\n", "<13>1 - - z"}, frames)
+
+	long := strings.Repeat("a", syslogMaxMessageSize+100)
+	frames, err = read(long + "\nnext\n")
+	require.NoError(t, err)
+	assert.Equal(t, []string{long[:syslogMaxMessageSize], "next\n"}, frames)
+
+	frames, err = read(fmt.Sprintf("%d %snext\n", len(long), long))
+	require.NoError(t, err)
+	assert.Equal(t, []string{long[:syslogMaxMessageSize], "next\n"}, frames)
+
+	_, err = read(strings.Repeat("9", 100))
+	assert.ErrorContains(t, err, "invalid frame length")
+}
@@ -2,13 +2,15 @@ package collector
 
 import (
 	"encoding/hex"
-	"io"
+	"fmt"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/ClickHouse/ch-go"
 	chproto "github.com/ClickHouse/ch-go/proto"
+	"github.com/coroot/coroot/db"
+	"github.com/prometheus/client_golang/prometheus"
 	semconv "go.opentelemetry.io/collector/semconv/v1.18.0"
 	v1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
 	"google.golang.org/protobuf/proto"
@@ -23,53 +25,66 @@ func (c *Collector) Traces(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	contentType := r.Header.Get("Content-Type")
-	switch contentType {
-	case "application/x-protobuf":
-	default:
-		http.Error(w, "unsupported content type: "+contentType, http.StatusBadRequest)
+	req := &v1.ExportTraceServiceRequest{}
+	mediaType, status, err := readOTLPRequest(r, req)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, err.Error(), status)
 		return
 	}
 
-	decoder, err := getDecoder(r.Header.Get("Content-Encoding"), r.Body)
+	rejected, err := c.ingestTraces(project, r.Header.Get(ApiKeyHeader), req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeIngestError(w, err)
 		return
 	}
+	resp := &v1.ExportTraceServiceResponse{}
+	if rejected.count() > 0 {
+		resp.PartialSuccess = &v1.ExportTracePartialSuccess{RejectedSpans: rejected.count(), ErrorMessage: rejected.message("spans")}
+	}
+	writeOTLPResponse(w, mediaType, resp)
+}
 
-	data, err := io.ReadAll(decoder)
+// ingestTraces applies the ingestion quotas and the ingest pipeline to the request and adds the spans to the batch.
+// It is shared by all the trace receivers.
+func (c *Collector) ingestTraces(project *db.Project, apiKey string, req *v1.ExportTraceServiceRequest) (rejection, error) {
+	release, err := c.acquire(project.Id)
 	if err != nil {
-		klog.Errorln(err)
-		http.Error(w, "", http.StatusInternalServerError)
-		return
+		return rejection{}, err
 	}
-	req := &v1.ExportTraceServiceRequest{}
-	err = proto.Unmarshal(data, req)
+	defer release()
+
+	if err = c.checkQuotas(project, apiKey, signalTraces, func() int { return countSpans(req) }, proto.Size(req)); err != nil {
+		klog.Warningln(err)
+		return rejection{}, err
+	}
+	c.processTraces(project, req)
+	rejected, err := c.getTracesBatch(project).Add(req)
 	if err != nil {
 		klog.Errorln(err)
-		http.Error(w, "", http.StatusBadRequest)
-		return
 	}
+	return rejected, err
+}
 
-	c.getTracesBatch(project).Add(req)
-
-	resp := &v1.ExportTraceServiceResponse{}
-	w.Header().Set("Content-Type", contentType)
-	data, err = proto.Marshal(resp)
-	if err != nil {
-		klog.Errorln(err)
-		http.Error(w, "", http.StatusInternalServerError)
-		return
+func countSpans(req *v1.ExportTraceServiceRequest) int {
+	var spans int
+	for _, rs := range req.GetResourceSpans() {
+		for _, ss := range rs.GetScopeSpans() {
+			spans += len(ss.GetSpans())
+		}
 	}
-	_, _ = w.Write(data)
+	return spans
 }
 
 type TracesBatch struct {
-	limit int
-	exec  func(query ch.Query) error
+	limit   int
+	exec    func(query ch.Query) error
+	wal     *WAL
+	dropped prometheus.Counter
 
-	lock sync.Mutex
-	done chan struct{}
+	lock    sync.Mutex
+	done    chan struct{}
+	retryAt time.Time
 
 	Timestamp          *chproto.ColDateTime64
 	TraceId            *chproto.ColStr
@@ -93,11 +108,13 @@ type TracesBatch struct {
 	LinksAttributes    *chproto.ColArr[map[string]string]
 }
 
-func NewTracesBatch(limit int, timeout time.Duration, exec func(query ch.Query) error) *TracesBatch {
+func NewTracesBatch(limit int, timeout time.Duration, wal *WAL, dropped prometheus.Counter, exec func(query ch.Query) error) *TracesBatch {
 	b := &TracesBatch{
-		limit: limit,
-		exec:  exec,
-		done:  make(chan struct{}),
+		limit:   limit,
+		exec:    exec,
+		wal:     wal,
+		dropped: dropped,
+		done:    make(chan struct{}),
 
 		Timestamp:          new(chproto.ColDateTime64).WithPrecision(chproto.PrecisionNano),
 		TraceId:            new(chproto.ColStr),
@@ -130,6 +147,7 @@ func NewTracesBatch(limit int, timeout time.Duration, exec func(query ch.Query)
 				return
 			case <-ticker.C:
 				b.lock.Lock()
+				b.replay()
 				b.save()
 				b.lock.Unlock()
 			}
@@ -143,13 +161,69 @@ func (b *TracesBatch) Close() {
 	b.done <- struct{}{}
 	b.lock.Lock()
 	defer b.lock.Unlock()
+	b.retryAt = time.Time{}
 	b.save()
+	if b.wal != nil {
+		b.wal.Close()
+	}
 }
 
-func (b *TracesBatch) Add(req *v1.ExportTraceServiceRequest) {
+// Add appends the spans of the request to the batch and returns the spans rejected due to invalid IDs
+// or exceeding the capacity of the batch. If the WAL is enabled, the request is written to it first.
+func (b *TracesBatch) Add(req *v1.ExportTraceServiceRequest) (rejection, error) {
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
+	var rejected rejection
+	capacity := b.limit*batchMaxPendingFactor - b.Timestamp.Rows()
+	if capacity <= 0 {
+		return rejected, SaturatedError{RetryAfter: retryAfter(b.retryAt)}
+	}
+	var spans int
+	for _, rs := range req.GetResourceSpans() {
+		for _, ss := range rs.GetScopeSpans() {
+			if n := capacity - spans; len(ss.Spans) > n {
+				rejected.overflow += int64(len(ss.Spans) - n)
+				ss.Spans = ss.Spans[:n]
+			}
+			spans += len(ss.Spans)
+		}
+	}
+
+	if b.wal != nil {
+		data, err := proto.Marshal(req)
+		if err != nil {
+			return rejected, err
+		}
+		if err = b.wal.Append(data, spans); err != nil {
+			return rejected, fmt.Errorf("failed to write to WAL: %w", err)
+		}
+	}
+	rejected.invalid = b.add(req)
+	if b.Timestamp.Rows() >= b.limit && time.Now().After(b.retryAt) {
+		b.save()
+	}
+	return rejected, nil
+}
+
+func (b *TracesBatch) replay() {
+	if b.wal == nil {
+		return
+	}
+	for b.Timestamp.Rows() < b.limit && b.wal.ReplayBacklog(func(data []byte) {
+		req := &v1.ExportTraceServiceRequest{}
+		if err := proto.Unmarshal(data, req); err != nil {
+			klog.Errorln(err)
+			return
+		}
+		b.add(req)
+	}) {
+	}
+}
+
+func (b *TracesBatch) add(req *v1.ExportTraceServiceRequest) int64 {
+	var rejected int64
+
 	for _, rs := range req.GetResourceSpans() {
 		var serviceName string
 		resourceAttributes := attributesToMap(rs.GetResource().GetAttributes())
@@ -162,6 +236,10 @@ func (b *TracesBatch) Add(req *v1.ExportTraceServiceRequest) {
 			scopeName := ss.GetScope().GetName()
 			scopeVersion := ss.GetScope().GetVersion()
 			for _, s := range ss.GetSpans() {
+				if len(s.GetTraceId()) != 16 || len(s.GetSpanId()) != 8 {
+					rejected++
+					continue
+				}
 				spanAttributes := attributesToMap(s.GetAttributes())
 				if scopeName != "" {
 					spanAttributes[semconv.AttributeOtelScopeName] = scopeName
@@ -211,14 +289,11 @@ func (b *TracesBatch) Add(req *v1.ExportTraceServiceRequest) {
 			}
 		}
 	}
-	if b.Timestamp.Rows() < b.limit {
-		return
-	}
-	b.save()
+	return rejected
 }
 
 func (b *TracesBatch) save() {
-	if b.Timestamp.Rows() == 0 {
+	if b.Timestamp.Rows() == 0 || time.Now().Before(b.retryAt) {
 		return
 	}
 
@@ -244,9 +319,22 @@ func (b *TracesBatch) save() {
 		{Name: "Links.TraceState", Data: b.LinksTraceState},
 		{Name: "Links.Attributes", Data: b.LinksAttributes},
 	}
+	var walSegment int
+	if b.wal != nil {
+		walSegment = b.wal.Cut()
+	}
 	err := b.exec(ch.Query{Body: input.Into("@@table_otel_traces@@"), Input: input})
 	if err != nil {
 		klog.Errorln(err)
+		if retryable(err) {
+			b.retryAt = time.Now().Add(batchRetryInterval)
+			return
+		}
+		b.dropped.Add(float64(b.Timestamp.Rows()))
+	}
+	b.retryAt = time.Time{}
+	if b.wal != nil {
+		b.wal.Commit(walSegment)
 	}
 	for _, i := range input {
 		i.Data.(chproto.Resettable).Reset()
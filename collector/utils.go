@@ -1,18 +1,19 @@
 package collector
 
 import (
-	"bytes"
 	"compress/gzip"
 	"compress/zlib"
 	"encoding/json"
 	"fmt"
 	"io"
 	"math"
+	"net/http"
 	"strconv"
 
 	"github.com/golang/snappy"
 	"github.com/klauspost/compress/zstd"
 	v1 "go.opentelemetry.io/proto/otlp/common/v1"
+	"google.golang.org/protobuf/encoding/protowire"
 )
 
 func attributesToMap(kv []*v1.KeyValue) map[string]string {
@@ -23,6 +24,10 @@ func attributesToMap(kv []*v1.KeyValue) map[string]string {
 	return res
 }
 
+func stringAttribute(key, value string) *v1.KeyValue {
+	return &v1.KeyValue{Key: key, Value: &v1.AnyValue{Value: &v1.AnyValue_StringValue{StringValue: value}}}
+}
+
 func valueToString(value *v1.AnyValue) string {
 	switch value.Value.(type) {
 	case *v1.AnyValue_StringValue:
@@ -65,6 +70,25 @@ func valueToString(value *v1.AnyValue) string {
 	return fmt.Sprintf("unknown attribute value type: %T", value.Value)
 }
 
+// requestBodyMaxSize limits both the size of a request body and its decompressed size.
+const requestBodyMaxSize = 64 << 20
+
+// readRequestBody reads and decompresses the request body, limiting both sizes to requestBodyMaxSize.
+func readRequestBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	decoder, err := getDecoder(r.Header.Get("Content-Encoding"), http.MaxBytesReader(w, r.Body, requestBodyMaxSize))
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(decoder, requestBodyMaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > requestBodyMaxSize {
+		return nil, fmt.Errorf("request body exceeds %d bytes", requestBodyMaxSize)
+	}
+	return data, nil
+}
+
 func getDecoder(encoding string, body io.ReadCloser) (io.ReadCloser, error) {
 	switch encoding {
 	case "", "node":
@@ -80,16 +104,45 @@ func getDecoder(encoding string, body io.ReadCloser) (io.ReadCloser, error) {
 		}
 		return r.IOReadCloser(), nil
 	case "snappy":
-		r := snappy.NewReader(body)
-		b := new(bytes.Buffer)
-		_, err := io.Copy(b, r)
-		if err != nil {
-			return nil, err
+		return struct {
+			io.Reader
+			io.Closer
+		}{snappy.NewReader(body), body}, nil
+	}
+	return nil, fmt.Errorf("unsupported content encoding: %q", encoding)
+}
+
+// walkProto calls f for each field of a protobuf message passing the value of length-delimited fields as v and the value of numeric fields as x.
+func walkProto(data []byte, f func(num protowire.Number, v []byte, x uint64) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
 		}
-		if err = body.Close(); err != nil {
-			return nil, err
+		data = data[n:]
+		var v []byte
+		var x uint64
+		switch typ {
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(data)
+		case protowire.VarintType:
+			x, n = protowire.ConsumeVarint(data)
+		case protowire.Fixed64Type:
+			x, n = protowire.ConsumeFixed64(data)
+		case protowire.Fixed32Type:
+			var x32 uint32
+			x32, n = protowire.ConsumeFixed32(data)
+			x = uint64(x32)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		if err := f(num, v, x); err != nil {
+			return err
 		}
-		return io.NopCloser(b), nil
 	}
-	return nil, fmt.Errorf("unsupported content encoding: %q", encoding)
+	return nil
 }
@@ -0,0 +1,277 @@
+package collector
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog"
+)
+
+const (
+	walSegmentExt       = ".wal"
+	walMaxSegmentSize   = 16 << 20
+	walRecordHeaderSize = 12
+)
+
+var (
+	walQueueRows = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "coroot_collector_wal_queue_rows",
+			Help: "Number of rows written to the collector WAL but not yet stored in ClickHouse",
+		},
+		[]string{"project_id", "signal"},
+	)
+	walQueueBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "coroot_collector_wal_queue_bytes",
+			Help: "Size of the collector WAL on disk",
+		},
+		[]string{"project_id", "signal"},
+	)
+)
+
+// WAL is a segmented on-disk log of the requests accepted by a batch.
+// The segments are removed once the batch containing their records is stored in ClickHouse.
+// The segments left after a restart are the backlog, which is replayed into the batch.
+// WAL is not safe for concurrent use, it is protected by the lock of the batch.
+type WAL struct {
+	dir         string
+	maxSize     int64
+	segmentSize int64
+
+	segments []*walSegment // ordered by id, the last one is the segment being written
+	current  *os.File
+	// memFrom is the id of the first segment whose records are in the batch, the older segments are the backlog.
+	memFrom int
+	// replayedTo is the id of the first backlog segment that hasn't been replayed into the batch yet.
+	replayedTo int
+
+	queueRows  prometheus.Gauge
+	queueBytes prometheus.Gauge
+	dropped    prometheus.Counter
+}
+
+type walSegment struct {
+	id   int
+	size int64
+	rows int
+}
+
+func OpenWAL(dir string, maxSize int64, projectId, signal string) (*WAL, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	w := &WAL{
+		dir:         dir,
+		maxSize:     maxSize,
+		segmentSize: min(walMaxSegmentSize, max(maxSize/4, 1)),
+		queueRows:   walQueueRows.WithLabelValues(projectId, signal),
+		queueBytes:  walQueueBytes.WithLabelValues(projectId, signal),
+		dropped:     droppedRows.WithLabelValues(projectId, signal),
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		id, err := strconv.Atoi(strings.TrimSuffix(e.Name(), walSegmentExt))
+		if err != nil || !strings.HasSuffix(e.Name(), walSegmentExt) {
+			continue
+		}
+		s := &walSegment{id: id}
+		err = readWALSegment(w.segmentPath(id), func(data []byte, rows int) {
+			s.rows += rows
+			s.size += int64(walRecordHeaderSize + len(data))
+		})
+		if err != nil {
+			klog.Warningf("failed to read WAL segment %s: %s", w.segmentPath(id), err)
+		}
+		w.segments = append(w.segments, s)
+	}
+	sort.Slice(w.segments, func(i, j int) bool { return w.segments[i].id < w.segments[j].id })
+	next := 1
+	if len(w.segments) > 0 {
+		next = w.segments[len(w.segments)-1].id + 1
+	}
+	// all the existing segments are the backlog
+	w.memFrom = next
+	if err = w.openSegment(next); err != nil {
+		return nil, err
+	}
+	w.updateMetrics()
+	return w, nil
+}
+
+// Append writes the record to the current segment and syncs it to disk.
+func (w *WAL) Append(data []byte, rows int) error {
+	s := w.segments[len(w.segments)-1]
+	if s.size > 0 && s.size+int64(walRecordHeaderSize+len(data)) > w.segmentSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+		s = w.segments[len(w.segments)-1]
+	}
+	header := make([]byte, walRecordHeaderSize)
+	binary.LittleEndian.PutUint32(header[0:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(header[4:], crc32.ChecksumIEEE(data))
+	binary.LittleEndian.PutUint32(header[8:], uint32(rows))
+	if _, err := w.current.Write(append(header, data...)); err != nil {
+		return err
+	}
+	if err := w.current.Sync(); err != nil {
+		return err
+	}
+	s.size += int64(walRecordHeaderSize + len(data))
+	s.rows += rows
+	w.evict()
+	w.updateMetrics()
+	return nil
+}
+
+// Cut starts a new segment, so that the records of the batch being stored are separated from the subsequent ones.
+// It returns the id of the new segment to be passed to Commit.
+func (w *WAL) Cut() int {
+	if s := w.segments[len(w.segments)-1]; s.size > 0 {
+		if err := w.rotate(); err != nil {
+			klog.Errorln("failed to rotate WAL:", err)
+		}
+	}
+	return w.segments[len(w.segments)-1].id
+}
+
+// Commit removes the segments whose records have been stored, including the replayed backlog segments.
+func (w *WAL) Commit(upTo int) {
+	w.segments = w.remove(func(s *walSegment) bool { return s.id < w.replayedTo || s.id >= w.memFrom && s.id < upTo })
+	w.memFrom = upTo
+	w.updateMetrics()
+}
+
+// ReplayBacklog passes the records of the oldest backlog segment that hasn't been replayed yet to the given function.
+// Segments are replayed one at a time to keep the batch size bounded, it returns false if the backlog is empty.
+func (w *WAL) ReplayBacklog(f func(data []byte)) bool {
+	var s *walSegment
+	for _, seg := range w.segments {
+		if seg.id >= w.replayedTo && seg.id < w.memFrom {
+			s = seg
+			break
+		}
+	}
+	if s == nil {
+		return false
+	}
+	err := readWALSegment(w.segmentPath(s.id), func(data []byte, _ int) {
+		f(data)
+	})
+	if err != nil {
+		klog.Warningf("failed to replay WAL segment %s: %s", w.segmentPath(s.id), err)
+	}
+	w.replayedTo = s.id + 1
+	return true
+}
+
+func (w *WAL) Close() {
+	if err := w.current.Close(); err != nil {
+		klog.Errorln(err)
+	}
+}
+
+func (w *WAL) openSegment(id int) error {
+	f, err := os.OpenFile(w.segmentPath(id), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	w.current = f
+	w.segments = append(w.segments, &walSegment{id: id})
+	return nil
+}
+
+func (w *WAL) rotate() error {
+	if err := w.current.Close(); err != nil {
+		return err
+	}
+	return w.openSegment(w.segments[len(w.segments)-1].id + 1)
+}
+
+// evict removes the oldest segments until the WAL fits the size limit.
+// The records of the backlog segments not replayed yet are lost, while the records of the other ones are still in the batch.
+func (w *WAL) evict() {
+	var size int64
+	for _, s := range w.segments {
+		size += s.size
+	}
+	for size > w.maxSize && len(w.segments) > 1 {
+		s := w.segments[0]
+		if s.id >= w.replayedTo && s.id < w.memFrom {
+			w.dropped.Add(float64(s.rows))
+			klog.Warningf("WAL %s exceeded %d bytes: dropped %d rows", w.dir, w.maxSize, s.rows)
+		}
+		w.segments = w.remove(func(seg *walSegment) bool { return seg == s })
+		size -= s.size
+	}
+}
+
+func (w *WAL) remove(f func(s *walSegment) bool) []*walSegment {
+	last := w.segments[len(w.segments)-1]
+	res := w.segments[:0]
+	for _, s := range w.segments {
+		if s != last && f(s) {
+			if err := os.Remove(w.segmentPath(s.id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				klog.Errorln(err)
+			}
+			continue
+		}
+		res = append(res, s)
+	}
+	return res
+}
+
+func (w *WAL) updateMetrics() {
+	var rows int
+	var size int64
+	for _, s := range w.segments {
+		rows += s.rows
+		size += s.size
+	}
+	w.queueRows.Set(float64(rows))
+	w.queueBytes.Set(float64(size))
+}
+
+func (w *WAL) segmentPath(id int) string {
+	return filepath.Join(w.dir, fmt.Sprintf("%08d%s", id, walSegmentExt))
+}
+
+func readWALSegment(path string, f func(data []byte, rows int)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	r := bufio.NewReader(file)
+	header := make([]byte, walRecordHeaderSize)
+	for {
+		if _, err = io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("truncated record header: %w", err)
+		}
+		data := make([]byte, binary.LittleEndian.Uint32(header[0:]))
+		if _, err = io.ReadFull(r, data); err != nil {
+			return fmt.Errorf("truncated record: %w", err)
+		}
+		if crc32.ChecksumIEEE(data) != binary.LittleEndian.Uint32(header[4:]) {
+			return fmt.Errorf("checksum mismatch")
+		}
+		f(data, int(binary.LittleEndian.Uint32(header[8:])))
+	}
+}
@@ -0,0 +1,84 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWAL(t *testing.T) {
+	dir := t.TempDir()
+	replay := func(w *WAL) []string {
+		var res []string
+		w.ReplayBacklog(func(data []byte) {
+			res = append(res, string(data))
+		})
+		return res
+	}
+
+	w, err := OpenWAL(dir, 1<<20, "wal-test", "traces")
+	require.NoError(t, err)
+	require.NoError(t, w.Append([]byte("r1"), 2))
+	require.NoError(t, w.Append([]byte("r2"), 3))
+	assert.Equal(t, float64(5), testutil.ToFloat64(walQueueRows.WithLabelValues("wal-test", "traces")))
+
+	// storing failed: the records are kept until the batch is stored
+	w.Cut()
+	require.NoError(t, w.Append([]byte("r3"), 1))
+	assert.Equal(t, float64(6), testutil.ToFloat64(walQueueRows.WithLabelValues("wal-test", "traces")))
+	w.Commit(w.Cut())
+	assert.Equal(t, float64(0), testutil.ToFloat64(walQueueRows.WithLabelValues("wal-test", "traces")))
+
+	// the records left after a restart are replayed one segment at a time, starting from the oldest one
+	require.NoError(t, w.Append([]byte("r4"), 1))
+	w.Cut()
+	require.NoError(t, w.Append([]byte("r5"), 1))
+	w.Cut()
+	require.NoError(t, w.Append([]byte("r6"), 1))
+	w.Close()
+	w, err = OpenWAL(dir, 1<<20, "wal-test", "traces")
+	require.NoError(t, err)
+	assert.Equal(t, float64(3), testutil.ToFloat64(walQueueRows.WithLabelValues("wal-test", "traces")))
+	assert.Equal(t, []string{"r4"}, replay(w))
+	require.NoError(t, w.Append([]byte("r7"), 1))
+
+	// the replayed segments are removed along with the new ones once the batch is stored
+	w.Commit(w.Cut())
+	assert.Equal(t, float64(2), testutil.ToFloat64(walQueueRows.WithLabelValues("wal-test", "traces")))
+	assert.Equal(t, []string{"r5"}, replay(w))
+	assert.Equal(t, []string{"r6"}, replay(w))
+	assert.False(t, w.ReplayBacklog(func([]byte) {}))
+	w.Commit(w.Cut())
+	assert.Equal(t, float64(0), testutil.ToFloat64(walQueueRows.WithLabelValues("wal-test", "traces")))
+	w.Close()
+
+	// nothing is left to replay after a restart
+	w, err = OpenWAL(dir, 1<<20, "wal-test", "traces")
+	require.NoError(t, err)
+	assert.Empty(t, replay(w))
+	assert.False(t, w.ReplayBacklog(func([]byte) {}))
+	w.Close()
+}
+
+func TestWALEviction(t *testing.T) {
+	dir := t.TempDir()
+	data := make([]byte, 40-walRecordHeaderSize)
+
+	w, err := OpenWAL(dir, 100, "wal-test", "logs")
+	require.NoError(t, err)
+	require.NoError(t, w.Append(data, 10))
+	require.NoError(t, w.Append(data, 20))
+	w.Close()
+
+	w, err = OpenWAL(dir, 100, "wal-test", "logs")
+	require.NoError(t, err)
+	defer w.Close()
+	require.NoError(t, w.Append(data, 30))
+
+	// the oldest segment of the backlog has been evicted
+	assert.Equal(t, float64(10), testutil.ToFloat64(droppedRows.WithLabelValues("wal-test", "logs")))
+	assert.Equal(t, float64(50), testutil.ToFloat64(walQueueRows.WithLabelValues("wal-test", "logs")))
+	assert.Equal(t, float64(80), testutil.ToFloat64(walQueueBytes.WithLabelValues("wal-test", "logs")))
+}
@@ -0,0 +1,289 @@
+package collector
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"sort"
+
+	semconv "go.opentelemetry.io/collector/semconv/v1.18.0"
+	v1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+	"k8s.io/klog"
+)
+
+var zipkinSpanKinds = map[string]tracev1.Span_SpanKind{
+	"CLIENT":   tracev1.Span_SPAN_KIND_CLIENT,
+	"SERVER":   tracev1.Span_SPAN_KIND_SERVER,
+	"PRODUCER": tracev1.Span_SPAN_KIND_PRODUCER,
+	"CONSUMER": tracev1.Span_SPAN_KIND_CONSUMER,
+}
+
+type zipkinSpan struct {
+	TraceId        string             `json:"traceId"`
+	ParentId       string             `json:"parentId"`
+	Id             string             `json:"id"`
+	Kind           string             `json:"kind"`
+	Name           string             `json:"name"`
+	Timestamp      uint64             `json:"timestamp"` // microseconds
+	Duration       uint64             `json:"duration"`  // microseconds
+	LocalEndpoint  *zipkinEndpoint    `json:"localEndpoint"`
+	RemoteEndpoint *zipkinEndpoint    `json:"remoteEndpoint"`
+	Annotations    []zipkinAnnotation `json:"annotations"`
+	Tags           map[string]string  `json:"tags"`
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+	Ipv4        string `json:"ipv4"`
+	Ipv6        string `json:"ipv6"`
+	Port        int64  `json:"port"`
+}
+
+type zipkinAnnotation struct {
+	Timestamp uint64 `json:"timestamp"`
+	Value     string `json:"value"`
+}
+
+// ZipkinSpans implements the Zipkin v2 API (/api/v2/spans) accepting both JSON and protobuf encoded spans.
+func (c *Collector) ZipkinSpans(w http.ResponseWriter, r *http.Request) {
+	apiKey := ApiKeyFromHTTPRequest(r)
+	project, err := c.getProject(apiKey)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	data, err := readRequestBody(w, r)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	spans, err := decodeZipkinRequest(r.Header.Get("Content-Type"), data)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rejected, err := c.ingestTraces(project, apiKey, zipkinSpansToOTLP(spans))
+	if err != nil {
+		writeIngestError(w, err)
+		return
+	}
+	if rejected.count() > 0 {
+		klog.Warningln(rejected.message("spans"))
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func decodeZipkinRequest(contentType string, data []byte) ([]zipkinSpan, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case ContentTypeJSON, "":
+		var spans []zipkinSpan
+		if err := json.Unmarshal(data, &spans); err != nil {
+			return nil, err
+		}
+		return spans, nil
+	case ContentTypeProtobuf:
+		return decodeZipkinProtobuf(data)
+	}
+	return nil, fmt.Errorf("unsupported content type: %s", contentType)
+}
+
+// decodeZipkinProtobuf decodes zipkin.proto3.ListOfSpans.
+func decodeZipkinProtobuf(data []byte) ([]zipkinSpan, error) {
+	var res []zipkinSpan
+	err := walkProto(data, func(num protowire.Number, v []byte, _ uint64) error {
+		if num != 1 {
+			return nil
+		}
+		var s zipkinSpan
+		err := walkProto(v, func(num protowire.Number, v []byte, x uint64) error {
+			var err error
+			switch num {
+			case 1:
+				s.TraceId = hex.EncodeToString(v)
+			case 2:
+				s.ParentId = hex.EncodeToString(v)
+			case 3:
+				s.Id = hex.EncodeToString(v)
+			case 4:
+				for kind, n := range zipkinSpanKinds {
+					if uint64(n) == x {
+						s.Kind = kind
+					}
+				}
+			case 5:
+				s.Name = string(v)
+			case 6:
+				s.Timestamp = x
+			case 7:
+				s.Duration = x
+			case 8:
+				s.LocalEndpoint, err = decodeZipkinEndpoint(v)
+			case 9:
+				s.RemoteEndpoint, err = decodeZipkinEndpoint(v)
+			case 10:
+				var a zipkinAnnotation
+				err = walkProto(v, func(num protowire.Number, v []byte, x uint64) error {
+					switch num {
+					case 1:
+						a.Timestamp = x
+					case 2:
+						a.Value = string(v)
+					}
+					return nil
+				})
+				s.Annotations = append(s.Annotations, a)
+			case 11:
+				var key, value string
+				err = walkProto(v, func(num protowire.Number, v []byte, _ uint64) error {
+					switch num {
+					case 1:
+						key = string(v)
+					case 2:
+						value = string(v)
+					}
+					return nil
+				})
+				if s.Tags == nil {
+					s.Tags = map[string]string{}
+				}
+				s.Tags[key] = value
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		res = append(res, s)
+		return nil
+	})
+	return res, err
+}
+
+func decodeZipkinEndpoint(data []byte) (*zipkinEndpoint, error) {
+	e := &zipkinEndpoint{}
+	err := walkProto(data, func(num protowire.Number, v []byte, x uint64) error {
+		switch num {
+		case 1:
+			e.ServiceName = string(v)
+		case 2:
+			e.Ipv4 = net.IP(v).String()
+		case 3:
+			e.Ipv6 = net.IP(v).String()
+		case 4:
+			e.Port = int64(x)
+		}
+		return nil
+	})
+	return e, err
+}
+
+// zipkinSpansToOTLP groups the spans by the local service name, annotations are converted to span events.
+func zipkinSpansToOTLP(spans []zipkinSpan) *v1.ExportTraceServiceRequest {
+	req := &v1.ExportTraceServiceRequest{}
+	byService := map[string]*tracev1.ScopeSpans{}
+	for _, s := range spans {
+		var serviceName string
+		if s.LocalEndpoint != nil {
+			serviceName = s.LocalEndpoint.ServiceName
+		}
+		ss := byService[serviceName]
+		if ss == nil {
+			ss = &tracev1.ScopeSpans{}
+			byService[serviceName] = ss
+			req.ResourceSpans = append(req.ResourceSpans, &tracev1.ResourceSpans{
+				Resource:   &resourcev1.Resource{Attributes: []*commonv1.KeyValue{stringAttribute(semconv.AttributeServiceName, serviceName)}},
+				ScopeSpans: []*tracev1.ScopeSpans{ss},
+			})
+		}
+
+		span := &tracev1.Span{
+			TraceId:           decodeZipkinId(s.TraceId, 16),
+			SpanId:            decodeZipkinId(s.Id, 8),
+			ParentSpanId:      decodeZipkinId(s.ParentId, 8),
+			Name:              s.Name,
+			Kind:              zipkinSpanKinds[s.Kind],
+			StartTimeUnixNano: s.Timestamp * 1000,
+			EndTimeUnixNano:   (s.Timestamp + s.Duration) * 1000,
+			Status:            &tracev1.Status{},
+		}
+		tags := make([]string, 0, len(s.Tags))
+		for k := range s.Tags {
+			tags = append(tags, k)
+		}
+		sort.Strings(tags)
+		for _, k := range tags {
+			v := s.Tags[k]
+			switch k {
+			case "otel.status_code":
+				span.Status.Code = tracev1.Status_StatusCode(tracev1.Status_StatusCode_value["STATUS_CODE_"+v])
+				continue
+			case "otel.status_description":
+				span.Status.Message = v
+				continue
+			case "error":
+				span.Status.Code = tracev1.Status_STATUS_CODE_ERROR
+				if v != "true" {
+					span.Status.Message = v
+				}
+			}
+			span.Attributes = append(span.Attributes, stringAttribute(k, v))
+		}
+		span.Attributes = append(span.Attributes, endpointAttributes(s.RemoteEndpoint, "net.peer")...)
+		if e := s.LocalEndpoint; e != nil {
+			span.Attributes = append(span.Attributes, endpointAttributes(&zipkinEndpoint{Ipv4: e.Ipv4, Ipv6: e.Ipv6, Port: e.Port}, "net.host")...)
+		}
+		for _, a := range s.Annotations {
+			span.Events = append(span.Events, &tracev1.Span_Event{TimeUnixNano: a.Timestamp * 1000, Name: a.Value})
+		}
+		ss.Spans = append(ss.Spans, span)
+	}
+	return req
+}
+
+func endpointAttributes(e *zipkinEndpoint, prefix string) []*commonv1.KeyValue {
+	if e == nil {
+		return nil
+	}
+	var res []*commonv1.KeyValue
+	if e.ServiceName != "" {
+		res = append(res, stringAttribute(prefix+".name", e.ServiceName))
+	}
+	if ip := e.Ipv4; ip != "" || e.Ipv6 != "" {
+		if ip == "" {
+			ip = e.Ipv6
+		}
+		res = append(res, stringAttribute(prefix+".ip", ip))
+	}
+	if e.Port > 0 {
+		res = append(res, &commonv1.KeyValue{Key: prefix + ".port", Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: e.Port}}})
+	}
+	return res
+}
+
+// decodeZipkinId decodes a hex ID left-padding it with zeros, 64-bit trace IDs are allowed.
+func decodeZipkinId(s string, size int) []byte {
+	if s == "" {
+		return nil
+	}
+	id, err := hex.DecodeString(s)
+	if err != nil || len(id) > size {
+		return nil
+	}
+	if len(id) < size {
+		id = append(make([]byte, size-len(id)), id...)
+	}
+	return id
+}
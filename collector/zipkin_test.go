@@ -0,0 +1,53 @@
+package collector
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestZipkinSpansToOTLP(t *testing.T) {
+	var spans []zipkinSpan
+	require.NoError(t, json.Unmarshal([]byte(`[{
+		"traceId": "5af7183fb1d4cf5f",
+		"parentId": "6b221d5bc9e6496c",
+		"id": "352bff9a74ca9ad2",
+		"kind": "CLIENT",
+		"name": "get /api",
+		"timestamp": 1556604172355737,
+		"duration": 1431,
+		"localEndpoint": {"serviceName": "frontend", "ipv4": "192.168.99.1", "port": 3306},
+		"remoteEndpoint": {"serviceName": "backend", "ipv4": "172.19.0.2", "port": 9000},
+		"annotations": [{"timestamp": 1556604172355800, "value": "ws"}],
+		"tags": {"http.method": "GET", "error": "connection refused"}
+	}]`), &spans))
+
+	req := zipkinSpansToOTLP(spans)
+	require.Len(t, req.ResourceSpans, 1)
+	rs := req.ResourceSpans[0]
+	assert.Equal(t, map[string]string{"service.name": "frontend"}, attributesToMap(rs.Resource.Attributes))
+	s := rs.ScopeSpans[0].Spans[0]
+	assert.Equal(t, "00000000000000005af7183fb1d4cf5f", hex.EncodeToString(s.TraceId))
+	assert.Equal(t, "352bff9a74ca9ad2", hex.EncodeToString(s.SpanId))
+	assert.Equal(t, "6b221d5bc9e6496c", hex.EncodeToString(s.ParentSpanId))
+	assert.Equal(t, tracev1.Span_SPAN_KIND_CLIENT, s.Kind)
+	assert.Equal(t, uint64(1556604172355737000), s.StartTimeUnixNano)
+	assert.Equal(t, uint64(1431000), s.EndTimeUnixNano-s.StartTimeUnixNano)
+	assert.Equal(t, tracev1.Status_STATUS_CODE_ERROR, s.Status.Code)
+	assert.Equal(t, "connection refused", s.Status.Message)
+	assert.Equal(t, map[string]string{
+		"http.method":   "GET",
+		"error":         "connection refused",
+		"net.peer.name": "backend",
+		"net.peer.ip":   "172.19.0.2",
+		"net.peer.port": "9000",
+		"net.host.ip":   "192.168.99.1",
+		"net.host.port": "3306",
+	}, attributesToMap(s.Attributes))
+	require.Len(t, s.Events, 1)
+	assert.Equal(t, "ws", s.Events[0].Name)
+}
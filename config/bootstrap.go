@@ -64,6 +64,8 @@ func (cfg *Config) Bootstrap(database *db.DB) error {
 		}
 		pp.Settings.Readonly = true
 		pp.Settings.ApiKeys = p.ApiKeys
+		pp.Settings.IngestionQuotas = p.IngestionQuotas
+		pp.Settings.IngestPipeline = p.IngestPipeline
 		if p.NotificationIntegrations != nil {
 			pp.Settings.Integrations.NotificationIntegrations = *p.NotificationIntegrations
 		}
@@ -7,4 +7,13 @@ type CollectorConfig struct {
 	LogsTTL     timeseries.Duration
 	ProfilesTTL timeseries.Duration
 	MetricsTTL  timeseries.Duration
+
+	MetricsTTL5m timeseries.Duration
+	MetricsTTL1h timeseries.Duration
+
+	WALDir     string // the WAL is disabled if empty
+	WALMaxSize int64
+
+	SyslogListenAddress string // the syslog receiver is disabled if empty
+	SyslogApiKey        string
 }
@@ -29,6 +29,9 @@ type Config struct {
 	Profiles Profiles `yaml:"profiles"`
 	Metrics  Metrics  `yaml:"metrics"`
 
+	CollectorWAL    CollectorWAL    `yaml:"collector_wal"`
+	CollectorSyslog CollectorSyslog `yaml:"collector_syslog"`
+
 	Postgres         *Postgres   `yaml:"postgres"`
 	GlobalPrometheus *Prometheus `yaml:"global_prometheus"`
 	GlobalClickhouse *Clickhouse `yaml:"global_clickhouse"`
@@ -100,8 +103,24 @@ type Profiles struct {
 	TTL timeseries.Duration `yaml:"ttl"`
 }
 
+// Metrics configures the retention of metrics stored in ClickHouse.
+// Raw samples are kept for TTL, while the 5-minute and hourly rollups are kept for TTL5m and TTL1h respectively.
 type Metrics struct {
-	TTL timeseries.Duration `yaml:"ttl"`
+	TTL   timeseries.Duration `yaml:"ttl"`
+	TTL5m timeseries.Duration `yaml:"ttl_5m"`
+	TTL1h timeseries.Duration `yaml:"ttl_1h"`
+}
+
+type CollectorWAL struct {
+	Enabled   bool `yaml:"enabled"`
+	MaxSizeMB int  `yaml:"max_size_mb"`
+}
+
+// CollectorSyslog configures the syslog receiver listening on both TCP and UDP.
+// Messages are stored in the project of ApiKey, unless they include an api_key structured data parameter.
+type CollectorSyslog struct {
+	ListenAddress string `yaml:"listen_address"`
+	ApiKey        string `yaml:"api_key"`
 }
 
 type Postgres struct {
@@ -207,7 +226,12 @@ func NewConfig() *Config {
 			TTL: 7 * timeseries.Day,
 		},
 		Metrics: Metrics{
-			TTL: 7 * timeseries.Day,
+			TTL:   7 * timeseries.Day,
+			TTL5m: 30 * timeseries.Day,
+			TTL1h: 365 * timeseries.Day,
+		},
+		CollectorWAL: CollectorWAL{
+			MaxSizeMB: 1024,
 		},
 
 		Auth: Auth{
@@ -283,6 +307,10 @@ func (cfg *Config) Validate() error {
 		}
 	}
 
+	if cfg.CollectorWAL.Enabled && cfg.CollectorWAL.MaxSizeMB <= 0 {
+		return fmt.Errorf("invalid collector_wal.max_size_mb: %d", cfg.CollectorWAL.MaxSizeMB)
+	}
+
 	for i, p := range cfg.Projects {
 		if err = p.Validate(); err != nil {
 			return fmt.Errorf("invalid project #%d: %w", i, err)
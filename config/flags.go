@@ -21,6 +21,12 @@ var (
 	logsTTL                                     = timeseries.DurationFlag(kingpin.Flag("logs-ttl", "Logs TTL (e.g. 8h, 3d, 2w; default 7d)").Envar("LOGS_TTL"))
 	profilesTTL                                 = timeseries.DurationFlag(kingpin.Flag("profiles-ttl", "Profiles TTL (e.g. 8h, 3d, 2w; default 7d)").Envar("PROFILES_TTL"))
 	metricsTTL                                  = timeseries.DurationFlag(kingpin.Flag("metrics-ttl", "Metrics TTL (e.g. 8h, 30d, 1y; default 7d)").Envar("METRICS_TTL"))
+	metricsTTL5m                                = timeseries.DurationFlag(kingpin.Flag("metrics-ttl-5m", "TTL of the 5-minute metric rollups (default 30d)").Envar("METRICS_TTL_5M"))
+	metricsTTL1h                                = timeseries.DurationFlag(kingpin.Flag("metrics-ttl-1h", "TTL of the hourly metric rollups (default 1y)").Envar("METRICS_TTL_1H"))
+	collectorWALEnabled                         = kingpin.Flag("collector-wal-enabled", "Write incoming telemetry to a WAL in the data directory before storing it in ClickHouse").Envar("COLLECTOR_WAL_ENABLED").Bool()
+	collectorWALMaxSizeMB                       = kingpin.Flag("collector-wal-max-size-mb", "Maximum size of the collector WAL per project and signal in megabytes (default 1024)").Envar("COLLECTOR_WAL_MAX_SIZE_MB").Int()
+	collectorSyslogListen                       = kingpin.Flag("collector-syslog-listen", "Syslog (RFC5424/RFC3164) listen address for TCP and UDP - ip:port or :port").Envar("COLLECTOR_SYSLOG_LISTEN").String()
+	collectorSyslogApiKey                       = kingpin.Flag("collector-syslog-api-key", "API key of the project to store syslog messages in").Envar("COLLECTOR_SYSLOG_API_KEY").String()
 	pgConnectionString                          = kingpin.Flag("pg-connection-string", "Postgres connection string (sqlite is used if not set)").Envar("PG_CONNECTION_STRING").String()
 	doNotCheckForDeployments                    = kingpin.Flag("do-not-check-for-deployments", "Don't check for new deployments").Envar("DO_NOT_CHECK_FOR_DEPLOYMENTS").Bool()
 	doNotCheckForUpdates                        = kingpin.Flag("do-not-check-for-updates", "Don't check for new versions").Envar("DO_NOT_CHECK_FOR_UPDATES").Bool()
@@ -99,6 +105,24 @@ func (cfg *Config) ApplyFlags() {
 	if *metricsTTL > 0 {
 		cfg.Metrics.TTL = *metricsTTL
 	}
+	if *metricsTTL5m > 0 {
+		cfg.Metrics.TTL5m = *metricsTTL5m
+	}
+	if *metricsTTL1h > 0 {
+		cfg.Metrics.TTL1h = *metricsTTL1h
+	}
+	if *collectorWALEnabled {
+		cfg.CollectorWAL.Enabled = *collectorWALEnabled
+	}
+	if *collectorWALMaxSizeMB > 0 {
+		cfg.CollectorWAL.MaxSizeMB = *collectorWALMaxSizeMB
+	}
+	if *collectorSyslogListen != "" {
+		cfg.CollectorSyslog.ListenAddress = *collectorSyslogListen
+	}
+	if *collectorSyslogApiKey != "" {
+		cfg.CollectorSyslog.ApiKey = *collectorSyslogApiKey
+	}
 	if *pgConnectionString != "" {
 		cfg.Postgres = &Postgres{ConnectionString: *pgConnectionString}
 	}
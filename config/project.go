@@ -19,6 +19,9 @@ type Project struct {
 	CustomApplications       []CustomApplication          `yaml:"customApplications"`
 
 	InspectionOverrides *InspectionOverrides `yaml:"inspectionOverrides"`
+
+	IngestionQuotas *db.IngestionQuotas `yaml:"ingestionQuotas"`
+	IngestPipeline  *db.IngestPipeline  `yaml:"ingestPipeline"`
 }
 
 func (p *Project) Validate() error {
@@ -60,6 +63,16 @@ func (p *Project) Validate() error {
 			return err
 		}
 	}
+	if p.IngestionQuotas != nil {
+		if err := p.IngestionQuotas.Validate(); err != nil {
+			return fmt.Errorf("invalid ingestion quotas: %w", err)
+		}
+	}
+	if p.IngestPipeline != nil {
+		if err := p.IngestPipeline.Validate(); err != nil {
+			return fmt.Errorf("invalid ingest pipeline: %w", err)
+		}
+	}
 
 	return nil
 }
@@ -0,0 +1,202 @@
+package constructor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coroot/coroot/clickhouse"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"k8s.io/klog"
+)
+
+const (
+	customSLICompliancePeriodStep = timeseries.Hour
+	customSLITracesRawStep        = timeseries.Minute
+)
+
+// LoadCustomSLIs evaluates the user-defined SLOs of the world's applications.
+// The ClickHouse client is optional, without it trace-based SLOs are reported as failed to evaluate.
+func LoadCustomSLIs(ctx context.Context, cache Cache, ch *clickhouse.Client, w *model.World, slos []*model.SLO) {
+	for _, slo := range slos {
+		app := w.GetApplication(slo.ApplicationId)
+		if app == nil {
+			continue
+		}
+		sli := &model.CustomSLI{Config: slo}
+		if err := loadCustomSLI(ctx, cache, ch, w.Ctx, sli); err != nil {
+			klog.Warningf("failed to evaluate SLO %s: %s", slo.Id, err)
+			sli.Error = err.Error()
+		}
+		app.CustomSLIs = append(app.CustomSLIs, sli)
+	}
+}
+
+type customSLIFetchFunc func(from, to timeseries.Time, step timeseries.Duration) (*timeseries.TimeSeries, *timeseries.TimeSeries, error)
+
+func customSLIFetcher(ctx context.Context, cache Cache, ch *clickhouse.Client, cfg *model.SLO) (customSLIFetchFunc, error) {
+	switch {
+	case cfg.Source.Metrics != nil:
+		return func(from, to timeseries.Time, step timeseries.Duration) (*timeseries.TimeSeries, *timeseries.TimeSeries, error) {
+			return customSLIFromMetrics(ctx, cache, cfg.Source.Metrics, from, to, step)
+		}, nil
+	case cfg.Source.Traces != nil:
+		if ch == nil {
+			return nil, fmt.Errorf("clickhouse is not configured")
+		}
+		return func(from, to timeseries.Time, step timeseries.Duration) (*timeseries.TimeSeries, *timeseries.TimeSeries, error) {
+			return customSLIFromTraces(ctx, ch, cfg.Source.Traces, from, to, step)
+		}, nil
+	}
+	return nil, fmt.Errorf("no source defined")
+}
+
+// CustomSLIEvents returns the numbers of good and total events of the SLO within the given time range.
+func CustomSLIEvents(ctx context.Context, cache Cache, ch *clickhouse.Client, slo *model.SLO, from, to timeseries.Time, step timeseries.Duration) (float32, float32, error) {
+	fetch, err := customSLIFetcher(ctx, cache, ch, slo)
+	if err != nil {
+		return 0, 0, err
+	}
+	if slo.Source.Traces != nil {
+		step = max(step, customSLITracesRawStep)
+	}
+	good, total, err := fetch(from, to.Add(-step), step)
+	if err != nil {
+		return 0, 0, err
+	}
+	return sumEvents(good, step), sumEvents(total, step), nil
+}
+
+func loadCustomSLI(ctx context.Context, cache Cache, ch *clickhouse.Client, wCtx timeseries.Context, sli *model.CustomSLI) error {
+	cfg := sli.Config
+	fetch, err := customSLIFetcher(ctx, cache, ch, cfg)
+	if err != nil {
+		return err
+	}
+	rawStep := wCtx.RawStep
+	if cfg.Source.Traces != nil {
+		rawStep = max(rawStep, customSLITracesRawStep)
+	}
+
+	var goodBefore, totalBefore float32
+	periodFrom := cfg.CompliancePeriod.From(wCtx.To)
+	if periodFrom.Before(wCtx.From) {
+		good, total, err := fetch(periodFrom, wCtx.From.Add(-customSLICompliancePeriodStep), customSLICompliancePeriodStep)
+		if err != nil {
+			return err
+		}
+		goodBefore = sumEvents(good, customSLICompliancePeriodStep)
+		totalBefore = sumEvents(total, customSLICompliancePeriodStep)
+	}
+
+	good, total, err := fetch(wCtx.From, wCtx.To, wCtx.Step)
+	if err != nil {
+		return err
+	}
+	if periodFrom.After(wCtx.From) {
+		// the points before the beginning of the compliance period don't affect its error budget
+		outOfPeriod := func(t timeseries.Time, v float32) float32 {
+			if t.Before(periodFrom) {
+				return timeseries.NaN
+			}
+			return v
+		}
+		good, total = good.Map(outOfPeriod), total.Map(outOfPeriod)
+	}
+	sli.Good, sli.Total = good, total
+	sli.ErrorBudgetRemaining, sli.Compliance = model.CalcErrorBudgetRemaining(goodBefore, totalBefore, good, total, wCtx.Step, cfg.ObjectivePercentage)
+
+	goodRaw, totalRaw, err := fetch(wCtx.To.Add(-cfg.MaxWindow()), wCtx.To, rawStep)
+	if err != nil {
+		return err
+	}
+	badRaw := timeseries.Aggregate2(totalRaw, goodRaw, badEvents)
+	if badRaw.IsEmpty() {
+		badRaw = totalRaw.Map(func(t timeseries.Time, v float32) float32 { return badEvents(v, timeseries.NaN) })
+	}
+	sli.BurnRates = model.CalcBurnRates(wCtx.To, model.SumFrom(badRaw), model.SumFrom(totalRaw), cfg.ObjectivePercentage, cfg.AlertRules())
+	return nil
+}
+
+func customSLIFromMetrics(ctx context.Context, cache Cache, m *model.SLOSourceMetrics, from, to timeseries.Time, step timeseries.Duration) (*timeseries.TimeSeries, *timeseries.TimeSeries, error) {
+	query := func(q string) (*timeseries.TimeSeries, error) {
+		values, err := cache.QueryRange(ctx, q, from, to, step, timeseries.FillAny)
+		if err != nil {
+			return nil, err
+		}
+		sum := timeseries.NewAggregate(timeseries.NanSum)
+		for _, mv := range values {
+			sum.Add(mv.Values)
+		}
+		return sum.Get(), nil
+	}
+	good, err := query(m.GoodQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+	total, err := query(m.TotalQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+	return good, total, nil
+}
+
+// customSLIFromTraces counts the spans of the service that haven't failed and are faster than the threshold as good.
+// The histogram doesn't split failed spans by duration, so slow failed spans are counted as bad twice (capped by the total).
+func customSLIFromTraces(ctx context.Context, ch *clickhouse.Client, t *model.SLOSourceTraces, from, to timeseries.Time, step timeseries.Duration) (*timeseries.TimeSeries, *timeseries.TimeSeries, error) {
+	q := clickhouse.SpanQuery{Ctx: timeseries.NewContext(from, to, step)}
+	q.AddFilter("ServiceName", "=", t.ServiceName)
+	histogram, err := ch.GetSpansByServiceNameHistogram(ctx, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(histogram) < 2 {
+		return nil, nil, nil
+	}
+	// the first series is the rate of failed spans, the rest are cumulative rates of spans by duration on top of it
+	failed, last := histogram[0].TimeSeries, histogram[len(histogram)-1].TimeSeries
+	fast := last
+	if t.LatencyThreshold > 0 {
+		fast = nil
+		for _, b := range histogram[1:] {
+			if b.Le <= t.LatencyThreshold {
+				fast = b.TimeSeries
+			}
+		}
+		if fast == nil {
+			fast = failed
+		}
+	}
+	total := timeseries.Sub(last, failed)
+	good := timeseries.Aggregate2(timeseries.Sub(fast, failed), failed, func(f, e float32) float32 {
+		if timeseries.IsNaN(f) {
+			return timeseries.NaN
+		}
+		if timeseries.IsNaN(e) {
+			e = 0
+		}
+		return max(f-e, 0)
+	})
+	return good, total, nil
+}
+
+func badEvents(total, good float32) float32 {
+	if timeseries.IsNaN(total) {
+		return timeseries.NaN
+	}
+	if timeseries.IsNaN(good) {
+		return total
+	}
+	return max(total-good, 0)
+}
+
+func sumEvents(ts *timeseries.TimeSeries, step timeseries.Duration) float32 {
+	var sum float32
+	iter := ts.Iter()
+	for iter.Next() {
+		if _, v := iter.Value(); !timeseries.IsNaN(v) {
+			sum += v * float32(step)
+		}
+	}
+	return sum
+}
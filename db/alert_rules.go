@@ -0,0 +1,357 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/coroot/coroot/utils"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+type AlertRules struct{}
+
+func (s *AlertRules) Migrate(m *Migrator) error {
+	return m.Exec(`
+	CREATE TABLE IF NOT EXISTS alert_rule (
+		project_id TEXT NOT NULL REFERENCES project(id),
+		id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		config TEXT NOT NULL DEFAULT '{}',
+		PRIMARY KEY (project_id, id)
+	);
+	CREATE TABLE IF NOT EXISTS alert (
+		project_id TEXT NOT NULL REFERENCES project(id),
+		rule_id TEXT NOT NULL,
+		key TEXT NOT NULL,
+		opened_at INT NOT NULL,
+		resolved_at INT NOT NULL DEFAULT 0,
+		severity INT NOT NULL,
+		details TEXT,
+		PRIMARY KEY (project_id, key)
+	);
+	CREATE INDEX IF NOT EXISTS alert_rule_id ON alert (project_id, rule_id, opened_at);
+`)
+}
+
+type AlertRule struct {
+	Id            string                                      `json:"id"`
+	Name          string                                      `json:"name"`
+	Description   string                                      `json:"description"`
+	Enabled       bool                                        `json:"enabled"`
+	ApplicationId *model.ApplicationId                        `json:"application_id,omitempty"`
+	Source        AlertRuleSource                             `json:"source"`
+	Condition     AlertRuleCondition                          `json:"condition"`
+	For           timeseries.Duration                         `json:"for"`
+	Severity      model.Status                                `json:"severity"`
+	Notifications ApplicationCategoryNotificationDestinations `json:"notifications"`
+}
+
+type AlertRuleSource struct {
+	Metrics *AlertRuleSourceMetrics `json:"metrics,omitempty"`
+	Logs    *AlertRuleSourceLogs    `json:"logs,omitempty"`
+	Traces  *AlertRuleSourceTraces  `json:"traces,omitempty"`
+}
+
+// AlertRuleSourceMetrics is a PromQL expression, each resulting series is checked separately.
+type AlertRuleSourceMetrics struct {
+	Query string `json:"query"`
+}
+
+// GroupingLabels returns the labels listed in the `by` clauses of the query.
+// They are preserved in the cached series and used to distinguish the alerting series.
+func (m *AlertRuleSourceMetrics) GroupingLabels() []string {
+	expr, err := parser.ParseExpr(strings.ReplaceAll(m.Query, "$RANGE", "1m"))
+	if err != nil {
+		return nil
+	}
+	var res []string
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		if agg, ok := node.(*parser.AggregateExpr); ok && !agg.Without {
+			res = append(res, agg.Grouping...)
+		}
+		return nil
+	})
+	return utils.Uniq(res)
+}
+
+// AlertRuleSourceLogs evaluates to the number of matching log entries per second.
+type AlertRuleSourceLogs struct {
+	Services []string             `json:"services"`
+	Filters  []AlertRuleLogFilter `json:"filters"`
+}
+
+type AlertRuleLogFilter struct {
+	Name  string `json:"name"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+// AlertRuleSourceTraces evaluates to the percentage of failed server spans of the service.
+type AlertRuleSourceTraces struct {
+	ServiceName string `json:"service_name"`
+}
+
+type AlertRuleOperator string
+
+const (
+	AlertRuleOperatorGreater      AlertRuleOperator = ">"
+	AlertRuleOperatorGreaterEqual AlertRuleOperator = ">="
+	AlertRuleOperatorLess         AlertRuleOperator = "<"
+	AlertRuleOperatorLessEqual    AlertRuleOperator = "<="
+	AlertRuleOperatorEqual        AlertRuleOperator = "=="
+	AlertRuleOperatorNotEqual     AlertRuleOperator = "!="
+)
+
+type AlertRuleCondition struct {
+	Operator  AlertRuleOperator `json:"operator"`
+	Threshold float32           `json:"threshold"`
+}
+
+func (c AlertRuleCondition) Check(v float32) bool {
+	if timeseries.IsNaN(v) {
+		return false
+	}
+	switch c.Operator {
+	case AlertRuleOperatorGreater:
+		return v > c.Threshold
+	case AlertRuleOperatorGreaterEqual:
+		return v >= c.Threshold
+	case AlertRuleOperatorLess:
+		return v < c.Threshold
+	case AlertRuleOperatorLessEqual:
+		return v <= c.Threshold
+	case AlertRuleOperatorEqual:
+		return v == c.Threshold
+	case AlertRuleOperatorNotEqual:
+		return v != c.Threshold
+	}
+	return false
+}
+
+func (c AlertRuleCondition) String() string {
+	return fmt.Sprintf("%s %s", c.Operator, utils.FormatFloat(c.Threshold))
+}
+
+func (r *AlertRule) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	sources := 0
+	if m := r.Source.Metrics; m != nil {
+		sources++
+		if _, err := parser.ParseExpr(strings.ReplaceAll(m.Query, "$RANGE", "1m")); err != nil {
+			return fmt.Errorf("invalid query: %w", err)
+		}
+	}
+	if l := r.Source.Logs; l != nil {
+		sources++
+		for _, f := range l.Filters {
+			if f.Name == "" {
+				return fmt.Errorf("invalid log filter")
+			}
+			switch f.Op {
+			case "=", "!=", "~", "!~":
+			default:
+				return fmt.Errorf("invalid log filter operator: %s", f.Op)
+			}
+		}
+	}
+	if t := r.Source.Traces; t != nil {
+		sources++
+		if t.ServiceName == "" {
+			return fmt.Errorf("service name is required")
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("exactly one source must be defined")
+	}
+	switch r.Condition.Operator {
+	case AlertRuleOperatorGreater, AlertRuleOperatorGreaterEqual, AlertRuleOperatorLess, AlertRuleOperatorLessEqual,
+		AlertRuleOperatorEqual, AlertRuleOperatorNotEqual:
+	default:
+		return fmt.Errorf("invalid operator: %s", r.Condition.Operator)
+	}
+	if r.For < 0 {
+		return fmt.Errorf("invalid duration: %s", r.For)
+	}
+	switch r.Severity {
+	case model.WARNING, model.CRITICAL:
+	default:
+		return fmt.Errorf("invalid severity: %s", r.Severity)
+	}
+	return nil
+}
+
+type Alert struct {
+	RuleId     string          `json:"rule_id"`
+	Key        string          `json:"key"`
+	OpenedAt   timeseries.Time `json:"opened_at"`
+	ResolvedAt timeseries.Time `json:"resolved_at"`
+	Severity   model.Status    `json:"severity"`
+	Details    AlertDetails    `json:"details"`
+}
+
+type AlertDetails struct {
+	Value   float32           `json:"value"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Message string            `json:"message"`
+	// NoData is the number of consecutive evaluations of the rule that returned no data
+	NoData int `json:"no_data,omitempty"`
+}
+
+func (a *Alert) Resolved() bool {
+	return !a.ResolvedAt.IsZero()
+}
+
+func (db *DB) GetAlertRules(projectId ProjectId) ([]*AlertRule, error) {
+	rows, err := db.db.Query("SELECT id, config FROM alert_rule WHERE project_id = $1 ORDER BY name", projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*AlertRule
+	for rows.Next() {
+		var id, config string
+		if err = rows.Scan(&id, &config); err != nil {
+			return nil, err
+		}
+		r := &AlertRule{}
+		if err = json.Unmarshal([]byte(config), r); err != nil {
+			return nil, err
+		}
+		r.Id = id
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+func (db *DB) GetAlertRule(projectId ProjectId, id string) (*AlertRule, error) {
+	var config string
+	err := db.db.QueryRow("SELECT config FROM alert_rule WHERE project_id = $1 AND id = $2", projectId, id).Scan(&config)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	r := &AlertRule{}
+	if err = json.Unmarshal([]byte(config), r); err != nil {
+		return nil, err
+	}
+	r.Id = id
+	return r, nil
+}
+
+func (db *DB) CreateAlertRule(projectId ProjectId, r *AlertRule) (string, error) {
+	r.Id = utils.NanoId(8)
+	config, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	_, err = db.db.Exec("INSERT INTO alert_rule (project_id, id, name, config) VALUES ($1, $2, $3, $4)", projectId, r.Id, r.Name, string(config))
+	return r.Id, err
+}
+
+func (db *DB) UpdateAlertRule(projectId ProjectId, id string, r *AlertRule) error {
+	r.Id = id
+	config, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	res, err := db.db.Exec("UPDATE alert_rule SET name = $1, config = $2 WHERE project_id = $3 AND id = $4", r.Name, string(config), projectId, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (db *DB) DeleteAlertRule(projectId ProjectId, id string) error {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if _, err = tx.Exec("DELETE FROM alert WHERE project_id = $1 AND rule_id = $2", projectId, id); err != nil {
+		return err
+	}
+	if _, err = tx.Exec("DELETE FROM alert_rule WHERE project_id = $1 AND id = $2", projectId, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (db *DB) GetActiveAlerts(projectId ProjectId) (map[string]*Alert, error) {
+	alerts, err := db.getAlerts("WHERE project_id = $1 AND resolved_at = 0", projectId)
+	if err != nil {
+		return nil, err
+	}
+	res := map[string]*Alert{}
+	for _, a := range alerts {
+		res[a.RuleId] = a
+	}
+	return res, nil
+}
+
+func (db *DB) GetAlertByKey(projectId ProjectId, key string) (*Alert, error) {
+	alerts, err := db.getAlerts("WHERE project_id = $1 AND key = $2", projectId, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(alerts) == 0 {
+		return nil, ErrNotFound
+	}
+	return alerts[0], nil
+}
+
+func (db *DB) GetAlerts(projectId ProjectId, ruleId string, limit int) ([]*Alert, error) {
+	return db.getAlerts("WHERE project_id = $1 AND rule_id = $2 ORDER BY opened_at DESC LIMIT $3", projectId, ruleId, limit)
+}
+
+func (db *DB) getAlerts(where string, args ...any) ([]*Alert, error) {
+	rows, err := db.db.Query("SELECT rule_id, key, opened_at, resolved_at, severity, details FROM alert "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*Alert
+	for rows.Next() {
+		var a Alert
+		var details sql.NullString
+		if err = rows.Scan(&a.RuleId, &a.Key, &a.OpenedAt, &a.ResolvedAt, &a.Severity, &details); err != nil {
+			return nil, err
+		}
+		if details.String != "" {
+			if err = json.Unmarshal([]byte(details.String), &a.Details); err != nil {
+				return nil, err
+			}
+		}
+		res = append(res, &a)
+	}
+	return res, nil
+}
+
+func (db *DB) CreateAlert(projectId ProjectId, a *Alert) error {
+	d, _ := json.Marshal(a.Details)
+	_, err := db.db.Exec(
+		"INSERT INTO alert (project_id, rule_id, key, opened_at, severity, details) VALUES ($1, $2, $3, $4, $5, $6)",
+		projectId, a.RuleId, a.Key, a.OpenedAt, a.Severity, string(d))
+	return err
+}
+
+func (db *DB) UpdateAlert(projectId ProjectId, a *Alert) error {
+	d, _ := json.Marshal(a.Details)
+	_, err := db.db.Exec(
+		"UPDATE alert SET severity = $1, details = $2, resolved_at = $3 WHERE project_id = $4 AND key = $5",
+		a.Severity, string(d), a.ResolvedAt, projectId, a.Key)
+	return err
+}
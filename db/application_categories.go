@@ -41,11 +41,16 @@ type ApplicationCategoryDeploymentNotificationSettings struct {
 }
 
 type ApplicationCategoryNotificationDestinations struct {
-	Slack     *ApplicationCategoryNotificationSettingsSlack     `json:"slack,omitempty" yaml:"slack,omitempty"`
-	Teams     *ApplicationCategoryNotificationSettingsTeams     `json:"teams,omitempty" yaml:"teams,omitempty"`
-	Pagerduty *ApplicationCategoryNotificationSettingsPagerduty `json:"pagerduty,omitempty" yaml:"pagerduty,omitempty"`
-	Opsgenie  *ApplicationCategoryNotificationSettingsOpsgenie  `json:"opsgenie,omitempty" yaml:"opsgenie,omitempty"`
-	Webhook   *ApplicationCategoryNotificationSettingsWebhook   `json:"webhook,omitempty" yaml:"webhook,omitempty"`
+	Slack      *ApplicationCategoryNotificationSettingsSlack      `json:"slack,omitempty" yaml:"slack,omitempty"`
+	Teams      *ApplicationCategoryNotificationSettingsTeams      `json:"teams,omitempty" yaml:"teams,omitempty"`
+	Pagerduty  *ApplicationCategoryNotificationSettingsPagerduty  `json:"pagerduty,omitempty" yaml:"pagerduty,omitempty"`
+	Opsgenie   *ApplicationCategoryNotificationSettingsOpsgenie   `json:"opsgenie,omitempty" yaml:"opsgenie,omitempty"`
+	Webhook    *ApplicationCategoryNotificationSettingsWebhook    `json:"webhook,omitempty" yaml:"webhook,omitempty"`
+	Email      *ApplicationCategoryNotificationSettingsEmail      `json:"email,omitempty" yaml:"email,omitempty"`
+	Mattermost *ApplicationCategoryNotificationSettingsMattermost `json:"mattermost,omitempty" yaml:"mattermost,omitempty"`
+	Discord    *ApplicationCategoryNotificationSettingsDiscord    `json:"discord,omitempty" yaml:"discord,omitempty"`
+	GoogleChat *ApplicationCategoryNotificationSettingsGoogleChat `json:"googlechat,omitempty" yaml:"googleChat,omitempty"`
+	Telegram   *ApplicationCategoryNotificationSettingsTelegram   `json:"telegram,omitempty" yaml:"telegram,omitempty"`
 }
 
 func (s ApplicationCategoryNotificationDestinations) hasEnabled() bool {
@@ -53,7 +58,47 @@ func (s ApplicationCategoryNotificationDestinations) hasEnabled() bool {
 		(s.Teams != nil && s.Teams.Enabled) ||
 		(s.Pagerduty != nil && s.Pagerduty.Enabled) ||
 		(s.Opsgenie != nil && s.Opsgenie.Enabled) ||
-		(s.Webhook != nil && s.Webhook.Enabled)
+		(s.Webhook != nil && s.Webhook.Enabled) ||
+		(s.Email != nil && s.Email.Enabled) ||
+		(s.Mattermost != nil && s.Mattermost.Enabled) ||
+		(s.Discord != nil && s.Discord.Enabled) ||
+		(s.GoogleChat != nil && s.GoogleChat.Enabled) ||
+		(s.Telegram != nil && s.Telegram.Enabled)
+}
+
+func (s ApplicationCategoryNotificationDestinations) IncidentDestinations() []IncidentNotificationDestination {
+	var res []IncidentNotificationDestination
+	if s.Slack != nil && s.Slack.Enabled {
+		res = append(res, IncidentNotificationDestination{IntegrationType: IntegrationTypeSlack, SlackChannel: s.Slack.Channel})
+	}
+	if s.Teams != nil && s.Teams.Enabled {
+		res = append(res, IncidentNotificationDestination{IntegrationType: IntegrationTypeTeams})
+	}
+	if s.Pagerduty != nil && s.Pagerduty.Enabled {
+		res = append(res, IncidentNotificationDestination{IntegrationType: IntegrationTypePagerduty})
+	}
+	if s.Opsgenie != nil && s.Opsgenie.Enabled {
+		res = append(res, IncidentNotificationDestination{IntegrationType: IntegrationTypeOpsgenie})
+	}
+	if s.Webhook != nil && s.Webhook.Enabled {
+		res = append(res, IncidentNotificationDestination{IntegrationType: IntegrationTypeWebhook})
+	}
+	if s.Email != nil && s.Email.Enabled {
+		res = append(res, IncidentNotificationDestination{IntegrationType: IntegrationTypeEmail, EmailTo: strings.Join(s.Email.To, ",")})
+	}
+	if s.Mattermost != nil && s.Mattermost.Enabled {
+		res = append(res, IncidentNotificationDestination{IntegrationType: IntegrationTypeMattermost})
+	}
+	if s.Discord != nil && s.Discord.Enabled {
+		res = append(res, IncidentNotificationDestination{IntegrationType: IntegrationTypeDiscord})
+	}
+	if s.GoogleChat != nil && s.GoogleChat.Enabled {
+		res = append(res, IncidentNotificationDestination{IntegrationType: IntegrationTypeGoogleChat})
+	}
+	if s.Telegram != nil && s.Telegram.Enabled {
+		res = append(res, IncidentNotificationDestination{IntegrationType: IntegrationTypeTelegram})
+	}
+	return res
 }
 
 type ApplicationCategoryNotificationSettingsSlack struct {
@@ -77,6 +122,27 @@ type ApplicationCategoryNotificationSettingsWebhook struct {
 	Enabled bool `json:"enabled" yaml:"enabled"`
 }
 
+type ApplicationCategoryNotificationSettingsMattermost struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+}
+
+type ApplicationCategoryNotificationSettingsDiscord struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+}
+
+type ApplicationCategoryNotificationSettingsGoogleChat struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+}
+
+type ApplicationCategoryNotificationSettingsTelegram struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+}
+
+type ApplicationCategoryNotificationSettingsEmail struct {
+	Enabled bool     `json:"enabled" yaml:"enabled"`
+	To      []string `json:"to" yaml:"to"`
+}
+
 func (p *Project) CalcApplicationCategory(appId model.ApplicationId) model.ApplicationCategory {
 	id := fmt.Sprintf("%s/%s", appId.Namespace, appId.Name)
 
@@ -212,6 +278,121 @@ func (p *Project) GetApplicationCategories() map[model.ApplicationCategory]*Appl
 				category.NotificationSettings.Deployments.Webhook = nil
 			}
 		}
+		{
+			integrationEmail := p.Settings.Integrations.Email
+			if integrationEmail != nil {
+				if integrationEmail.Incidents {
+					if category.NotificationSettings.Incidents.Email == nil {
+						category.NotificationSettings.Incidents.Enabled = true
+						category.NotificationSettings.Incidents.Email = &ApplicationCategoryNotificationSettingsEmail{Enabled: true, To: integrationEmail.To}
+					}
+				}
+				if integrationEmail.Deployments {
+					if category.NotificationSettings.Deployments.Email == nil {
+						category.NotificationSettings.Deployments.Enabled = notifyOfDeployments
+						category.NotificationSettings.Deployments.Email = &ApplicationCategoryNotificationSettingsEmail{Enabled: notifyOfDeployments, To: integrationEmail.To}
+					}
+				}
+			}
+			if integrationEmail == nil || !integrationEmail.Incidents {
+				category.NotificationSettings.Incidents.Email = nil
+			}
+			if integrationEmail == nil || !integrationEmail.Deployments {
+				category.NotificationSettings.Deployments.Email = nil
+			}
+		}
+		{
+			integrationMattermost := p.Settings.Integrations.Mattermost
+			if integrationMattermost != nil {
+				if integrationMattermost.Incidents {
+					if category.NotificationSettings.Incidents.Mattermost == nil {
+						category.NotificationSettings.Incidents.Enabled = true
+						category.NotificationSettings.Incidents.Mattermost = &ApplicationCategoryNotificationSettingsMattermost{Enabled: true}
+					}
+				}
+				if integrationMattermost.Deployments {
+					if category.NotificationSettings.Deployments.Mattermost == nil {
+						category.NotificationSettings.Deployments.Enabled = notifyOfDeployments
+						category.NotificationSettings.Deployments.Mattermost = &ApplicationCategoryNotificationSettingsMattermost{Enabled: notifyOfDeployments}
+					}
+				}
+			}
+			if integrationMattermost == nil || !integrationMattermost.Incidents {
+				category.NotificationSettings.Incidents.Mattermost = nil
+			}
+			if integrationMattermost == nil || !integrationMattermost.Deployments {
+				category.NotificationSettings.Deployments.Mattermost = nil
+			}
+		}
+		{
+			integrationDiscord := p.Settings.Integrations.Discord
+			if integrationDiscord != nil {
+				if integrationDiscord.Incidents {
+					if category.NotificationSettings.Incidents.Discord == nil {
+						category.NotificationSettings.Incidents.Enabled = true
+						category.NotificationSettings.Incidents.Discord = &ApplicationCategoryNotificationSettingsDiscord{Enabled: true}
+					}
+				}
+				if integrationDiscord.Deployments {
+					if category.NotificationSettings.Deployments.Discord == nil {
+						category.NotificationSettings.Deployments.Enabled = notifyOfDeployments
+						category.NotificationSettings.Deployments.Discord = &ApplicationCategoryNotificationSettingsDiscord{Enabled: notifyOfDeployments}
+					}
+				}
+			}
+			if integrationDiscord == nil || !integrationDiscord.Incidents {
+				category.NotificationSettings.Incidents.Discord = nil
+			}
+			if integrationDiscord == nil || !integrationDiscord.Deployments {
+				category.NotificationSettings.Deployments.Discord = nil
+			}
+		}
+		{
+			integrationGoogleChat := p.Settings.Integrations.GoogleChat
+			if integrationGoogleChat != nil {
+				if integrationGoogleChat.Incidents {
+					if category.NotificationSettings.Incidents.GoogleChat == nil {
+						category.NotificationSettings.Incidents.Enabled = true
+						category.NotificationSettings.Incidents.GoogleChat = &ApplicationCategoryNotificationSettingsGoogleChat{Enabled: true}
+					}
+				}
+				if integrationGoogleChat.Deployments {
+					if category.NotificationSettings.Deployments.GoogleChat == nil {
+						category.NotificationSettings.Deployments.Enabled = notifyOfDeployments
+						category.NotificationSettings.Deployments.GoogleChat = &ApplicationCategoryNotificationSettingsGoogleChat{Enabled: notifyOfDeployments}
+					}
+				}
+			}
+			if integrationGoogleChat == nil || !integrationGoogleChat.Incidents {
+				category.NotificationSettings.Incidents.GoogleChat = nil
+			}
+			if integrationGoogleChat == nil || !integrationGoogleChat.Deployments {
+				category.NotificationSettings.Deployments.GoogleChat = nil
+			}
+		}
+		{
+			integrationTelegram := p.Settings.Integrations.Telegram
+			if integrationTelegram != nil {
+				if integrationTelegram.Incidents {
+					if category.NotificationSettings.Incidents.Telegram == nil {
+						category.NotificationSettings.Incidents.Enabled = true
+						category.NotificationSettings.Incidents.Telegram = &ApplicationCategoryNotificationSettingsTelegram{Enabled: true}
+					}
+				}
+				if integrationTelegram.Deployments {
+					if category.NotificationSettings.Deployments.Telegram == nil {
+						category.NotificationSettings.Deployments.Enabled = notifyOfDeployments
+						category.NotificationSettings.Deployments.Telegram = &ApplicationCategoryNotificationSettingsTelegram{Enabled: notifyOfDeployments}
+					}
+				}
+			}
+			if integrationTelegram == nil || !integrationTelegram.Incidents {
+				category.NotificationSettings.Incidents.Telegram = nil
+			}
+			if integrationTelegram == nil || !integrationTelegram.Deployments {
+				category.NotificationSettings.Deployments.Telegram = nil
+			}
+		}
 		{
 			integrationPagerduty := p.Settings.Integrations.Pagerduty
 			if integrationPagerduty != nil {
@@ -278,6 +459,46 @@ func (p *Project) NewApplicationCategory() *ApplicationCategory {
 			category.NotificationSettings.Deployments.Webhook = &ApplicationCategoryNotificationSettingsWebhook{}
 		}
 	}
+	if email := p.Settings.Integrations.Email; email != nil {
+		if email.Incidents {
+			category.NotificationSettings.Incidents.Email = &ApplicationCategoryNotificationSettingsEmail{To: email.To}
+		}
+		if email.Deployments {
+			category.NotificationSettings.Deployments.Email = &ApplicationCategoryNotificationSettingsEmail{To: email.To}
+		}
+	}
+	if mattermost := p.Settings.Integrations.Mattermost; mattermost != nil {
+		if mattermost.Incidents {
+			category.NotificationSettings.Incidents.Mattermost = &ApplicationCategoryNotificationSettingsMattermost{}
+		}
+		if mattermost.Deployments {
+			category.NotificationSettings.Deployments.Mattermost = &ApplicationCategoryNotificationSettingsMattermost{}
+		}
+	}
+	if discord := p.Settings.Integrations.Discord; discord != nil {
+		if discord.Incidents {
+			category.NotificationSettings.Incidents.Discord = &ApplicationCategoryNotificationSettingsDiscord{}
+		}
+		if discord.Deployments {
+			category.NotificationSettings.Deployments.Discord = &ApplicationCategoryNotificationSettingsDiscord{}
+		}
+	}
+	if googleChat := p.Settings.Integrations.GoogleChat; googleChat != nil {
+		if googleChat.Incidents {
+			category.NotificationSettings.Incidents.GoogleChat = &ApplicationCategoryNotificationSettingsGoogleChat{}
+		}
+		if googleChat.Deployments {
+			category.NotificationSettings.Deployments.GoogleChat = &ApplicationCategoryNotificationSettingsGoogleChat{}
+		}
+	}
+	if telegram := p.Settings.Integrations.Telegram; telegram != nil {
+		if telegram.Incidents {
+			category.NotificationSettings.Incidents.Telegram = &ApplicationCategoryNotificationSettingsTelegram{}
+		}
+		if telegram.Deployments {
+			category.NotificationSettings.Deployments.Telegram = &ApplicationCategoryNotificationSettingsTelegram{}
+		}
+	}
 	if pagerduty := p.Settings.Integrations.Pagerduty; pagerduty != nil {
 		if pagerduty.Incidents {
 			category.NotificationSettings.Incidents.Pagerduty = &ApplicationCategoryNotificationSettingsPagerduty{}
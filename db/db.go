@@ -85,9 +85,15 @@ func (db *DB) Migrate(extraTables ...Table) error {
 		&CheckConfigs{},
 		&Incident{},
 		&IncidentNotification{},
+		&NotificationDelivery{},
 		&ApplicationDeployment{},
 		&ApplicationSettings{},
 		&Dashboards{},
+		&AlertRules{},
+		&SLOs{},
+		&SLOHistory{},
+		&Silences{},
+		&LogExports{},
 		&Setting{},
 		&User{},
 	}
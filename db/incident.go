@@ -37,7 +37,36 @@ func (i *Incident) Migrate(m *Migrator) error {
 	if err = m.AddColumnIfNotExists("incident", "rca", "text"); err != nil {
 		return err
 	}
-	return nil
+	if err = m.AddColumnIfNotExists("incident", "lifecycle", "text"); err != nil {
+		return err
+	}
+	return m.Exec(`
+	CREATE TABLE IF NOT EXISTS incident_event (
+		project_id TEXT NOT NULL REFERENCES project(id),
+		incident_key TEXT NOT NULL,
+		timestamp INT NOT NULL,
+		type TEXT NOT NULL,
+		author TEXT NOT NULL DEFAULT '',
+		text TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX IF NOT EXISTS incident_event_incident_key ON incident_event (project_id, incident_key, timestamp);
+`)
+}
+
+type IncidentEventType string
+
+const (
+	IncidentEventAcknowledged IncidentEventType = "acknowledged"
+	IncidentEventAssigned     IncidentEventType = "assigned"
+	IncidentEventCommented    IncidentEventType = "commented"
+	IncidentEventClosed       IncidentEventType = "closed"
+)
+
+type IncidentEvent struct {
+	Timestamp timeseries.Time   `json:"timestamp"`
+	Type      IncidentEventType `json:"type"`
+	Author    string            `json:"author"`
+	Text      string            `json:"text"`
 }
 
 type IncidentNotification struct {
@@ -50,10 +79,12 @@ type IncidentNotification struct {
 	SentAt        timeseries.Time
 	ExternalKey   string
 	Details       *IncidentNotificationDetails
+	Attempts      int
+	LastError     string
 }
 
 func (n *IncidentNotification) Migrate(m *Migrator) error {
-	return m.Exec(`
+	err := m.Exec(`
 	CREATE TABLE IF NOT EXISTS incident_notification (
 		project_id TEXT NOT NULL REFERENCES project(id),
 		application_id TEXT NOT NULL,
@@ -66,24 +97,41 @@ func (n *IncidentNotification) Migrate(m *Migrator) error {
 		details TEXT
 	);
 `)
+	if err != nil {
+		return err
+	}
+	if err = m.AddColumnIfNotExists("incident_notification", "attempts", "INT NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	return m.AddColumnIfNotExists("incident_notification", "last_error", "TEXT NOT NULL DEFAULT ''")
 }
 
 type IncidentNotificationDestination struct {
 	IntegrationType IntegrationType
 	SlackChannel    string
+	EmailTo         string // comma-separated list of recipients
 }
 
 func (d IncidentNotificationDestination) Value() (driver.Value, error) {
 	switch d.IntegrationType {
 	case IntegrationTypeSlack:
 		return fmt.Sprintf("%s:%s", d.IntegrationType, d.SlackChannel), nil
+	case IntegrationTypeEmail:
+		if d.EmailTo != "" {
+			return fmt.Sprintf("%s:%s", d.IntegrationType, d.EmailTo), nil
+		}
 	}
 	return fmt.Sprintf("%s", d.IntegrationType), nil
 }
 
+func (d IncidentNotificationDestination) String() string {
+	v, _ := d.Value()
+	return v.(string)
+}
+
 func (d *IncidentNotificationDestination) Scan(src any) error {
 	*d = IncidentNotificationDestination{}
-	parts := strings.Split(src.(string), ":")
+	parts := strings.SplitN(src.(string), ":", 2)
 	if len(parts) == 0 {
 		return nil
 	}
@@ -92,13 +140,24 @@ func (d *IncidentNotificationDestination) Scan(src any) error {
 		switch d.IntegrationType {
 		case IntegrationTypeSlack:
 			d.SlackChannel = parts[1]
+		case IntegrationTypeEmail:
+			d.EmailTo = parts[1]
 		}
 	}
 	return nil
 }
 
 type IncidentNotificationDetails struct {
-	Reports []IncidentNotificationDetailsReport `json:"reports"`
+	Reports    []IncidentNotificationDetailsReport   `json:"reports"`
+	AlertRule  *IncidentNotificationDetailsAlertRule `json:"alert_rule,omitempty"`
+	Escalation bool                                  `json:"escalation,omitempty"`
+	// Silenced is the target of the silence that deferred the notification, it is re-checked at the delivery time
+	Silenced *SilenceTarget `json:"silenced,omitempty"`
+}
+
+type IncidentNotificationDetailsAlertRule struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
 }
 
 type IncidentNotificationDetailsReport struct {
@@ -109,10 +168,10 @@ type IncidentNotificationDetailsReport struct {
 
 func (db *DB) GetIncidentByKey(projectId ProjectId, key string) (*model.ApplicationIncident, error) {
 	i := &model.ApplicationIncident{Key: key}
-	var d, rca sql.NullString
+	var d, rca, lc sql.NullString
 	err := db.db.QueryRow(
-		"SELECT application_id, opened_at, resolved_at, severity, details, rca FROM incident WHERE project_id = $1 AND key = $2 LIMIT 1",
-		projectId, key).Scan(&i.ApplicationId, &i.OpenedAt, &i.ResolvedAt, &i.Severity, &d, &rca)
+		"SELECT application_id, opened_at, resolved_at, severity, details, rca, lifecycle FROM incident WHERE project_id = $1 AND key = $2 LIMIT 1",
+		projectId, key).Scan(&i.ApplicationId, &i.OpenedAt, &i.ResolvedAt, &i.Severity, &d, &rca, &lc)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrNotFound
 	}
@@ -127,12 +186,17 @@ func (db *DB) GetIncidentByKey(projectId ProjectId, key string) (*model.Applicat
 			return nil, err
 		}
 	}
+	if lc.String != "" {
+		if err = json.Unmarshal([]byte(lc.String), &i.Lifecycle); err != nil {
+			return nil, err
+		}
+	}
 	return i, err
 }
 
 func (db *DB) GetLatestIncidents(projectId ProjectId, limit int) ([]*model.ApplicationIncident, error) {
 	rows, err := db.db.Query(
-		"SELECT application_id, key, opened_at, resolved_at, severity, details, rca FROM incident WHERE project_id = $1 ORDER BY (resolved_at = 0) DESC, opened_at DESC LIMIT $2",
+		"SELECT application_id, key, opened_at, resolved_at, severity, details, rca, lifecycle FROM incident WHERE project_id = $1 ORDER BY (resolved_at = 0) DESC, opened_at DESC LIMIT $2",
 		projectId, limit)
 	if err != nil {
 		return nil, err
@@ -143,8 +207,8 @@ func (db *DB) GetLatestIncidents(projectId ProjectId, limit int) ([]*model.Appli
 	var res []*model.ApplicationIncident
 	for rows.Next() {
 		var i model.ApplicationIncident
-		var d, rca sql.NullString
-		if err := rows.Scan(&i.ApplicationId, &i.Key, &i.OpenedAt, &i.ResolvedAt, &i.Severity, &d, &rca); err != nil {
+		var d, rca, lc sql.NullString
+		if err := rows.Scan(&i.ApplicationId, &i.Key, &i.OpenedAt, &i.ResolvedAt, &i.Severity, &d, &rca, &lc); err != nil {
 			return nil, err
 		}
 		if d.String != "" {
@@ -158,6 +222,11 @@ func (db *DB) GetLatestIncidents(projectId ProjectId, limit int) ([]*model.Appli
 				return nil, err
 			}
 		}
+		if lc.String != "" {
+			if err = json.Unmarshal([]byte(lc.String), &i.Lifecycle); err != nil {
+				return nil, err
+			}
+		}
 		res = append(res, &i)
 	}
 	return res, err
@@ -165,7 +234,7 @@ func (db *DB) GetLatestIncidents(projectId ProjectId, limit int) ([]*model.Appli
 
 func (db *DB) GetApplicationIncidents(projectId ProjectId, from, to timeseries.Time) (map[model.ApplicationId][]*model.ApplicationIncident, error) {
 	rows, err := db.db.Query(
-		"SELECT application_id, key, opened_at, resolved_at, severity, details, rca FROM incident WHERE project_id = $1 AND opened_at <= $2 AND (resolved_at = 0 OR resolved_at >= $3) ORDER BY opened_at ASC",
+		"SELECT application_id, key, opened_at, resolved_at, severity, details, rca, lifecycle FROM incident WHERE project_id = $1 AND opened_at <= $2 AND (resolved_at = 0 OR resolved_at >= $3) ORDER BY opened_at ASC",
 		projectId, to, from)
 	if err != nil {
 		return nil, err
@@ -176,8 +245,8 @@ func (db *DB) GetApplicationIncidents(projectId ProjectId, from, to timeseries.T
 	res := map[model.ApplicationId][]*model.ApplicationIncident{}
 	for rows.Next() {
 		var i model.ApplicationIncident
-		var d, rca sql.NullString
-		if err := rows.Scan(&i.ApplicationId, &i.Key, &i.OpenedAt, &i.ResolvedAt, &i.Severity, &d, &rca); err != nil {
+		var d, rca, lc sql.NullString
+		if err := rows.Scan(&i.ApplicationId, &i.Key, &i.OpenedAt, &i.ResolvedAt, &i.Severity, &d, &rca, &lc); err != nil {
 			return nil, err
 		}
 		if d.String != "" {
@@ -191,6 +260,11 @@ func (db *DB) GetApplicationIncidents(projectId ProjectId, from, to timeseries.T
 				return nil, err
 			}
 		}
+		if lc.String != "" {
+			if err = json.Unmarshal([]byte(lc.String), &i.Lifecycle); err != nil {
+				return nil, err
+			}
+		}
 		res[i.ApplicationId] = append(res[i.ApplicationId], &i)
 	}
 	return res, err
@@ -200,10 +274,10 @@ func (db *DB) GetLastOpenIncident(projectId ProjectId, appId model.ApplicationId
 	last := model.ApplicationIncident{
 		ApplicationId: appId,
 	}
-	var dd, rca sql.NullString
+	var dd, rca, lc sql.NullString
 	err := db.db.QueryRow(
-		"SELECT key, opened_at, resolved_at, severity, details, rca FROM incident WHERE project_id = $1 AND application_id = $2 AND resolved_at = 0 ORDER BY opened_at DESC LIMIT 1",
-		projectId, appId.String()).Scan(&last.Key, &last.OpenedAt, &last.ResolvedAt, &last.Severity, &dd, &rca)
+		"SELECT key, opened_at, resolved_at, severity, details, rca, lifecycle FROM incident WHERE project_id = $1 AND application_id = $2 AND resolved_at = 0 ORDER BY opened_at DESC LIMIT 1",
+		projectId, appId.String()).Scan(&last.Key, &last.OpenedAt, &last.ResolvedAt, &last.Severity, &dd, &rca, &lc)
 	switch err {
 	case nil:
 		if dd.String != "" {
@@ -217,6 +291,11 @@ func (db *DB) GetLastOpenIncident(projectId ProjectId, appId model.ApplicationId
 				return nil, err
 			}
 		}
+		if lc.String != "" {
+			if err = json.Unmarshal([]byte(lc.String), &last.Lifecycle); err != nil {
+				return nil, err
+			}
+		}
 		return &last, nil
 	case sql.ErrNoRows:
 		return nil, nil
@@ -253,9 +332,70 @@ func (db *DB) UpdateIncidentRCA(projectId ProjectId, i *model.ApplicationInciden
 	return err
 }
 
+func (db *DB) UpdateIncidentLifecycle(projectId ProjectId, i *model.ApplicationIncident) error {
+	d, err := json.Marshal(i.Lifecycle)
+	if err != nil {
+		return err
+	}
+	res, err := db.db.Exec("UPDATE incident SET lifecycle = $1 WHERE project_id = $2 AND key = $3 AND resolved_at = 0", string(d), projectId, i.Key)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// CloseIncident resolves the incident manually, it returns ErrConflict if the incident has already been resolved.
+func (db *DB) CloseIncident(projectId ProjectId, i *model.ApplicationIncident) error {
+	d, err := json.Marshal(i.Lifecycle)
+	if err != nil {
+		return err
+	}
+	res, err := db.db.Exec(
+		"UPDATE incident SET lifecycle = $1, resolved_at = $2 WHERE project_id = $3 AND key = $4 AND resolved_at = 0",
+		string(d), i.ResolvedAt, projectId, i.Key)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (db *DB) AddIncidentEvent(projectId ProjectId, incidentKey string, e IncidentEvent) error {
+	_, err := db.db.Exec(
+		"INSERT INTO incident_event (project_id, incident_key, timestamp, type, author, text) VALUES ($1, $2, $3, $4, $5, $6)",
+		projectId, incidentKey, e.Timestamp, e.Type, e.Author, e.Text)
+	return err
+}
+
+func (db *DB) GetIncidentEvents(projectId ProjectId, incidentKey string) ([]*IncidentEvent, error) {
+	rows, err := db.db.Query(
+		"SELECT timestamp, type, author, text FROM incident_event WHERE project_id = $1 AND incident_key = $2 ORDER BY timestamp",
+		projectId, incidentKey)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var res []*IncidentEvent
+	for rows.Next() {
+		var e IncidentEvent
+		if err = rows.Scan(&e.Timestamp, &e.Type, &e.Author, &e.Text); err != nil {
+			return nil, err
+		}
+		res = append(res, &e)
+	}
+	return res, nil
+}
+
 func (db *DB) ResolveIncident(projectId ProjectId, appId model.ApplicationId, incident *model.ApplicationIncident) error {
 	_, err := db.db.Exec(
-		"UPDATE incident SET resolved_at = $1 WHERE project_id = $2 AND application_id = $3 AND key = $4",
+		"UPDATE incident SET resolved_at = $1 WHERE project_id = $2 AND application_id = $3 AND key = $4 AND resolved_at = 0",
 		incident.ResolvedAt, projectId, appId.String(), incident.Key)
 	return err
 }
@@ -283,13 +423,61 @@ func (db *DB) UpdateIncidentNotification(n IncidentNotification) error {
 	return err
 }
 
-func (db *DB) GetNotSentIncidentNotifications(from timeseries.Time) ([]IncidentNotification, error) {
+// RecordIncidentNotificationAttempt counts a delivery attempt of the notification and keeps the error, if any.
+func (db *DB) RecordIncidentNotificationAttempt(n IncidentNotification, sendErr error) error {
+	lastError := ""
+	if sendErr != nil {
+		lastError = sendErr.Error()
+	}
+	_, err := db.db.Exec(
+		"UPDATE incident_notification SET attempts = attempts + 1, last_error = $1 WHERE project_id = $2 AND application_id = $3 AND incident_key = $4 AND timestamp = $5 AND destination = $6",
+		lastError, n.ProjectId, n.ApplicationId, n.IncidentKey, n.Timestamp, n.Destination,
+	)
+	return err
+}
+
+func (db *DB) GetIncidentNotifications(projectId ProjectId, from timeseries.Time, incidentKey string) ([]IncidentNotification, error) {
+	q := `
+		SELECT project_id, application_id, incident_key, status, destination, timestamp, sent_at, external_key, details, attempts, last_error
+		FROM incident_notification
+		WHERE project_id = $1 AND timestamp >= $2`
+	args := []any{projectId, from}
+	if incidentKey != "" {
+		q += " AND incident_key = $3"
+		args = append(args, incidentKey)
+	}
+	q += " ORDER BY timestamp DESC"
+	rows, err := db.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var res []IncidentNotification
+	var details sql.NullString
+	for rows.Next() {
+		var n IncidentNotification
+		if err := rows.Scan(&n.ProjectId, &n.ApplicationId, &n.IncidentKey, &n.Status, &n.Destination, &n.Timestamp, &n.SentAt, &n.ExternalKey, &details, &n.Attempts, &n.LastError); err != nil {
+			return nil, err
+		}
+		if details.String != "" {
+			if err := unmarshal(details.String, &n.Details); err != nil {
+				klog.Warningln(err)
+			}
+		}
+		res = append(res, n)
+	}
+	return res, nil
+}
+
+func (db *DB) GetNotSentIncidentNotifications(from, to timeseries.Time) ([]IncidentNotification, error) {
 	rows, err := db.db.Query(`
 		SELECT project_id, application_id, incident_key, status, destination, timestamp, external_key, details 
 		FROM incident_notification 
-		WHERE timestamp >= $1 AND sent_at = 0 
+		WHERE timestamp >= $1 AND timestamp <= $2 AND sent_at = 0 
 		ORDER BY project_id, application_id, incident_key, timestamp
-	`, from)
+	`, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -313,6 +501,23 @@ func (db *DB) GetNotSentIncidentNotifications(from timeseries.Time) ([]IncidentN
 	return res, nil
 }
 
+func (db *DB) DeleteIncidentNotification(n IncidentNotification) error {
+	_, err := db.db.Exec(
+		"DELETE FROM incident_notification WHERE project_id = $1 AND application_id = $2 AND incident_key = $3 AND timestamp = $4 AND destination = $5 AND sent_at = 0",
+		n.ProjectId, n.ApplicationId, n.IncidentKey, n.Timestamp, n.Destination,
+	)
+	return err
+}
+
+// DeleteScheduledIncidentNotifications removes not yet sent notifications scheduled after the given one, e.g. pending escalations.
+func (db *DB) DeleteScheduledIncidentNotifications(n IncidentNotification) error {
+	_, err := db.db.Exec(
+		"DELETE FROM incident_notification WHERE project_id = $1 AND application_id = $2 AND incident_key = $3 AND destination = $4 AND timestamp > $5 AND sent_at = 0",
+		n.ProjectId, n.ApplicationId, n.IncidentKey, n.Destination, n.Timestamp,
+	)
+	return err
+}
+
 func (db *DB) GetPreviousIncidentNotifications(n IncidentNotification) ([]IncidentNotification, error) {
 	rows, err := db.db.Query(`
 		SELECT project_id, application_id, incident_key, status, destination, timestamp, external_key, details 
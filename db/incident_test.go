@@ -0,0 +1,91 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncidentLifecycle(t *testing.T) {
+	database, err := NewSqlite(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, database.Migrate())
+	p := &Project{Name: "test"}
+	require.NoError(t, database.SaveProject(p))
+
+	appId := model.NewApplicationId("default", model.ApplicationKindDeployment, "app")
+	now := timeseries.Time(1700000000)
+	open := func(key string, openedAt timeseries.Time) *model.ApplicationIncident {
+		i := &model.ApplicationIncident{ApplicationId: appId, Key: key, OpenedAt: openedAt, Severity: model.CRITICAL}
+		require.NoError(t, database.CreateIncident(p.Id, appId, i))
+		return i
+	}
+	get := func(key string) *model.ApplicationIncident {
+		i, err := database.GetIncidentByKey(p.Id, key)
+		require.NoError(t, err)
+		return i
+	}
+
+	i := open("i1", now)
+
+	i.Lifecycle.AcknowledgedAt = now.Add(60)
+	i.Lifecycle.AcknowledgedBy = "alice"
+	require.NoError(t, database.UpdateIncidentLifecycle(p.Id, i))
+	require.NoError(t, database.AddIncidentEvent(p.Id, i.Key, IncidentEvent{Timestamp: now.Add(60), Type: IncidentEventAcknowledged, Author: "alice"}))
+	assert.True(t, get("i1").Acknowledged())
+
+	i.Lifecycle.Assignee = "bob"
+	require.NoError(t, database.UpdateIncidentLifecycle(p.Id, i))
+	require.NoError(t, database.AddIncidentEvent(p.Id, i.Key, IncidentEvent{Timestamp: now.Add(120), Type: IncidentEventAssigned, Author: "alice", Text: "bob"}))
+	require.NoError(t, database.AddIncidentEvent(p.Id, i.Key, IncidentEvent{Timestamp: now.Add(180), Type: IncidentEventCommented, Author: "bob", Text: "looking"}))
+
+	i.ResolvedAt = now.Add(240)
+	i.Lifecycle.ClosedBy = "bob"
+	require.NoError(t, database.CloseIncident(p.Id, i))
+	require.NoError(t, database.AddIncidentEvent(p.Id, i.Key, IncidentEvent{Timestamp: now.Add(240), Type: IncidentEventClosed, Author: "bob"}))
+
+	closed := get("i1")
+	assert.Equal(t, now.Add(240), closed.ResolvedAt)
+	assert.Equal(t, model.Lifecycle{AcknowledgedAt: now.Add(60), AcknowledgedBy: "alice", Assignee: "bob", ClosedBy: "bob"}, closed.Lifecycle)
+	last, err := database.GetLastOpenIncident(p.Id, appId)
+	require.NoError(t, err)
+	assert.Nil(t, last)
+
+	// a stale copy of the incident must not overwrite the closed one
+	stale := &model.ApplicationIncident{ApplicationId: appId, Key: "i1", OpenedAt: now}
+	stale.Lifecycle.AcknowledgedAt = now.Add(300)
+	stale.Lifecycle.AcknowledgedBy = "carol"
+	assert.ErrorIs(t, database.UpdateIncidentLifecycle(p.Id, stale), ErrConflict)
+	stale.ResolvedAt = now.Add(300)
+	stale.Lifecycle.ClosedBy = "carol"
+	assert.ErrorIs(t, database.CloseIncident(p.Id, stale), ErrConflict)
+	require.NoError(t, database.ResolveIncident(p.Id, appId, stale))
+	assert.Equal(t, closed, get("i1"))
+
+	events, err := database.GetIncidentEvents(p.Id, "i1")
+	require.NoError(t, err)
+	assert.Equal(t, []*IncidentEvent{
+		{Timestamp: now.Add(60), Type: IncidentEventAcknowledged, Author: "alice"},
+		{Timestamp: now.Add(120), Type: IncidentEventAssigned, Author: "alice", Text: "bob"},
+		{Timestamp: now.Add(180), Type: IncidentEventCommented, Author: "bob", Text: "looking"},
+		{Timestamp: now.Add(240), Type: IncidentEventClosed, Author: "bob"},
+	}, events)
+
+	// closing an incident resolved by Coroot
+	i = open("i2", now.Add(3600))
+	i.ResolvedAt = now.Add(3660)
+	require.NoError(t, database.ResolveIncident(p.Id, appId, i))
+	i.ResolvedAt = now.Add(3720)
+	i.Lifecycle.ClosedBy = "alice"
+	assert.ErrorIs(t, database.CloseIncident(p.Id, i), ErrConflict)
+	resolved := get("i2")
+	assert.Equal(t, now.Add(3660), resolved.ResolvedAt)
+	assert.Empty(t, resolved.Lifecycle.ClosedBy)
+
+	events, err = database.GetIncidentEvents(p.Id, "i2")
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
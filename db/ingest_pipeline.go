@@ -0,0 +1,157 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/coroot/coroot/logparser"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+)
+
+const (
+	IngestSignalTraces = "traces"
+	IngestSignalLogs   = "logs"
+)
+
+// IngestPipeline is applied by the collector to traces and logs before they are stored.
+// The steps are applied in order: log parsing, renames, drop rules, head sampling, redactions, tail sampling.
+type IngestPipeline struct {
+	ParsingRules []LogParsingRule  `json:"parsing_rules" yaml:"parsingRules"`
+	Renames      []IngestRename    `json:"renames" yaml:"renames"`
+	DropRules    []IngestDropRule  `json:"drop_rules" yaml:"dropRules"`
+	HeadSampling *HeadSampling     `json:"head_sampling,omitempty" yaml:"headSampling"`
+	Redactions   []IngestRedaction `json:"redactions" yaml:"redactions"`
+	TailSampling *TailSampling     `json:"tail_sampling,omitempty" yaml:"tailSampling"`
+}
+
+// LogParsingRule lifts the fields of log bodies to the log record attributes. Existing attributes are not overwritten.
+// The severity and the timestamp are derived from the parsed fields only if they are missing in the record.
+// The rules matching the service name are tried in order, the first one that parses the body is applied.
+type LogParsingRule struct {
+	Name            string `json:"name" yaml:"name"`
+	ServiceName     string `json:"service_name" yaml:"serviceName"` // regexp, all services if empty
+	Format          string `json:"format" yaml:"format"`            // json, logfmt, regex or grok
+	Pattern         string `json:"pattern" yaml:"pattern"`          // regexp with named groups or grok pattern
+	SeverityField   string `json:"severity_field" yaml:"severityField"`
+	TimestampField  string `json:"timestamp_field" yaml:"timestampField"`
+	TimestampLayout string `json:"timestamp_layout" yaml:"timestampLayout"` // Go time layout, common formats are detected if empty
+}
+
+type IngestRename struct {
+	From string `json:"from" yaml:"from"`
+	To   string `json:"to" yaml:"to"`
+}
+
+// IngestDropRule drops the spans or log records matching all the specified conditions.
+type IngestDropRule struct {
+	Name        string `json:"name" yaml:"name"`
+	Signal      string `json:"signal" yaml:"signal"`            // traces, logs or empty for both
+	ServiceName string `json:"service_name" yaml:"serviceName"` // regexp
+	Attribute   string `json:"attribute" yaml:"attribute"`      // span, log record or resource attribute
+	Value       string `json:"value" yaml:"value"`              // regexp matching the value of the attribute
+	MaxSeverity string `json:"max_severity" yaml:"maxSeverity"` // logs only: drop records of this severity or lower
+}
+
+// HeadSampling keeps the given percentage of traces and log records, zero disables sampling.
+// Log records having a trace ID are sampled at the traces rate, so they are kept along with their traces.
+type HeadSampling struct {
+	TracesPercent float64 `json:"traces_percent" yaml:"tracesPercent"`
+	LogsPercent   float64 `json:"logs_percent" yaml:"logsPercent"`
+}
+
+// IngestRedaction replaces the parts of attribute values matching the pattern.
+// If Attribute is empty, the redaction applies to all the attributes and to log bodies.
+type IngestRedaction struct {
+	Name        string `json:"name" yaml:"name"`
+	Attribute   string `json:"attribute" yaml:"attribute"` // regexp matching attribute names
+	Pattern     string `json:"pattern" yaml:"pattern"`
+	Replacement string `json:"replacement" yaml:"replacement"`
+}
+
+// TailSampling buffers the spans of a trace for DecisionWait and keeps the trace
+// if it contains an error or a span slower than SlowThresholdMs, or with the given probability otherwise.
+type TailSampling struct {
+	DecisionWait    timeseries.Duration `json:"decision_wait" yaml:"decisionWait"`
+	KeepErrors      bool                `json:"keep_errors" yaml:"keepErrors"`
+	SlowThresholdMs int64               `json:"slow_threshold_ms" yaml:"slowThresholdMs"`
+	Percent         float64             `json:"percent" yaml:"percent"`
+}
+
+func (p *IngestPipeline) IsEmpty() bool {
+	return p == nil || (len(p.ParsingRules) == 0 && len(p.Renames) == 0 && len(p.DropRules) == 0 && p.HeadSampling == nil && len(p.Redactions) == 0 && p.TailSampling == nil)
+}
+
+func (p *IngestPipeline) Validate() error {
+	for i, r := range p.ParsingRules {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("parsing rule #%d: %w", i+1, err)
+		}
+	}
+	for i, r := range p.Renames {
+		if r.From == "" || r.To == "" {
+			return fmt.Errorf("rename #%d: from and to are required", i+1)
+		}
+	}
+	for i, r := range p.DropRules {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("drop rule #%d: %w", i+1, err)
+		}
+	}
+	if hs := p.HeadSampling; hs != nil {
+		if hs.TracesPercent < 0 || hs.TracesPercent > 100 || hs.LogsPercent < 0 || hs.LogsPercent > 100 {
+			return fmt.Errorf("head sampling: percent must be between 0 and 100")
+		}
+	}
+	for i, r := range p.Redactions {
+		if r.Pattern == "" {
+			return fmt.Errorf("redaction #%d: pattern is required", i+1)
+		}
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("redaction #%d: invalid pattern: %w", i+1, err)
+		}
+		if _, err := regexp.Compile(r.Attribute); err != nil {
+			return fmt.Errorf("redaction #%d: invalid attribute: %w", i+1, err)
+		}
+	}
+	if ts := p.TailSampling; ts != nil {
+		if ts.Percent < 0 || ts.Percent > 100 {
+			return fmt.Errorf("tail sampling: percent must be between 0 and 100")
+		}
+		if ts.DecisionWait < 0 || ts.SlowThresholdMs < 0 {
+			return fmt.Errorf("tail sampling: decision wait and slow threshold must not be negative")
+		}
+	}
+	return nil
+}
+
+func (r *LogParsingRule) Validate() error {
+	if _, err := logparser.New(r.Format, r.Pattern); err != nil {
+		return err
+	}
+	if _, err := regexp.Compile(r.ServiceName); err != nil {
+		return fmt.Errorf("invalid service name: %w", err)
+	}
+	return nil
+}
+
+func (r *IngestDropRule) Validate() error {
+	switch r.Signal {
+	case "", IngestSignalTraces, IngestSignalLogs:
+	default:
+		return fmt.Errorf("unknown signal: %s", r.Signal)
+	}
+	if r.ServiceName == "" && r.Attribute == "" && r.MaxSeverity == "" {
+		return fmt.Errorf("at least one of service name, attribute or severity is required")
+	}
+	if _, err := regexp.Compile(r.ServiceName); err != nil {
+		return fmt.Errorf("invalid service name: %w", err)
+	}
+	if _, err := regexp.Compile(r.Value); err != nil {
+		return fmt.Errorf("invalid value: %w", err)
+	}
+	if r.MaxSeverity != "" && model.SeverityFromString(r.MaxSeverity) == model.SeverityUnknown {
+		return fmt.Errorf("unknown severity: %s", r.MaxSeverity)
+	}
+	return nil
+}
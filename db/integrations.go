@@ -2,6 +2,7 @@ package db
 
 import (
 	"fmt"
+	"net/mail"
 	"net/url"
 	"strings"
 
@@ -20,6 +21,11 @@ const (
 	IntegrationTypeTeams      IntegrationType = "teams"
 	IntegrationTypeOpsgenie   IntegrationType = "opsgenie"
 	IntegrationTypeWebhook    IntegrationType = "webhook"
+	IntegrationTypeEmail      IntegrationType = "email"
+	IntegrationTypeMattermost IntegrationType = "mattermost"
+	IntegrationTypeDiscord    IntegrationType = "discord"
+	IntegrationTypeGoogleChat IntegrationType = "googlechat"
+	IntegrationTypeTelegram   IntegrationType = "telegram"
 )
 
 type Integrations struct {
@@ -39,6 +45,12 @@ type NotificationIntegrations struct {
 	Pagerduty *IntegrationPagerduty `json:"pagerduty,omitempty" yaml:"pagerduty,omitempty"`
 	Opsgenie  *IntegrationOpsgenie  `json:"opsgenie,omitempty" yaml:"opsgenie,omitempty"`
 	Webhook   *IntegrationWebhook   `json:"webhook,omitempty" yaml:"webhook,omitempty"`
+	Email     *IntegrationEmail     `json:"email,omitempty" yaml:"email,omitempty"`
+
+	Mattermost *IntegrationMattermost `json:"mattermost,omitempty" yaml:"mattermost,omitempty"`
+	Discord    *IntegrationDiscord    `json:"discord,omitempty" yaml:"discord,omitempty"`
+	GoogleChat *IntegrationGoogleChat `json:"googlechat,omitempty" yaml:"googleChat,omitempty"`
+	Telegram   *IntegrationTelegram   `json:"telegram,omitempty" yaml:"telegram,omitempty"`
 }
 
 func (i *NotificationIntegrations) Validate() error {
@@ -75,6 +87,31 @@ func (i *NotificationIntegrations) Validate() error {
 			return fmt.Errorf("invalid webhook configuration: %w", err)
 		}
 	}
+	if i.Email != nil {
+		if err := i.Email.Validate(); err != nil {
+			return fmt.Errorf("invalid email configuration: %w", err)
+		}
+	}
+	if i.Mattermost != nil {
+		if err := i.Mattermost.Validate(); err != nil {
+			return fmt.Errorf("invalid mattermost configuration: %w", err)
+		}
+	}
+	if i.Discord != nil {
+		if err := i.Discord.Validate(); err != nil {
+			return fmt.Errorf("invalid discord configuration: %w", err)
+		}
+	}
+	if i.GoogleChat != nil {
+		if err := i.GoogleChat.Validate(); err != nil {
+			return fmt.Errorf("invalid google chat configuration: %w", err)
+		}
+	}
+	if i.Telegram != nil {
+		if err := i.Telegram.Validate(); err != nil {
+			return fmt.Errorf("invalid telegram configuration: %w", err)
+		}
+	}
 
 	return nil
 
@@ -136,6 +173,51 @@ func (integrations Integrations) GetInfo() []IntegrationInfo {
 	}
 	res = append(res, i)
 
+	i = IntegrationInfo{Type: IntegrationTypeEmail, Title: "Email"}
+	if cfg := integrations.Email; cfg != nil {
+		i.Configured = true
+		i.Incidents = cfg.Incidents
+		i.Deployments = cfg.Deployments
+		i.Details = fmt.Sprintf("recipients: %s", strings.Join(cfg.To, ", "))
+	}
+	res = append(res, i)
+
+	i = IntegrationInfo{Type: IntegrationTypeMattermost, Title: "Mattermost"}
+	if cfg := integrations.Mattermost; cfg != nil {
+		i.Configured = true
+		i.Incidents = cfg.Incidents
+		i.Deployments = cfg.Deployments
+		if cfg.Channel != "" {
+			i.Details = fmt.Sprintf("channel: %s", cfg.Channel)
+		}
+	}
+	res = append(res, i)
+
+	i = IntegrationInfo{Type: IntegrationTypeDiscord, Title: "Discord"}
+	if cfg := integrations.Discord; cfg != nil {
+		i.Configured = true
+		i.Incidents = cfg.Incidents
+		i.Deployments = cfg.Deployments
+	}
+	res = append(res, i)
+
+	i = IntegrationInfo{Type: IntegrationTypeGoogleChat, Title: "Google Chat"}
+	if cfg := integrations.GoogleChat; cfg != nil {
+		i.Configured = true
+		i.Incidents = cfg.Incidents
+		i.Deployments = cfg.Deployments
+	}
+	res = append(res, i)
+
+	i = IntegrationInfo{Type: IntegrationTypeTelegram, Title: "Telegram"}
+	if cfg := integrations.Telegram; cfg != nil {
+		i.Configured = true
+		i.Incidents = cfg.Incidents
+		i.Deployments = cfg.Deployments
+		i.Details = fmt.Sprintf("chat: %s", cfg.ChatId)
+	}
+	res = append(res, i)
+
 	return res
 }
 
@@ -223,6 +305,8 @@ type IntegrationWebhook struct {
 	TlsSkipVerify      bool             `json:"tls_skip_verify" yaml:"tlsSkipVerify"`
 	BasicAuth          *utils.BasicAuth `json:"basic_auth" yaml:"basicAuth"`
 	CustomHeaders      []utils.Header   `json:"custom_headers" yaml:"customHeaders"`
+	Secret             string           `json:"secret" yaml:"secret"` // if set, the payload is signed with HMAC-SHA256
+	MaxAttempts        int              `json:"max_attempts" yaml:"maxAttempts"`
 	Incidents          bool             `json:"incidents" yaml:"incidents"`
 	Deployments        bool             `json:"deployments" yaml:"deployments"`
 	IncidentTemplate   string           `json:"incident_template" yaml:"incidentTemplate"`
@@ -239,6 +323,131 @@ func (i *IntegrationWebhook) Validate() error {
 	if i.Deployments && i.DeploymentTemplate == "" {
 		return fmt.Errorf("deployment template is required")
 	}
+	if i.MaxAttempts < 0 || i.MaxAttempts > 10 {
+		return fmt.Errorf("max attempts must be between 0 and 10")
+	}
+	return nil
+}
+
+type IntegrationMattermost struct {
+	WebhookUrl  string `json:"webhook_url" yaml:"webhookURL"`
+	Channel     string `json:"channel" yaml:"channel"` // overrides the channel of the webhook if set
+	Incidents   bool   `json:"incidents" yaml:"incidents"`
+	Deployments bool   `json:"deployments" yaml:"deployments"`
+}
+
+func (i *IntegrationMattermost) Validate() error {
+	if i.WebhookUrl == "" {
+		return fmt.Errorf("webhook url is required")
+	}
+	if _, err := url.Parse(i.WebhookUrl); err != nil {
+		return fmt.Errorf("invalid webhook url")
+	}
+	return nil
+}
+
+type IntegrationDiscord struct {
+	WebhookUrl  string `json:"webhook_url" yaml:"webhookURL"`
+	Incidents   bool   `json:"incidents" yaml:"incidents"`
+	Deployments bool   `json:"deployments" yaml:"deployments"`
+}
+
+func (i *IntegrationDiscord) Validate() error {
+	if i.WebhookUrl == "" {
+		return fmt.Errorf("webhook url is required")
+	}
+	if _, err := url.Parse(i.WebhookUrl); err != nil {
+		return fmt.Errorf("invalid webhook url")
+	}
+	return nil
+}
+
+type IntegrationGoogleChat struct {
+	WebhookUrl  string `json:"webhook_url" yaml:"webhookURL"`
+	Incidents   bool   `json:"incidents" yaml:"incidents"`
+	Deployments bool   `json:"deployments" yaml:"deployments"`
+}
+
+func (i *IntegrationGoogleChat) Validate() error {
+	if i.WebhookUrl == "" {
+		return fmt.Errorf("webhook url is required")
+	}
+	if _, err := url.Parse(i.WebhookUrl); err != nil {
+		return fmt.Errorf("invalid webhook url")
+	}
+	return nil
+}
+
+type IntegrationTelegram struct {
+	BotToken    string `json:"bot_token" yaml:"botToken"`
+	ChatId      string `json:"chat_id" yaml:"chatId"`
+	Incidents   bool   `json:"incidents" yaml:"incidents"`
+	Deployments bool   `json:"deployments" yaml:"deployments"`
+}
+
+func (i *IntegrationTelegram) Validate() error {
+	if i.BotToken == "" {
+		return fmt.Errorf("bot token is required")
+	}
+	if i.ChatId == "" {
+		return fmt.Errorf("chat id is required")
+	}
+	return nil
+}
+
+type EmailTLSMode string
+
+const (
+	EmailTLSModeNone     EmailTLSMode = "none"
+	EmailTLSModeTLS      EmailTLSMode = "tls"
+	EmailTLSModeStartTLS EmailTLSMode = "starttls"
+)
+
+type IntegrationEmail struct {
+	SmtpHost      string       `json:"smtp_host" yaml:"smtpHost"`
+	SmtpPort      int          `json:"smtp_port" yaml:"smtpPort"`
+	TLS           EmailTLSMode `json:"tls" yaml:"tls"`
+	TlsSkipVerify bool         `json:"tls_skip_verify" yaml:"tlsSkipVerify"`
+	Username      string       `json:"username" yaml:"username"`
+	Password      string       `json:"password" yaml:"password"`
+	From          string       `json:"from" yaml:"from"`
+	To            []string     `json:"to" yaml:"to"` // default recipients, can be overridden per application category
+	Incidents     bool         `json:"incidents" yaml:"incidents"`
+	Deployments   bool         `json:"deployments" yaml:"deployments"`
+}
+
+func (i *IntegrationEmail) Validate() error {
+	if i.SmtpHost == "" {
+		return fmt.Errorf("smtp host is required")
+	}
+	if i.SmtpPort <= 0 || i.SmtpPort > 65535 {
+		return fmt.Errorf("invalid smtp port")
+	}
+	switch i.TLS {
+	case EmailTLSModeNone, EmailTLSModeTLS, EmailTLSModeStartTLS:
+	case "":
+		i.TLS = EmailTLSModeStartTLS
+	default:
+		return fmt.Errorf("invalid tls mode: %s", i.TLS)
+	}
+	if _, err := mail.ParseAddress(i.From); err != nil {
+		return fmt.Errorf("invalid sender address: %s", i.From)
+	}
+	if len(i.To) == 0 {
+		return fmt.Errorf("at least one recipient is required")
+	}
+	if err := ValidateEmailRecipients(i.To); err != nil {
+		return err
+	}
+	return nil
+}
+
+func ValidateEmailRecipients(addresses []string) error {
+	for _, a := range addresses {
+		if _, err := mail.ParseAddress(a); err != nil || strings.ContainsAny(a, ",:") {
+			return fmt.Errorf("invalid recipient address: %s", a)
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/coroot/coroot/utils"
+)
+
+// LogExport records who exported which logs, the record is created before the data is sent
+// so that interrupted exports are also recorded.
+type LogExport struct {
+	Id            string              `json:"id"`
+	User          string              `json:"user"`
+	ApplicationId model.ApplicationId `json:"application_id"`
+	Query         string              `json:"query"`
+	Format        string              `json:"format"`
+	From          timeseries.Time     `json:"from"`
+	To            timeseries.Time     `json:"to"`
+	StartedAt     timeseries.Time     `json:"started_at"`
+	FinishedAt    timeseries.Time     `json:"finished_at"`
+	Rows          int64               `json:"rows"`
+	Error         string              `json:"error"`
+}
+
+type LogExports struct{}
+
+func (e *LogExports) Migrate(m *Migrator) error {
+	return m.Exec(`
+	CREATE TABLE IF NOT EXISTS log_export (
+		project_id TEXT NOT NULL REFERENCES project(id),
+		id TEXT NOT NULL,
+		user_name TEXT NOT NULL,
+		application_id TEXT NOT NULL,
+		query TEXT NOT NULL,
+		format TEXT NOT NULL,
+		from_ts INT NOT NULL,
+		to_ts INT NOT NULL,
+		started_at INT NOT NULL,
+		finished_at INT NOT NULL DEFAULT 0,
+		rows_count INT NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (project_id, id)
+	);
+	CREATE INDEX IF NOT EXISTS log_export_project_id_started_at ON log_export (project_id, started_at);
+`)
+}
+
+func (db *DB) CreateLogExport(projectId ProjectId, e *LogExport) error {
+	e.Id = utils.NanoId(8)
+	_, err := db.db.Exec(
+		"INSERT INTO log_export (project_id, id, user_name, application_id, query, format, from_ts, to_ts, started_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+		projectId, e.Id, e.User, e.ApplicationId.String(), e.Query, e.Format, e.From, e.To, e.StartedAt)
+	return err
+}
+
+func (db *DB) FinishLogExport(projectId ProjectId, e *LogExport) error {
+	_, err := db.db.Exec(
+		"UPDATE log_export SET finished_at = $1, rows_count = $2, error = $3 WHERE project_id = $4 AND id = $5",
+		e.FinishedAt, e.Rows, e.Error, projectId, e.Id)
+	return err
+}
+
+func (db *DB) GetLogExports(projectId ProjectId, limit int) ([]*LogExport, error) {
+	rows, err := db.db.Query(
+		"SELECT id, user_name, application_id, query, format, from_ts, to_ts, started_at, finished_at, rows_count, error FROM log_export WHERE project_id = $1 ORDER BY started_at DESC LIMIT $2",
+		projectId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*LogExport
+	for rows.Next() {
+		e := &LogExport{}
+		var appId string
+		if err = rows.Scan(&e.Id, &e.User, &appId, &e.Query, &e.Format, &e.From, &e.To, &e.StartedAt, &e.FinishedAt, &e.Rows, &e.Error); err != nil {
+			return nil, err
+		}
+		if e.ApplicationId, err = model.NewApplicationIdFromString(appId); err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+)
+
+type LogMetricType string
+
+const (
+	LogMetricTypeCount LogMetricType = "count"
+	LogMetricTypeSum   LogMetricType = "sum"
+	LogMetricTypeAvg   LogMetricType = "avg"
+	LogMetricTypeMin   LogMetricType = "min"
+	LogMetricTypeMax   LogMetricType = "max"
+)
+
+var logMetricNameRe = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// LogMetric is a time series derived from the logs stored in ClickHouse.
+// Every minute, the entries matching Services and Search are either counted
+// or aggregated by the numeric value extracted from Attribute or from the body using Regex.
+// The result is written to the project's metric storage, so it can be queried with PromQL.
+type LogMetric struct {
+	Name        string        `json:"name" yaml:"name"`
+	Description string        `json:"description" yaml:"description"`
+	Services    []string      `json:"services" yaml:"services"`
+	Search      string        `json:"search" yaml:"search"`
+	Type        LogMetricType `json:"type" yaml:"type"`
+	Attribute   string        `json:"attribute" yaml:"attribute"`
+	Regex       string        `json:"regex" yaml:"regex"` // the first capturing group is used as the value
+	GroupBy     []string      `json:"group_by" yaml:"groupBy"`
+	Disabled    bool          `json:"disabled" yaml:"disabled"`
+}
+
+func (m *LogMetric) Validate() error {
+	if !logMetricNameRe.MatchString(m.Name) {
+		return fmt.Errorf("invalid metric name: %q", m.Name)
+	}
+	switch m.Type {
+	case LogMetricTypeCount:
+		if m.Attribute != "" || m.Regex != "" {
+			return fmt.Errorf("attribute and regex are not applicable to count metrics")
+		}
+	case LogMetricTypeSum, LogMetricTypeAvg, LogMetricTypeMin, LogMetricTypeMax:
+		if (m.Attribute == "") == (m.Regex == "") {
+			return fmt.Errorf("either attribute or regex is required")
+		}
+		if m.Regex != "" {
+			re, err := regexp.Compile(m.Regex)
+			if err != nil {
+				return fmt.Errorf("invalid regex: %w", err)
+			}
+			if re.NumSubexp() < 1 {
+				return fmt.Errorf("regex must contain a capturing group")
+			}
+		}
+	default:
+		return fmt.Errorf("unknown type: %s", m.Type)
+	}
+	for _, g := range m.GroupBy {
+		if g == "" {
+			return fmt.Errorf("empty group by attribute")
+		}
+	}
+	return nil
+}
+
+func ValidateLogMetrics(metrics []LogMetric) error {
+	names := map[string]bool{}
+	for i, m := range metrics {
+		if err := m.Validate(); err != nil {
+			return fmt.Errorf("log metric #%d: %w", i+1, err)
+		}
+		if names[m.Name] {
+			return fmt.Errorf("log metric #%d: duplicate name: %s", i+1, m.Name)
+		}
+		names[m.Name] = true
+	}
+	return nil
+}
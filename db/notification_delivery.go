@@ -0,0 +1,92 @@
+package db
+
+import (
+	"github.com/coroot/coroot/timeseries"
+)
+
+// NotificationDelivery is an attempt to deliver an incident notification to its destination.
+type NotificationDelivery struct {
+	IncidentKey           string          `json:"incident_key"`
+	Destination           string          `json:"destination"`
+	NotificationTimestamp timeseries.Time `json:"notification_timestamp"`
+	AttemptedAt           timeseries.Time `json:"attempted_at"`
+	Attempt               int             `json:"attempt"`
+	StatusCode            int             `json:"status_code"`
+	Error                 string          `json:"error"`
+	DurationMs            int64           `json:"duration_ms"`
+}
+
+func (d *NotificationDelivery) Migrate(m *Migrator) error {
+	return m.Exec(`
+	CREATE TABLE IF NOT EXISTS notification_delivery (
+		project_id TEXT NOT NULL REFERENCES project(id),
+		incident_key TEXT NOT NULL,
+		destination TEXT NOT NULL,
+		notification_timestamp INT NOT NULL,
+		attempted_at INT NOT NULL,
+		attempt INT NOT NULL,
+		status_code INT NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		duration_ms INT NOT NULL DEFAULT 0
+	);
+	CREATE INDEX IF NOT EXISTS notification_delivery_incident_key ON notification_delivery (project_id, incident_key, attempted_at);
+`)
+}
+
+func (db *DB) AddNotificationDeliveries(n IncidentNotification, deliveries []NotificationDelivery) error {
+	destination, err := n.Destination.Value()
+	if err != nil {
+		return err
+	}
+	for _, d := range deliveries {
+		_, err = db.db.Exec(
+			"INSERT INTO notification_delivery (project_id, incident_key, destination, notification_timestamp, attempted_at, attempt, status_code, error, duration_ms) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+			n.ProjectId, n.IncidentKey, destination, n.Timestamp, d.AttemptedAt, d.Attempt, d.StatusCode, d.Error, d.DurationMs)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *DB) GetNotificationDeliveries(projectId ProjectId, incidentKey string) ([]*NotificationDelivery, error) {
+	rows, err := db.db.Query(
+		"SELECT incident_key, destination, notification_timestamp, attempted_at, attempt, status_code, error, duration_ms FROM notification_delivery WHERE project_id = $1 AND incident_key = $2 ORDER BY attempted_at, attempt",
+		projectId, incidentKey)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var res []*NotificationDelivery
+	for rows.Next() {
+		var d NotificationDelivery
+		if err = rows.Scan(&d.IncidentKey, &d.Destination, &d.NotificationTimestamp, &d.AttemptedAt, &d.Attempt, &d.StatusCode, &d.Error, &d.DurationMs); err != nil {
+			return nil, err
+		}
+		res = append(res, &d)
+	}
+	return res, nil
+}
+
+func (db *DB) GetProjectNotificationDeliveries(projectId ProjectId, from timeseries.Time) ([]*NotificationDelivery, error) {
+	rows, err := db.db.Query(
+		"SELECT incident_key, destination, notification_timestamp, attempted_at, attempt, status_code, error, duration_ms FROM notification_delivery WHERE project_id = $1 AND notification_timestamp >= $2 ORDER BY attempted_at, attempt",
+		projectId, from)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var res []*NotificationDelivery
+	for rows.Next() {
+		var d NotificationDelivery
+		if err = rows.Scan(&d.IncidentKey, &d.Destination, &d.NotificationTimestamp, &d.AttemptedAt, &d.Attempt, &d.StatusCode, &d.Error, &d.DurationMs); err != nil {
+			return nil, err
+		}
+		res = append(res, &d)
+	}
+	return res, nil
+}
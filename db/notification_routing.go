@@ -0,0 +1,181 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/coroot/coroot/utils"
+)
+
+// NotificationRouting is a tree of routes evaluated top-down in order.
+// The deepest matching route defines the destinations of a notification.
+// Unless Continue is set, the first matching sibling stops the evaluation on its level.
+// If no route matches, the destinations configured for the application category are used.
+type NotificationRouting struct {
+	Routes []*NotificationRoute `json:"routes"`
+}
+
+type NotificationRoute struct {
+	Name          string                         `json:"name"`
+	Match         NotificationRouteMatch         `json:"match"`
+	Destinations  []NotificationRouteDestination `json:"destinations"`
+	EscalateAfter timeseries.Duration            `json:"escalate_after"`
+	Escalation    []NotificationRouteDestination `json:"escalation"`
+	Continue      bool                           `json:"continue"`
+	Routes        []*NotificationRoute           `json:"routes,omitempty"`
+}
+
+type NotificationRouteMatch struct {
+	ApplicationIds []string                    `json:"application_ids,omitempty"`
+	Namespaces     []string                    `json:"namespaces,omitempty"`
+	Categories     []model.ApplicationCategory `json:"categories,omitempty"`
+	Severities     []model.Status              `json:"severities,omitempty"`
+	TimeWindow     *RecurringTimeWindow        `json:"time_window,omitempty"`
+}
+
+type NotificationRouteDestination struct {
+	IntegrationType IntegrationType `json:"type"`
+	SlackChannel    string          `json:"slack_channel,omitempty"`
+	EmailTo         []string        `json:"email_to,omitempty"`
+}
+
+type NotificationTarget struct {
+	ApplicationId model.ApplicationId
+	Category      model.ApplicationCategory
+	Severity      model.Status
+	Time          timeseries.Time
+	Resolved      bool
+}
+
+type RoutedDestination struct {
+	Destination   IncidentNotificationDestination
+	EscalateAfter timeseries.Duration
+}
+
+func (r *NotificationRouting) Validate() error {
+	for _, route := range r.Routes {
+		if err := route.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *NotificationRoute) Validate() error {
+	m := r.Match
+	if !utils.GlobValidate(m.ApplicationIds) || !utils.GlobValidate(m.Namespaces) {
+		return fmt.Errorf("%s: invalid pattern", r.Name)
+	}
+	for _, s := range m.Severities {
+		if s != model.WARNING && s != model.CRITICAL {
+			return fmt.Errorf("%s: invalid severity: %s", r.Name, s)
+		}
+	}
+	if m.TimeWindow != nil {
+		if err := m.TimeWindow.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", r.Name, err)
+		}
+	}
+	for _, d := range slices.Concat(r.Destinations, r.Escalation) {
+		switch d.IntegrationType {
+		case IntegrationTypeSlack, IntegrationTypeTeams, IntegrationTypePagerduty, IntegrationTypeOpsgenie, IntegrationTypeWebhook,
+			IntegrationTypeMattermost, IntegrationTypeDiscord, IntegrationTypeGoogleChat, IntegrationTypeTelegram:
+		case IntegrationTypeEmail:
+			if err := ValidateEmailRecipients(d.EmailTo); err != nil {
+				return fmt.Errorf("%s: %w", r.Name, err)
+			}
+		default:
+			return fmt.Errorf("%s: unsupported destination: %s", r.Name, d.IntegrationType)
+		}
+	}
+	if r.EscalateAfter < 0 || (len(r.Escalation) > 0 && r.EscalateAfter == 0) {
+		return fmt.Errorf("%s: invalid escalation delay", r.Name)
+	}
+	for _, child := range r.Routes {
+		if err := child.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Route returns the destinations of the deepest matching routes and whether any route has matched.
+// For resolved incidents, severity and time window matchers are ignored and the destinations of all
+// the matching routes are returned, since the resolution must reach every destination notified of the incident.
+func (r *NotificationRouting) Route(t NotificationTarget) ([]RoutedDestination, bool) {
+	if r == nil {
+		return nil, false
+	}
+	var res []RoutedDestination
+	matched := route(r.Routes, t, &res)
+	return res, matched
+}
+
+func route(routes []*NotificationRoute, t NotificationTarget, res *[]RoutedDestination) bool {
+	matched := false
+	for _, r := range routes {
+		if !r.Match.matches(t) {
+			continue
+		}
+		matched = true
+		if t.Resolved {
+			route(r.Routes, t, res)
+			for _, d := range slices.Concat(r.Destinations, r.Escalation) {
+				addRoutedDestination(res, d, 0)
+			}
+		} else if !route(r.Routes, t, res) {
+			for _, d := range r.Destinations {
+				addRoutedDestination(res, d, 0)
+			}
+			for _, d := range r.Escalation {
+				addRoutedDestination(res, d, r.EscalateAfter)
+			}
+		}
+		if !r.Continue {
+			break
+		}
+	}
+	return matched
+}
+
+func addRoutedDestination(res *[]RoutedDestination, d NotificationRouteDestination, escalateAfter timeseries.Duration) {
+	dest := IncidentNotificationDestination{IntegrationType: d.IntegrationType}
+	switch d.IntegrationType {
+	case IntegrationTypeSlack:
+		dest.SlackChannel = d.SlackChannel
+	case IntegrationTypeEmail:
+		dest.EmailTo = strings.Join(d.EmailTo, ",")
+	}
+	for i := range *res {
+		if (*res)[i].Destination == dest {
+			(*res)[i].EscalateAfter = min((*res)[i].EscalateAfter, escalateAfter)
+			return
+		}
+	}
+	*res = append(*res, RoutedDestination{Destination: dest, EscalateAfter: escalateAfter})
+}
+
+func (m NotificationRouteMatch) matches(t NotificationTarget) bool {
+	if len(m.ApplicationIds) > 0 && !utils.GlobMatch(t.ApplicationId.String(), m.ApplicationIds...) {
+		return false
+	}
+	if len(m.Namespaces) > 0 && !utils.GlobMatch(t.ApplicationId.Namespace, m.Namespaces...) {
+		return false
+	}
+	if len(m.Categories) > 0 && !slices.Contains(m.Categories, t.Category) {
+		return false
+	}
+	if t.Resolved {
+		return true
+	}
+	if len(m.Severities) > 0 && !slices.Contains(m.Severities, t.Severity) {
+		return false
+	}
+	if m.TimeWindow != nil && !m.TimeWindow.IsActive(t.Time) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationRouting(t *testing.T) {
+	slack := func(channel string) NotificationRouteDestination {
+		return NotificationRouteDestination{IntegrationType: IntegrationTypeSlack, SlackChannel: channel}
+	}
+	pagerduty := NotificationRouteDestination{IntegrationType: IntegrationTypePagerduty}
+
+	routing := &NotificationRouting{Routes: []*NotificationRoute{
+		{
+			Name:         "databases",
+			Match:        NotificationRouteMatch{Namespaces: []string{"db-*"}},
+			Destinations: []NotificationRouteDestination{slack("dba")},
+			Routes: []*NotificationRoute{
+				{
+					Match:         NotificationRouteMatch{Severities: []model.Status{model.CRITICAL}},
+					Destinations:  []NotificationRouteDestination{slack("dba-critical")},
+					Escalation:    []NotificationRouteDestination{pagerduty},
+					EscalateAfter: 15 * timeseries.Minute,
+				},
+			},
+		},
+		{
+			Name:         "control-plane",
+			Match:        NotificationRouteMatch{Categories: []model.ApplicationCategory{"control-plane"}},
+			Destinations: []NotificationRouteDestination{slack("platform")},
+			Continue:     true,
+		},
+		{
+			Name:         "everything",
+			Destinations: []NotificationRouteDestination{slack("alerts")},
+		},
+	}}
+	assert.NoError(t, routing.Validate())
+
+	route := func(ns string, category model.ApplicationCategory, severity model.Status, resolved bool) string {
+		ds, matched := routing.Route(NotificationTarget{
+			ApplicationId: model.NewApplicationId(ns, model.ApplicationKindDeployment, "app"),
+			Category:      category,
+			Severity:      severity,
+			Resolved:      resolved,
+		})
+		if !matched {
+			return "-"
+		}
+		var res []string
+		for _, d := range ds {
+			res = append(res, fmt.Sprintf("%s:%s+%s", d.Destination.IntegrationType, d.Destination.SlackChannel, d.EscalateAfter))
+		}
+		return strings.Join(res, ";")
+	}
+
+	assert.Equal(t, "slack:dba+0s", route("db-main", "application", model.WARNING, false))
+	assert.Equal(t, "slack:dba-critical+0s;pagerduty:+15m", route("db-main", "application", model.CRITICAL, false))
+	assert.Equal(t, "slack:dba-critical+0s;pagerduty:+0s;slack:dba+0s", route("db-main", "application", model.OK, true))
+	assert.Equal(t, "slack:platform+0s;slack:alerts+0s", route("kube-system", "control-plane", model.WARNING, false))
+	assert.Equal(t, "slack:alerts+0s", route("default", "application", model.WARNING, false))
+
+	routing.Routes = routing.Routes[:2]
+	assert.Equal(t, "-", route("default", "application", model.WARNING, false))
+
+	var empty *NotificationRouting
+	_, matched := empty.Route(NotificationTarget{})
+	assert.False(t, matched)
+
+	routing.Routes[0].Routes[0].EscalateAfter = 0
+	assert.Error(t, routing.Validate())
+}
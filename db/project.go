@@ -33,20 +33,49 @@ type ProjectSettings struct {
 	CustomApplications          map[string]model.CustomApplication                         `json:"custom_applications"`
 	ApiKeys                     []ApiKey                                                   `json:"api_keys"`
 	CustomCloudPricing          *CustomCloudPricing                                        `json:"custom_cloud_pricing"`
+	NotificationRouting         *NotificationRouting                                       `json:"notification_routing,omitempty"`
+	IngestionQuotas             *IngestionQuotas                                           `json:"ingestion_quotas,omitempty"`
+	IngestPipeline              *IngestPipeline                                            `json:"ingest_pipeline,omitempty"`
+	LogMetrics                  []LogMetric                                                `json:"log_metrics,omitempty"`
 }
 
 type ApiKey struct {
-	Key         string `json:"key" yaml:"key"`
-	Description string `json:"description" yaml:"description"`
+	Key         string           `json:"key" yaml:"key"`
+	Description string           `json:"description" yaml:"description"`
+	Quotas      *IngestionQuotas `json:"quotas,omitempty" yaml:"quotas"`
 }
 
 func (k *ApiKey) Validate() error {
 	if k.Key == "" {
 		return fmt.Errorf("key is required")
 	}
+	if k.Quotas != nil {
+		if err := k.Quotas.Validate(); err != nil {
+			return fmt.Errorf("invalid quotas: %w", err)
+		}
+	}
 	return nil
 }
 
+// IngestionQuotas limits the telemetry data accepted by the collector, zero means unlimited.
+type IngestionQuotas struct {
+	SpansPerSecond   int64 `json:"spans_per_second" yaml:"spansPerSecond"`
+	LogsPerSecond    int64 `json:"logs_per_second" yaml:"logsPerSecond"`
+	SamplesPerSecond int64 `json:"samples_per_second" yaml:"samplesPerSecond"`
+	BytesPerDay      int64 `json:"bytes_per_day" yaml:"bytesPerDay"`
+}
+
+func (q *IngestionQuotas) Validate() error {
+	if q.SpansPerSecond < 0 || q.LogsPerSecond < 0 || q.SamplesPerSecond < 0 || q.BytesPerDay < 0 {
+		return fmt.Errorf("quotas must not be negative")
+	}
+	return nil
+}
+
+func (q *IngestionQuotas) IsEmpty() bool {
+	return q == nil || *q == IngestionQuotas{}
+}
+
 func (p *Project) Migrate(m *Migrator) error {
 	err := m.Exec(`
 	CREATE TABLE IF NOT EXISTS project (
@@ -223,6 +252,12 @@ func (db *DB) DeleteProject(id ProjectId) error {
 	if _, err = tx.Exec("DELETE FROM incident_notification WHERE project_id = $1", id); err != nil {
 		return err
 	}
+	if _, err = tx.Exec("DELETE FROM notification_delivery WHERE project_id = $1", id); err != nil {
+		return err
+	}
+	if _, err = tx.Exec("DELETE FROM incident_event WHERE project_id = $1", id); err != nil {
+		return err
+	}
 	if _, err = tx.Exec("DELETE FROM incident WHERE project_id = $1", id); err != nil {
 		return err
 	}
@@ -235,6 +270,24 @@ func (db *DB) DeleteProject(id ProjectId) error {
 	if _, err = tx.Exec("DELETE FROM dashboards WHERE project_id = $1", id); err != nil {
 		return err
 	}
+	if _, err = tx.Exec("DELETE FROM alert WHERE project_id = $1", id); err != nil {
+		return err
+	}
+	if _, err = tx.Exec("DELETE FROM alert_rule WHERE project_id = $1", id); err != nil {
+		return err
+	}
+	if _, err = tx.Exec("DELETE FROM silence WHERE project_id = $1", id); err != nil {
+		return err
+	}
+	if _, err = tx.Exec("DELETE FROM slo_daily WHERE project_id = $1", id); err != nil {
+		return err
+	}
+	if _, err = tx.Exec("DELETE FROM slo WHERE project_id = $1", id); err != nil {
+		return err
+	}
+	if _, err = tx.Exec("DELETE FROM log_export WHERE project_id = $1", id); err != nil {
+		return err
+	}
 	if _, err = tx.Exec("DELETE FROM project WHERE id = $1", id); err != nil {
 		return err
 	}
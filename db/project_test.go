@@ -0,0 +1,30 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteProject(t *testing.T) {
+	database, err := NewSqlite(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, database.Migrate())
+	p := &Project{Name: "test"}
+	require.NoError(t, database.SaveProject(p))
+
+	e := &LogExport{
+		User:          "admin",
+		ApplicationId: model.NewApplicationId("default", model.ApplicationKindDeployment, "app"),
+		Format:        "ndjson",
+		StartedAt:     timeseries.Now(),
+	}
+	require.NoError(t, database.CreateLogExport(p.Id, e))
+
+	require.NoError(t, database.DeleteProject(p.Id))
+	_, err = database.GetProject(p.Id)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,313 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/coroot/coroot/utils"
+)
+
+type Silences struct{}
+
+func (s *Silences) Migrate(m *Migrator) error {
+	return m.Exec(`
+	CREATE TABLE IF NOT EXISTS silence (
+		project_id TEXT NOT NULL REFERENCES project(id),
+		id TEXT NOT NULL,
+		config TEXT NOT NULL DEFAULT '{}',
+		PRIMARY KEY (project_id, id)
+	)`)
+}
+
+type SilenceMatcherName string
+
+const (
+	SilenceMatcherApplicationId SilenceMatcherName = "application_id"
+	SilenceMatcherCategory      SilenceMatcherName = "category"
+	SilenceMatcherNamespace     SilenceMatcherName = "namespace"
+	SilenceMatcherCheckId       SilenceMatcherName = "check_id"
+)
+
+type Silence struct {
+	Id         string               `json:"id"`
+	Comment    string               `json:"comment"`
+	Matchers   []SilenceMatcher     `json:"matchers"`
+	StartsAt   timeseries.Time      `json:"starts_at"`
+	EndsAt     timeseries.Time      `json:"ends_at"`
+	Recurrence *RecurringTimeWindow `json:"recurrence,omitempty"` // turns the silence into a maintenance window
+	CreatedBy  string               `json:"created_by"`
+	CreatedAt  timeseries.Time      `json:"created_at"`
+}
+
+type SilenceMatcher struct {
+	Name  SilenceMatcherName `json:"name"`
+	Op    string             `json:"op"`
+	Value string             `json:"value"`
+}
+
+// RecurringTimeWindow is active for Duration starting at Start (HH:MM in Timezone)
+// on the given weekdays (every day if empty).
+type RecurringTimeWindow struct {
+	Weekdays []time.Weekday      `json:"weekdays"`
+	Start    string              `json:"start"`
+	Duration timeseries.Duration `json:"duration"`
+	Timezone string              `json:"timezone"`
+}
+
+type SilenceTarget struct {
+	ApplicationId model.ApplicationId       `json:"application_id"`
+	Category      model.ApplicationCategory `json:"category"`
+	CheckIds      []model.CheckId           `json:"check_ids"`
+}
+
+func NewIncidentSilenceTarget(app *model.Application, incident *model.ApplicationIncident) SilenceTarget {
+	t := SilenceTarget{ApplicationId: app.Id, Category: app.Category}
+	if len(incident.Details.AvailabilityBurnRates) > 0 {
+		t.CheckIds = append(t.CheckIds, model.Checks.SLOAvailability.Id)
+	}
+	if len(incident.Details.LatencyBurnRates) > 0 {
+		t.CheckIds = append(t.CheckIds, model.Checks.SLOLatency.Id)
+	}
+	for _, r := range app.Reports {
+		for _, ch := range r.Checks {
+			if ch.Status >= model.WARNING && !slices.Contains(t.CheckIds, ch.Id) {
+				t.CheckIds = append(t.CheckIds, ch.Id)
+			}
+		}
+	}
+	return t
+}
+
+func (s *Silence) Validate() error {
+	if len(s.Matchers) == 0 {
+		return fmt.Errorf("at least one matcher is required")
+	}
+	for _, m := range s.Matchers {
+		switch m.Name {
+		case SilenceMatcherApplicationId, SilenceMatcherCategory, SilenceMatcherNamespace, SilenceMatcherCheckId:
+		default:
+			return fmt.Errorf("unknown matcher: %s", m.Name)
+		}
+		switch m.Op {
+		case "=", "!=":
+		case "~", "!~":
+			if _, err := regexp.Compile(m.Value); err != nil {
+				return fmt.Errorf("invalid regexp: %w", err)
+			}
+		default:
+			return fmt.Errorf("invalid matcher operator: %s", m.Op)
+		}
+	}
+	if s.StartsAt.IsZero() {
+		return fmt.Errorf("start time is required")
+	}
+	if !s.EndsAt.IsZero() && !s.EndsAt.After(s.StartsAt) {
+		return fmt.Errorf("end time must be after start time")
+	}
+	if s.Recurrence != nil {
+		if err := s.Recurrence.Validate(); err != nil {
+			return err
+		}
+	} else if s.EndsAt.IsZero() {
+		return fmt.Errorf("end time is required")
+	}
+	return nil
+}
+
+func (s *Silence) Matches(t SilenceTarget) bool {
+	for _, m := range s.Matchers {
+		var values []string
+		switch m.Name {
+		case SilenceMatcherApplicationId:
+			values = []string{t.ApplicationId.String()}
+		case SilenceMatcherCategory:
+			values = []string{string(t.Category)}
+		case SilenceMatcherNamespace:
+			values = []string{t.ApplicationId.Namespace}
+		case SilenceMatcherCheckId:
+			for _, id := range t.CheckIds {
+				values = append(values, string(id))
+			}
+		default:
+			return false
+		}
+		if !m.matches(values) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m SilenceMatcher) matches(values []string) bool {
+	var re *regexp.Regexp
+	if m.Op == "~" || m.Op == "!~" {
+		var err error
+		if re, err = regexp.Compile("^(?:" + m.Value + ")$"); err != nil {
+			return false
+		}
+	}
+	found := false
+	for _, v := range values {
+		if re != nil {
+			found = re.MatchString(v)
+		} else {
+			found = v == m.Value
+		}
+		if found {
+			break
+		}
+	}
+	switch m.Op {
+	case "=", "~":
+		return found
+	case "!=", "!~":
+		return !found
+	}
+	return false
+}
+
+func (s *Silence) IsActive(t timeseries.Time) bool {
+	return s.ActiveDuring(t, t)
+}
+
+func (s *Silence) ActiveDuring(from, to timeseries.Time) bool {
+	if from.Before(s.StartsAt) {
+		from = s.StartsAt
+	}
+	if !s.EndsAt.IsZero() && !to.Before(s.EndsAt) {
+		to = s.EndsAt.Add(-1)
+	}
+	if to.Before(from) {
+		return false
+	}
+	if s.Recurrence == nil {
+		return true
+	}
+	return s.Recurrence.ActiveDuring(from, to)
+}
+
+func (w *RecurringTimeWindow) Validate() error {
+	if _, err := time.Parse("15:04", w.Start); err != nil {
+		return fmt.Errorf("invalid window start: %s", w.Start)
+	}
+	if w.Duration <= 0 || w.Duration > 24*timeseries.Hour {
+		return fmt.Errorf("window duration must be between 0 and 24h")
+	}
+	if _, err := time.LoadLocation(w.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone: %s", w.Timezone)
+	}
+	for _, d := range w.Weekdays {
+		if d < time.Sunday || d > time.Saturday {
+			return fmt.Errorf("invalid weekday: %d", d)
+		}
+	}
+	return nil
+}
+
+func (w *RecurringTimeWindow) IsActive(t timeseries.Time) bool {
+	return w.ActiveDuring(t, t)
+}
+
+func (w *RecurringTimeWindow) ActiveDuring(from, to timeseries.Time) bool {
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return false
+	}
+	start, err := time.Parse("15:04", w.Start)
+	if err != nil {
+		return false
+	}
+	f := from.ToStandard().In(loc)
+	day := time.Date(f.Year(), f.Month(), f.Day()-1, 0, 0, 0, 0, loc)
+	for ; !day.After(to.ToStandard()); day = day.AddDate(0, 0, 1) {
+		if len(w.Weekdays) > 0 && !slices.Contains(w.Weekdays, day.Weekday()) {
+			continue
+		}
+		ws := timeseries.Time(time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, loc).Unix())
+		we := ws.Add(w.Duration)
+		if !ws.After(to) && we.After(from) {
+			return true
+		}
+	}
+	return false
+}
+
+func (db *DB) GetSilences(projectId ProjectId) ([]*Silence, error) {
+	rows, err := db.db.Query("SELECT id, config FROM silence WHERE project_id = $1", projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*Silence
+	for rows.Next() {
+		var id, config string
+		if err = rows.Scan(&id, &config); err != nil {
+			return nil, err
+		}
+		s := &Silence{}
+		if err = json.Unmarshal([]byte(config), s); err != nil {
+			return nil, err
+		}
+		s.Id = id
+		res = append(res, s)
+	}
+	slices.SortFunc(res, func(a, b *Silence) int {
+		return int(b.StartsAt - a.StartsAt)
+	})
+	return res, nil
+}
+
+func (db *DB) GetSilence(projectId ProjectId, id string) (*Silence, error) {
+	var config string
+	err := db.db.QueryRow("SELECT config FROM silence WHERE project_id = $1 AND id = $2", projectId, id).Scan(&config)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	s := &Silence{}
+	if err = json.Unmarshal([]byte(config), s); err != nil {
+		return nil, err
+	}
+	s.Id = id
+	return s, nil
+}
+
+func (db *DB) CreateSilence(projectId ProjectId, s *Silence) (string, error) {
+	s.Id = utils.NanoId(8)
+	config, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	_, err = db.db.Exec("INSERT INTO silence (project_id, id, config) VALUES ($1, $2, $3)", projectId, s.Id, string(config))
+	return s.Id, err
+}
+
+func (db *DB) UpdateSilence(projectId ProjectId, id string, s *Silence) error {
+	s.Id = id
+	config, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	res, err := db.db.Exec("UPDATE silence SET config = $1 WHERE project_id = $2 AND id = $3", string(config), projectId, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (db *DB) DeleteSilence(projectId ProjectId, id string) error {
+	_, err := db.db.Exec("DELETE FROM silence WHERE project_id = $1 AND id = $2", projectId, id)
+	return err
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSilence(t *testing.T) {
+	ts := func(s string) timeseries.Time {
+		tt, err := time.Parse(time.RFC3339, s)
+		assert.NoError(t, err)
+		return timeseries.Time(tt.Unix())
+	}
+
+	s := &Silence{
+		Matchers: []SilenceMatcher{
+			{Name: SilenceMatcherNamespace, Op: "=", Value: "db"},
+			{Name: SilenceMatcherCheckId, Op: "!~", Value: "SLO.*"},
+		},
+		StartsAt: ts("2024-05-01T10:00:00Z"),
+		EndsAt:   ts("2024-05-01T12:00:00Z"),
+	}
+	assert.NoError(t, s.Validate())
+
+	target := SilenceTarget{ApplicationId: model.NewApplicationId("db", model.ApplicationKindStatefulSet, "pg")}
+	assert.True(t, s.Matches(target))
+	target.CheckIds = []model.CheckId{model.Checks.CPUContainer.Id}
+	assert.True(t, s.Matches(target))
+	target.CheckIds = append(target.CheckIds, model.Checks.SLOLatency.Id)
+	assert.False(t, s.Matches(target))
+	target = SilenceTarget{ApplicationId: model.NewApplicationId("default", model.ApplicationKindDeployment, "api")}
+	assert.False(t, s.Matches(target))
+
+	assert.False(t, s.IsActive(ts("2024-05-01T09:59:59Z")))
+	assert.True(t, s.IsActive(ts("2024-05-01T10:00:00Z")))
+	assert.True(t, s.IsActive(ts("2024-05-01T11:59:59Z")))
+	assert.False(t, s.IsActive(ts("2024-05-01T12:00:00Z")))
+	assert.True(t, s.ActiveDuring(ts("2024-05-01T09:00:00Z"), ts("2024-05-01T10:30:00Z")))
+
+	// every Saturday from 23:00 to 01:00 (Europe/Berlin, UTC+2 in summer)
+	s = &Silence{
+		Matchers: []SilenceMatcher{{Name: SilenceMatcherCategory, Op: "=", Value: "application"}},
+		StartsAt: ts("2024-05-01T00:00:00Z"),
+		Recurrence: &RecurringTimeWindow{
+			Weekdays: []time.Weekday{time.Saturday},
+			Start:    "23:00",
+			Duration: 2 * timeseries.Hour,
+			Timezone: "Europe/Berlin",
+		},
+	}
+	assert.NoError(t, s.Validate())
+	assert.False(t, s.IsActive(ts("2024-05-04T20:59:59Z")))
+	assert.True(t, s.IsActive(ts("2024-05-04T21:00:00Z")))
+	assert.True(t, s.IsActive(ts("2024-05-04T22:59:59Z")))
+	assert.False(t, s.IsActive(ts("2024-05-04T23:00:00Z")))
+	assert.False(t, s.IsActive(ts("2024-05-05T21:30:00Z")))
+	assert.True(t, s.IsActive(ts("2024-05-11T21:30:00Z")))
+	assert.True(t, s.ActiveDuring(ts("2024-05-03T00:00:00Z"), ts("2024-05-06T00:00:00Z")))
+	assert.False(t, s.ActiveDuring(ts("2024-05-05T00:00:00Z"), ts("2024-05-10T00:00:00Z")))
+
+	s.Recurrence.Start = "25:00"
+	assert.Error(t, s.Validate())
+}
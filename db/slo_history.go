@@ -0,0 +1,94 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+)
+
+const (
+	SLOAvailability = "availability"
+	SLOLatency      = "latency"
+)
+
+// SLODaily is the number of good and total events of an SLO within a day.
+// SLO is either SLOAvailability, SLOLatency or the ID of a user-defined SLO.
+type SLODaily struct {
+	ApplicationId model.ApplicationId
+	SLO           string
+	Day           timeseries.Time
+	Objective     float32
+	Good          float64
+	Total         float64
+}
+
+type SLOHistory struct{}
+
+func (h *SLOHistory) Migrate(m *Migrator) error {
+	return m.Exec(`
+	CREATE TABLE IF NOT EXISTS slo_daily (
+		project_id TEXT NOT NULL REFERENCES project(id),
+		application_id TEXT NOT NULL,
+		slo TEXT NOT NULL,
+		day INT NOT NULL,
+		objective REAL NOT NULL,
+		good REAL NOT NULL,
+		total REAL NOT NULL,
+		PRIMARY KEY (project_id, application_id, slo, day)
+	);
+	CREATE INDEX IF NOT EXISTS slo_daily_project_id_day ON slo_daily (project_id, day);
+`)
+}
+
+// SaveSLODaily replaces the aggregates of the project for the given day.
+func (db *DB) SaveSLODaily(projectId ProjectId, day timeseries.Time, items []SLODaily) error {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if _, err = tx.Exec("DELETE FROM slo_daily WHERE project_id = $1 AND day = $2", projectId, day); err != nil {
+		return err
+	}
+	for _, i := range items {
+		_, err = tx.Exec(
+			"INSERT INTO slo_daily (project_id, application_id, slo, day, objective, good, total) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+			projectId, i.ApplicationId.String(), i.SLO, day, i.Objective, i.Good, i.Total)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (db *DB) GetSLODailyLastDay(projectId ProjectId) (timeseries.Time, error) {
+	var day sql.NullInt64
+	err := db.db.QueryRow("SELECT max(day) FROM slo_daily WHERE project_id = $1", projectId).Scan(&day)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+	return timeseries.Time(day.Int64), nil
+}
+
+func (db *DB) GetSLODaily(projectId ProjectId, from, to timeseries.Time) ([]SLODaily, error) {
+	rows, err := db.db.Query(
+		"SELECT application_id, slo, day, objective, good, total FROM slo_daily WHERE project_id = $1 AND day >= $2 AND day < $3 ORDER BY day",
+		projectId, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []SLODaily
+	for rows.Next() {
+		var i SLODaily
+		if err = rows.Scan(&i.ApplicationId, &i.SLO, &i.Day, &i.Objective, &i.Good, &i.Total); err != nil {
+			return nil, err
+		}
+		res = append(res, i)
+	}
+	return res, rows.Err()
+}
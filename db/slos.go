@@ -0,0 +1,124 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/utils"
+)
+
+type SLOs struct{}
+
+func (s *SLOs) Migrate(m *Migrator) error {
+	return m.Exec(`
+	CREATE TABLE IF NOT EXISTS slo (
+		project_id TEXT NOT NULL REFERENCES project(id),
+		id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		config TEXT NOT NULL DEFAULT '{}',
+		PRIMARY KEY (project_id, id)
+	);
+`)
+}
+
+func (db *DB) GetSLOs(projectId ProjectId) ([]*model.SLO, error) {
+	rows, err := db.db.Query("SELECT id, config FROM slo WHERE project_id = $1 ORDER BY name", projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*model.SLO
+	for rows.Next() {
+		var id, config string
+		if err = rows.Scan(&id, &config); err != nil {
+			return nil, err
+		}
+		s := &model.SLO{}
+		if err = json.Unmarshal([]byte(config), s); err != nil {
+			return nil, err
+		}
+		s.Id = id
+		res = append(res, s)
+	}
+	return res, nil
+}
+
+func (db *DB) GetApplicationSLOs(projectId ProjectId, appId model.ApplicationId) ([]*model.SLO, error) {
+	slos, err := db.GetSLOs(projectId)
+	if err != nil {
+		return nil, err
+	}
+	var res []*model.SLO
+	for _, s := range slos {
+		if s.ApplicationId == appId {
+			res = append(res, s)
+		}
+	}
+	return res, nil
+}
+
+func (db *DB) GetSLO(projectId ProjectId, id string) (*model.SLO, error) {
+	var config string
+	err := db.db.QueryRow("SELECT config FROM slo WHERE project_id = $1 AND id = $2", projectId, id).Scan(&config)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	s := &model.SLO{}
+	if err = json.Unmarshal([]byte(config), s); err != nil {
+		return nil, err
+	}
+	s.Id = id
+	return s, nil
+}
+
+func (db *DB) CreateSLO(projectId ProjectId, s *model.SLO) (string, error) {
+	s.Id = utils.NanoId(8)
+	config, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	_, err = db.db.Exec("INSERT INTO slo (project_id, id, name, config) VALUES ($1, $2, $3, $4)", projectId, s.Id, s.Name, string(config))
+	return s.Id, err
+}
+
+func (db *DB) UpdateSLO(projectId ProjectId, id string, s *model.SLO) error {
+	s.Id = id
+	config, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	res, err := db.db.Exec("UPDATE slo SET name = $1, config = $2 WHERE project_id = $3 AND id = $4", s.Name, string(config), projectId, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (db *DB) DeleteSLO(projectId ProjectId, id string) error {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	res, err := tx.Exec("DELETE FROM slo WHERE project_id = $1 AND id = $2", projectId, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	if _, err = tx.Exec("DELETE FROM slo_daily WHERE project_id = $1 AND slo = $2", projectId, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -304,6 +304,30 @@ export default class Api {
         this.get(this.projectPath(`app/${encodeURIComponent(appId)}/logs`), { query }, cb);
     }
 
+    tailLogs(appId, query, onEntries, onError) {
+        const { from, to } = this.router.currentRoute.query;
+        const params = new URLSearchParams({ query });
+        from && params.set('from', from);
+        to && params.set('to', to);
+        const url = this.basePath + 'api/' + this.projectPath(`app/${encodeURIComponent(appId)}/logs/tail`) + '?' + params;
+        const source = new EventSource(url);
+        source.addEventListener('entries', (e) => onEntries(JSON.parse(e.data)));
+        // the connection isn't re-established automatically to avoid receiving duplicate entries
+        source.addEventListener('error', (e) => {
+            source.close();
+            onError(e.data ? JSON.parse(e.data) : 'Live tail connection lost');
+        });
+        return source;
+    }
+
+    logsExportUrl(appId, query, format) {
+        const { from, to } = this.router.currentRoute.query;
+        const params = new URLSearchParams({ query, format });
+        from && params.set('from', from);
+        to && params.set('to', to);
+        return this.basePath + 'api/' + this.projectPath(`app/${encodeURIComponent(appId)}/logs/export`) + '?' + params;
+    }
+
     saveLogsSettings(appId, form, cb) {
         this.post(this.projectPath(`app/${encodeURIComponent(appId)}/logs`), form, cb);
     }
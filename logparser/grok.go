@@ -0,0 +1,73 @@
+package logparser
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// grokPatterns is a subset of the Logstash grok patterns, other patterns can be written as plain regular expressions.
+var grokPatterns = map[string]string{
+	"WORD":              `\b\w+\b`,
+	"NOTSPACE":          `\S+`,
+	"SPACE":             `\s*`,
+	"DATA":              `.*?`,
+	"GREEDYDATA":        `.*`,
+	"INT":               `[+-]?\d+`,
+	"POSINT":            `\b[1-9]\d*\b`,
+	"NUMBER":            `[+-]?(?:\d+(?:\.\d*)?|\.\d+)(?:[eE][+-]?\d+)?`,
+	"BASE10NUM":         `[+-]?(?:\d+(?:\.\d*)?|\.\d+)`,
+	"IPV4":              `(?:\d{1,3}\.){3}\d{1,3}`,
+	"IPV6":              `[0-9A-Fa-f]{0,4}(?::[0-9A-Fa-f]{0,4}){2,7}`,
+	"IP":                `(?:(?:\d{1,3}\.){3}\d{1,3}|[0-9A-Fa-f]{0,4}(?::[0-9A-Fa-f]{0,4}){2,7})`,
+	"HOSTNAME":          `\b[0-9A-Za-z][0-9A-Za-z-]{0,62}(?:\.[0-9A-Za-z][0-9A-Za-z-]{0,62})*\.?\b`,
+	"UUID":              `[A-Fa-f0-9]{8}-(?:[A-Fa-f0-9]{4}-){3}[A-Fa-f0-9]{12}`,
+	"TIMESTAMP_ISO8601": `\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}(?::\d{2}(?:[.,]\d+)?)?(?:Z|[+-]\d{2}:?\d{2})?`,
+	"LOGLEVEL":          `(?i:trace|debug|info|notice|warn(?:ing)?|err(?:or)?|crit(?:ical)?|fatal|severe|emerg(?:ency)?|alert|panic)`,
+	"QUOTEDSTRING":      `(?:"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*')`,
+	"URIPATH":           `/[^\s?#]*`,
+	"URIPATHPARAM":      `/[^\s#]*`,
+	"HTTPMETHOD":        `\b(?:GET|HEAD|POST|PUT|DELETE|CONNECT|OPTIONS|TRACE|PATCH)\b`,
+}
+
+const grokGroupPrefix = "__grok"
+
+var grokRef = regexp.MustCompile(`%\{(\w+)(?::([\w.@-]+))?(?::\w+)?}`)
+
+// compileGrok converts %{PATTERN} and %{PATTERN:field} references to regex groups.
+// Field names may contain dots, which are not allowed in regex group names, so the groups are numbered.
+func compileGrok(pattern string) (*regexp.Regexp, []string, error) {
+	var err error
+	var names []string
+	expr := grokRef.ReplaceAllStringFunc(pattern, func(ref string) string {
+		m := grokRef.FindStringSubmatch(ref)
+		re, ok := grokPatterns[m[1]]
+		if !ok {
+			if err == nil {
+				err = fmt.Errorf("unknown grok pattern: %s", m[1])
+			}
+			return ref
+		}
+		if m[2] == "" {
+			return "(?:" + re + ")"
+		}
+		names = append(names, m[2])
+		return fmt.Sprintf("(?P<%s%d>%s)", grokGroupPrefix, len(names)-1, re)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, nil, err
+	}
+	fields := slices.Clone(re.SubexpNames())
+	for i, n := range fields {
+		if idx, e := strconv.Atoi(strings.TrimPrefix(n, grokGroupPrefix)); e == nil && strings.HasPrefix(n, grokGroupPrefix) && idx < len(names) {
+			fields[i] = names[idx]
+		}
+	}
+	return re, fields, nil
+}
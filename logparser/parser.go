@@ -0,0 +1,244 @@
+package logparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coroot/coroot/model"
+)
+
+const (
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+	FormatRegex  = "regex"
+	FormatGrok   = "grok"
+)
+
+var (
+	DefaultSeverityFields  = []string{"level", "severity", "lvl", "loglevel", "log.level"}
+	DefaultTimestampFields = []string{"timestamp", "time", "ts", "@timestamp"}
+)
+
+// Parser extracts fields from log message bodies.
+type Parser struct {
+	format string
+	re     *regexp.Regexp
+	fields []string // field names of the regex groups, empty for unnamed groups
+}
+
+// New returns a parser of the given format. The pattern is required for the regex and grok formats:
+// the fields are the named groups of the regex or the %{PATTERN:field} references of the grok pattern.
+func New(format, pattern string) (*Parser, error) {
+	p := &Parser{format: format}
+	switch format {
+	case FormatJSON, FormatLogfmt:
+		return p, nil
+	case FormatRegex:
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		p.re = re
+		p.fields = re.SubexpNames()
+	case FormatGrok:
+		re, fields, err := compileGrok(pattern)
+		if err != nil {
+			return nil, err
+		}
+		p.re = re
+		p.fields = fields
+	default:
+		return nil, fmt.Errorf("unknown format: %s", format)
+	}
+	hasFields := false
+	for _, f := range p.fields {
+		if f != "" {
+			hasFields = true
+		}
+	}
+	if !hasFields {
+		return nil, fmt.Errorf("the pattern must contain at least one named field")
+	}
+	return p, nil
+}
+
+// Parse returns the fields extracted from the body, or nil if the body doesn't match the format.
+func (p *Parser) Parse(body string) map[string]string {
+	switch p.format {
+	case FormatJSON:
+		return parseJSON(body)
+	case FormatLogfmt:
+		return parseLogfmt(body)
+	}
+	m := p.re.FindStringSubmatch(body)
+	if m == nil {
+		return nil
+	}
+	res := map[string]string{}
+	for i, f := range p.fields {
+		if f != "" && m[i] != "" {
+			res[f] = m[i]
+		}
+	}
+	return res
+}
+
+func parseJSON(body string) map[string]string {
+	body = strings.TrimSpace(body)
+	if !strings.HasPrefix(body, "{") {
+		return nil
+	}
+	d := json.NewDecoder(strings.NewReader(body))
+	d.UseNumber()
+	var obj map[string]any
+	if err := d.Decode(&obj); err != nil {
+		return nil
+	}
+	res := map[string]string{}
+	flattenJSON("", obj, res)
+	return res
+}
+
+func flattenJSON(prefix string, obj map[string]any, res map[string]string) {
+	for k, v := range obj {
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+		switch vv := v.(type) {
+		case nil:
+		case string:
+			res[k] = vv
+		case json.Number:
+			res[k] = vv.String()
+		case bool:
+			res[k] = strconv.FormatBool(vv)
+		case map[string]any:
+			flattenJSON(k, vv, res)
+		default:
+			buf := &bytes.Buffer{}
+			enc := json.NewEncoder(buf)
+			enc.SetEscapeHTML(false)
+			if enc.Encode(vv) == nil {
+				res[k] = strings.TrimSuffix(buf.String(), "\n")
+			}
+		}
+	}
+}
+
+// parseLogfmt requires all the space-separated tokens of the body to be key=value pairs,
+// so that plain text messages containing an occasional "=" are not parsed.
+func parseLogfmt(body string) map[string]string {
+	res := map[string]string{}
+	s := strings.TrimSpace(body)
+	for len(s) > 0 {
+		eq := strings.IndexAny(s, "= \t")
+		if eq <= 0 || s[eq] != '=' {
+			return nil
+		}
+		key := s[:eq]
+		s = s[eq+1:]
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := 1
+			for ; end < len(s); end++ {
+				if s[end] == '\\' {
+					end++
+					continue
+				}
+				if s[end] == '"' {
+					break
+				}
+			}
+			if end >= len(s) {
+				return nil
+			}
+			v, err := strconv.Unquote(s[:end+1])
+			if err != nil {
+				return nil
+			}
+			value = v
+			s = s[end+1:]
+			if len(s) > 0 && s[0] != ' ' && s[0] != '\t' {
+				return nil
+			}
+		} else {
+			end := strings.IndexAny(s, " \t")
+			if end < 0 {
+				end = len(s)
+			}
+			value = s[:end]
+			s = s[end:]
+		}
+		if value != "" {
+			res[key] = value
+		}
+		s = strings.TrimLeft(s, " \t")
+	}
+	if len(res) == 0 {
+		return nil
+	}
+	return res
+}
+
+// Severity converts the commonly used level names to the severity, it returns SeverityUnknown if the level is not recognized.
+func Severity(level string) model.Severity {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "trace", "trc":
+		return model.SeverityTrace
+	case "debug", "dbg":
+		return model.SeverityDebug
+	case "info", "inf", "information", "notice":
+		return model.SeverityInfo
+	case "warn", "warning", "wrn":
+		return model.SeverityWarning
+	case "error", "err", "eror", "severe":
+		return model.SeverityError
+	case "fatal", "critical", "crit", "panic", "alert", "emerg", "emergency":
+		return model.SeverityFatal
+	}
+	return model.SeverityUnknown
+}
+
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05,999999999",
+}
+
+// Timestamp parses the value using the layout if specified, otherwise it tries the common formats
+// and Unix timestamps in seconds, milliseconds, microseconds or nanoseconds. Timestamps without a zone are UTC.
+func Timestamp(value, layout string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if layout != "" {
+		t, err := time.Parse(layout, value)
+		return t, err == nil
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		switch {
+		case f <= 0:
+			return time.Time{}, false
+		case f < 1e11:
+			return time.Unix(0, int64(f*1e9)), true
+		case f < 1e14:
+			return time.Unix(0, int64(f*1e6)), true
+		case f < 1e17:
+			return time.Unix(0, int64(f*1e3)), true
+		default:
+			return time.Unix(0, int64(f)), true
+		}
+	}
+	for _, l := range timestampLayouts {
+		if t, err := time.Parse(l, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
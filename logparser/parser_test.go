@@ -0,0 +1,72 @@
+package logparser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coroot/coroot/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser(t *testing.T) {
+	parse := func(format, pattern, body string) map[string]string {
+		p, err := New(format, pattern)
+		require.NoError(t, err)
+		return p.Parse(body)
+	}
+
+	assert.Equal(t,
+		map[string]string{"msg": "done", "ok": "true", "took": "1.5", "req.id": "abc", "tags": `["a","b"]`},
+		parse(FormatJSON, "", ` {"msg":"done","ok":true,"took":1.5,"req":{"id":"abc"},"tags":["a","b"],"err":null}`))
+	assert.Nil(t, parse(FormatJSON, "", `done {"a":1}`))
+	assert.Nil(t, parse(FormatJSON, "", `{"a":`))
+
+	assert.Equal(t,
+		map[string]string{"level": "info", "msg": `request "done"`, "duration": "12ms"},
+		parse(FormatLogfmt, "", `level=info msg="request \"done\"" duration=12ms empty=`))
+	assert.Nil(t, parse(FormatLogfmt, "", `connected to db=orders`))
+	assert.Nil(t, parse(FormatLogfmt, "", `msg="unterminated`))
+
+	assert.Equal(t,
+		map[string]string{"method": "GET", "status": "200"},
+		parse(FormatRegex, `^(?P<method>\w+) \S+ (?P<status>\d+)(?: (?P<size>\d+))?$`, "GET /api 200"))
+	assert.Nil(t, parse(FormatRegex, `^(?P<method>\w+) (?P<status>\d+)$`, "oops"))
+
+	assert.Equal(t,
+		map[string]string{"client.ip": "10.0.0.1", "http.method": "POST", "http.path": "/orders?id=1", "status": "201"},
+		parse(FormatGrok, `%{IP:client.ip} "%{HTTPMETHOD:http.method} %{URIPATHPARAM:http.path}" (?P<status>\d+) %{NUMBER}`, `10.0.0.1 "POST /orders?id=1" 201 0.5`))
+
+	for _, c := range []struct{ format, pattern string }{
+		{"xml", ""},
+		{FormatRegex, `(\d+)`},
+		{FormatRegex, `(?P<a>`},
+		{FormatGrok, `%{UNKNOWN:a}`},
+		{FormatGrok, `%{WORD}`},
+	} {
+		_, err := New(c.format, c.pattern)
+		assert.Error(t, err, c)
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	assert.Equal(t, model.SeverityWarning, Severity("WARN"))
+	assert.Equal(t, model.SeverityError, Severity("err"))
+	assert.Equal(t, model.SeverityFatal, Severity("Critical"))
+	assert.Equal(t, model.SeverityUnknown, Severity("30"))
+}
+
+func TestTimestamp(t *testing.T) {
+	expected := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, v := range []string{"2025-01-02T03:04:05Z", "2025-01-02T05:04:05+02:00", "2025-01-02 03:04:05", "1735787045", "1735787045000", "1735787045000000", "1735787045000000000"} {
+		ts, ok := Timestamp(v, "")
+		require.True(t, ok, v)
+		assert.True(t, expected.Equal(ts), v)
+	}
+	ts, ok := Timestamp("02/Jan/2025:03:04:05 +0000", "02/Jan/2006:15:04:05 -0700")
+	require.True(t, ok)
+	assert.True(t, expected.Equal(ts))
+
+	_, ok = Timestamp("yesterday", "")
+	assert.False(t, ok)
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/coroot/coroot/config"
 	"github.com/coroot/coroot/db"
 	"github.com/coroot/coroot/grpc"
+	"github.com/coroot/coroot/notifications"
 	"github.com/coroot/coroot/rbac"
 	"github.com/coroot/coroot/stats"
 	"github.com/coroot/coroot/utils"
@@ -117,7 +118,16 @@ func main() {
 		LogsTTL:     cfg.Logs.TTL,
 		ProfilesTTL: cfg.Profiles.TTL,
 		MetricsTTL:  cfg.Metrics.TTL,
+
+		MetricsTTL5m: cfg.Metrics.TTL5m,
+		MetricsTTL1h: cfg.Metrics.TTL1h,
+	}
+	if cfg.CollectorWAL.Enabled {
+		collConfig.WALDir = path.Join(cfg.DataDir, "collector-wal")
+		collConfig.WALMaxSize = int64(cfg.CollectorWAL.MaxSizeMB) << 20
 	}
+	collConfig.SyslogListenAddress = cfg.CollectorSyslog.ListenAddress
+	collConfig.SyslogApiKey = cfg.CollectorSyslog.ApiKey
 	coll := collector.New(collConfig, database, promCache, globalClickhouse, globalPrometheus, grpcServer)
 
 	go func() {
@@ -134,15 +144,19 @@ func main() {
 		klog.Exitln(err)
 	}
 
-	a := api.NewApi(promCache, database, coll, pricing, rbac.NewStaticRoleManager(), nil, globalClickhouse, globalPrometheus, deploymentUuid, instanceUuid, nil)
+	notifier := notifications.NewIncidentNotifier(database)
+	a := api.NewApi(promCache, database, coll, pricing, rbac.NewStaticRoleManager(), nil, notifier, globalClickhouse, globalPrometheus, deploymentUuid, instanceUuid, nil)
 	err = a.AuthInit(cfg.Auth.AnonymousRole, cfg.Auth.BootstrapAdminPassword)
 	if err != nil {
 		klog.Exitln(err)
 	}
 
-	incidents := watchers.NewIncidents(database, a.IncidentRCA)
+	incidents := watchers.NewIncidents(database, notifier, a.IncidentRCA, a.GetClickhouseClient)
+	alerts := watchers.NewAlerts(database, notifier, a.GetClickhouseClient)
+	sloHistory := watchers.NewSLOHistory(database, a.GetClickhouseClient)
+	logMetrics := watchers.NewLogMetrics(a.GetClickhouseClient, coll.WriteMetrics)
 
-	watchers.Start(database, promCache, pricing, incidents, !cfg.DoNotCheckForDeployments, globalClickhouse, cfg.ClickHouseSpaceManager)
+	watchers.Start(database, promCache, pricing, incidents, alerts, sloHistory, logMetrics, !cfg.DoNotCheckForDeployments, globalClickhouse, cfg.ClickHouseSpaceManager)
 
 	statsCollector := stats.NewCollector(cfg.DisableUsageStatistics, instanceUuid, version, Edition, database, promCache, pricing, globalClickhouse)
 
@@ -156,6 +170,9 @@ func main() {
 	router.HandleFunc("/v1/logs", coll.Logs)
 	router.HandleFunc("/v1/profiles", coll.Profiles)
 	router.HandleFunc("/v1/config", coll.Config)
+	router.HandleFunc("/loki/api/v1/push", coll.LokiPush).Methods(http.MethodPost)
+	router.HandleFunc("/api/v2/spans", coll.ZipkinSpans).Methods(http.MethodPost)
+	router.HandleFunc("/api/traces", coll.JaegerTraces).Methods(http.MethodPost)
 
 	r := router
 	if cfg.UrlBasePath != "/" {
@@ -165,6 +182,9 @@ func main() {
 		r.HandleFunc("/v1/logs", coll.Logs)
 		r.HandleFunc("/v1/profiles", coll.Profiles)
 		r.HandleFunc("/v1/config", coll.Config)
+		r.HandleFunc("/loki/api/v1/push", coll.LokiPush).Methods(http.MethodPost)
+		r.HandleFunc("/api/v2/spans", coll.ZipkinSpans).Methods(http.MethodPost)
+		r.HandleFunc("/api/traces", coll.JaegerTraces).Methods(http.MethodPost)
 	}
 	r.UseEncodedPath()
 	r.HandleFunc("/api/login", a.Login).Methods(http.MethodPost)
@@ -180,11 +200,24 @@ func main() {
 	r.HandleFunc("/api/project/{project}", a.Auth(a.Project)).Methods(http.MethodGet, http.MethodPost, http.MethodDelete)
 	r.HandleFunc("/api/project/{project}/status", a.Auth(a.Status)).Methods(http.MethodGet)
 	r.HandleFunc("/api/project/{project}/api_keys", a.Auth(a.ApiKeys)).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/ingestion_quotas", a.Auth(a.IngestionQuotas)).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/ingest_pipeline", a.Auth(a.IngestPipeline)).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/log_metrics", a.Auth(a.LogMetrics)).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/log_exports", a.Auth(a.LogExports)).Methods(http.MethodGet)
 	r.HandleFunc("/api/project/{project}/overview/{view}", a.Auth(a.Overview)).Methods(http.MethodGet)
 	r.HandleFunc("/api/project/{project}/incidents", a.Auth(a.Incidents)).Methods(http.MethodGet)
-	r.HandleFunc("/api/project/{project}/incident/{incident}", a.Auth(a.Incident)).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/incident/{incident}", a.Auth(a.Incident)).Methods(http.MethodGet, http.MethodPost)
 	r.HandleFunc("/api/project/{project}/dashboards", a.Auth(a.Dashboards)).Methods(http.MethodGet, http.MethodPost)
 	r.HandleFunc("/api/project/{project}/dashboards/{dashboard}", a.Auth(a.Dashboards)).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/alert_rules", a.Auth(a.AlertRules)).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/alert_rules/{rule}", a.Auth(a.AlertRules)).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/slos", a.Auth(a.SLOs)).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/slos/{slo}", a.Auth(a.SLOs)).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/slo_history", a.Auth(a.SLOHistory)).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/silences", a.Auth(a.Silences)).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/silences/{silence}", a.Auth(a.Silences)).Methods(http.MethodPost)
+	r.HandleFunc("/api/project/{project}/notifications", a.Auth(a.Notifications)).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/notification_routing", a.Auth(a.NotificationRouting)).Methods(http.MethodGet, http.MethodPost, http.MethodDelete)
 	r.HandleFunc("/api/project/{project}/panel/data", a.Auth(a.PanelData)).Methods(http.MethodGet)
 	r.HandleFunc("/api/project/{project}/inspections", a.Auth(a.Inspections)).Methods(http.MethodGet)
 	r.HandleFunc("/api/project/{project}/application_categories", a.Auth(a.ApplicationCategories)).Methods(http.MethodGet, http.MethodPost)
@@ -199,9 +232,12 @@ func main() {
 	r.HandleFunc("/api/project/{project}/app/{app}/profiling", a.Auth(a.Profiling)).Methods(http.MethodGet, http.MethodPost)
 	r.HandleFunc("/api/project/{project}/app/{app}/tracing", a.Auth(a.Tracing)).Methods(http.MethodGet, http.MethodPost)
 	r.HandleFunc("/api/project/{project}/app/{app}/logs", a.Auth(a.Logs)).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/project/{project}/app/{app}/logs/tail", a.Auth(a.LogsTail)).Methods(http.MethodGet)
+	r.HandleFunc("/api/project/{project}/app/{app}/logs/export", a.Auth(a.LogsExport)).Methods(http.MethodGet)
 	r.HandleFunc("/api/project/{project}/app/{app}/risks", a.Auth(a.Risks)).Methods(http.MethodPost)
 	r.HandleFunc("/api/project/{project}/node/{node}", a.Auth(a.Node)).Methods(http.MethodGet)
-	r.PathPrefix("/api/project/{project}/prom/api/v1/{rest:.+}").HandlerFunc(a.Auth(a.Prom))
+	r.HandleFunc("/api/project/{project}/prom/federate", a.AuthWithApiKey(a.PromFederate)).Methods(http.MethodGet, http.MethodPost)
+	r.PathPrefix("/api/project/{project}/prom/api/v1/{rest:.+}").HandlerFunc(a.AuthWithApiKey(a.Prom))
 
 	r.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
 		statsCollector.RegisterRequest(r)
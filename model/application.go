@@ -30,6 +30,7 @@ type Application struct {
 
 	LatencySLIs      []*LatencySLI
 	AvailabilitySLIs []*AvailabilitySLI
+	CustomSLIs       []*CustomSLI
 
 	Events      []*ApplicationEvent
 	Deployments []*ApplicationDeployment
@@ -99,6 +99,21 @@ type ApplicationDeploymentNotifications struct {
 	Webhook struct {
 		State ApplicationDeploymentState `json:"state"`
 	} `json:"webhook"`
+	Email struct {
+		State ApplicationDeploymentState `json:"state"`
+	} `json:"email"`
+	Mattermost struct {
+		State ApplicationDeploymentState `json:"state"`
+	} `json:"mattermost"`
+	Discord struct {
+		State ApplicationDeploymentState `json:"state"`
+	} `json:"discord"`
+	GoogleChat struct {
+		State ApplicationDeploymentState `json:"state"`
+	} `json:"googlechat"`
+	Telegram struct {
+		State ApplicationDeploymentState `json:"state"`
+	} `json:"telegram"`
 }
 
 type ApplicationDeploymentSummary struct {
@@ -12,10 +12,17 @@ type Impact struct {
 }
 
 type IncidentDetails struct {
-	AvailabilityBurnRates []BurnRate `json:"availability_burn_rates"`
-	LatencyBurnRates      []BurnRate `json:"latency_burn_rates"`
-	AvailabilityImpact    Impact     `json:"availability_impact"`
-	LatencyImpact         Impact     `json:"latency_impact"`
+	AvailabilityBurnRates []BurnRate           `json:"availability_burn_rates"`
+	LatencyBurnRates      []BurnRate           `json:"latency_burn_rates"`
+	CustomSLOBurnRates    []CustomSLOBurnRates `json:"custom_slo_burn_rates,omitempty"`
+	AvailabilityImpact    Impact               `json:"availability_impact"`
+	LatencyImpact         Impact               `json:"latency_impact"`
+}
+
+type CustomSLOBurnRates struct {
+	SLOId     string     `json:"slo_id"`
+	Name      string     `json:"name"`
+	BurnRates []BurnRate `json:"burn_rates"`
 }
 
 type RCA struct {
@@ -73,12 +80,25 @@ type ApplicationIncident struct {
 	Severity      Status          `json:"severity"`
 	Details       IncidentDetails `json:"details"`
 	RCA           *RCA            `json:"rca"`
+	Lifecycle     Lifecycle       `json:"lifecycle"`
+}
+
+// Lifecycle holds the state of the incident managed by users, as opposed to the state detected by Coroot.
+type Lifecycle struct {
+	AcknowledgedAt timeseries.Time `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy string          `json:"acknowledged_by,omitempty"`
+	Assignee       string          `json:"assignee,omitempty"`
+	ClosedBy       string          `json:"closed_by,omitempty"`
 }
 
 func (i *ApplicationIncident) Resolved() bool {
 	return !i.ResolvedAt.IsZero()
 }
 
+func (i *ApplicationIncident) Acknowledged() bool {
+	return !i.Lifecycle.AcknowledgedAt.IsZero()
+}
+
 func (i *ApplicationIncident) ShortDescription() string {
 	var (
 		a, l bool
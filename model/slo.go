@@ -0,0 +1,269 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/coroot/coroot/timeseries"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+type SLOCompliancePeriod string
+
+const (
+	SLOCompliancePeriod7d            SLOCompliancePeriod = "7d"
+	SLOCompliancePeriod28d           SLOCompliancePeriod = "28d"
+	SLOCompliancePeriod30d           SLOCompliancePeriod = "30d"
+	SLOCompliancePeriodCalendarMonth SLOCompliancePeriod = "calendar_month"
+)
+
+// From returns the beginning of the compliance period that includes the given time.
+func (p SLOCompliancePeriod) From(now timeseries.Time) timeseries.Time {
+	switch p {
+	case SLOCompliancePeriod7d:
+		return now.Add(-7 * timeseries.Day)
+	case SLOCompliancePeriod28d:
+		return now.Add(-28 * timeseries.Day)
+	case SLOCompliancePeriodCalendarMonth:
+		t := now.ToStandard().UTC()
+		return timeseries.Time(time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC).Unix())
+	}
+	return now.Add(-30 * timeseries.Day)
+}
+
+type SLOBurnRateWindow struct {
+	LongWindow  timeseries.Duration `json:"long_window"`
+	ShortWindow timeseries.Duration `json:"short_window"`
+	Threshold   float32             `json:"threshold"`
+	Severity    Status              `json:"severity"`
+}
+
+// SLO is a user-defined objective based on the ratio of good events to the total number of events.
+type SLO struct {
+	Id                  string              `json:"id"`
+	Name                string              `json:"name"`
+	Description         string              `json:"description"`
+	ApplicationId       ApplicationId       `json:"application_id"`
+	Source              SLOSource           `json:"source"`
+	ObjectivePercentage float32             `json:"objective_percentage"`
+	CompliancePeriod    SLOCompliancePeriod `json:"compliance_period"`
+	BurnRateWindows     []SLOBurnRateWindow `json:"burn_rate_windows"`
+}
+
+type SLOSource struct {
+	Metrics *SLOSourceMetrics `json:"metrics,omitempty"`
+	Traces  *SLOSourceTraces  `json:"traces,omitempty"`
+}
+
+// SLOSourceMetrics defines the SLI using two PromQL expressions that evaluate to the per-second rates of events.
+type SLOSourceMetrics struct {
+	GoodQuery  string `json:"good_query"`
+	TotalQuery string `json:"total_query"`
+}
+
+// SLOSourceTraces defines the SLI using the server spans of the service:
+// a span is good if it hasn't failed and, if the threshold is set, is faster than the threshold.
+type SLOSourceTraces struct {
+	ServiceName      string  `json:"service_name"`
+	LatencyThreshold float32 `json:"latency_threshold"` // in seconds
+}
+
+func (s *SLO) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if s.ApplicationId.IsZero() {
+		return fmt.Errorf("application is required")
+	}
+	sources := 0
+	if m := s.Source.Metrics; m != nil {
+		sources++
+		for _, q := range []string{m.GoodQuery, m.TotalQuery} {
+			if _, err := parser.ParseExpr(strings.ReplaceAll(q, "$RANGE", "1m")); err != nil {
+				return fmt.Errorf("invalid query: %w", err)
+			}
+		}
+	}
+	if t := s.Source.Traces; t != nil {
+		sources++
+		if t.ServiceName == "" {
+			return fmt.Errorf("service name is required")
+		}
+		if t.LatencyThreshold < 0 {
+			return fmt.Errorf("invalid latency threshold")
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("exactly one source must be defined")
+	}
+	if s.ObjectivePercentage <= 0 || s.ObjectivePercentage >= 100 {
+		return fmt.Errorf("objective must be between 0 and 100")
+	}
+	switch s.CompliancePeriod {
+	case SLOCompliancePeriod7d, SLOCompliancePeriod28d, SLOCompliancePeriod30d, SLOCompliancePeriodCalendarMonth:
+	default:
+		return fmt.Errorf("invalid compliance period: %s", s.CompliancePeriod)
+	}
+	for _, w := range s.BurnRateWindows {
+		if w.ShortWindow <= 0 || w.LongWindow < w.ShortWindow || w.LongWindow > 7*timeseries.Day {
+			return fmt.Errorf("invalid burn rate window: %s/%s", w.LongWindow, w.ShortWindow)
+		}
+		if w.Threshold <= 0 {
+			return fmt.Errorf("invalid burn rate threshold: %v", w.Threshold)
+		}
+		switch w.Severity {
+		case WARNING, CRITICAL:
+		default:
+			return fmt.Errorf("invalid severity: %s", w.Severity)
+		}
+	}
+	return nil
+}
+
+// AlertRules returns the burn rate window pairs of the SLO, or the default ones if not configured.
+func (s *SLO) AlertRules() []AlertRule {
+	if len(s.BurnRateWindows) == 0 {
+		return AlertRules
+	}
+	res := make([]AlertRule, 0, len(s.BurnRateWindows))
+	for _, w := range s.BurnRateWindows {
+		res = append(res, AlertRule{LongWindow: w.LongWindow, ShortWindow: w.ShortWindow, BurnRateThreshold: w.Threshold, Severity: w.Severity})
+	}
+	return res
+}
+
+func (s *SLO) MaxWindow() timeseries.Duration {
+	var res timeseries.Duration
+	for _, r := range s.AlertRules() {
+		res = max(res, r.LongWindow)
+	}
+	return res
+}
+
+type CustomSLI struct {
+	Config *SLO
+
+	// Good and Total are the per-second rates of events within the world's time range.
+	Good  *timeseries.TimeSeries
+	Total *timeseries.TimeSeries
+
+	// ErrorBudgetRemaining is the percentage of the error budget of the current compliance period
+	// that remained at each point of the world's time range.
+	ErrorBudgetRemaining *timeseries.TimeSeries
+	Compliance           float32
+
+	BurnRates []BurnRate
+	Error     string
+}
+
+func (sli *CustomSLI) Status() Status {
+	status := OK
+	for _, br := range sli.BurnRates {
+		if br.Severity > status {
+			status = br.Severity
+		}
+	}
+	return status
+}
+
+// CalcErrorBudgetRemaining returns the percentage of the error budget remaining at each point of the series
+// and the resulting compliance. goodBefore and totalBefore are the numbers of events since the beginning of the compliance
+// period till the beginning of the series, good and total are the per-second rates of events.
+func CalcErrorBudgetRemaining(goodBefore, totalBefore float32, good, total *timeseries.TimeSeries, step timeseries.Duration, objectivePercentage float32) (*timeseries.TimeSeries, float32) {
+	allowed := 1 - objectivePercentage/100
+	goodSum, totalSum := goodBefore, totalBefore
+	var res *timeseries.TimeSeries
+	if !total.IsEmpty() {
+		data := make([]float32, total.Len())
+		totalIter := total.Iter()
+		var goodIter *timeseries.Iterator
+		if !good.IsEmpty() {
+			goodIter = good.Iter()
+		}
+		for i := 0; totalIter.Next(); i++ {
+			_, t := totalIter.Value()
+			g := timeseries.NaN
+			if goodIter != nil && goodIter.Next() {
+				_, g = goodIter.Value()
+			}
+			if !timeseries.IsNaN(t) {
+				if timeseries.IsNaN(g) {
+					g = 0
+				}
+				goodSum += min(g, t) * float32(step)
+				totalSum += t * float32(step)
+			}
+			data[i] = timeseries.NaN
+			if totalSum > 0 {
+				data[i] = (1 - (1-goodSum/totalSum)/allowed) * 100
+			}
+		}
+		res = total.NewWithData(data)
+	}
+	compliance := timeseries.NaN
+	if totalSum > 0 {
+		compliance = goodSum / totalSum * 100
+	}
+	return res, compliance
+}
+
+type SumFromFunc func(from timeseries.Time) float32
+
+// SumFrom returns a function that sums the values of the series since the given time.
+// The sum is NaN if less than a half of the points are defined.
+func SumFrom(ts *timeseries.TimeSeries) SumFromFunc {
+	return func(from timeseries.Time) float32 {
+		iter := ts.IterFrom(from)
+		var sum float32
+		var count, countDefined int
+		for iter.Next() {
+			_, v := iter.Value()
+			count++
+			if timeseries.IsNaN(v) {
+				continue
+			}
+			sum += v
+			countDefined++
+		}
+		if float32(countDefined)/float32(count) < 0.5 {
+			return timeseries.NaN
+		}
+		return sum
+	}
+}
+
+func CalcBurnRates(now timeseries.Time, badSum, totalSum SumFromFunc, objectivePercentage float32, rules []AlertRule) []BurnRate {
+	objective := 1 - objectivePercentage/100
+	var res []BurnRate
+
+	for _, r := range rules {
+		from := now.Add(-r.LongWindow)
+		total := totalSum(from)
+		bad := badSum(from)
+		br := BurnRate{
+			LongWindow:  r.LongWindow,
+			ShortWindow: r.ShortWindow,
+			Threshold:   r.BurnRateThreshold,
+			Severity:    OK,
+		}
+		if v := bad / total; !timeseries.IsNaN(v) {
+			br.LongWindowPercentage = v * 100
+			br.LongWindowBurnRate = v / objective
+		} else {
+			continue
+		}
+		from = now.Add(-r.ShortWindow)
+		if v := badSum(from) / totalSum(from); !timeseries.IsNaN(v) {
+			br.ShortWindowPercentage = v * 100
+			br.ShortWindowBurnRate = v / objective
+		} else {
+			continue
+		}
+		if br.LongWindowBurnRate > r.BurnRateThreshold && br.ShortWindowBurnRate > r.BurnRateThreshold {
+			br.Severity = r.Severity
+		}
+		res = append(res, br)
+	}
+	return res
+}
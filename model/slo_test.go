@@ -0,0 +1,51 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalcErrorBudgetRemaining(t *testing.T) {
+	step := timeseries.Minute
+	total := timeseries.NewWithData(0, step, []float32{10, 10, timeseries.NaN, 10})
+	good := timeseries.NewWithData(0, step, []float32{10, 9, timeseries.NaN, timeseries.NaN})
+
+	// objective 90%: 10% of events are allowed to be bad
+	remaining, compliance := CalcErrorBudgetRemaining(0, 0, good, total, step, 90)
+	var values []float32
+	iter := remaining.Iter()
+	for iter.Next() {
+		_, v := iter.Value()
+		values = append(values, v)
+	}
+	assert.InDeltaSlice(t, []float32{100, 50, 50, -266.67}, values, 0.01)
+	assert.InDelta(t, 19.0/30*100, compliance, 0.01)
+
+	_, compliance = CalcErrorBudgetRemaining(990, 1000, nil, nil, step, 90)
+	assert.InDelta(t, 99, compliance, 0.01)
+}
+
+func TestSLOValidate(t *testing.T) {
+	slo := SLO{
+		Name:                "checkout",
+		ApplicationId:       NewApplicationId("default", ApplicationKindDeployment, "checkout"),
+		Source:              SLOSource{Metrics: &SLOSourceMetrics{GoodQuery: `sum(rate(ok_total[$RANGE]))`, TotalQuery: `sum(rate(req_total[$RANGE]))`}},
+		ObjectivePercentage: 99.9,
+		CompliancePeriod:    SLOCompliancePeriod28d,
+	}
+	assert.NoError(t, slo.Validate())
+	assert.Equal(t, AlertRules, slo.AlertRules())
+
+	slo.Source.Traces = &SLOSourceTraces{ServiceName: "checkout"}
+	assert.Error(t, slo.Validate())
+	slo.Source.Traces = nil
+
+	slo.BurnRateWindows = []SLOBurnRateWindow{{LongWindow: timeseries.Hour, ShortWindow: 5 * timeseries.Minute, Threshold: 14.4, Severity: CRITICAL}}
+	assert.NoError(t, slo.Validate())
+	assert.Equal(t, timeseries.Hour, slo.MaxWindow())
+
+	slo.CompliancePeriod = "1y"
+	assert.Error(t, slo.Validate())
+}
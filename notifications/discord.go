@@ -0,0 +1,96 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+)
+
+type Discord struct {
+	webhookUrl string
+}
+
+type discordMessage struct {
+	Username string         `json:"username"`
+	Embeds   []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Url         string         `json:"url"`
+	Description string         `json:"description,omitempty"`
+	Color       int            `json:"color"`
+	Fields      []discordField `json:"fields,omitempty"`
+	Timestamp   string         `json:"timestamp,omitempty"`
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+func NewDiscord(webhookUrl string) *Discord {
+	return &Discord{webhookUrl: webhookUrl}
+}
+
+func (d *Discord) SendIncident(ctx context.Context, baseUrl string, n *db.IncidentNotification) error {
+	e := discordEmbed{
+		Url:   incidentUrl(baseUrl, n),
+		Color: discordColor(n.Status),
+	}
+	subject, problem := incidentSubject(n)
+	if n.Status == model.OK {
+		e.Title = fmt.Sprintf("%s incident resolved", subject)
+	} else {
+		e.Title = fmt.Sprintf("[%s] %s %s", strings.ToUpper(n.Status.String()), subject, problem)
+	}
+	if n.Details != nil {
+		var details []string
+		for _, r := range n.Details.Reports {
+			details = append(details, fmt.Sprintf("• **%s** / %s: %s", r.Name, r.Check, r.Message))
+		}
+		e.Description = strings.Join(details, "\n")
+	}
+	if !n.Timestamp.IsZero() {
+		e.Timestamp = n.Timestamp.ToStandard().Format("2006-01-02T15:04:05Z07:00")
+	}
+	return d.send(ctx, e)
+}
+
+func (d *Discord) SendDeployment(ctx context.Context, project *db.Project, ds model.ApplicationDeploymentStatus) error {
+	if ds.State == model.ApplicationDeploymentStateInProgress {
+		return nil
+	}
+	dep := ds.Deployment
+	e := discordEmbed{
+		Title: fmt.Sprintf("Deployment of %s to %s", dep.ApplicationId.Name, project.Name),
+		Url:   deploymentUrl(project.Settings.Integrations.BaseUrl, project.Id, dep),
+		Color: discordColor(ds.Status),
+		Fields: []discordField{
+			{Name: "Status", Value: deploymentStatus(ds), Inline: true},
+			{Name: "Version", Value: dep.Version(), Inline: true},
+		},
+		Timestamp: dep.StartedAt.ToStandard().Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if summary := deploymentSummary(ds); len(summary) > 0 {
+		e.Fields = append(e.Fields, discordField{Name: "Summary", Value: strings.Join(summary, "\n")})
+	}
+	return d.send(ctx, e)
+}
+
+func (d *Discord) send(ctx context.Context, e discordEmbed) error {
+	if err := postJson(ctx, d.webhookUrl, discordMessage{Username: "Coroot", Embeds: []discordEmbed{e}}, nil); err != nil {
+		return fmt.Errorf("discord error: %w", err)
+	}
+	return nil
+}
+
+func discordColor(s model.Status) int {
+	c, _ := strconv.ParseInt(strings.TrimPrefix(s.Color(), "#"), 16, 32)
+	return int(c)
+}
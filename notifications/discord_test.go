@@ -0,0 +1,60 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscord(t *testing.T) {
+	var messages []discordMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var msg discordMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		messages = append(messages, msg)
+		if len(messages) > 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"message":"You are being rate limited."}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	d := NewDiscord(srv.URL)
+	n := &db.IncidentNotification{
+		ProjectId:     "p1",
+		ApplicationId: model.NewApplicationId("default", model.ApplicationKindDeployment, "app"),
+		IncidentKey:   "abc",
+		Status:        model.WARNING,
+		Timestamp:     timeseries.Time(1700000000),
+		Details: &db.IncidentNotificationDetails{
+			Reports: []db.IncidentNotificationDetailsReport{{Name: "SLO", Check: "Latency", Message: "p99 > 500ms"}},
+		},
+	}
+	require.NoError(t, d.SendIncident(context.Background(), "http://coroot", n))
+	require.Len(t, messages, 1)
+	assert.Equal(t, "Coroot", messages[0].Username)
+	require.Len(t, messages[0].Embeds, 1)
+	e := messages[0].Embeds[0]
+	assert.Equal(t, "[WARNING] app is not meeting its SLOs", e.Title)
+	assert.Equal(t, "http://coroot/p/p1/incidents?incident=abc", e.Url)
+	assert.Equal(t, "• **SLO** / Latency: p99 > 500ms", e.Description)
+	assert.Equal(t, 0xffdd57, e.Color)
+	assert.Equal(t, "2023-11-14T22:13:20Z", e.Timestamp)
+
+	n.Status = model.OK
+	err := d.SendIncident(context.Background(), "http://coroot", n)
+	assert.EqualError(t, err, `discord error: 429 Too Many Requests: {"message":"You are being rate limited."}`)
+	assert.Equal(t, "app incident resolved", messages[1].Embeds[0].Title)
+	assert.Equal(t, 0x23d160, messages[1].Embeds[0].Color)
+}
@@ -0,0 +1,269 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/utils"
+)
+
+type Email struct {
+	cfg *db.IntegrationEmail
+	to  []string
+}
+
+func NewEmail(cfg *db.IntegrationEmail, to []string) *Email {
+	if len(to) == 0 {
+		to = cfg.To
+	}
+	return &Email{cfg: cfg, to: to}
+}
+
+type emailIncidentValues struct {
+	Title    string
+	Status   string
+	Resolved bool
+	Reports  []db.IncidentNotificationDetailsReport
+	URL      string
+}
+
+type emailDeploymentValues struct {
+	Title   string
+	Status  string
+	Version string
+	Summary []string
+	URL     string
+}
+
+func (e *Email) SendIncident(ctx context.Context, baseUrl string, n *db.IncidentNotification) error {
+	subject, problem := incidentSubject(n)
+	values := emailIncidentValues{
+		Status:   strings.ToUpper(n.Status.String()),
+		Resolved: n.Status == model.OK,
+		URL:      incidentUrl(baseUrl, n),
+	}
+	if values.Resolved {
+		values.Title = fmt.Sprintf("%s incident resolved", subject)
+	} else {
+		values.Title = fmt.Sprintf("[%s] %s %s", values.Status, subject, problem)
+	}
+	if n.Details != nil {
+		values.Reports = n.Details.Reports
+	}
+	return e.send(ctx, values.Title, emailIncidentTextTemplate, emailIncidentHtmlTemplate, values)
+}
+
+func (e *Email) SendDeployment(ctx context.Context, project *db.Project, ds model.ApplicationDeploymentStatus) error {
+	d := ds.Deployment
+	values := emailDeploymentValues{
+		Title:   fmt.Sprintf("Deployment of %s to %s", d.ApplicationId.Name, project.Name),
+		Status:  "Deployed",
+		Version: d.Version(),
+		URL:     deploymentUrl(project.Settings.Integrations.BaseUrl, project.Id, d),
+	}
+	switch ds.State {
+	case model.ApplicationDeploymentStateInProgress:
+		return nil
+	case model.ApplicationDeploymentStateStuck:
+		values.Status = "Stuck"
+	case model.ApplicationDeploymentStateCancelled:
+		values.Status = "Cancelled"
+	case model.ApplicationDeploymentStateSummary:
+		for _, s := range ds.Summary {
+			values.Summary = append(values.Summary, fmt.Sprintf("%s %s", s.Emoji(), s.Message))
+		}
+		if len(values.Summary) == 0 {
+			values.Summary = append(values.Summary, "No notable changes")
+		}
+	}
+	return e.send(ctx, values.Title, emailDeploymentTextTemplate, emailDeploymentHtmlTemplate, values)
+}
+
+func (e *Email) send(ctx context.Context, subject string, textTmpl *template.Template, htmlTmpl *htmltemplate.Template, values any) error {
+	var text, html bytes.Buffer
+	if err := textTmpl.Execute(&text, values); err != nil {
+		return err
+	}
+	if err := htmlTmpl.Execute(&html, values); err != nil {
+		return err
+	}
+	msg, err := e.message(subject, text.Bytes(), html.Bytes())
+	if err != nil {
+		return err
+	}
+	return e.deliver(ctx, msg)
+}
+
+func (e *Email) message(subject string, text, html []byte) ([]byte, error) {
+	from, err := mail.ParseAddress(e.cfg.From)
+	if err != nil {
+		return nil, err
+	}
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		contentType string
+		data        []byte
+	}{{"text/plain", text}, {"text/html", html}} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=UTF-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(w)
+		if _, err = qw.Write(part.data); err != nil {
+			return nil, err
+		}
+		if err = qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err = mw.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	headers := [][2]string{
+		{"From", from.String()},
+		{"To", strings.Join(e.to, ", ")},
+		{"Subject", mime.QEncoding.Encode("UTF-8", subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@%s>", utils.NanoId(16), emailDomain(from.Address))},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + mw.Boundary()},
+	}
+	for _, h := range headers {
+		msg.WriteString(h[0] + ": " + h[1] + "\r\n")
+	}
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+func (e *Email) deliver(ctx context.Context, msg []byte) error {
+	addr := net.JoinHostPort(e.cfg.SmtpHost, strconv.Itoa(e.cfg.SmtpPort))
+	tlsConfig := &tls.Config{ServerName: e.cfg.SmtpHost, InsecureSkipVerify: e.cfg.TlsSkipVerify}
+
+	d := &net.Dialer{}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if e.cfg.TLS == db.EmailTLSModeTLS {
+		conn = tls.Client(conn, tlsConfig)
+	}
+	c, err := smtp.NewClient(conn, e.cfg.SmtpHost)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if e.cfg.TLS == db.EmailTLSModeStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("the smtp server doesn't support STARTTLS")
+		}
+		if err = c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if e.cfg.Username != "" {
+		if err = c.Auth(smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.SmtpHost)); err != nil {
+			return err
+		}
+	}
+	from, err := mail.ParseAddress(e.cfg.From)
+	if err != nil {
+		return err
+	}
+	if err = c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, to := range e.to {
+		a, err := mail.ParseAddress(to)
+		if err != nil {
+			return err
+		}
+		if err = c.Rcpt(a.Address); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func emailDomain(address string) string {
+	if i := strings.LastIndex(address, "@"); i >= 0 {
+		return address[i+1:]
+	}
+	return "coroot"
+}
+
+var (
+	emailIncidentTextTemplate = template.Must(template.New("incident").Parse(`{{ .Title }}
+{{ range .Reports }}
+* {{ .Name }} / {{ .Check }}: {{ .Message }}{{ end }}
+
+View incident: {{ .URL }}
+`))
+
+	emailIncidentHtmlTemplate = htmltemplate.Must(htmltemplate.New("incident").Parse(`<html><body style="font-family: sans-serif">
+<h3 style="color: {{ if .Resolved }}#4caf50{{ else }}#f44336{{ end }}">{{ .Title }}</h3>
+{{ if .Reports }}<ul>{{ range .Reports }}
+<li><b>{{ .Name }}</b> / {{ .Check }}: {{ .Message }}</li>{{ end }}
+</ul>{{ end }}
+<p><a href="{{ .URL }}">View incident</a></p>
+</body></html>
+`))
+
+	emailDeploymentTextTemplate = template.Must(template.New("deployment").Parse(`{{ .Title }}
+
+Status: {{ .Status }}
+Version: {{ .Version }}
+{{ if .Summary }}
+Summary:{{ range .Summary }}
+* {{ . }}{{ end }}
+{{ end }}
+View deployment: {{ .URL }}
+`))
+
+	emailDeploymentHtmlTemplate = htmltemplate.Must(htmltemplate.New("deployment").Parse(`<html><body style="font-family: sans-serif">
+<h3>{{ .Title }}</h3>
+<p><b>Status:</b> {{ .Status }}<br><b>Version:</b> {{ .Version }}</p>
+{{ if .Summary }}<p><b>Summary:</b></p><ul>{{ range .Summary }}
+<li>{{ . }}</li>{{ end }}
+</ul>{{ end }}
+<p><a href="{{ .URL }}">View deployment</a></p>
+</body></html>
+`))
+)
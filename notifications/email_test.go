@@ -0,0 +1,106 @@
+package notifications
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpStub accepts a single session and returns the envelope recipients and the message data.
+func smtpStub(t *testing.T) (string, int, chan []string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	res := make(chan []string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		c := textproto.NewConn(conn)
+		var session []string
+		_ = c.PrintfLine("220 localhost ESMTP stub")
+		for {
+			line, err := c.ReadLine()
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch cmd {
+			case "EHLO", "HELO":
+				_ = c.PrintfLine("250 localhost")
+			case "RCPT":
+				session = append(session, line)
+				_ = c.PrintfLine("250 OK")
+			case "DATA":
+				_ = c.PrintfLine("354 go ahead")
+				data, _ := c.ReadDotLines()
+				session = append(session, strings.Join(data, "\n"))
+				_ = c.PrintfLine("250 OK")
+			case "QUIT":
+				_ = c.PrintfLine("221 bye")
+				res <- session
+				return
+			default:
+				_ = c.PrintfLine("250 OK")
+			}
+		}
+	}()
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return host, p, res
+}
+
+func TestEmail(t *testing.T) {
+	host, port, sessions := smtpStub(t)
+	cfg := &db.IntegrationEmail{
+		SmtpHost: host,
+		SmtpPort: port,
+		TLS:      db.EmailTLSModeNone,
+		From:     "Coroot <coroot@example.com>",
+		To:       []string{"oncall@example.com"},
+	}
+	assert.NoError(t, cfg.Validate())
+
+	n := &db.IncidentNotification{
+		ProjectId:     "p1",
+		ApplicationId: model.NewApplicationId("default", model.ApplicationKindDeployment, "app"),
+		IncidentKey:   "abc",
+		Status:        model.CRITICAL,
+		Details: &db.IncidentNotificationDetails{
+			Reports: []db.IncidentNotificationDetailsReport{{Name: model.AuditReportLogs, Check: "Errors", Message: "<b>5 errors</b>"}},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	e := NewEmail(cfg, splitRecipients("a@example.com,b@example.com"))
+	require.NoError(t, e.SendIncident(ctx, "http://coroot", n))
+
+	session := <-sessions
+	require.Len(t, session, 3)
+	assert.Equal(t, "RCPT TO:<a@example.com>", session[0])
+	assert.Equal(t, "RCPT TO:<b@example.com>", session[1])
+
+	msg := session[2]
+	assert.Contains(t, msg, "Subject: [CRITICAL] app is not meeting its SLOs")
+	assert.Contains(t, msg, "To: a@example.com, b@example.com")
+	assert.Contains(t, msg, "Content-Type: multipart/alternative")
+	assert.Contains(t, msg, "* Logs / Errors: <b>5 errors</b>")
+	assert.Contains(t, msg, "&lt;b&gt;5 errors&lt;/b&gt;")
+
+	r := bufio.NewReader(strings.NewReader(msg))
+	h, err := textproto.NewReader(r).ReadMIMEHeader()
+	require.NoError(t, err)
+	assert.Equal(t, `"Coroot" <coroot@example.com>`, h.Get("From"))
+}
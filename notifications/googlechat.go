@@ -0,0 +1,136 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+)
+
+type GoogleChat struct {
+	webhookUrl string
+}
+
+type googleChatMessage struct {
+	Text    string               `json:"text,omitempty"`
+	CardsV2 []googleChatCardV2   `json:"cardsV2"`
+	Thread  *googleChatThreadKey `json:"thread,omitempty"`
+}
+
+type googleChatThreadKey struct {
+	ThreadKey string `json:"threadKey"`
+}
+
+type googleChatCardV2 struct {
+	CardId string         `json:"cardId"`
+	Card   googleChatCard `json:"card"`
+}
+
+type googleChatCard struct {
+	Header   googleChatCardHeader `json:"header"`
+	Sections []googleChatSection  `json:"sections"`
+}
+
+type googleChatCardHeader struct {
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle,omitempty"`
+}
+
+type googleChatSection struct {
+	Header  string             `json:"header,omitempty"`
+	Widgets []googleChatWidget `json:"widgets"`
+}
+
+type googleChatWidget map[string]any
+
+func NewGoogleChat(webhookUrl string) *GoogleChat {
+	return &GoogleChat{webhookUrl: webhookUrl}
+}
+
+func (g *GoogleChat) SendIncident(ctx context.Context, baseUrl string, n *db.IncidentNotification) error {
+	subject, problem := incidentSubject(n)
+	header := googleChatCardHeader{Title: subject}
+	if n.Status == model.OK {
+		header.Subtitle = "incident resolved"
+	} else {
+		header.Subtitle = fmt.Sprintf("[%s] %s", strings.ToUpper(n.Status.String()), problem)
+	}
+	var widgets []googleChatWidget
+	if n.Details != nil {
+		for _, r := range n.Details.Reports {
+			widgets = append(widgets, googleChatText("• <b>%s</b> / %s: %s", html.EscapeString(string(r.Name)), html.EscapeString(r.Check), html.EscapeString(r.Message)))
+		}
+	}
+	widgets = append(widgets, googleChatStatus(n.Status), googleChatButton("View incident", incidentUrl(baseUrl, n)))
+	msg := googleChatMessage{
+		CardsV2: []googleChatCardV2{{CardId: "incident", Card: googleChatCard{Header: header, Sections: []googleChatSection{{Widgets: widgets}}}}},
+		// all the notifications of an incident are posted to the same thread
+		Thread: &googleChatThreadKey{ThreadKey: fmt.Sprintf("%s-%s", n.ProjectId, n.IncidentKey)},
+	}
+	return g.send(ctx, msg)
+}
+
+func (g *GoogleChat) SendDeployment(ctx context.Context, project *db.Project, ds model.ApplicationDeploymentStatus) error {
+	if ds.State == model.ApplicationDeploymentStateInProgress {
+		return nil
+	}
+	d := ds.Deployment
+	widgets := []googleChatWidget{
+		{"decoratedText": map[string]any{"topLabel": "Status", "text": html.EscapeString(deploymentStatus(ds))}},
+		{"decoratedText": map[string]any{"topLabel": "Version", "text": html.EscapeString(d.Version())}},
+	}
+	sections := []googleChatSection{{Widgets: widgets}}
+	if summary := deploymentSummary(ds); len(summary) > 0 {
+		var items []googleChatWidget
+		for _, s := range summary {
+			items = append(items, googleChatText("%s", html.EscapeString(s)))
+		}
+		sections = append(sections, googleChatSection{Header: "Summary", Widgets: items})
+	}
+	sections = append(sections, googleChatSection{Widgets: []googleChatWidget{
+		googleChatButton("View deployment", deploymentUrl(project.Settings.Integrations.BaseUrl, project.Id, d)),
+	}})
+	header := googleChatCardHeader{Title: fmt.Sprintf("Deployment of %s to %s", d.ApplicationId.Name, project.Name)}
+	msg := googleChatMessage{
+		CardsV2: []googleChatCardV2{{CardId: "deployment", Card: googleChatCard{Header: header, Sections: sections}}},
+	}
+	return g.send(ctx, msg)
+}
+
+func (g *GoogleChat) send(ctx context.Context, msg googleChatMessage) error {
+	u, err := url.Parse(g.webhookUrl)
+	if err != nil {
+		return err
+	}
+	if msg.Thread != nil {
+		q := u.Query()
+		q.Set("messageReplyOption", "REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD")
+		u.RawQuery = q.Encode()
+	}
+	if err = postJson(ctx, u.String(), msg, nil); err != nil {
+		return fmt.Errorf("google chat error: %w", err)
+	}
+	return nil
+}
+
+func googleChatText(format string, a ...any) googleChatWidget {
+	return googleChatWidget{"textParagraph": map[string]any{"text": fmt.Sprintf(format, a...)}}
+}
+
+func googleChatStatus(s model.Status) googleChatWidget {
+	status := strings.ToUpper(s.String())
+	if s == model.OK {
+		status = "RESOLVED"
+	}
+	return googleChatText(`<font color="%s"><b>%s</b></font>`, s.Color(), status)
+}
+
+func googleChatButton(text, link string) googleChatWidget {
+	return googleChatWidget{"buttonList": map[string]any{"buttons": []map[string]any{
+		{"text": text, "onClick": map[string]any{"openLink": map[string]any{"url": link}}},
+	}}}
+}
@@ -0,0 +1,60 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoogleChat(t *testing.T) {
+	var queries []string
+	var bodies []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(data, &body))
+		bodies = append(bodies, body)
+		if len(bodies) > 1 {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte("invalid key"))
+		}
+	}))
+	defer srv.Close()
+
+	g := NewGoogleChat(srv.URL + "/v1/spaces/s1/messages?key=k&token=t")
+	n := &db.IncidentNotification{
+		ProjectId:     "p1",
+		ApplicationId: model.NewApplicationId("default", model.ApplicationKindDeployment, "app"),
+		IncidentKey:   "abc",
+		Status:        model.CRITICAL,
+		Details: &db.IncidentNotificationDetails{
+			Reports: []db.IncidentNotificationDetailsReport{{Name: "SLO", Check: "Availability", Message: "errors > 1%"}},
+		},
+	}
+	require.NoError(t, g.SendIncident(context.Background(), "http://coroot", n))
+	assert.Equal(t, "key=k&messageReplyOption=REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD&token=t", queries[0])
+	assert.Equal(t, map[string]any{"threadKey": "p1-abc"}, bodies[0]["thread"])
+	cards := bodies[0]["cardsV2"].([]any)
+	require.Len(t, cards, 1)
+	card := cards[0].(map[string]any)["card"].(map[string]any)
+	assert.Equal(t, map[string]any{"title": "app", "subtitle": "[CRITICAL] is not meeting its SLOs"}, card["header"])
+	widgets := card["sections"].([]any)[0].(map[string]any)["widgets"].([]any)
+	require.Len(t, widgets, 3)
+	assert.Equal(t, map[string]any{"textParagraph": map[string]any{"text": "• <b>SLO</b> / Availability: errors &gt; 1%"}}, widgets[0])
+	assert.Equal(t, map[string]any{"textParagraph": map[string]any{"text": `<font color="#f44034"><b>CRITICAL</b></font>`}}, widgets[1])
+	assert.Contains(t, widgets[2].(map[string]any)["buttonList"], "buttons")
+
+	n.Status = model.OK
+	err := g.SendIncident(context.Background(), "http://coroot", n)
+	assert.EqualError(t, err, "google chat error: 403 Forbidden: invalid key")
+	assert.Equal(t, map[string]any{"threadKey": "p1-abc"}, bodies[1]["thread"])
+}
@@ -2,6 +2,7 @@ package notifications
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -26,32 +27,105 @@ func NewIncidentNotifier(db *db.DB) *IncidentNotifier {
 }
 
 func (n *IncidentNotifier) Enqueue(project *db.Project, app *model.Application, incident *model.ApplicationIncident, now timeseries.Time) {
-	categorySettings := project.GetApplicationCategories()[app.Category]
-	if categorySettings == nil {
-		return
-	}
-	notificationSettings := categorySettings.NotificationSettings.Incidents
-	if !notificationSettings.Enabled {
-		return
+	target := db.NotificationTarget{
+		ApplicationId: app.Id,
+		Category:      app.Category,
+		Severity:      incident.Severity,
+		Time:          now,
+		Resolved:      incident.Resolved(),
 	}
-	if slack := notificationSettings.Slack; slack != nil && slack.Enabled {
-		n.enqueue(now, project, app, incident, db.IncidentNotificationDestination{IntegrationType: db.IntegrationTypeSlack, SlackChannel: slack.Channel})
+	destinations, routed := project.Settings.NotificationRouting.Route(target)
+	if !routed {
+		categorySettings := project.GetApplicationCategories()[app.Category]
+		if categorySettings == nil {
+			return
+		}
+		notificationSettings := categorySettings.NotificationSettings.Incidents
+		if !notificationSettings.Enabled {
+			return
+		}
+		destinations = immediately(notificationSettings.IncidentDestinations())
 	}
-	if teams := notificationSettings.Teams; teams != nil && teams.Enabled {
-		n.enqueue(now, project, app, incident, db.IncidentNotificationDestination{IntegrationType: db.IntegrationTypeTeams})
+	notification := db.IncidentNotification{
+		ProjectId:     project.Id,
+		ApplicationId: app.Id,
+		IncidentKey:   incident.Key,
+		Timestamp:     now,
+		Status:        incident.Severity,
 	}
-	if pagerduty := notificationSettings.Pagerduty; pagerduty != nil && pagerduty.Enabled {
-		n.enqueue(now, project, app, incident, db.IncidentNotificationDestination{IntegrationType: db.IntegrationTypePagerduty})
+	silenceTarget := db.NewIncidentSilenceTarget(app, incident)
+	silencedUntil := n.silencedUntil(project.Id, silenceTarget, now)
+	n.enqueueAll(notification, destinations, incident.Resolved(), incidentDetails(app, incident), silenceTarget, silencedUntil, routed)
+	n.sendIncidents()
+}
+
+func (n *IncidentNotifier) EnqueueAlert(project *db.Project, rule *db.AlertRule, alert *db.Alert, now timeseries.Time) {
+	var appId model.ApplicationId
+	silenceTarget := db.SilenceTarget{CheckIds: []model.CheckId{model.CheckId(rule.Id)}}
+	routingTarget := db.NotificationTarget{Severity: alert.Severity, Time: now, Resolved: alert.Resolved()}
+	if rule.ApplicationId != nil {
+		appId = *rule.ApplicationId
+		silenceTarget.ApplicationId = appId
+		silenceTarget.Category = project.CalcApplicationCategory(appId)
+		routingTarget.ApplicationId = silenceTarget.ApplicationId
+		routingTarget.Category = silenceTarget.Category
 	}
-	if opsgenie := notificationSettings.Opsgenie; opsgenie != nil && opsgenie.Enabled {
-		n.enqueue(now, project, app, incident, db.IncidentNotificationDestination{IntegrationType: db.IntegrationTypeOpsgenie})
+	// the destinations of the rule take precedence over the routing policies
+	destinations := immediately(rule.Notifications.IncidentDestinations())
+	routed := false
+	if len(destinations) == 0 {
+		destinations, routed = project.Settings.NotificationRouting.Route(routingTarget)
 	}
-	if webhook := notificationSettings.Webhook; webhook != nil && webhook.Enabled {
-		n.enqueue(now, project, app, incident, db.IncidentNotificationDestination{IntegrationType: db.IntegrationTypeWebhook})
+	notification := db.IncidentNotification{
+		ProjectId:     project.Id,
+		ApplicationId: appId,
+		IncidentKey:   alert.Key,
+		Timestamp:     now,
+		Status:        alert.Severity,
 	}
+	silencedUntil := n.silencedUntil(project.Id, silenceTarget, now)
+	n.enqueueAll(notification, destinations, alert.Resolved(), alertDetails(rule, alert), silenceTarget, silencedUntil, routed)
 	n.sendIncidents()
 }
 
+// enqueueAll schedules the notification to the destinations. If the incident is silenced, the notification
+// is deferred until silencedUntil, when it is delivered only if the incident is still open and no longer silenced.
+func (n *IncidentNotifier) enqueueAll(notification db.IncidentNotification, destinations []db.RoutedDestination, resolved bool, details *db.IncidentNotificationDetails, silenceTarget db.SilenceTarget, silencedUntil timeseries.Time, routed bool) {
+	silenced := !silencedUntil.IsZero()
+	for _, d := range destinations {
+		nn := notification
+		nn.Destination = d.Destination
+		dd := details
+		if (d.EscalateAfter > 0 || silenced) && !resolved {
+			dd = &db.IncidentNotificationDetails{}
+			if details != nil {
+				*dd = *details
+			}
+			if d.EscalateAfter > 0 {
+				nn.Timestamp = nn.Timestamp.Add(d.EscalateAfter)
+				dd.Escalation = true
+			}
+			if silenced {
+				dd.Silenced = &silenceTarget
+				if nn.Timestamp.Before(silencedUntil) {
+					nn.Timestamp = silencedUntil
+				}
+			}
+		}
+		// a resolution is sent only to the destinations notified of the incident
+		// if the incident was silenced or the routing could have changed since then
+		n.enqueue(nn, resolved, dd, silenced || routed)
+	}
+}
+
+func immediately(destinations []db.IncidentNotificationDestination) []db.RoutedDestination {
+	res := make([]db.RoutedDestination, 0, len(destinations))
+	for _, d := range destinations {
+		res = append(res, db.RoutedDestination{Destination: d})
+	}
+	return res
+}
+
 func (n *IncidentNotifier) sendIncidents() {
 	ps, err := n.db.GetProjects()
 	if err != nil {
@@ -71,7 +145,8 @@ func (n *IncidentNotifier) sendIncidents() {
 		destination db.IncidentNotificationDestination
 	}
 	failedDestinations := map[destinationKey]bool{}
-	notifications, err := n.db.GetNotSentIncidentNotifications(timeseries.Now().Add(-retryWindow))
+	now := timeseries.Now()
+	notifications, err := n.db.GetNotSentIncidentNotifications(now.Add(-RetryWindow), now)
 	if err != nil {
 		klog.Errorln(err)
 		return
@@ -85,11 +160,31 @@ func (n *IncidentNotifier) sendIncidents() {
 		if project == nil {
 			continue
 		}
+		if notification.Details != nil && notification.Details.Silenced != nil && notification.Status != model.OK {
+			if resolved, _ := n.incidentState(notification); resolved {
+				if err = n.db.DeleteIncidentNotification(notification); err != nil {
+					klog.Errorln(err)
+				}
+				continue
+			}
+			if until := n.silencedUntil(project.Id, *notification.Details.Silenced, now); !until.IsZero() {
+				n.reschedule(notification, until)
+				continue
+			}
+		}
+		if notification.Details != nil && notification.Details.Escalation && notification.Status != model.OK && !n.needsEscalation(notification) {
+			if err = n.db.DeleteIncidentNotification(notification); err != nil {
+				klog.Errorln(err)
+			}
+			continue
+		}
 		integrations := project.Settings.Integrations
 		var sendErr error
 		client := getClient(notification.Destination, integrations)
 		if client != nil {
-			if notification.Destination.IntegrationType == db.IntegrationTypeSlack {
+			// replies are threaded to the message about the opened incident
+			switch notification.Destination.IntegrationType {
+			case db.IntegrationTypeSlack, db.IntegrationTypeTelegram:
 				if prevNotifications, err := n.db.GetPreviousIncidentNotifications(notification); err != nil {
 					klog.Errorln(err)
 				} else {
@@ -100,9 +195,30 @@ func (n *IncidentNotifier) sendIncidents() {
 					}
 				}
 			}
+			start := time.Now()
 			ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
 			sendErr = client.SendIncident(ctx, integrations.BaseUrl, &notification)
 			cancel()
+			var deliveries []db.NotificationDelivery
+			if logger, ok := client.(DeliveryLogger); ok {
+				deliveries = logger.Deliveries()
+			} else {
+				d := db.NotificationDelivery{
+					AttemptedAt: timeseries.Time(start.Unix()),
+					Attempt:     notification.Attempts + 1,
+					DurationMs:  time.Since(start).Milliseconds(),
+				}
+				if sendErr != nil {
+					d.Error = sendErr.Error()
+				}
+				deliveries = append(deliveries, d)
+			}
+			if err = n.db.AddNotificationDeliveries(notification, deliveries); err != nil {
+				klog.Errorln(err)
+			}
+			if err = n.db.RecordIncidentNotificationAttempt(notification, sendErr); err != nil {
+				klog.Errorln(err)
+			}
 		}
 		if sendErr != nil {
 			klog.Errorf("failed to send to %s: %s", notification.Destination.IntegrationType, sendErr)
@@ -116,21 +232,76 @@ func (n *IncidentNotifier) sendIncidents() {
 	}
 }
 
-func (n *IncidentNotifier) enqueue(now timeseries.Time, project *db.Project, app *model.Application, incident *model.ApplicationIncident, destination db.IncidentNotificationDestination) {
-	notification := db.IncidentNotification{
-		ProjectId:     project.Id,
-		ApplicationId: app.Id,
-		IncidentKey:   incident.Key,
-		Destination:   destination,
-		Timestamp:     now,
-		Status:        incident.Severity,
+// Resend schedules another delivery of a previously enqueued notification regardless of whether it was sent or not.
+func (n *IncidentNotifier) Resend(notification db.IncidentNotification) {
+	notification.Timestamp = timeseries.Now()
+	notification.SentAt = 0
+	if notification.Details != nil {
+		details := *notification.Details
+		// a manually resent notification must be delivered even if the incident has been acknowledged
+		details.Escalation = false
+		notification.Details = &details
+	}
+	n.db.PutIncidentNotification(notification)
+}
+
+// silencedUntil returns the time when the target should be checked again if it is silenced, or zero otherwise.
+// It is the end of the matching silences, but no later than silenceRecheckInterval from now, since open-ended
+// and recurring silences have no fixed end and the silences can be changed.
+func (n *IncidentNotifier) silencedUntil(projectId db.ProjectId, target db.SilenceTarget, now timeseries.Time) timeseries.Time {
+	silences, err := n.db.GetSilences(projectId)
+	if err != nil {
+		klog.Errorln(err)
+		return 0
+	}
+	var until timeseries.Time
+	for _, s := range silences {
+		if !s.IsActive(now) || !s.Matches(target) {
+			continue
+		}
+		end := now.Add(silenceRecheckInterval)
+		if s.Recurrence == nil && !s.EndsAt.IsZero() && s.EndsAt.Before(end) {
+			end = s.EndsAt
+		}
+		if end.After(until) {
+			until = end
+		}
+	}
+	return until
+}
+
+func (n *IncidentNotifier) reschedule(notification db.IncidentNotification, at timeseries.Time) {
+	deferred := notification
+	deferred.Timestamp = at
+	n.db.PutIncidentNotification(deferred)
+	if err := n.db.DeleteIncidentNotification(notification); err != nil {
+		klog.Errorln(err)
 	}
-	switch destination.IntegrationType {
-	case db.IntegrationTypeSlack, db.IntegrationTypeTeams, db.IntegrationTypeWebhook:
-		if incident.Resolved() {
-			n.onResolve("", notification, incidentDetails(app, incident))
+}
+
+func (n *IncidentNotifier) enqueue(notification db.IncidentNotification, resolved bool, details *db.IncidentNotificationDetails, onlyIfNotified bool) {
+	if resolved {
+		if err := n.db.DeleteScheduledIncidentNotifications(notification); err != nil {
+			klog.Errorln(err)
+		}
+		if onlyIfNotified {
+			prev, err := n.db.GetPreviousIncidentNotifications(notification)
+			if err != nil {
+				klog.Errorln(err)
+				return
+			}
+			if len(prev) == 0 {
+				return
+			}
+		}
+	}
+	switch notification.Destination.IntegrationType {
+	case db.IntegrationTypeSlack, db.IntegrationTypeTeams, db.IntegrationTypeWebhook, db.IntegrationTypeEmail,
+		db.IntegrationTypeMattermost, db.IntegrationTypeDiscord, db.IntegrationTypeGoogleChat, db.IntegrationTypeTelegram:
+		if resolved {
+			n.onResolve("", notification, details)
 		} else {
-			n.onOpen("", notification, incidentDetails(app, incident))
+			n.onOpen("", notification, details)
 		}
 	case db.IntegrationTypePagerduty, db.IntegrationTypeOpsgenie:
 		openCriticalKey, openWarningKey, err := n.getOpenIncidents(notification)
@@ -138,26 +309,82 @@ func (n *IncidentNotifier) enqueue(now timeseries.Time, project *db.Project, app
 			klog.Errorln(err)
 			return
 		}
-		externalKey := fmt.Sprintf("%s:%s:%s", project.Id, incident.Key, incident.Severity.String())
+		externalKey := fmt.Sprintf("%s:%s:%s", notification.ProjectId, notification.IncidentKey, notification.Status.String())
 		switch {
-		case incident.Resolved():
+		case resolved:
 			if openCriticalKey != "" {
 				n.onResolve(openCriticalKey, notification, nil)
 			}
 			if openWarningKey != "" {
 				n.onResolve(openWarningKey, notification, nil)
 			}
-		case incident.Severity == model.WARNING:
+		case notification.Status == model.WARNING:
 			if openCriticalKey != "" {
 				n.onResolve(openCriticalKey, notification, nil)
 			}
-			n.onOpen(externalKey, notification, incidentDetails(app, incident))
-		case incident.Severity == model.CRITICAL:
-			n.onOpen(externalKey, notification, incidentDetails(app, incident))
+			n.onOpen(externalKey, notification, details)
+		case notification.Status == model.CRITICAL:
+			n.onOpen(externalKey, notification, details)
 		}
 	default:
-		klog.Errorln("unknown destination:", destination)
+		klog.Errorln("unknown destination:", notification.Destination)
+	}
+}
+
+// Acknowledge propagates the acknowledgement of the incident to the paging integrations it was sent to.
+func (n *IncidentNotifier) Acknowledge(project *db.Project, incident *model.ApplicationIncident) {
+	for _, it := range []db.IntegrationType{db.IntegrationTypePagerduty, db.IntegrationTypeOpsgenie} {
+		notification := db.IncidentNotification{
+			ProjectId:     project.Id,
+			ApplicationId: incident.ApplicationId,
+			IncidentKey:   incident.Key,
+			Destination:   db.IncidentNotificationDestination{IntegrationType: it},
+			Timestamp:     timeseries.Now().Add(1),
+		}
+		acknowledger, ok := getClient(notification.Destination, project.Settings.Integrations).(IncidentAcknowledger)
+		if !ok {
+			continue
+		}
+		openCriticalKey, openWarningKey, err := n.getOpenIncidents(notification)
+		if err != nil {
+			klog.Errorln(err)
+			continue
+		}
+		for _, key := range []string{openCriticalKey, openWarningKey} {
+			if key == "" {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+			if err = acknowledger.AcknowledgeIncident(ctx, key, incident.Lifecycle.AcknowledgedBy); err != nil {
+				klog.Errorf("failed to acknowledge in %s: %s", it, err)
+			}
+			cancel()
+		}
+	}
+}
+
+func (n *IncidentNotifier) needsEscalation(notification db.IncidentNotification) bool {
+	resolved, acknowledged := n.incidentState(notification)
+	return !resolved && !acknowledged
+}
+
+// incidentState returns whether the incident or the alert the notification is about is resolved or acknowledged.
+// If the state can't be determined, the incident is considered open, unless the alert is gone.
+func (n *IncidentNotifier) incidentState(notification db.IncidentNotification) (bool, bool) {
+	incident, err := n.db.GetIncidentByKey(notification.ProjectId, notification.IncidentKey)
+	if err == nil {
+		return incident.Resolved(), incident.Acknowledged()
+	}
+	if !errors.Is(err, db.ErrNotFound) {
+		klog.Errorln(err)
+		return false, false
+	}
+	alert, err := n.db.GetAlertByKey(notification.ProjectId, notification.IncidentKey)
+	if err != nil {
+		klog.Errorln(err)
+		return true, false
 	}
+	return alert.Resolved(), false
 }
 
 func (n *IncidentNotifier) onOpen(externalKey string, notification db.IncidentNotification, details *db.IncidentNotificationDetails) {
@@ -0,0 +1,93 @@
+package notifications
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSilencedNotifications(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer srv.Close()
+
+	database, err := db.NewSqlite(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, database.Migrate())
+	project := &db.Project{Name: "test"}
+	require.NoError(t, database.SaveProject(project))
+	project.Settings.Integrations.Webhook = &db.IntegrationWebhook{Url: srv.URL, Incidents: true, IncidentTemplate: `{{.Key}}`}
+	require.NoError(t, database.SaveProjectIntegration(project, db.IntegrationTypeWebhook))
+
+	rule := &db.AlertRule{Name: "errors", Severity: model.CRITICAL}
+	rule.Id, err = database.CreateAlertRule(project.Id, rule)
+	require.NoError(t, err)
+	alert := &db.Alert{RuleId: rule.Id, Key: "k1", OpenedAt: 100, Severity: model.CRITICAL}
+	require.NoError(t, database.CreateAlert(project.Id, alert))
+
+	now := timeseries.Now()
+	silence := &db.Silence{
+		Matchers: []db.SilenceMatcher{{Name: db.SilenceMatcherCheckId, Op: "=", Value: rule.Id}},
+		StartsAt: now.Add(-timeseries.Minute),
+		EndsAt:   now.Add(timeseries.Minute),
+	}
+	silence.Id, err = database.CreateSilence(project.Id, silence)
+	require.NoError(t, err)
+
+	n := &IncidentNotifier{db: database}
+	target := db.SilenceTarget{CheckIds: []model.CheckId{model.CheckId(rule.Id)}}
+	until := n.silencedUntil(project.Id, target, now)
+	assert.Equal(t, silence.EndsAt, until)
+	assert.True(t, n.silencedUntil(project.Id, db.SilenceTarget{CheckIds: []model.CheckId{"other"}}, now).IsZero())
+
+	pending := func() []db.IncidentNotification {
+		res, err := database.GetNotSentIncidentNotifications(now.Add(-timeseries.Hour), now.Add(timeseries.Hour))
+		require.NoError(t, err)
+		return res
+	}
+
+	// the notification is deferred until the end of the silence instead of being dropped
+	notification := db.IncidentNotification{ProjectId: project.Id, IncidentKey: alert.Key, Timestamp: now, Status: model.CRITICAL}
+	destinations := []db.RoutedDestination{{Destination: db.IncidentNotificationDestination{IntegrationType: db.IntegrationTypeWebhook}}}
+	n.enqueueAll(notification, destinations, false, alertDetails(rule, alert), target, until, false)
+	n.sendIncidents()
+	assert.Equal(t, 0, requests)
+	p := pending()
+	require.Len(t, p, 1)
+	assert.Equal(t, silence.EndsAt, p[0].Timestamp)
+	require.NotNil(t, p[0].Details.Silenced)
+
+	// the notification becomes due while the incident is still silenced
+	n.reschedule(p[0], now.Add(-1))
+	n.sendIncidents()
+	assert.Equal(t, 0, requests)
+	p = pending()
+	require.Len(t, p, 1)
+	assert.Equal(t, silence.EndsAt, p[0].Timestamp)
+
+	// the silence is over
+	require.NoError(t, database.DeleteSilence(project.Id, silence.Id))
+	n.reschedule(p[0], now.Add(-1))
+	n.sendIncidents()
+	assert.Equal(t, 1, requests)
+	assert.Empty(t, pending())
+
+	// the incident was resolved during the silence
+	alert.ResolvedAt = now
+	require.NoError(t, database.UpdateAlert(project.Id, alert))
+	notification.Timestamp = now.Add(-2)
+	notification.Destination = destinations[0].Destination
+	notification.Details = &db.IncidentNotificationDetails{Silenced: &target}
+	database.PutIncidentNotification(notification)
+	n.sendIncidents()
+	assert.Equal(t, 1, requests)
+	assert.Empty(t, pending())
+}
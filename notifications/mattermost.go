@@ -0,0 +1,97 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+)
+
+type Mattermost struct {
+	webhookUrl string
+	channel    string
+}
+
+type mattermostMessage struct {
+	Channel     string                 `json:"channel,omitempty"`
+	Username    string                 `json:"username"`
+	Attachments []mattermostAttachment `json:"attachments"`
+}
+
+type mattermostAttachment struct {
+	Fallback  string            `json:"fallback"`
+	Color     string            `json:"color"`
+	Title     string            `json:"title"`
+	TitleLink string            `json:"title_link"`
+	Text      string            `json:"text,omitempty"`
+	Fields    []mattermostField `json:"fields,omitempty"`
+}
+
+type mattermostField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+func NewMattermost(webhookUrl, channel string) *Mattermost {
+	return &Mattermost{webhookUrl: webhookUrl, channel: channel}
+}
+
+func (m *Mattermost) SendIncident(ctx context.Context, baseUrl string, n *db.IncidentNotification) error {
+	var title string
+	subject, problem := incidentSubject(n)
+	if n.Status == model.OK {
+		title = fmt.Sprintf("%s incident resolved", subject)
+	} else {
+		title = fmt.Sprintf("[%s] %s %s", strings.ToUpper(n.Status.String()), subject, problem)
+	}
+	var details []string
+	if n.Details != nil {
+		for _, r := range n.Details.Reports {
+			details = append(details, fmt.Sprintf("- **%s** / %s: %s", r.Name, r.Check, r.Message))
+		}
+	}
+	return m.send(ctx, mattermostAttachment{
+		Fallback:  title,
+		Color:     n.Status.Color(),
+		Title:     title,
+		TitleLink: incidentUrl(baseUrl, n),
+		Text:      strings.Join(details, "\n"),
+	})
+}
+
+func (m *Mattermost) SendDeployment(ctx context.Context, project *db.Project, ds model.ApplicationDeploymentStatus) error {
+	if ds.State == model.ApplicationDeploymentStateInProgress {
+		return nil
+	}
+	d := ds.Deployment
+	title := fmt.Sprintf("Deployment of %s to %s", d.ApplicationId.Name, project.Name)
+	a := mattermostAttachment{
+		Fallback:  fmt.Sprintf("%s: %s", title, deploymentStatus(ds)),
+		Color:     ds.Status.Color(),
+		Title:     title,
+		TitleLink: deploymentUrl(project.Settings.Integrations.BaseUrl, project.Id, d),
+		Fields: []mattermostField{
+			{Title: "Status", Value: deploymentStatus(ds), Short: true},
+			{Title: "Version", Value: d.Version(), Short: true},
+		},
+	}
+	if summary := deploymentSummary(ds); len(summary) > 0 {
+		a.Fields = append(a.Fields, mattermostField{Title: "Summary", Value: strings.Join(summary, "\n")})
+	}
+	return m.send(ctx, a)
+}
+
+func (m *Mattermost) send(ctx context.Context, a mattermostAttachment) error {
+	msg := mattermostMessage{
+		Channel:     m.channel,
+		Username:    "Coroot",
+		Attachments: []mattermostAttachment{a},
+	}
+	if err := postJson(ctx, m.webhookUrl, msg, nil); err != nil {
+		return fmt.Errorf("mattermost error: %w", err)
+	}
+	return nil
+}
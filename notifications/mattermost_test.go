@@ -0,0 +1,52 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMattermost(t *testing.T) {
+	var messages []mattermostMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg mattermostMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		messages = append(messages, msg)
+		if msg.Channel == "archived" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("channel is archived"))
+		}
+	}))
+	defer srv.Close()
+
+	n := &db.IncidentNotification{
+		ProjectId:   "p1",
+		IncidentKey: "abc",
+		Status:      model.CRITICAL,
+		Details: &db.IncidentNotificationDetails{
+			AlertRule: &db.IncidentNotificationDetailsAlertRule{Id: "r1", Name: "High error rate"},
+			Reports:   []db.IncidentNotificationDetailsReport{{Name: "Alert", Check: "High error rate", Message: "value 10 > 5"}},
+		},
+	}
+	require.NoError(t, NewMattermost(srv.URL, "alerts").SendIncident(context.Background(), "http://coroot", n))
+	require.Len(t, messages, 1)
+	assert.Equal(t, "alerts", messages[0].Channel)
+	assert.Equal(t, "Coroot", messages[0].Username)
+	require.Len(t, messages[0].Attachments, 1)
+	a := messages[0].Attachments[0]
+	assert.Equal(t, "[CRITICAL] High error rate is firing", a.Title)
+	assert.Equal(t, a.Title, a.Fallback)
+	assert.Equal(t, "#f44034", a.Color)
+	assert.Equal(t, "http://coroot/p/p1/alert_rules?rule=r1", a.TitleLink)
+	assert.Equal(t, "- **Alert** / High error rate: value 10 > 5", a.Text)
+
+	err := NewMattermost(srv.URL, "archived").SendIncident(context.Background(), "http://coroot", n)
+	assert.EqualError(t, err, "mattermost error: 400 Bad Request: channel is archived")
+}
@@ -1,9 +1,14 @@
 package notifications
 
 import (
+	"bytes"
 	"cmp"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/coroot/coroot/db"
@@ -12,9 +17,10 @@ import (
 )
 
 const (
-	sendTimeout   = 30 * time.Second
-	retryInterval = time.Minute
-	retryWindow   = timeseries.Hour
+	sendTimeout            = 30 * time.Second
+	retryInterval          = time.Minute
+	RetryWindow            = timeseries.Hour
+	silenceRecheckInterval = 5 * timeseries.Minute
 )
 
 type NotificationClient interface {
@@ -22,6 +28,16 @@ type NotificationClient interface {
 	SendDeployment(ctx context.Context, project *db.Project, ds model.ApplicationDeploymentStatus) error
 }
 
+// DeliveryLogger is implemented by the clients that keep the log of their delivery attempts.
+type DeliveryLogger interface {
+	Deliveries() []db.NotificationDelivery
+}
+
+// IncidentAcknowledger is implemented by the paging integrations that escalate incidents until they are acknowledged.
+type IncidentAcknowledger interface {
+	AcknowledgeIncident(ctx context.Context, externalKey string, user string) error
+}
+
 func getClient(destination db.IncidentNotificationDestination, integrations db.Integrations) NotificationClient {
 	switch destination.IntegrationType {
 	case db.IntegrationTypeSlack:
@@ -44,10 +60,91 @@ func getClient(destination db.IncidentNotificationDestination, integrations db.I
 		if cfg := integrations.Webhook; cfg != nil && cfg.Incidents {
 			return NewWebhook(cfg)
 		}
+	case db.IntegrationTypeEmail:
+		if cfg := integrations.Email; cfg != nil && cfg.Incidents {
+			return NewEmail(cfg, splitRecipients(destination.EmailTo))
+		}
+	case db.IntegrationTypeMattermost:
+		if cfg := integrations.Mattermost; cfg != nil && cfg.Incidents {
+			return NewMattermost(cfg.WebhookUrl, cfg.Channel)
+		}
+	case db.IntegrationTypeDiscord:
+		if cfg := integrations.Discord; cfg != nil && cfg.Incidents {
+			return NewDiscord(cfg.WebhookUrl)
+		}
+	case db.IntegrationTypeGoogleChat:
+		if cfg := integrations.GoogleChat; cfg != nil && cfg.Incidents {
+			return NewGoogleChat(cfg.WebhookUrl)
+		}
+	case db.IntegrationTypeTelegram:
+		if cfg := integrations.Telegram; cfg != nil && cfg.Incidents {
+			return NewTelegram(cfg.BotToken, cfg.ChatId)
+		}
 	}
 	return nil
 }
 
+func postJson(ctx context.Context, url string, payload any, result any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%s: %s", resp.Status, string(body))
+	}
+	if result != nil && len(body) > 0 {
+		return json.Unmarshal(body, result)
+	}
+	return nil
+}
+
+func deploymentStatus(ds model.ApplicationDeploymentStatus) string {
+	switch ds.State {
+	case model.ApplicationDeploymentStateInProgress:
+		return "In-progress"
+	case model.ApplicationDeploymentStateStuck:
+		return "Stuck"
+	case model.ApplicationDeploymentStateCancelled:
+		return "Cancelled"
+	}
+	return "Deployed"
+}
+
+func deploymentSummary(ds model.ApplicationDeploymentStatus) []string {
+	if ds.State != model.ApplicationDeploymentStateSummary {
+		return nil
+	}
+	if len(ds.Summary) == 0 {
+		return []string{"No notable changes"}
+	}
+	var res []string
+	for _, s := range ds.Summary {
+		res = append(res, fmt.Sprintf("%s %s", s.Emoji(), s.Message))
+	}
+	return res
+}
+
+func splitRecipients(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
 func incidentDetails(app *model.Application, incident *model.ApplicationIncident) *db.IncidentNotificationDetails {
 	var reports []db.IncidentNotificationDetailsReport
 	if !incident.Resolved() {
@@ -75,10 +172,40 @@ func incidentDetails(app *model.Application, incident *model.ApplicationIncident
 	return &db.IncidentNotificationDetails{Reports: reports}
 }
 
+func alertDetails(rule *db.AlertRule, alert *db.Alert) *db.IncidentNotificationDetails {
+	details := &db.IncidentNotificationDetails{
+		AlertRule: &db.IncidentNotificationDetailsAlertRule{Id: rule.Id, Name: rule.Name},
+	}
+	if !alert.Resolved() && alert.Details.Message != "" {
+		details.Reports = append(details.Reports, db.IncidentNotificationDetailsReport{
+			Name:    "Alert",
+			Check:   rule.Name,
+			Message: alert.Details.Message,
+		})
+	}
+	return details
+}
+
+// incidentSubject returns the name of the affected application or the alert rule, and the description of the problem.
+func incidentSubject(n *db.IncidentNotification) (string, string) {
+	if n.Details != nil && n.Details.AlertRule != nil {
+		return n.Details.AlertRule.Name, "is firing"
+	}
+	return n.ApplicationId.Name, "is not meeting its SLOs"
+}
+
 func incidentUrl(baseUrl string, n *db.IncidentNotification) string {
+	if n.Details != nil && n.Details.AlertRule != nil {
+		return fmt.Sprintf("%s/p/%s/alert_rules?rule=%s", baseUrl, n.ProjectId, n.Details.AlertRule.Id)
+	}
 	return fmt.Sprintf("%s/p/%s/incidents?incident=%s", baseUrl, n.ProjectId, n.IncidentKey)
 }
 
+// rcaUrl returns the link to the root cause analysis of the incident, which is shown in the overview of the incident.
+func rcaUrl(baseUrl string, n *db.IncidentNotification) string {
+	return incidentUrl(baseUrl, n) + "&view=overview"
+}
+
 func deploymentUrl(baseUrl string, projectId db.ProjectId, d *model.ApplicationDeployment) string {
 	return fmt.Sprintf("%s/p/%s/app/%s/Deployments#%s", baseUrl, projectId, d.ApplicationId.String(), d.Id())
 }
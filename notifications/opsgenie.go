@@ -42,8 +42,9 @@ func (og *Opsgenie) SendIncident(ctx context.Context, baseUrl string, n *db.Inci
 		return err
 	}
 
+	subject, problem := incidentSubject(n)
 	req := &alert.CreateAlertRequest{
-		Message: fmt.Sprintf("[%s] %s is not meeting its SLOs", strings.ToUpper(n.Status.String()), n.ApplicationId.Name),
+		Message: fmt.Sprintf("[%s] %s %s", strings.ToUpper(n.Status.String()), subject, problem),
 		Alias:   n.ExternalKey,
 		Source:  "Coroot",
 	}
@@ -65,6 +66,17 @@ func (og *Opsgenie) SendIncident(ctx context.Context, baseUrl string, n *db.Inci
 	return err
 }
 
+func (og *Opsgenie) AcknowledgeIncident(ctx context.Context, externalKey string, user string) error {
+	req := &alert.AcknowledgeAlertRequest{
+		IdentifierType:  alert.ALIAS,
+		IdentifierValue: externalKey,
+		User:            user,
+		Source:          "Coroot",
+	}
+	_, err := og.client.Acknowledge(ctx, req)
+	return err
+}
+
 func (og *Opsgenie) SendDeployment(ctx context.Context, project *db.Project, ds model.ApplicationDeploymentStatus) error {
 	return fmt.Errorf("not supported")
 }
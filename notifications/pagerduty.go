@@ -26,11 +26,12 @@ func (pd *Pagerduty) SendIncident(ctx context.Context, baseUrl string, n *db.Inc
 	if n.Status == model.OK {
 		e.Action = "resolve"
 	} else {
+		subject, problem := incidentSubject(n)
 		e.Action = "trigger"
 		e.Client = "Coroot"
 		e.ClientURL = incidentUrl(baseUrl, n)
 		e.Payload = &pagerduty.V2Payload{
-			Summary:   fmt.Sprintf("[%s] %s is not meeting its SLOs", strings.ToUpper(n.Status.String()), n.ApplicationId.Name),
+			Summary:   fmt.Sprintf("[%s] %s %s", strings.ToUpper(n.Status.String()), subject, problem),
 			Source:    "Coroot",
 			Severity:  n.Status.String(),
 			Timestamp: n.Timestamp.ToStandard().String(),
@@ -47,6 +48,16 @@ func (pd *Pagerduty) SendIncident(ctx context.Context, baseUrl string, n *db.Inc
 	return err
 }
 
+func (pd *Pagerduty) AcknowledgeIncident(ctx context.Context, externalKey string, user string) error {
+	e := pagerduty.V2Event{
+		RoutingKey: pd.integrationKey,
+		DedupKey:   externalKey,
+		Action:     "acknowledge",
+	}
+	_, err := pagerduty.ManageEventWithContext(ctx, e)
+	return err
+}
+
 func (pd *Pagerduty) SendDeployment(ctx context.Context, project *db.Project, ds model.ApplicationDeploymentStatus) error {
 	return fmt.Errorf("not supported")
 }
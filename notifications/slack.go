@@ -33,12 +33,13 @@ func (s *Slack) SendIncident(ctx context.Context, baseUrl string, n *db.Incident
 		ch = s.channel
 	}
 	var header, snippet string
+	subject, problem := incidentSubject(n)
 	if n.Status == model.OK {
-		header = fmt.Sprintf("<%s|*%s* incident resolved>", incidentUrl(baseUrl, n), n.ApplicationId.Name)
-		snippet = fmt.Sprintf("%s incident resolved", n.ApplicationId.Name)
+		header = fmt.Sprintf("<%s|*%s* incident resolved>", incidentUrl(baseUrl, n), subject)
+		snippet = fmt.Sprintf("%s incident resolved", subject)
 	} else {
-		header = fmt.Sprintf("[%s] <%s|*%s* is not meeting its SLOs>", strings.ToUpper(n.Status.String()), incidentUrl(baseUrl, n), n.ApplicationId.Name)
-		snippet = fmt.Sprintf("%s is not meeting its SLOs", n.ApplicationId.Name)
+		header = fmt.Sprintf("[%s] <%s|*%s* %s>", strings.ToUpper(n.Status.String()), incidentUrl(baseUrl, n), subject, problem)
+		snippet = fmt.Sprintf("%s %s", subject, problem)
 	}
 	var details []string
 	if n.Details != nil {
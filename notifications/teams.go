@@ -27,10 +27,11 @@ func NewTeams(webhookUrl string) *Teams {
 
 func (t *Teams) SendIncident(ctx context.Context, baseUrl string, n *db.IncidentNotification) error {
 	var title string
+	subject, problem := incidentSubject(n)
 	if n.Status == model.OK {
-		title = fmt.Sprintf("**%s** incident resolved", n.ApplicationId.Name)
+		title = fmt.Sprintf("**%s** incident resolved", subject)
 	} else {
-		title = fmt.Sprintf("[%s] **%s** is not meeting its SLOs", strings.ToUpper(n.Status.String()), n.ApplicationId.Name)
+		title = fmt.Sprintf("[%s] **%s** %s", strings.ToUpper(n.Status.String()), subject, problem)
 	}
 	text := ""
 	if n.Details != nil {
@@ -0,0 +1,124 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+)
+
+const telegramApiUrl = "https://api.telegram.org"
+
+type Telegram struct {
+	apiUrl   string
+	botToken string
+	chatId   string
+}
+
+type telegramMessage struct {
+	ChatId             string                     `json:"chat_id"`
+	Text               string                     `json:"text"`
+	ParseMode          string                     `json:"parse_mode"`
+	LinkPreviewOptions telegramLinkPreviewOptions `json:"link_preview_options"`
+	ReplyParameters    *telegramReplyParameters   `json:"reply_parameters,omitempty"`
+}
+
+type telegramLinkPreviewOptions struct {
+	IsDisabled bool `json:"is_disabled"`
+}
+
+type telegramReplyParameters struct {
+	MessageId                int  `json:"message_id"`
+	AllowSendingWithoutReply bool `json:"allow_sending_without_reply"`
+}
+
+type telegramResponse struct {
+	Ok          bool   `json:"ok"`
+	Description string `json:"description"`
+	Result      struct {
+		MessageId int `json:"message_id"`
+	} `json:"result"`
+}
+
+func NewTelegram(botToken, chatId string) *Telegram {
+	return &Telegram{apiUrl: telegramApiUrl, botToken: botToken, chatId: chatId}
+}
+
+func (t *Telegram) SendIncident(ctx context.Context, baseUrl string, n *db.IncidentNotification) error {
+	subject, problem := incidentSubject(n)
+	var text strings.Builder
+	if n.Status == model.OK {
+		fmt.Fprintf(&text, "✅ <a href=\"%s\"><b>%s</b> incident resolved</a>", html.EscapeString(incidentUrl(baseUrl, n)), html.EscapeString(subject))
+	} else {
+		icon := "🟡"
+		if n.Status == model.CRITICAL {
+			icon = "🔴"
+		}
+		fmt.Fprintf(&text, "%s [%s] <a href=\"%s\"><b>%s</b> %s</a>",
+			icon, strings.ToUpper(n.Status.String()), html.EscapeString(incidentUrl(baseUrl, n)), html.EscapeString(subject), html.EscapeString(problem))
+	}
+	if n.Details != nil {
+		for _, r := range n.Details.Reports {
+			fmt.Fprintf(&text, "\n• <b>%s</b> / %s: %s", html.EscapeString(string(r.Name)), html.EscapeString(r.Check), html.EscapeString(r.Message))
+		}
+	}
+	// the external key holds the id of the message about the opened incident, so that updates are sent as replies
+	var replyTo int
+	if n.ExternalKey != "" {
+		replyTo, _ = strconv.Atoi(n.ExternalKey)
+	}
+	id, err := t.send(ctx, text.String(), replyTo)
+	if err != nil {
+		return err
+	}
+	if replyTo == 0 {
+		n.ExternalKey = strconv.Itoa(id)
+	}
+	return nil
+}
+
+func (t *Telegram) SendDeployment(ctx context.Context, project *db.Project, ds model.ApplicationDeploymentStatus) error {
+	if ds.State == model.ApplicationDeploymentStateInProgress {
+		return nil
+	}
+	d := ds.Deployment
+	url := deploymentUrl(project.Settings.Integrations.BaseUrl, project.Id, d)
+	var text strings.Builder
+	fmt.Fprintf(&text, "Deployment of <a href=\"%s\"><b>%s</b></a> to <b>%s</b>\n\n", html.EscapeString(url), html.EscapeString(d.ApplicationId.Name), html.EscapeString(project.Name))
+	fmt.Fprintf(&text, "<b>Status</b>: %s\n", html.EscapeString(deploymentStatus(ds)))
+	fmt.Fprintf(&text, "<b>Version</b>: %s\n", html.EscapeString(d.Version()))
+	if summary := deploymentSummary(ds); len(summary) > 0 {
+		text.WriteString("\n<b>Summary</b>:")
+		for _, s := range summary {
+			fmt.Fprintf(&text, "\n%s", html.EscapeString(s))
+		}
+	}
+	_, err := t.send(ctx, text.String(), 0)
+	return err
+}
+
+func (t *Telegram) send(ctx context.Context, text string, replyTo int) (int, error) {
+	msg := telegramMessage{
+		ChatId:             t.chatId,
+		Text:               text,
+		ParseMode:          "HTML",
+		LinkPreviewOptions: telegramLinkPreviewOptions{IsDisabled: true},
+	}
+	if replyTo > 0 {
+		msg.ReplyParameters = &telegramReplyParameters{MessageId: replyTo, AllowSendingWithoutReply: true}
+	}
+	var resp telegramResponse
+	err := postJson(ctx, fmt.Sprintf("%s/bot%s/sendMessage", t.apiUrl, t.botToken), msg, &resp)
+	if err != nil {
+		// the bot token is a part of the url, so it must not be included in the error
+		return 0, fmt.Errorf("telegram error: %s", strings.ReplaceAll(err.Error(), t.botToken, "<hidden>"))
+	}
+	if !resp.Ok {
+		return 0, fmt.Errorf("telegram error: %s", resp.Description)
+	}
+	return resp.Result.MessageId, nil
+}
@@ -0,0 +1,72 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelegram(t *testing.T) {
+	var paths []string
+	var messages []telegramMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		var msg telegramMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		messages = append(messages, msg)
+		if msg.ChatId == "unknown" {
+			_, _ = w.Write([]byte(`{"ok":false,"description":"Bad Request: chat not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true,"result":{"message_id":42}}`))
+	}))
+	defer srv.Close()
+
+	tg := &Telegram{apiUrl: srv.URL, botToken: "123:secret", chatId: "-100"}
+	n := &db.IncidentNotification{
+		ProjectId:   "p1",
+		IncidentKey: "abc",
+		Status:      model.CRITICAL,
+		Details: &db.IncidentNotificationDetails{
+			AlertRule: &db.IncidentNotificationDetailsAlertRule{Id: "r1", Name: "5xx <errors>"},
+			Reports:   []db.IncidentNotificationDetailsReport{{Name: "Alert", Check: "5xx <errors>", Message: "value 10 > 5"}},
+		},
+	}
+	require.NoError(t, tg.SendIncident(context.Background(), "http://coroot", n))
+	assert.Equal(t, "42", n.ExternalKey)
+	assert.Equal(t, "/bot123:secret/sendMessage", paths[0])
+	assert.Equal(t, "-100", messages[0].ChatId)
+	assert.Equal(t, "HTML", messages[0].ParseMode)
+	assert.True(t, messages[0].LinkPreviewOptions.IsDisabled)
+	assert.Nil(t, messages[0].ReplyParameters)
+	assert.Equal(t,
+		"🔴 [CRITICAL] <a href=\"http://coroot/p/p1/alert_rules?rule=r1\"><b>5xx &lt;errors&gt;</b> is firing</a>\n"+
+			"• <b>Alert</b> / 5xx &lt;errors&gt;: value 10 &gt; 5",
+		messages[0].Text)
+
+	// the resolution is a reply to the message about the opened incident
+	n.Status = model.OK
+	require.NoError(t, tg.SendIncident(context.Background(), "http://coroot", n))
+	assert.Equal(t, "42", n.ExternalKey)
+	require.NotNil(t, messages[1].ReplyParameters)
+	assert.Equal(t, 42, messages[1].ReplyParameters.MessageId)
+	assert.True(t, messages[1].ReplyParameters.AllowSendingWithoutReply)
+
+	tg.chatId = "unknown"
+	err := tg.SendIncident(context.Background(), "http://coroot", n)
+	assert.EqualError(t, err, "telegram error: Bad Request: chat not found")
+
+	// the bot token is a part of the url, which is included in the transport errors
+	srv.Close()
+	err = tg.SendIncident(context.Background(), "http://coroot", n)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "123:secret")
+	assert.Contains(t, err.Error(), "/bot<hidden>/sendMessage")
+}
@@ -2,30 +2,47 @@ package notifications
 
 import (
 	"bytes"
+	"cmp"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/coroot/coroot/utils"
 
 	"github.com/coroot/coroot/db"
 	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+)
+
+const (
+	webhookSignatureHeader    = "X-Coroot-Signature"
+	webhookDefaultMaxAttempts = 3
+	webhookRetryBackoff       = time.Second
 )
 
 type Webhook struct {
-	cfg *db.IntegrationWebhook
+	cfg        *db.IntegrationWebhook
+	deliveries []db.NotificationDelivery
 }
 
 type IncidentTemplateValues struct {
+	Key         string                                 `json:"key"`
 	Status      string                                 `json:"status"`
 	Application model.ApplicationId                    `json:"application"`
 	Reports     []db.IncidentNotificationDetailsReport `json:"reports"`
+	AlertRule   string                                 `json:"alert_rule,omitempty"`
+	Timestamp   time.Time                              `json:"timestamp"`
 	URL         string                                 `json:"url"`
+	RCAURL      string                                 `json:"rca_url,omitempty"`
 }
 
 type DeploymentTemplateValues struct {
@@ -33,6 +50,7 @@ type DeploymentTemplateValues struct {
 	Application model.ApplicationId `json:"application"`
 	Version     string              `json:"version"`
 	Summary     []string            `json:"summary"`
+	StartedAt   time.Time           `json:"started_at"`
 	URL         string              `json:"url"`
 }
 
@@ -48,12 +66,20 @@ func (wh *Webhook) SendIncident(ctx context.Context, baseUrl string, n *db.Incid
 
 	var data bytes.Buffer
 	values := IncidentTemplateValues{
+		Key:         n.IncidentKey,
 		Status:      strings.ToUpper(n.Status.String()),
 		Application: n.ApplicationId,
+		Timestamp:   n.Timestamp.ToStandard(),
 		URL:         incidentUrl(baseUrl, n),
 	}
 	if n.Details != nil {
 		values.Reports = n.Details.Reports
+		if n.Details.AlertRule != nil {
+			values.AlertRule = n.Details.AlertRule.Name
+		}
+	}
+	if values.AlertRule == "" {
+		values.RCAURL = rcaUrl(baseUrl, n)
 	}
 	err = tmpl.Execute(&data, values)
 	if err != nil {
@@ -93,6 +119,7 @@ func (wh *Webhook) SendDeployment(ctx context.Context, project *db.Project, ds m
 		Status:      status,
 		Version:     ds.Deployment.Version(),
 		Summary:     summary,
+		StartedAt:   ds.Deployment.StartedAt.ToStandard(),
 		URL:         deploymentUrl(project.Settings.Integrations.BaseUrl, project.Id, ds.Deployment),
 	})
 	if err != nil {
@@ -103,9 +130,38 @@ func (wh *Webhook) SendDeployment(ctx context.Context, project *db.Project, ds m
 }
 
 func (wh *Webhook) send(ctx context.Context, data []byte) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.cfg.Url, bytes.NewReader(utils.EscapeJsonMultilineStrings(data)))
+	body := utils.EscapeJsonMultilineStrings(data)
+	maxAttempts := cmp.Or(wh.cfg.MaxAttempts, webhookDefaultMaxAttempts)
+	backoff := webhookRetryBackoff
+	for attempt := 1; ; attempt++ {
+		retryable, err := wh.post(ctx, body, attempt)
+		if err == nil || !retryable || attempt >= maxAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// post makes a single delivery attempt and reports whether it's worth retrying in case of an error.
+func (wh *Webhook) post(ctx context.Context, body []byte, attempt int) (retryable bool, err error) {
+	start := time.Now()
+	delivery := db.NotificationDelivery{AttemptedAt: timeseries.Now(), Attempt: attempt}
+	defer func() {
+		delivery.DurationMs = time.Since(start).Milliseconds()
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+		wh.deliveries = append(wh.deliveries, delivery)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.cfg.Url, bytes.NewReader(body))
 	if err != nil {
-		return err
+		return false, err
 	}
 	if wh.cfg.BasicAuth != nil && wh.cfg.BasicAuth.User != "" && wh.cfg.BasicAuth.Password != "" {
 		req.SetBasicAuth(wh.cfg.BasicAuth.User, wh.cfg.BasicAuth.Password)
@@ -114,6 +170,9 @@ func (wh *Webhook) send(ctx context.Context, data []byte) error {
 	for _, h := range wh.cfg.CustomHeaders {
 		req.Header.Add(h.Key, h.Value)
 	}
+	if wh.cfg.Secret != "" {
+		req.Header.Set(webhookSignatureHeader, webhookSignature(wh.cfg.Secret, body))
+	}
 	httpClient := &http.Client{}
 	if wh.cfg.TlsSkipVerify {
 		httpClient.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
@@ -121,19 +180,34 @@ func (wh *Webhook) send(ctx context.Context, data []byte) error {
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		return err
+		return true, err
 	}
 	defer resp.Body.Close()
+	delivery.StatusCode = resp.StatusCode
 
 	if resp.StatusCode >= http.StatusBadRequest {
-		body, err := io.ReadAll(resp.Body)
+		retryable = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
 		if err != nil {
-			return fmt.Errorf("response status: %s", resp.Status)
+			return retryable, fmt.Errorf("response status: %s", resp.Status)
 		}
-		return fmt.Errorf("%s: %s", resp.Status, string(body))
+		return retryable, fmt.Errorf("%s: %s", resp.Status, string(body))
 	}
 
-	return nil
+	return false, nil
+}
+
+// Deliveries returns the log of the delivery attempts made by the client.
+func (wh *Webhook) Deliveries() []db.NotificationDelivery {
+	return wh.deliveries
+}
+
+// webhookSignature returns the value of the signature header: the hex-encoded HMAC-SHA256 of the body.
+// Receivers should compute it using the shared secret and compare with the received one in constant time.
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
 }
 
 var (
@@ -142,5 +216,15 @@ var (
 			data, err := json.Marshal(arg)
 			return string(data), err
 		},
+		"jsonEscape": func(s string) string {
+			data, _ := json.Marshal(s)
+			return string(data[1 : len(data)-1])
+		},
+		"formatTime": func(layout string, t time.Time) string {
+			return t.UTC().Format(layout)
+		},
+		"unixTime": func(t time.Time) int64 {
+			return t.Unix()
+		},
 	}
 )
@@ -0,0 +1,67 @@
+package notifications
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/coroot/coroot/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhook(t *testing.T) {
+	var bodies []string
+	var signatures []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		signatures = append(signatures, r.Header.Get(webhookSignatureHeader))
+		assert.Equal(t, "value", r.Header.Get("X-Custom"))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	wh := NewWebhook(&db.IntegrationWebhook{
+		Url:              srv.URL,
+		Secret:           "secret",
+		CustomHeaders:    []utils.Header{{Key: "X-Custom", Value: "value"}},
+		IncidentTemplate: `{"key": "{{.Key}}", "text": "{{jsonEscape .AlertRule}}", "at": "{{.Timestamp | formatTime "2006-01-02"}}", "rca": "{{.RCAURL}}"}`,
+	})
+	n := &db.IncidentNotification{
+		ProjectId:     "p1",
+		ApplicationId: model.NewApplicationId("default", model.ApplicationKindDeployment, "app"),
+		IncidentKey:   "abc",
+		Status:        model.CRITICAL,
+		Timestamp:     timeseries.Time(1700000000),
+		Details:       &db.IncidentNotificationDetails{AlertRule: &db.IncidentNotificationDetailsAlertRule{Name: `"quoted"`}},
+	}
+	require.NoError(t, wh.SendIncident(context.Background(), "http://coroot", n))
+
+	require.Len(t, bodies, 2)
+	assert.Equal(t, bodies[0], bodies[1])
+	assert.Equal(t, `{"key": "abc", "text": "\"quoted\"", "at": "2023-11-14", "rca": ""}`, bodies[1])
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(bodies[1]))
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), signatures[1])
+
+	deliveries := wh.Deliveries()
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, 1, deliveries[0].Attempt)
+	assert.Equal(t, http.StatusServiceUnavailable, deliveries[0].StatusCode)
+	assert.NotEmpty(t, deliveries[0].Error)
+	assert.Equal(t, 2, deliveries[1].Attempt)
+	assert.Equal(t, http.StatusOK, deliveries[1].StatusCode)
+	assert.Empty(t, deliveries[1].Error)
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	chgo "github.com/ClickHouse/ch-go"
@@ -27,10 +28,16 @@ import (
 	"k8s.io/klog"
 )
 
+const rollupsFromRefreshInterval = time.Hour
+
 type ClickHouse struct {
 	ch     *ch.LowLevelClient
 	engine *promql.Engine
 	step   timeseries.Duration
+
+	rollupsFrom          map[string]int64
+	rollupsFromUpdatedAt time.Time
+	rollupsFromLock      sync.Mutex
 }
 
 func newClickHouse(cfg *db.IntegrationClickhouse, step timeseries.Duration) (*ClickHouse, error) {
@@ -60,7 +67,41 @@ func (c *ClickHouse) GetStep(from, to timeseries.Time) (timeseries.Duration, err
 }
 
 func (c *ClickHouse) Querier(mint, maxt int64) (storage.Querier, error) {
-	return &clickhouseQuerier{ch: c.ch, mint: mint, maxt: maxt}, nil
+	return &clickhouseQuerier{ch: c.ch, mint: mint, maxt: maxt, rollupsFrom: c.getRollupsFrom()}, nil
+}
+
+// getRollupsFrom returns the time (ms) of the oldest data in the rollup tables, the empty tables are omitted.
+// It returns nil if the time can't be determined, so that only the raw metrics are queried.
+// The result is refreshed periodically, since the oldest data is removed according to the TTL of each table.
+func (c *ClickHouse) getRollupsFrom() map[string]int64 {
+	c.rollupsFromLock.Lock()
+	defer c.rollupsFromLock.Unlock()
+	if c.rollupsFrom != nil && time.Since(c.rollupsFromUpdatedAt) < rollupsFromRefreshInterval {
+		return c.rollupsFrom
+	}
+	res := map[string]int64{}
+	for _, r := range metricsRollups {
+		from := &proto.ColUInt32{}
+		err := c.ch.Do(context.TODO(), chgo.Query{
+			Body: "SELECT toUnixTimestamp(min(Timestamp)) AS MinTimestamp FROM " + r.table,
+			Result: proto.Results{
+				{Name: "MinTimestamp", Data: from},
+			},
+			OnResult: func(ctx context.Context, block proto.Block) error {
+				if block.Rows > 0 && from.Row(0) > 0 {
+					res[r.table] = int64(from.Row(0)) * 1000
+				}
+				return nil
+			},
+		})
+		if err != nil {
+			klog.Errorln("failed to get the oldest data of the metric rollups:", err)
+			return c.rollupsFrom
+		}
+	}
+	c.rollupsFrom = res
+	c.rollupsFromUpdatedAt = time.Now()
+	return res
 }
 
 func (c *ClickHouse) QueryRange(ctx context.Context, query string, filterLabels FilterLabelsF, from, to timeseries.Time, step timeseries.Duration) ([]*model.MetricValues, error) {
@@ -21,9 +21,59 @@ import (
 type clickhouseQuerier struct {
 	ch         *ch.LowLevelClient
 	mint, maxt int64
+	// rollupsFrom maps the rollup tables to the time (ms) of their oldest data
+	rollupsFrom map[string]int64
 }
 
-func (q *clickhouseQuerier) Select(ctx context.Context, _ bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+type metricsRollup struct {
+	table      string
+	resolution int64 // seconds
+}
+
+// metricsRollups are ordered from the coarsest to the finest
+var metricsRollups = []metricsRollup{
+	{table: "@@table_metrics_1h@@", resolution: 3600},
+	{table: "@@table_metrics_5m@@", resolution: 300},
+}
+
+// getMetricsRollup returns the coarsest rollup whose resolution divides the requested step.
+// Range selectors must cover at least two rollup points, otherwise rate() and similar functions return nothing.
+// The rollups are populated only since their views were created, so they are not used before their oldest complete point.
+// resets() and irate() depend on the individual samples and are always evaluated on the raw data.
+func getMetricsRollup(hints *storage.SelectHints, mint int64, rollupsFrom map[string]int64) *metricsRollup {
+	if hints == nil || hints.Step <= 0 {
+		return nil
+	}
+	switch hints.Func {
+	case "resets", "irate":
+		return nil
+	}
+	for _, r := range metricsRollups {
+		resolution := r.resolution * 1000
+		from, ok := rollupsFrom[r.table]
+		if !ok || mint < from+resolution {
+			continue
+		}
+		if hints.Step%resolution == 0 && (hints.Range == 0 || hints.Range >= 2*resolution) {
+			return &r
+		}
+	}
+	return nil
+}
+
+// rollupValueExpr returns the aggregate approximating the raw samples within a rollup interval for the given function.
+// Counters are represented by the maximum value, which is the last one unless the counter was reset.
+func rollupValueExpr(fn string) string {
+	switch fn {
+	case "rate", "increase", "max_over_time":
+		return "max(Max)"
+	case "min_over_time":
+		return "min(Min)"
+	}
+	return "sum(Sum) / sum(Count)"
+}
+
+func (q *clickhouseQuerier) selectQuery(hints *storage.SelectHints, matchers []*labels.Matcher) string {
 	query := fmt.Sprintf(`
 		SELECT
 		    MetricName,
@@ -36,6 +86,35 @@ func (q *clickhouseQuerier) Select(ctx context.Context, _ bool, hints *storage.S
 		GROUP BY MetricName, Labels
 		ORDER BY MetricName, Labels
 	`, q.mint/1000, q.maxt/1000, q.buildWhere(matchers))
+	if r := getMetricsRollup(hints, q.mint, q.rollupsFrom); r != nil {
+		// rollup points are timestamped with the end of their interval, so they don't include future samples
+		query = fmt.Sprintf(`
+			SELECT
+			    MetricName,
+			    Ls AS Labels,
+			    groupArray(T) AS Timestamps,
+			    groupArray(V) AS Values
+			FROM (
+				SELECT
+				    MetricName,
+				    MetricHash,
+				    any(Labels) AS Ls,
+				    toUInt32(toUnixTimestamp(Timestamp) + %d) AS T,
+				    %s AS V
+				FROM %s
+				WHERE
+				 Timestamp >= toDateTime(%d) AND Timestamp <= toDateTime(%d) %s
+				GROUP BY MetricName, MetricHash, Timestamp
+			)
+			GROUP BY MetricName, Ls
+			ORDER BY MetricName, Ls
+		`, r.resolution, rollupValueExpr(hints.Func), r.table, q.mint/1000-r.resolution, q.maxt/1000-r.resolution, q.buildWhere(matchers))
+	}
+	return query
+}
+
+func (q *clickhouseQuerier) Select(ctx context.Context, _ bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	query := q.selectQuery(hints, matchers)
 
 	metricName := (&proto.ColStr{}).LowCardinality()
 	metricLabels := proto.NewMap[string, string]((&proto.ColStr{}).LowCardinality(), &proto.ColStr{})
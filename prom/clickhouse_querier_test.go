@@ -0,0 +1,61 @@
+package prom
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMetricsRollup(t *testing.T) {
+	rollupsFrom := map[string]int64{"@@table_metrics_5m@@": 0, "@@table_metrics_1h@@": 0}
+	mint := int64(100 * 3600000)
+	table := func(hints *storage.SelectHints) string {
+		if r := getMetricsRollup(hints, mint, rollupsFrom); r != nil {
+			return r.table
+		}
+		return "raw"
+	}
+	assert.Equal(t, "raw", table(nil))
+	assert.Equal(t, "raw", table(&storage.SelectHints{Step: 15000}))
+	assert.Equal(t, "raw", table(&storage.SelectHints{Step: 450000}))
+	assert.Equal(t, "@@table_metrics_5m@@", table(&storage.SelectHints{Step: 300000}))
+	assert.Equal(t, "@@table_metrics_5m@@", table(&storage.SelectHints{Step: 900000, Range: 2700000}))
+	assert.Equal(t, "raw", table(&storage.SelectHints{Step: 300000, Range: 300000}))
+	assert.Equal(t, "@@table_metrics_1h@@", table(&storage.SelectHints{Step: 3600000, Range: 3 * 3600000}))
+	assert.Equal(t, "@@table_metrics_5m@@", table(&storage.SelectHints{Step: 3600000, Range: 3600000}))
+	assert.Equal(t, "raw", table(&storage.SelectHints{Step: 3600000, Range: 3 * 3600000, Func: "resets"}))
+	assert.Equal(t, "raw", table(&storage.SelectHints{Step: 300000, Range: 900000, Func: "irate"}))
+
+	// the oldest point of the hourly rollup may be incomplete, and the 5-minute rollup is empty
+	rollupsFrom = map[string]int64{"@@table_metrics_1h@@": mint - 1800000}
+	assert.Equal(t, "raw", table(&storage.SelectHints{Step: 3600000, Range: 3 * 3600000}))
+	rollupsFrom = map[string]int64{"@@table_metrics_1h@@": mint - 3600000}
+	assert.Equal(t, "@@table_metrics_1h@@", table(&storage.SelectHints{Step: 3600000, Range: 3 * 3600000}))
+	rollupsFrom = nil
+	assert.Equal(t, "raw", table(&storage.SelectHints{Step: 300000}))
+
+	assert.Equal(t, "max(Max)", rollupValueExpr("rate"))
+	assert.Equal(t, "min(Min)", rollupValueExpr("min_over_time"))
+	assert.Equal(t, "sum(Sum) / sum(Count)", rollupValueExpr(""))
+}
+
+func TestClickhouseQuerierSelectQuery(t *testing.T) {
+	q := &clickhouseQuerier{mint: 7200000, maxt: 10800000, rollupsFrom: map[string]int64{"@@table_metrics_5m@@": 0, "@@table_metrics_1h@@": 0}}
+	matchers := []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "http_requests_total")}
+
+	query := q.selectQuery(&storage.SelectHints{Step: 300000, Range: 900000, Func: "rate"}, matchers)
+	assert.Contains(t, query, "FROM @@table_metrics_5m@@")
+	assert.Contains(t, query, "max(Max) AS V")
+	assert.Contains(t, query, "toUInt32(toUnixTimestamp(Timestamp) + 300) AS T")
+	assert.Contains(t, query, "Timestamp >= toDateTime(6900) AND Timestamp <= toDateTime(10500) AND MetricName = 'http_requests_total'")
+
+	query = q.selectQuery(&storage.SelectHints{Step: 300000, Range: 900000, Func: "resets"}, matchers)
+	assert.Contains(t, query, "FROM @@table_metrics@@")
+	assert.Contains(t, query, "Timestamp >= toDateTime(7200) AND Timestamp <= toDateTime(10800) AND MetricName = 'http_requests_total'")
+
+	q.rollupsFrom = nil
+	query = q.selectQuery(&storage.SelectHints{Step: 300000, Range: 900000, Func: "rate"}, matchers)
+	assert.Contains(t, query, "FROM @@table_metrics@@")
+}
@@ -0,0 +1,229 @@
+package prom
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/timestamp"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"k8s.io/klog"
+)
+
+const (
+	remoteReadMaxSamples = 50000000
+	federateLookback     = 5 * time.Minute
+)
+
+// RemoteRead implements the Prometheus remote read API. Only the SAMPLES response type is supported,
+// Prometheus and Thanos fall back to it when streamed chunks are not available.
+func (c *ClickHouse) RemoteRead(r *http.Request, w http.ResponseWriter) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := snappy.Decode(nil, body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req prompb.ReadRequest
+	if err = proto.Unmarshal(data, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := &prompb.ReadResponse{Results: make([]*prompb.QueryResult, 0, len(req.Queries))}
+	samples := 0
+	for _, query := range req.Queries {
+		matchers, err := fromLabelMatchers(query.Matchers)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		q, _ := c.Querier(query.StartTimestampMs, query.EndTimestampMs)
+		hints := &storage.SelectHints{
+			Start: query.StartTimestampMs,
+			End:   query.EndTimestampMs,
+			Step:  int64(c.step * 1000),
+		}
+		if h := query.Hints; h != nil {
+			hints.Func = h.Func
+			hints.Grouping = h.Grouping
+			hints.By = h.By
+			hints.Range = h.RangeMs
+			if h.StepMs > 0 {
+				hints.Step = h.StepMs
+			}
+		}
+		set := q.Select(r.Context(), true, hints, matchers...)
+		result := &prompb.QueryResult{}
+		for set.Next() {
+			s := set.At()
+			ts := &prompb.TimeSeries{}
+			s.Labels().Range(func(l labels.Label) {
+				ts.Labels = append(ts.Labels, prompb.Label{Name: l.Name, Value: l.Value})
+			})
+			it := s.Iterator(nil)
+			for it.Next() == chunkenc.ValFloat {
+				t, v := it.At()
+				ts.Samples = append(ts.Samples, prompb.Sample{Timestamp: t, Value: v})
+			}
+			if err = it.Err(); err != nil {
+				klog.Errorln(err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			samples += len(ts.Samples)
+			if samples > remoteReadMaxSamples {
+				http.Error(w, fmt.Sprintf("exceeded sample limit (%d)", remoteReadMaxSamples), http.StatusBadRequest)
+				return
+			}
+			result.Timeseries = append(result.Timeseries, ts)
+		}
+		if err = set.Err(); err != nil {
+			klog.Errorln(err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	data, err = proto.Marshal(resp)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.Header().Set("Content-Encoding", "snappy")
+	_, _ = w.Write(snappy.Encode(nil, data))
+}
+
+// Federate implements the Prometheus /federate endpoint returning the latest sample of each series matching match[].
+func (c *ClickHouse) Federate(r *http.Request, w http.ResponseWriter) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, fmt.Sprintf("error parsing form values: %s", err), http.StatusBadRequest)
+		return
+	}
+	if len(r.Form["match[]"]) == 0 {
+		http.Error(w, "no match[] parameter provided", http.StatusBadRequest)
+		return
+	}
+	matcherSets, err := parser.ParseMetricSelectors(r.Form["match[]"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	mint, maxt := timestamp.FromTime(now.Add(-federateLookback)), timestamp.FromTime(now)
+	q, _ := c.Querier(mint, maxt)
+	hints := &storage.SelectHints{Start: mint, End: maxt, Step: int64(c.step * 1000)}
+	var sets []storage.SeriesSet
+	for _, mset := range matcherSets {
+		sets = append(sets, q.Select(r.Context(), true, hints, mset...))
+	}
+	set := storage.NewMergeSeriesSet(sets, storage.ChainedSeriesMerge)
+
+	families, err := federateMetricFamilies(set)
+	if err != nil {
+		klog.Errorln(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	format := expfmt.Negotiate(r.Header)
+	w.Header().Set("Content-Type", string(format))
+	enc := expfmt.NewEncoder(w, format)
+	for _, mf := range families {
+		if err = enc.Encode(mf); err != nil {
+			klog.Errorln(err)
+			return
+		}
+	}
+}
+
+// federateMetricFamilies groups the latest samples of the series by metric name. Metric types are not stored,
+// so all metrics are exposed as untyped.
+func federateMetricFamilies(set storage.SeriesSet) ([]*dto.MetricFamily, error) {
+	byName := map[string]*dto.MetricFamily{}
+	for set.Next() {
+		s := set.At()
+		it := s.Iterator(nil)
+		var t int64
+		var v float64
+		found := false
+		for it.Next() == chunkenc.ValFloat {
+			t, v = it.At()
+			found = true
+		}
+		if err := it.Err(); err != nil {
+			return nil, err
+		}
+		if !found {
+			continue
+		}
+		m := &dto.Metric{Untyped: &dto.Untyped{Value: proto.Float64(v)}, TimestampMs: proto.Int64(t)}
+		var name string
+		s.Labels().Range(func(l labels.Label) {
+			if l.Name == labels.MetricName {
+				name = l.Value
+				return
+			}
+			m.Label = append(m.Label, &dto.LabelPair{Name: proto.String(l.Name), Value: proto.String(l.Value)})
+		})
+		mf := byName[name]
+		if mf == nil {
+			mf = &dto.MetricFamily{Name: proto.String(name), Type: dto.MetricType_UNTYPED.Enum()}
+			byName[name] = mf
+		}
+		mf.Metric = append(mf.Metric, m)
+	}
+	if err := set.Err(); err != nil {
+		return nil, err
+	}
+	res := make([]*dto.MetricFamily, 0, len(byName))
+	for _, mf := range byName {
+		res = append(res, mf)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].GetName() < res[j].GetName() })
+	return res, nil
+}
+
+func fromLabelMatchers(matchers []*prompb.LabelMatcher) ([]*labels.Matcher, error) {
+	res := make([]*labels.Matcher, 0, len(matchers))
+	for _, m := range matchers {
+		var t labels.MatchType
+		switch m.Type {
+		case prompb.LabelMatcher_EQ:
+			t = labels.MatchEqual
+		case prompb.LabelMatcher_NEQ:
+			t = labels.MatchNotEqual
+		case prompb.LabelMatcher_RE:
+			t = labels.MatchRegexp
+		case prompb.LabelMatcher_NRE:
+			t = labels.MatchNotRegexp
+		default:
+			return nil, errors.New("invalid matcher type")
+		}
+		matcher, err := labels.NewMatcher(t, m.Name, m.Value)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, matcher)
+	}
+	return res, nil
+}
@@ -0,0 +1,36 @@
+package prom
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFederateMetricFamilies(t *testing.T) {
+	set := &seriesSet{series: []storage.Series{
+		storage.NewListSeries(labels.FromStrings("__name__", "up", "job", "b"), []chunks.Sample{sample{t: 1000, f: 0}, sample{t: 2000, f: 1}}),
+		storage.NewListSeries(labels.FromStrings("__name__", "up", "job", "a"), []chunks.Sample{sample{t: 3000, f: 1}}),
+		storage.NewListSeries(labels.FromStrings("__name__", "node_load1"), []chunks.Sample{sample{t: 4000, f: 0.5}}),
+		storage.NewListSeries(labels.FromStrings("__name__", "empty"), nil),
+	}}
+	families, err := federateMetricFamilies(set)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	enc := expfmt.NewEncoder(buf, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, mf := range families {
+		require.NoError(t, enc.Encode(mf))
+	}
+	assert.Equal(t, `# TYPE node_load1 untyped
+node_load1 0.5 4000
+# TYPE up untyped
+up{job="b"} 1 2000
+up{job="a"} 1 3000
+`, buf.String())
+}
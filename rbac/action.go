@@ -26,6 +26,11 @@ const (
 	ScopeProjectCosts                 Scope = "project.costs"
 	ScopeProjectAnomalies             Scope = "project.anomalies"
 	ScopeProjectRisks                 Scope = "project.risks"
+	ScopeProjectAlertRules            Scope = "project.alert_rules"
+	ScopeProjectSilences              Scope = "project.silences"
+	ScopeProjectSLOs                  Scope = "project.slos"
+	ScopeProjectNotificationRouting   Scope = "project.notification_routing"
+	ScopeProjectIncidents             Scope = "project.incidents"
 	ScopeApplication                  Scope = "project.application"
 	ScopeNode                         Scope = "project.node"
 	ScopeDashboards                   Scope = "project.dashboards"
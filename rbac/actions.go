@@ -76,6 +76,11 @@ func (as ProjectActionSet) List() []Action {
 		as.Anomalies().View(),
 		as.Risks().View(),
 		as.Risks().Edit(),
+		as.AlertRules().Edit(),
+		as.Silences().Edit(),
+		as.SLOs().Edit(),
+		as.NotificationRouting().Edit(),
+		as.Incidents().Edit(),
 		as.Application("*", "*", "*", "*").View(),
 		as.Node("*").View(),
 		as.Dashboards().Edit(),
@@ -131,6 +136,26 @@ func (as ProjectActionSet) Risks() ProjectAction {
 	return ProjectAction{project: &as, scope: ScopeProjectRisks}
 }
 
+func (as ProjectActionSet) AlertRules() ProjectEditAction {
+	return ProjectEditAction{project: &as, scope: ScopeProjectAlertRules}
+}
+
+func (as ProjectActionSet) Silences() ProjectEditAction {
+	return ProjectEditAction{project: &as, scope: ScopeProjectSilences}
+}
+
+func (as ProjectActionSet) SLOs() ProjectEditAction {
+	return ProjectEditAction{project: &as, scope: ScopeProjectSLOs}
+}
+
+func (as ProjectActionSet) NotificationRouting() ProjectEditAction {
+	return ProjectEditAction{project: &as, scope: ScopeProjectNotificationRouting}
+}
+
+func (as ProjectActionSet) Incidents() ProjectEditAction {
+	return ProjectEditAction{project: &as, scope: ScopeProjectIncidents}
+}
+
 func (as ProjectActionSet) Application(category model.ApplicationCategory, namespace string, kind model.ApplicationKind, name string) ApplicationActionSet {
 	return ApplicationActionSet{project: &as, category: category, namespace: namespace, kind: kind, name: name}
 }
@@ -22,6 +22,11 @@ var (
 			NewPermission(ScopeProjectCustomCloudPricing, ActionEdit, nil),
 			NewPermission(ScopeProjectInspections, ActionEdit, nil),
 			NewPermission(ScopeProjectRisks, ActionEdit, nil),
+			NewPermission(ScopeProjectAlertRules, ActionEdit, nil),
+			NewPermission(ScopeProjectSilences, ActionEdit, nil),
+			NewPermission(ScopeProjectSLOs, ActionEdit, nil),
+			NewPermission(ScopeProjectNotificationRouting, ActionEdit, nil),
+			NewPermission(ScopeProjectIncidents, ActionEdit, nil),
 			NewPermission(ScopeDashboards, ActionEdit, nil),
 		),
 		NewRole(RoleViewer,
@@ -0,0 +1,235 @@
+package watchers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/coroot/coroot/cache"
+	"github.com/coroot/coroot/clickhouse"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/notifications"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/coroot/coroot/utils"
+	"k8s.io/klog"
+)
+
+// alertNoDataEvaluations is the number of consecutive evaluations without data after which an active alert is resolved.
+const alertNoDataEvaluations = 3
+
+type Alerts struct {
+	db         *db.DB
+	clickhouse ClickhouseClient
+	notifier   *notifications.IncidentNotifier
+}
+
+type ClickhouseClient func(project *db.Project) (*clickhouse.Client, error)
+
+func NewAlerts(db *db.DB, notifier *notifications.IncidentNotifier, clickhouse ClickhouseClient) *Alerts {
+	return &Alerts{db: db, notifier: notifier, clickhouse: clickhouse}
+}
+
+func (w *Alerts) Check(project *db.Project, world *model.World, cacheClient *cache.Client) {
+	start := time.Now()
+
+	rules, err := w.db.GetAlertRules(project.Id)
+	if err != nil {
+		klog.Errorln(err)
+		return
+	}
+	active, err := w.db.GetActiveAlerts(project.Id)
+	if err != nil {
+		klog.Errorln(err)
+		return
+	}
+	now := timeseries.Now()
+	var enabled []*db.AlertRule
+	needsClickhouse := false
+	byId := map[string]*db.AlertRule{}
+	for _, rule := range rules {
+		byId[rule.Id] = rule
+		if !rule.Enabled {
+			continue
+		}
+		enabled = append(enabled, rule)
+		if rule.Source.Logs != nil || rule.Source.Traces != nil {
+			needsClickhouse = true
+		}
+	}
+	for ruleId, alert := range active {
+		rule := byId[ruleId]
+		switch {
+		case rule == nil:
+			rule = &db.AlertRule{Id: ruleId, Name: ruleId}
+		case rule.Enabled:
+			continue
+		}
+		w.resolve(project, rule, alert, now)
+	}
+	if len(enabled) == 0 {
+		return
+	}
+
+	ctx := context.TODO()
+	var ch *clickhouse.Client
+	if needsClickhouse && w.clickhouse != nil {
+		if ch, err = w.clickhouse(project); err != nil {
+			klog.Warningln(err)
+		}
+		defer ch.Close()
+	}
+
+	for _, rule := range enabled {
+		values, err := w.query(ctx, rule, world.Ctx, cacheClient, ch)
+		if err != nil {
+			klog.Warningf("%s: failed to evaluate alert rule %s: %s", project.Id, rule.Id, err)
+			continue
+		}
+		firing, value, hasData := evaluateAlertRule(values, world.Ctx.To.Add(-rule.For), rule.Condition)
+		alert := active[rule.Id]
+		if !hasData {
+			if alert == nil {
+				continue
+			}
+			// the alert is resolved if the data is gone for good, e.g. the service has been removed
+			alert.Details.NoData++
+			if alert.Details.NoData >= alertNoDataEvaluations {
+				w.resolve(project, rule, alert, now)
+			} else if err = w.db.UpdateAlert(project.Id, alert); err != nil {
+				klog.Errorln(err)
+			}
+			continue
+		}
+		switch {
+		case firing != nil && alert == nil:
+			alert = &db.Alert{
+				RuleId:   rule.Id,
+				Key:      utils.NanoId(8),
+				OpenedAt: now,
+				Severity: rule.Severity,
+				Details:  alertDetails(rule, firing.Labels, value),
+			}
+			if err = w.db.CreateAlert(project.Id, alert); err != nil {
+				klog.Errorln(err)
+				continue
+			}
+			w.notifier.EnqueueAlert(project, rule, alert, now)
+		case firing != nil && alert != nil:
+			alert.Details = alertDetails(rule, firing.Labels, value)
+			if err = w.db.UpdateAlert(project.Id, alert); err != nil {
+				klog.Errorln(err)
+			}
+		case firing == nil && alert != nil:
+			w.resolve(project, rule, alert, now)
+		}
+	}
+	klog.Infof("%s: checked %d alert rules in %s", project.Id, len(enabled), time.Since(start).Truncate(time.Millisecond))
+}
+
+func (w *Alerts) resolve(project *db.Project, rule *db.AlertRule, alert *db.Alert, now timeseries.Time) {
+	alert.ResolvedAt = now
+	if err := w.db.UpdateAlert(project.Id, alert); err != nil {
+		klog.Errorln(err)
+		return
+	}
+	w.notifier.EnqueueAlert(project, rule, alert, now)
+}
+
+func (w *Alerts) query(ctx context.Context, rule *db.AlertRule, tsCtx timeseries.Context, cacheClient *cache.Client, ch *clickhouse.Client) ([]*model.MetricValues, error) {
+	switch {
+	case rule.Source.Metrics != nil:
+		return cacheClient.QueryRange(ctx, rule.Source.Metrics.Query, tsCtx.From, tsCtx.To, tsCtx.Step, timeseries.FillAny)
+	case rule.Source.Logs != nil:
+		if ch == nil {
+			return nil, fmt.Errorf("clickhouse is not configured")
+		}
+		q := clickhouse.LogQuery{Ctx: tsCtx, Services: rule.Source.Logs.Services}
+		for _, f := range rule.Source.Logs.Filters {
+			q.Filters = append(q.Filters, clickhouse.LogFilter{Name: f.Name, Op: f.Op, Value: f.Value})
+		}
+		histogram, err := ch.GetLogsHistogram(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		total := timeseries.NewAggregate(timeseries.NanSum)
+		for _, b := range histogram {
+			total.Add(b.Timeseries)
+		}
+		perSecond := total.Get().Map(func(t timeseries.Time, v float32) float32 {
+			return v / float32(tsCtx.Step)
+		})
+		if perSecond.IsEmpty() {
+			perSecond = timeseries.New(tsCtx.From, tsCtx.PointsCount(), tsCtx.Step)
+		}
+		return []*model.MetricValues{{Values: perSecond.Map(timeseries.NanToZero)}}, nil
+	case rule.Source.Traces != nil:
+		if ch == nil {
+			return nil, fmt.Errorf("clickhouse is not configured")
+		}
+		q := clickhouse.SpanQuery{Ctx: tsCtx}
+		q.AddFilter("ServiceName", "=", rule.Source.Traces.ServiceName)
+		histogram, err := ch.GetSpansByServiceNameHistogram(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		if len(histogram) < 2 {
+			return nil, nil
+		}
+		errors, total := histogram[0].TimeSeries, histogram[len(histogram)-1].TimeSeries
+		percentage := timeseries.Aggregate2(errors, total, func(e, t float32) float32 {
+			if timeseries.IsNaN(t) || t == 0 {
+				return timeseries.NaN
+			}
+			if timeseries.IsNaN(e) {
+				e = 0
+			}
+			return e / t * 100
+		})
+		return []*model.MetricValues{{Values: percentage}}, nil
+	}
+	return nil, nil
+}
+
+// evaluateAlertRule returns the first series that violated the condition at every point since `from`,
+// its latest value, and whether any of the series had data in this window.
+func evaluateAlertRule(values []*model.MetricValues, from timeseries.Time, condition db.AlertRuleCondition) (*model.MetricValues, float32, bool) {
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Labels.String() < values[j].Labels.String()
+	})
+	hasData := false
+	for _, mv := range values {
+		defined := 0
+		violated := true
+		last := timeseries.NaN
+		iter := mv.Values.IterFrom(from)
+		for iter.Next() {
+			_, v := iter.Value()
+			if timeseries.IsNaN(v) {
+				continue
+			}
+			defined++
+			last = v
+			if !condition.Check(v) {
+				violated = false
+			}
+		}
+		if defined == 0 {
+			continue
+		}
+		hasData = true
+		if violated {
+			return mv, last, true
+		}
+	}
+	return nil, timeseries.NaN, hasData
+}
+
+func alertDetails(rule *db.AlertRule, labels model.Labels, value float32) db.AlertDetails {
+	msg := fmt.Sprintf("value %s %s", utils.FormatFloat(value), rule.Condition)
+	if ls := labels.String(); ls != "" {
+		msg += " for " + ls
+	}
+	return db.AlertDetails{Value: value, Labels: labels, Message: msg}
+}
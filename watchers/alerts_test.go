@@ -0,0 +1,78 @@
+package watchers
+
+import (
+	"testing"
+
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/notifications"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateAlertRule(t *testing.T) {
+	nan := timeseries.NaN
+	cond := db.AlertRuleCondition{Operator: db.AlertRuleOperatorGreater, Threshold: 10}
+	series := func(labels model.Labels, data ...float32) *model.MetricValues {
+		return &model.MetricValues{Labels: labels, Values: timeseries.NewWithData(1, 1, data)}
+	}
+
+	firing, _, hasData := evaluateAlertRule(nil, 1, cond)
+	assert.Nil(t, firing)
+	assert.False(t, hasData)
+
+	firing, _, hasData = evaluateAlertRule([]*model.MetricValues{series(nil, nan, nan, nan)}, 1, cond)
+	assert.Nil(t, firing)
+	assert.False(t, hasData)
+
+	firing, _, hasData = evaluateAlertRule([]*model.MetricValues{series(nil, 1, 20, 5)}, 1, cond)
+	assert.Nil(t, firing)
+	assert.True(t, hasData)
+
+	firing, v, _ := evaluateAlertRule([]*model.MetricValues{series(nil, 1, 20, 30)}, 2, cond)
+	assert.NotNil(t, firing)
+	assert.Equal(t, float32(30), v)
+
+	firing, _, _ = evaluateAlertRule([]*model.MetricValues{series(nil, 1, 20, 30)}, 1, cond)
+	assert.Nil(t, firing)
+
+	firing, v, _ = evaluateAlertRule([]*model.MetricValues{series(nil, 20, nan, 15, nan)}, 1, cond)
+	assert.NotNil(t, firing)
+	assert.Equal(t, float32(15), v)
+
+	firing, v, _ = evaluateAlertRule([]*model.MetricValues{
+		series(model.Labels{"pod": "a"}, 1, 1, 1),
+		series(model.Labels{"pod": "b"}, 11, 12, 13),
+	}, 1, cond)
+	assert.Equal(t, "b", firing.Labels["pod"])
+	assert.Equal(t, float32(13), v)
+}
+
+func TestAlertsResolveInactiveRules(t *testing.T) {
+	database, err := db.NewSqlite(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, database.Migrate())
+	project := &db.Project{Name: "test"}
+	require.NoError(t, database.SaveProject(project))
+
+	rule := &db.AlertRule{Name: "disabled", Severity: model.WARNING}
+	rule.Id, err = database.CreateAlertRule(project.Id, rule)
+	require.NoError(t, err)
+	disabled := &db.Alert{RuleId: rule.Id, Key: "disabled", OpenedAt: 100, Severity: model.WARNING}
+	require.NoError(t, database.CreateAlert(project.Id, disabled))
+	orphan := &db.Alert{RuleId: "deleted", Key: "orphan", OpenedAt: 100, Severity: model.WARNING}
+	require.NoError(t, database.CreateAlert(project.Id, orphan))
+
+	w := NewAlerts(database, notifications.NewIncidentNotifier(database), nil)
+	w.Check(project, model.NewWorld(0, 0, 0, 0), nil)
+
+	active, err := database.GetActiveAlerts(project.Id)
+	require.NoError(t, err)
+	assert.Empty(t, active)
+	for _, key := range []string{"disabled", "orphan"} {
+		a, err := database.GetAlertByKey(project.Id, key)
+		require.NoError(t, err)
+		assert.True(t, a.Resolved())
+	}
+}
@@ -156,6 +156,66 @@ func (w *Deployments) sendNotifications(project *db.Project, world *model.World)
 					needSave = true
 				}
 			}
+			if email := integrations.Email; email != nil && email.Deployments && notificationSettings.Email != nil && notificationSettings.Email.Enabled && d.Notifications.Email.State < ds.State {
+				client := notifications.NewEmail(email, notificationSettings.Email.To)
+				ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+				err := client.SendDeployment(ctx, project, ds)
+				cancel()
+				if err != nil {
+					klog.Errorln(err)
+				} else {
+					d.Notifications.Email.State = ds.State
+					needSave = true
+				}
+			}
+			if mattermost := integrations.Mattermost; mattermost != nil && mattermost.Deployments && notificationSettings.Mattermost != nil && notificationSettings.Mattermost.Enabled && d.Notifications.Mattermost.State < ds.State {
+				client := notifications.NewMattermost(mattermost.WebhookUrl, mattermost.Channel)
+				ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+				err := client.SendDeployment(ctx, project, ds)
+				cancel()
+				if err != nil {
+					klog.Errorln(err)
+				} else {
+					d.Notifications.Mattermost.State = ds.State
+					needSave = true
+				}
+			}
+			if discord := integrations.Discord; discord != nil && discord.Deployments && notificationSettings.Discord != nil && notificationSettings.Discord.Enabled && d.Notifications.Discord.State < ds.State {
+				client := notifications.NewDiscord(discord.WebhookUrl)
+				ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+				err := client.SendDeployment(ctx, project, ds)
+				cancel()
+				if err != nil {
+					klog.Errorln(err)
+				} else {
+					d.Notifications.Discord.State = ds.State
+					needSave = true
+				}
+			}
+			if googleChat := integrations.GoogleChat; googleChat != nil && googleChat.Deployments && notificationSettings.GoogleChat != nil && notificationSettings.GoogleChat.Enabled && d.Notifications.GoogleChat.State < ds.State {
+				client := notifications.NewGoogleChat(googleChat.WebhookUrl)
+				ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+				err := client.SendDeployment(ctx, project, ds)
+				cancel()
+				if err != nil {
+					klog.Errorln(err)
+				} else {
+					d.Notifications.GoogleChat.State = ds.State
+					needSave = true
+				}
+			}
+			if telegram := integrations.Telegram; telegram != nil && telegram.Deployments && notificationSettings.Telegram != nil && notificationSettings.Telegram.Enabled && d.Notifications.Telegram.State < ds.State {
+				client := notifications.NewTelegram(telegram.BotToken, telegram.ChatId)
+				ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+				err := client.SendDeployment(ctx, project, ds)
+				cancel()
+				if err != nil {
+					klog.Errorln(err)
+				} else {
+					d.Notifications.Telegram.State = ds.State
+					needSave = true
+				}
+			}
 			if !needSave {
 				continue
 			}
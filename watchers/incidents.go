@@ -2,9 +2,13 @@ package watchers
 
 import (
 	"context"
+	"slices"
 	"time"
 
 	"github.com/coroot/coroot/auditor"
+	"github.com/coroot/coroot/cache"
+	"github.com/coroot/coroot/clickhouse"
+	"github.com/coroot/coroot/constructor"
 	"github.com/coroot/coroot/db"
 	"github.com/coroot/coroot/model"
 	"github.com/coroot/coroot/notifications"
@@ -14,20 +18,23 @@ import (
 )
 
 type Incidents struct {
-	db       *db.DB
-	rca      IncidentRCA
-	notifier *notifications.IncidentNotifier
+	db         *db.DB
+	rca        IncidentRCA
+	notifier   *notifications.IncidentNotifier
+	clickhouse ClickhouseClient
 }
 
 type IncidentRCA func(ctx context.Context, project *db.Project, world *model.World, incident *model.ApplicationIncident)
 
-func NewIncidents(db *db.DB, rca IncidentRCA) *Incidents {
-	return &Incidents{db: db, notifier: notifications.NewIncidentNotifier(db), rca: rca}
+func NewIncidents(db *db.DB, notifier *notifications.IncidentNotifier, rca IncidentRCA, clickhouse ClickhouseClient) *Incidents {
+	return &Incidents{db: db, notifier: notifier, rca: rca, clickhouse: clickhouse}
 }
 
-func (w *Incidents) Check(project *db.Project, world *model.World) {
+func (w *Incidents) Check(project *db.Project, world *model.World, cacheClient *cache.Client) {
 	start := time.Now()
 
+	w.loadCustomSLIs(project, world, cacheClient)
+
 	auditor.Audit(world, project, nil, false, nil)
 
 	var apps int
@@ -36,14 +43,15 @@ func (w *Incidents) Check(project *db.Project, world *model.World) {
 
 	for _, app := range world.Applications {
 		var (
-			aBadF, aTotalF sumFromFunc
-			lBadF, lTotalF sumFromFunc
+			aBadF, aTotalF model.SumFromFunc
+			lBadF, lTotalF model.SumFromFunc
 		)
 		details := model.IncidentDetails{}
 		details.AvailabilityBurnRates, aBadF, aTotalF = availability(world.Ctx, app)
 		details.LatencyBurnRates, lBadF, lTotalF = latency(world.Ctx, app)
+		details.CustomSLOBurnRates = customSLOs(app)
 
-		calcImpact := func(openedAt timeseries.Time, badF, totalF sumFromFunc) float32 {
+		calcImpact := func(openedAt timeseries.Time, badF, totalF model.SumFromFunc) float32 {
 			from := openedAt.Add(-model.MinAlertRuleShortWindow)
 			dataFrom := now.Add(-model.MaxAlertRuleWindow)
 
@@ -71,6 +79,13 @@ func (w *Incidents) Check(project *db.Project, world *model.World) {
 				status = br.Severity
 			}
 		}
+		for _, slo := range details.CustomSLOBurnRates {
+			for _, br := range slo.BurnRates {
+				if br.Severity > status {
+					status = br.Severity
+				}
+			}
+		}
 		if status == model.UNKNOWN {
 			continue
 		}
@@ -113,6 +128,7 @@ func (w *Incidents) Check(project *db.Project, world *model.World) {
 				incident.Severity = status
 				incident.Details.AvailabilityBurnRates = details.AvailabilityBurnRates
 				incident.Details.LatencyBurnRates = details.LatencyBurnRates
+				incident.Details.CustomSLOBurnRates = details.CustomSLOBurnRates
 				incident.Details.AvailabilityImpact.AffectedRequestPercentage = calcImpact(incident.OpenedAt, aBadF, aTotalF)
 				incident.Details.LatencyImpact.AffectedRequestPercentage = calcImpact(incident.OpenedAt, lBadF, lTotalF)
 				if err = w.db.UpdateIncident(project.Id, app.Id, incident.Key, incident.Severity, incident.Details); err != nil {
@@ -131,9 +147,39 @@ func (w *Incidents) Check(project *db.Project, world *model.World) {
 	klog.Infof("%s: checked %d apps in %s", project.Id, apps, time.Since(start).Truncate(time.Millisecond))
 }
 
-type sumFromFunc func(from timeseries.Time) float32
+func (w *Incidents) loadCustomSLIs(project *db.Project, world *model.World, cacheClient *cache.Client) {
+	slos, err := w.db.GetSLOs(project.Id)
+	if err != nil {
+		klog.Errorln(err)
+		return
+	}
+	if len(slos) == 0 {
+		return
+	}
+	var ch *clickhouse.Client
+	if w.clickhouse != nil && slices.ContainsFunc(slos, func(s *model.SLO) bool { return s.Source.Traces != nil }) {
+		if ch, err = w.clickhouse(project); err != nil {
+			klog.Warningln(err)
+		}
+		if ch != nil {
+			defer ch.Close()
+		}
+	}
+	constructor.LoadCustomSLIs(context.TODO(), cacheClient, ch, world, slos)
+}
+
+func customSLOs(app *model.Application) []model.CustomSLOBurnRates {
+	var res []model.CustomSLOBurnRates
+	for _, sli := range app.CustomSLIs {
+		if len(sli.BurnRates) == 0 {
+			continue
+		}
+		res = append(res, model.CustomSLOBurnRates{SLOId: sli.Config.Id, Name: sli.Config.Name, BurnRates: sli.BurnRates})
+	}
+	return res
+}
 
-func availability(ctx timeseries.Context, app *model.Application) ([]model.BurnRate, sumFromFunc, sumFromFunc) {
+func availability(ctx timeseries.Context, app *model.Application) ([]model.BurnRate, model.SumFromFunc, model.SumFromFunc) {
 	if len(app.AvailabilitySLIs) == 0 {
 		return nil, nil, nil
 	}
@@ -142,7 +188,7 @@ func availability(ctx timeseries.Context, app *model.Application) ([]model.BurnR
 		return nil, nil, nil
 	}
 
-	totalF := totalSum(sli.TotalRequestsRaw)
+	totalF := model.SumFrom(sli.TotalRequestsRaw)
 	failedF := func(from timeseries.Time) float32 {
 		return 0
 	}
@@ -160,18 +206,18 @@ func availability(ctx timeseries.Context, app *model.Application) ([]model.BurnR
 			return sum
 		}
 	}
-	return calcBurnRates(ctx.To, failedF, totalF, sli.Config.ObjectivePercentage), failedF, totalF
+	return model.CalcBurnRates(ctx.To, failedF, totalF, sli.Config.ObjectivePercentage, model.AlertRules), failedF, totalF
 }
 
-func latency(ctx timeseries.Context, app *model.Application) ([]model.BurnRate, sumFromFunc, sumFromFunc) {
+func latency(ctx timeseries.Context, app *model.Application) ([]model.BurnRate, model.SumFromFunc, model.SumFromFunc) {
 	if len(app.LatencySLIs) == 0 {
 		return nil, nil, nil
 	}
 	sli := app.LatencySLIs[0]
 	totalRaw, fastRaw := sli.GetTotalAndFast(true)
 
-	totalF := totalSum(totalRaw)
-	var slowF sumFromFunc
+	totalF := model.SumFrom(totalRaw)
+	var slowF model.SumFromFunc
 	if !fastRaw.IsEmpty() {
 		slowF = func(from timeseries.Time) float32 {
 			totalIter := totalRaw.IterFrom(from)
@@ -195,61 +241,5 @@ func latency(ctx timeseries.Context, app *model.Application) ([]model.BurnRate,
 	if slowF == nil {
 		return nil, nil, nil
 	}
-	return calcBurnRates(ctx.To, slowF, totalF, sli.Config.ObjectivePercentage), slowF, totalF
-}
-
-func calcBurnRates(now timeseries.Time, badSum, totalSum sumFromFunc, objectivePercentage float32) []model.BurnRate {
-	objective := 1 - objectivePercentage/100
-	var res []model.BurnRate
-
-	for _, r := range model.AlertRules {
-		from := now.Add(-r.LongWindow)
-		total := totalSum(from)
-		bad := badSum(from)
-		br := model.BurnRate{
-			LongWindow:  r.LongWindow,
-			ShortWindow: r.ShortWindow,
-			Threshold:   r.BurnRateThreshold,
-			Severity:    model.OK,
-		}
-		if v := bad / total; !timeseries.IsNaN(v) {
-			br.LongWindowPercentage = v * 100
-			br.LongWindowBurnRate = v / objective
-		} else {
-			continue
-		}
-		from = now.Add(-r.ShortWindow)
-		if v := badSum(from) / totalSum(from); !timeseries.IsNaN(v) {
-			br.ShortWindowPercentage = v * 100
-			br.ShortWindowBurnRate = v / objective
-		} else {
-			continue
-		}
-		if br.LongWindowBurnRate > r.BurnRateThreshold && br.ShortWindowBurnRate > r.BurnRateThreshold {
-			br.Severity = r.Severity
-		}
-		res = append(res, br)
-	}
-	return res
-}
-
-func totalSum(ts *timeseries.TimeSeries) sumFromFunc {
-	return func(from timeseries.Time) float32 {
-		iter := ts.IterFrom(from)
-		var sum float32
-		var count, countDefined int
-		for iter.Next() {
-			_, v := iter.Value()
-			count++
-			if timeseries.IsNaN(v) {
-				continue
-			}
-			sum += v
-			countDefined++
-		}
-		if float32(countDefined)/float32(count) < 0.5 {
-			return timeseries.NaN
-		}
-		return sum
-	}
+	return model.CalcBurnRates(ctx.To, slowF, totalF, sli.Config.ObjectivePercentage, model.AlertRules), slowF, totalF
 }
@@ -0,0 +1,135 @@
+package watchers
+
+import (
+	"context"
+	"time"
+
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"k8s.io/klog"
+)
+
+const (
+	logMetricsStep = timeseries.Minute
+	// entries usually land in ClickHouse a few seconds after their timestamps because of the collector batching
+	logMetricsLag         = 30 * timeseries.Second
+	logMetricsMaxBackfill = 15 * timeseries.Minute
+	logMetricsTimeout     = time.Minute
+)
+
+type MetricsWriter func(ctx context.Context, project *db.Project, wr *prompb.WriteRequest) error
+
+// LogMetrics calculates the log-based metrics defined in the project settings
+// and writes them to the project's metric storage.
+type LogMetrics struct {
+	clickhouse ClickhouseClient
+	write      MetricsWriter
+	lastTo     map[db.ProjectId]map[string]timeseries.Time
+}
+
+func NewLogMetrics(clickhouse ClickhouseClient, write MetricsWriter) *LogMetrics {
+	return &LogMetrics{clickhouse: clickhouse, write: write, lastTo: map[db.ProjectId]map[string]timeseries.Time{}}
+}
+
+func (w *LogMetrics) Check(project *db.Project) {
+	var metrics []db.LogMetric
+	for _, m := range project.Settings.LogMetrics {
+		if !m.Disabled {
+			metrics = append(metrics, m)
+		}
+	}
+	if len(metrics) == 0 {
+		delete(w.lastTo, project.Id)
+		return
+	}
+	to := timeseries.Now().Add(-logMetricsLag).Truncate(logMetricsStep)
+	lastTo := w.lastTo[project.Id]
+	if lastTo == nil {
+		lastTo = map[string]timeseries.Time{}
+		w.lastTo[project.Id] = lastTo
+	}
+	var pending []db.LogMetric
+	for _, m := range metrics {
+		if lastTo[m.Name].Before(to) {
+			pending = append(pending, m)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	ch, err := w.clickhouse(project)
+	if err != nil {
+		klog.Warningln(err)
+		return
+	}
+	if ch == nil {
+		return
+	}
+	defer ch.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), logMetricsTimeout)
+	defer cancel()
+
+	start := time.Now()
+	for _, m := range pending {
+		from := lastTo[m.Name]
+		if from.IsZero() {
+			from = to.Add(-logMetricsStep)
+		}
+		if from.Before(to.Add(-logMetricsMaxBackfill)) {
+			from = to.Add(-logMetricsMaxBackfill)
+		}
+		values, err := ch.GetLogMetric(ctx, &m, from, to, logMetricsStep)
+		if err != nil {
+			klog.Warningf("%s: failed to calculate log metric %s: %s", project.Id, m.Name, err)
+			continue
+		}
+		wr := logMetricWriteRequest(&m, values, from, to)
+		if len(wr.Timeseries) > 0 {
+			if err = w.write(ctx, project, wr); err != nil {
+				klog.Warningf("%s: failed to write log metric %s: %s", project.Id, m.Name, err)
+				continue
+			}
+		}
+		lastTo[m.Name] = to
+	}
+	klog.Infof("%s: calculated %d log metrics in %s", project.Id, len(pending), time.Since(start).Truncate(time.Millisecond))
+}
+
+// logMetricWriteRequest converts the values to samples timestamped with the end of their intervals.
+// Intervals without matching entries are reported as zeros for count metrics and skipped for the others.
+func logMetricWriteRequest(m *db.LogMetric, values []*model.MetricValues, from, to timeseries.Time) *prompb.WriteRequest {
+	isCount := m.Type == db.LogMetricTypeCount
+	if isCount && len(values) == 0 && len(m.GroupBy) == 0 {
+		values = append(values, &model.MetricValues{Labels: model.Labels{}, Values: timeseries.New(from, int(to.Sub(from)/logMetricsStep), logMetricsStep)})
+	}
+	wr := &prompb.WriteRequest{}
+	for _, mv := range values {
+		ts := prompb.TimeSeries{Labels: []prompb.Label{{Name: labels.MetricName, Value: m.Name}}}
+		for k, v := range mv.Labels {
+			ts.Labels = append(ts.Labels, prompb.Label{Name: k, Value: v})
+		}
+		iter := mv.Values.Iter()
+		for iter.Next() {
+			t, v := iter.Value()
+			if !t.Before(to) {
+				break
+			}
+			if timeseries.IsNaN(v) {
+				if !isCount {
+					continue
+				}
+				v = 0
+			}
+			ts.Samples = append(ts.Samples, prompb.Sample{Timestamp: t.Add(logMetricsStep).ToStandard().UnixMilli(), Value: float64(v)})
+		}
+		if len(ts.Samples) > 0 {
+			wr.Timeseries = append(wr.Timeseries, ts)
+		}
+	}
+	return wr
+}
@@ -0,0 +1,39 @@
+package watchers
+
+import (
+	"testing"
+
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogMetricWriteRequest(t *testing.T) {
+	from := timeseries.Time(600)
+	to := from.Add(3 * logMetricsStep)
+	nan := timeseries.NaN
+
+	count := &db.LogMetric{Name: "errors_total", Type: db.LogMetricTypeCount}
+	wr := logMetricWriteRequest(count, nil, from, to)
+	require.Len(t, wr.Timeseries, 1)
+	assert.Equal(t, []prompb.Label{{Name: "__name__", Value: "errors_total"}}, wr.Timeseries[0].Labels)
+	assert.Equal(t, []prompb.Sample{{Timestamp: 660000, Value: 0}, {Timestamp: 720000, Value: 0}, {Timestamp: 780000, Value: 0}}, wr.Timeseries[0].Samples)
+
+	values := []*model.MetricValues{{
+		Labels: model.Labels{"status": "500"},
+		Values: timeseries.NewWithData(from, logMetricsStep, []float32{5, nan, 7}),
+	}}
+	wr = logMetricWriteRequest(count, values, from, to)
+	require.Len(t, wr.Timeseries, 1)
+	assert.Equal(t, []prompb.Label{{Name: "__name__", Value: "errors_total"}, {Name: "status", Value: "500"}}, wr.Timeseries[0].Labels)
+	assert.Equal(t, []prompb.Sample{{Timestamp: 660000, Value: 5}, {Timestamp: 720000, Value: 0}, {Timestamp: 780000, Value: 7}}, wr.Timeseries[0].Samples)
+
+	latency := &db.LogMetric{Name: "latency_max", Type: db.LogMetricTypeMax, Attribute: "duration"}
+	assert.Empty(t, logMetricWriteRequest(latency, nil, from, to).Timeseries)
+	wr = logMetricWriteRequest(latency, values, from, to)
+	require.Len(t, wr.Timeseries, 1)
+	assert.Equal(t, []prompb.Sample{{Timestamp: 660000, Value: 5}, {Timestamp: 780000, Value: 7}}, wr.Timeseries[0].Samples)
+}
@@ -0,0 +1,148 @@
+package watchers
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/coroot/coroot/cache"
+	"github.com/coroot/coroot/clickhouse"
+	"github.com/coroot/coroot/constructor"
+	"github.com/coroot/coroot/db"
+	"github.com/coroot/coroot/model"
+	"github.com/coroot/coroot/timeseries"
+	"k8s.io/klog"
+)
+
+const (
+	// the metric cache keeps a limited history, so only the recent days can be aggregated retroactively
+	sloHistoryBackfill = 7 * timeseries.Day
+	sloHistoryTimeout  = 5 * time.Minute
+)
+
+// SLOHistory stores daily aggregates of the SLIs, so that the compliance can be calculated
+// for periods longer than the retention of the metric cache.
+type SLOHistory struct {
+	db         *db.DB
+	clickhouse ClickhouseClient
+	lastDay    map[db.ProjectId]timeseries.Time
+}
+
+func NewSLOHistory(database *db.DB, clickhouse ClickhouseClient) *SLOHistory {
+	return &SLOHistory{db: database, clickhouse: clickhouse, lastDay: map[db.ProjectId]timeseries.Time{}}
+}
+
+func (w *SLOHistory) Check(project *db.Project, cacheClient *cache.Client, to timeseries.Time) {
+	last, ok := w.lastDay[project.Id]
+	if !ok {
+		var err error
+		if last, err = w.db.GetSLODailyLastDay(project.Id); err != nil {
+			klog.Errorln(err)
+			return
+		}
+		w.lastDay[project.Id] = last
+	}
+	from := to.Add(-sloHistoryBackfill).Truncate(timeseries.Day)
+	if !last.IsZero() && last.Add(timeseries.Day).After(from) {
+		from = last.Add(timeseries.Day)
+	}
+	// at most one day is aggregated per iteration, so that the backfill doesn't delay the other watchers
+	if day := from; !day.Add(timeseries.Day).After(to) {
+		start := time.Now()
+		items, err := w.aggregate(project, cacheClient, day)
+		if err != nil {
+			klog.Errorln("failed to aggregate SLIs:", err)
+			return
+		}
+		if err = w.db.SaveSLODaily(project.Id, day, items); err != nil {
+			klog.Errorln(err)
+			return
+		}
+		w.lastDay[project.Id] = day
+		klog.Infof("%s: aggregated %d SLIs for %s in %s", project.Id, len(items), day.ToStandard().UTC().Format("2006-01-02"), time.Since(start).Truncate(time.Millisecond))
+	}
+}
+
+func (w *SLOHistory) aggregate(project *db.Project, cacheClient *cache.Client, day timeseries.Time) ([]db.SLODaily, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sloHistoryTimeout)
+	defer cancel()
+
+	to := day.Add(timeseries.Day)
+	ctr := constructor.New(w.db, project, cacheClient, nil)
+	world, err := ctr.LoadWorld(ctx, day, to, timeseries.Hour, nil)
+	if err != nil {
+		return nil, err
+	}
+	rawStep := world.Ctx.RawStep
+
+	var res []db.SLODaily
+	add := func(appId model.ApplicationId, slo string, objective, good, total float32) {
+		if timeseries.IsNaN(total) || total <= 0 {
+			return
+		}
+		res = append(res, db.SLODaily{
+			ApplicationId: appId,
+			SLO:           slo,
+			Day:           day,
+			Objective:     objective,
+			Good:          float64(min(max(good, 0), total)),
+			Total:         float64(total),
+		})
+	}
+
+	for _, app := range world.Applications {
+		if len(app.AvailabilitySLIs) > 0 {
+			sli := app.AvailabilitySLIs[0]
+			total := eventsWithin(sli.TotalRequestsRaw, day, to, rawStep)
+			failed := eventsWithin(sli.FailedRequestsRaw, day, to, rawStep)
+			add(app.Id, db.SLOAvailability, sli.Config.ObjectivePercentage, total-failed, total)
+		}
+		if len(app.LatencySLIs) > 0 {
+			sli := app.LatencySLIs[0]
+			total, fast := sli.GetTotalAndFast(true)
+			add(app.Id, db.SLOLatency, sli.Config.ObjectivePercentage, eventsWithin(fast, day, to, rawStep), eventsWithin(total, day, to, rawStep))
+		}
+	}
+
+	slos, err := w.db.GetSLOs(project.Id)
+	if err != nil {
+		return nil, err
+	}
+	var ch *clickhouse.Client
+	if slices.ContainsFunc(slos, func(s *model.SLO) bool { return s.Source.Traces != nil }) {
+		if ch, err = w.clickhouse(project); err != nil {
+			klog.Warningln(err)
+		}
+		if ch != nil {
+			defer ch.Close()
+		}
+	}
+	for _, slo := range slos {
+		good, total, err := constructor.CustomSLIEvents(ctx, cacheClient, ch, slo, day, to, rawStep)
+		if err != nil {
+			klog.Warningf("failed to aggregate SLO %s: %s", slo.Id, err)
+			continue
+		}
+		add(slo.ApplicationId, slo.Id, slo.ObjectivePercentage, good, total)
+	}
+	return res, nil
+}
+
+// eventsWithin returns the number of events within [from, to) given the per-second rates of events.
+func eventsWithin(ts *timeseries.TimeSeries, from, to timeseries.Time, step timeseries.Duration) float32 {
+	if ts.IsEmpty() {
+		return 0
+	}
+	var sum float32
+	iter := ts.IterFrom(from)
+	for iter.Next() {
+		t, v := iter.Value()
+		if !t.Before(to) {
+			break
+		}
+		if !timeseries.IsNaN(v) {
+			sum += v * float32(step)
+		}
+	}
+	return sum
+}
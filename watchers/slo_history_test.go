@@ -0,0 +1,19 @@
+package watchers
+
+import (
+	"testing"
+
+	"github.com/coroot/coroot/timeseries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventsWithin(t *testing.T) {
+	nan := timeseries.NaN
+	rates := timeseries.NewWithData(0, 15, []float32{1, 2, nan, 4, 5})
+
+	assert.Equal(t, float32(0), eventsWithin(nil, 0, 75, 15))
+	assert.Equal(t, float32(0), eventsWithin(timeseries.New(0, 5, 15), 0, 75, 15))
+	assert.Equal(t, float32((1+2+4+5)*15), eventsWithin(rates, 0, 75, 15))
+	assert.Equal(t, float32((2+4)*15), eventsWithin(rates, 15, 60, 15))
+	assert.Equal(t, float32(0), eventsWithin(rates, 75, 150, 15))
+}
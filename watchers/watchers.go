@@ -15,13 +15,13 @@ import (
 	"k8s.io/klog"
 )
 
-func Start(database *db.DB, cache *cache.Cache, pricing *pricing.Manager, incidents *Incidents, checkDeployments bool, globalClickHouse *db.IntegrationClickhouse, spaceManagerCfg config.ClickHouseSpaceManager) {
+func Start(database *db.DB, cache *cache.Cache, pricing *pricing.Manager, incidents *Incidents, alerts *Alerts, sloHistory *SLOHistory, logMetrics *LogMetrics, checkDeployments bool, globalClickHouse *db.IntegrationClickhouse, spaceManagerCfg config.ClickHouseSpaceManager) {
 	var deployments *Deployments
 	if checkDeployments {
 		deployments = NewDeployments(database, pricing)
 	}
 
-	if incidents == nil && deployments == nil {
+	if incidents == nil && alerts == nil && sloHistory == nil && logMetrics == nil && deployments == nil {
 		return
 	}
 
@@ -61,7 +61,7 @@ func Start(database *db.DB, cache *cache.Cache, pricing *pricing.Manager, incide
 				continue
 			}
 
-			handleProjectUpdate(database, cache, pricing, incidents, deployments, projectId)
+			handleProjectUpdate(database, cache, pricing, incidents, alerts, sloHistory, logMetrics, deployments, projectId)
 
 			if time.Since(lastSpaceManagerRun) >= time.Hour {
 				lastSpaceManagerRun = time.Now()
@@ -71,7 +71,7 @@ func Start(database *db.DB, cache *cache.Cache, pricing *pricing.Manager, incide
 	}()
 }
 
-func handleProjectUpdate(database *db.DB, cache *cache.Cache, pricing *pricing.Manager, incidents *Incidents, deployments *Deployments, projectId db.ProjectId) {
+func handleProjectUpdate(database *db.DB, cache *cache.Cache, pricing *pricing.Manager, incidents *Incidents, alerts *Alerts, sloHistory *SLOHistory, logMetrics *LogMetrics, deployments *Deployments, projectId db.ProjectId) {
 	start := time.Now()
 	project, err := database.GetProject(projectId)
 	if err != nil {
@@ -108,7 +108,28 @@ func handleProjectUpdate(database *db.DB, cache *cache.Cache, pricing *pricing.M
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			incidents.Check(project, world)
+			incidents.Check(project, world, cacheClient)
+		}()
+	}
+	if alerts != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			alerts.Check(project, world, cacheClient)
+		}()
+	}
+	if sloHistory != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sloHistory.Check(project, cacheClient, cacheTo)
+		}()
+	}
+	if logMetrics != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logMetrics.Check(project)
 		}()
 	}
 	if deployments != nil {